@@ -94,5 +94,27 @@ func init() {
 		flockConfigCmd,
 		webhookConfigCmd,
 		msteamsConfigCmd,
+		pagerdutyConfigCmd,
+		discordConfigCmd,
+		telegramConfigCmd,
+		emailConfigCmd,
+		snsConfigCmd,
+		opsgenieConfigCmd,
+		googlechatConfigCmd,
+		kafkaConfigCmd,
+		stdoutConfigCmd,
+		syslogConfigCmd,
+		elasticsearchConfigCmd,
+		natsConfigCmd,
+		mqttConfigCmd,
+		sentryConfigCmd,
+		gotifyConfigCmd,
+		pushoverConfigCmd,
+		webexConfigCmd,
+		victoropsConfigCmd,
+		matrixConfigCmd,
+		datadogConfigCmd,
+		tcpConfigCmd,
+		jiraConfigCmd,
 	)
 }
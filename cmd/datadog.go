@@ -0,0 +1,63 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/spf13/cobra"
+)
+
+// datadogConfigCmd represents the datadog subcommand
+var datadogConfigCmd = &cobra.Command{
+	Use:   "datadog",
+	Short: "specific datadog configuration",
+	Long:  `specific datadog configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		apikey, err := cmd.Flags().GetString("apikey")
+		if err == nil {
+			if len(apikey) > 0 {
+				conf.Handler.Datadog.APIKey = apikey
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		site, err := cmd.Flags().GetString("site")
+		if err == nil {
+			if len(site) > 0 {
+				conf.Handler.Datadog.Site = site
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	datadogConfigCmd.Flags().StringP("apikey", "k", "", "Specify Datadog api key")
+	datadogConfigCmd.Flags().StringP("site", "s", "", "Specify Datadog site, e.g. datadoghq.eu")
+}
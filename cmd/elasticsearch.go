@@ -0,0 +1,63 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/spf13/cobra"
+)
+
+// elasticsearchConfigCmd represents the elasticsearch subcommand
+var elasticsearchConfigCmd = &cobra.Command{
+	Use:   "elasticsearch",
+	Short: "specific elasticsearch configuration",
+	Long:  `specific elasticsearch configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		addresses, err := cmd.Flags().GetStringSlice("addresses")
+		if err == nil {
+			if len(addresses) > 0 {
+				conf.Handler.Elasticsearch.Addresses = addresses
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		index, err := cmd.Flags().GetString("index")
+		if err == nil {
+			if len(index) > 0 {
+				conf.Handler.Elasticsearch.Index = index
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	elasticsearchConfigCmd.Flags().StringSliceP("addresses", "a", nil, "Specify Elasticsearch addresses")
+	elasticsearchConfigCmd.Flags().StringP("index", "i", "", "Specify the prefix of the daily Elasticsearch indices")
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/spf13/cobra"
+)
+
+// emailConfigCmd represents the email subcommand
+var emailConfigCmd = &cobra.Command{
+	Use:   "email",
+	Short: "specific email configuration",
+	Long:  `specific email configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		host, err := cmd.Flags().GetString("host")
+		if err == nil {
+			if len(host) > 0 {
+				conf.Handler.Email.Host = host
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		username, err := cmd.Flags().GetString("username")
+		if err == nil {
+			if len(username) > 0 {
+				conf.Handler.Email.Username = username
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		password, err := cmd.Flags().GetString("password")
+		if err == nil {
+			if len(password) > 0 {
+				conf.Handler.Email.Password = password
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		from, err := cmd.Flags().GetString("from")
+		if err == nil {
+			if len(from) > 0 {
+				conf.Handler.Email.From = from
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		port, err := cmd.Flags().GetInt("port")
+		if err == nil {
+			if port > 0 {
+				conf.Handler.Email.Port = port
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		to, err := cmd.Flags().GetStringSlice("to")
+		if err == nil {
+			if len(to) > 0 {
+				conf.Handler.Email.To = to
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	emailConfigCmd.Flags().String("host", "", "Specify SMTP host")
+	emailConfigCmd.Flags().Int("port", 0, "Specify SMTP port, 465 uses implicit TLS")
+	emailConfigCmd.Flags().StringP("username", "u", "", "Specify SMTP username")
+	emailConfigCmd.Flags().StringP("password", "p", "", "Specify SMTP password")
+	emailConfigCmd.Flags().StringP("from", "f", "", "Specify from address")
+	emailConfigCmd.Flags().StringSliceP("to", "t", nil, "Specify to addresses")
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/spf13/cobra"
+)
+
+// gotifyConfigCmd represents the gotify subcommand
+var gotifyConfigCmd = &cobra.Command{
+	Use:   "gotify",
+	Short: "specific gotify configuration",
+	Long:  `specific gotify configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		url, err := cmd.Flags().GetString("url")
+		if err == nil {
+			if len(url) > 0 {
+				conf.Handler.Gotify.Url = url
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		token, err := cmd.Flags().GetString("token")
+		if err == nil {
+			if len(token) > 0 {
+				conf.Handler.Gotify.Token = token
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	gotifyConfigCmd.Flags().StringP("url", "u", "", "Specify Gotify server url")
+	gotifyConfigCmd.Flags().StringP("token", "t", "", "Specify Gotify application token")
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/spf13/cobra"
+)
+
+// matrixConfigCmd represents the matrix subcommand
+var matrixConfigCmd = &cobra.Command{
+	Use:   "matrix",
+	Short: "specific matrix configuration",
+	Long:  `specific matrix configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		homeserver, err := cmd.Flags().GetString("homeserver")
+		if err == nil {
+			if len(homeserver) > 0 {
+				conf.Handler.Matrix.Homeserver = homeserver
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		token, err := cmd.Flags().GetString("token")
+		if err == nil {
+			if len(token) > 0 {
+				conf.Handler.Matrix.AccessToken = token
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		roomid, err := cmd.Flags().GetString("roomid")
+		if err == nil {
+			if len(roomid) > 0 {
+				conf.Handler.Matrix.RoomID = roomid
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	matrixConfigCmd.Flags().StringP("homeserver", "s", "", "Specify Matrix homeserver url")
+	matrixConfigCmd.Flags().StringP("token", "t", "", "Specify Matrix access token")
+	matrixConfigCmd.Flags().StringP("roomid", "r", "", "Specify Matrix room id")
+}
@@ -60,6 +60,24 @@ var mattermostConfigCmd = &cobra.Command{
 			logrus.Fatal(err)
 		}
 
+		token, err := cmd.Flags().GetString("token")
+		if err == nil {
+			if len(token) > 0 {
+				conf.Handler.Mattermost.Token = token
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		channelID, err := cmd.Flags().GetString("channelid")
+		if err == nil {
+			if len(channelID) > 0 {
+				conf.Handler.Mattermost.ChannelID = channelID
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
 		if err = conf.Write(); err != nil {
 			logrus.Fatal(err)
 		}
@@ -70,4 +88,6 @@ func init() {
 	mattermostConfigCmd.Flags().StringP("channel", "c", "", "Specify Mattermost channel")
 	mattermostConfigCmd.Flags().StringP("url", "u", "", "Specify Mattermost url")
 	mattermostConfigCmd.Flags().StringP("username", "n", "", "Specify Mattermost username")
+	mattermostConfigCmd.Flags().StringP("token", "t", "", "Specify Mattermost bot token, to post through the REST API")
+	mattermostConfigCmd.Flags().StringP("channelid", "i", "", "Specify Mattermost channel id of the bot")
 }
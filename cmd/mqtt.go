@@ -0,0 +1,73 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/spf13/cobra"
+)
+
+// mqttConfigCmd represents the mqtt subcommand
+var mqttConfigCmd = &cobra.Command{
+	Use:   "mqtt",
+	Short: "specific mqtt configuration",
+	Long:  `specific mqtt configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		broker, err := cmd.Flags().GetString("broker")
+		if err == nil {
+			if len(broker) > 0 {
+				conf.Handler.MQTT.Broker = broker
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		topic, err := cmd.Flags().GetString("topic")
+		if err == nil {
+			if len(topic) > 0 {
+				conf.Handler.MQTT.Topic = topic
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		clientid, err := cmd.Flags().GetString("clientid")
+		if err == nil {
+			if len(clientid) > 0 {
+				conf.Handler.MQTT.ClientID = clientid
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	mqttConfigCmd.Flags().StringP("broker", "b", "", "Specify MQTT broker url, e.g. tcp://mqtt:1883")
+	mqttConfigCmd.Flags().StringP("topic", "t", "", "Specify the MQTT topic template")
+	mqttConfigCmd.Flags().StringP("clientid", "c", "", "Specify the MQTT client ID")
+}
@@ -42,6 +42,15 @@ var msteamsConfigCmd = &cobra.Command{
 			logrus.Fatal(err)
 		}
 
+		adaptiveCard, err := cmd.Flags().GetBool("adaptivecard")
+		if err == nil {
+			if adaptiveCard {
+				conf.Handler.MSTeams.AdaptiveCard = true
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
 		if err = conf.Write(); err != nil {
 			logrus.Fatal(err)
 		}
@@ -50,4 +59,5 @@ var msteamsConfigCmd = &cobra.Command{
 
 func init() {
 	msteamsConfigCmd.Flags().StringP("webhookurl", "w", "", "Specify MS Teams webhook URL")
+	msteamsConfigCmd.Flags().Bool("adaptivecard", false, "Post Adaptive Cards to a Power Automate Workflows webhook")
 }
@@ -0,0 +1,63 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/spf13/cobra"
+)
+
+// opsgenieConfigCmd represents the opsgenie subcommand
+var opsgenieConfigCmd = &cobra.Command{
+	Use:   "opsgenie",
+	Short: "specific Opsgenie configuration",
+	Long:  `specific Opsgenie configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		apikey, err := cmd.Flags().GetString("apikey")
+		if err == nil {
+			if len(apikey) > 0 {
+				conf.Handler.Opsgenie.APIKey = apikey
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		region, err := cmd.Flags().GetString("region")
+		if err == nil {
+			if len(region) > 0 {
+				conf.Handler.Opsgenie.Region = region
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	opsgenieConfigCmd.Flags().StringP("apikey", "k", "", "Specify Opsgenie API key")
+	opsgenieConfigCmd.Flags().StringP("region", "r", "", "Specify Opsgenie region, us or eu")
+}
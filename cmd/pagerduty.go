@@ -0,0 +1,63 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/spf13/cobra"
+)
+
+// pagerdutyConfigCmd represents the pagerduty subcommand
+var pagerdutyConfigCmd = &cobra.Command{
+	Use:   "pagerduty",
+	Short: "specific pagerduty configuration",
+	Long:  `specific pagerduty configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		integrationKey, err := cmd.Flags().GetString("integrationkey")
+		if err == nil {
+			if len(integrationKey) > 0 {
+				conf.Handler.PagerDuty.IntegrationKey = integrationKey
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		severity, err := cmd.Flags().GetString("severity")
+		if err == nil {
+			if len(severity) > 0 {
+				conf.Handler.PagerDuty.Severity = severity
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	pagerdutyConfigCmd.Flags().StringP("integrationkey", "k", "", "Specify PagerDuty integration key")
+	pagerdutyConfigCmd.Flags().StringP("severity", "s", "", "Specify PagerDuty incident severity")
+}
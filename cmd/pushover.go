@@ -0,0 +1,73 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/spf13/cobra"
+)
+
+// pushoverConfigCmd represents the pushover subcommand
+var pushoverConfigCmd = &cobra.Command{
+	Use:   "pushover",
+	Short: "specific pushover configuration",
+	Long:  `specific pushover configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		token, err := cmd.Flags().GetString("token")
+		if err == nil {
+			if len(token) > 0 {
+				conf.Handler.Pushover.Token = token
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		user, err := cmd.Flags().GetString("user")
+		if err == nil {
+			if len(user) > 0 {
+				conf.Handler.Pushover.User = user
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		device, err := cmd.Flags().GetString("device")
+		if err == nil {
+			if len(device) > 0 {
+				conf.Handler.Pushover.Device = device
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	pushoverConfigCmd.Flags().StringP("token", "t", "", "Specify Pushover application token")
+	pushoverConfigCmd.Flags().StringP("user", "u", "", "Specify Pushover user or group key")
+	pushoverConfigCmd.Flags().StringP("device", "d", "", "Specify Pushover device, all devices of the user by default")
+}
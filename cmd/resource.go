@@ -105,10 +105,22 @@ func configureResource(operation string, cmd *cobra.Command, conf *config.Config
 			"ns",
 			&conf.Resource.Namespace,
 		},
+		{
+			"sts",
+			&conf.Resource.StatefulSet,
+		},
 		{
 			"job",
 			&conf.Resource.Job,
 		},
+		{
+			"cronjob",
+			&conf.Resource.CronJob,
+		},
+		{
+			"node",
+			&conf.Resource.Node,
+		},
 		{
 			"pv",
 			&conf.Resource.PersistentVolume,
@@ -129,6 +141,46 @@ func configureResource(operation string, cmd *cobra.Command, conf *config.Config
 			"ing",
 			&conf.Resource.Ingress,
 		},
+		{
+			"event",
+			&conf.Resource.KubeEvent,
+		},
+		{
+			"hpa",
+			&conf.Resource.HorizontalPodAutoscaler,
+		},
+		{
+			"sa",
+			&conf.Resource.ServiceAccount,
+		},
+		{
+			"role",
+			&conf.Resource.Role,
+		},
+		{
+			"rolebinding",
+			&conf.Resource.RoleBinding,
+		},
+		{
+			"sc",
+			&conf.Resource.StorageClass,
+		},
+		{
+			"netpol",
+			&conf.Resource.NetworkPolicy,
+		},
+		{
+			"quota",
+			&conf.Resource.ResourceQuota,
+		},
+		{
+			"limits",
+			&conf.Resource.LimitRange,
+		},
+		{
+			"pdb",
+			&conf.Resource.PodDisruptionBudget,
+		},
 	}
 
 	for _, flag := range flags {
@@ -167,10 +219,23 @@ func init() {
 	resourceConfigCmd.PersistentFlags().Bool("rc", false, "watch for replication controllers")
 	resourceConfigCmd.PersistentFlags().Bool("rs", false, "watch for replicasets")
 	resourceConfigCmd.PersistentFlags().Bool("ns", false, "watch for namespaces")
+	resourceConfigCmd.PersistentFlags().Bool("node", false, "watch for nodes")
 	resourceConfigCmd.PersistentFlags().Bool("pv", false, "watch for persistent volumes")
+	resourceConfigCmd.PersistentFlags().Bool("sts", false, "watch for statefulsets")
 	resourceConfigCmd.PersistentFlags().Bool("job", false, "watch for jobs")
+	resourceConfigCmd.PersistentFlags().Bool("cronjob", false, "watch for cronjobs")
 	resourceConfigCmd.PersistentFlags().Bool("ds", false, "watch for daemonsets")
 	resourceConfigCmd.PersistentFlags().Bool("secret", false, "watch for plain secrets")
 	resourceConfigCmd.PersistentFlags().Bool("cm", false, "watch for plain configmaps")
 	resourceConfigCmd.PersistentFlags().Bool("ing", false, "watch for ingresses")
+	resourceConfigCmd.PersistentFlags().Bool("event", false, "watch for kubernetes events")
+	resourceConfigCmd.PersistentFlags().Bool("hpa", false, "watch for horizontal pod autoscalers")
+	resourceConfigCmd.PersistentFlags().Bool("sa", false, "watch for service accounts")
+	resourceConfigCmd.PersistentFlags().Bool("role", false, "watch for roles")
+	resourceConfigCmd.PersistentFlags().Bool("rolebinding", false, "watch for role bindings")
+	resourceConfigCmd.PersistentFlags().Bool("sc", false, "watch for storage classes")
+	resourceConfigCmd.PersistentFlags().Bool("netpol", false, "watch for network policies")
+	resourceConfigCmd.PersistentFlags().Bool("quota", false, "watch for resource quotas")
+	resourceConfigCmd.PersistentFlags().Bool("limits", false, "watch for limit ranges")
+	resourceConfigCmd.PersistentFlags().Bool("pdb", false, "watch for pod disruption budgets")
 }
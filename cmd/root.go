@@ -21,7 +21,6 @@ import (
 	"os"
 
 	"github.com/Sirupsen/logrus"
-	"github.com/mudasirmirza/kubewatch/config"
 	c "github.com/mudasirmirza/kubewatch/pkg/client"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -29,6 +28,19 @@ import (
 
 var cfgFile string
 
+// kubeconfig and kubeContext override the config file when set
+var kubeconfig, kubeContext string
+
+// logLevel and logFormat override the log config when set
+var logLevel, logFormat string
+
+// pprofPort overrides the pprof port of the server config when set
+var pprofPort int
+
+// kubeAPIQPS and kubeAPIBurst override the client side rate limits when set
+var kubeAPIQPS float32
+var kubeAPIBurst int
+
 // RootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
 	Use:   "kubewatch",
@@ -49,12 +61,34 @@ supported webhooks:
 `,
 
 	Run: func(cmd *cobra.Command, args []string) {
-		config := &config.Config{}
-		if err := config.Load(); err != nil {
+		config, err := c.LoadConfig()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		if kubeconfig != "" {
+			config.Kubeconfig = kubeconfig
+		}
+		if kubeContext != "" {
+			config.Context = kubeContext
+		}
+		if logLevel != "" {
+			config.Log.Level = logLevel
+		}
+		if logFormat != "" {
+			config.Log.Format = logFormat
+		}
+		if pprofPort != 0 {
+			config.Server.PprofPort = pprofPort
+		}
+		if kubeAPIQPS != 0 {
+			config.QPS = kubeAPIQPS
+		}
+		if kubeAPIBurst != 0 {
+			config.Burst = kubeAPIBurst
+		}
+		if err := c.SetupLogging(config.Log); err != nil {
 			logrus.Fatal(err)
 		}
-		config.CheckMissingResourceEnvvars()
-		config.UnmarshallConfig()
 		c.Run(config)
 	},
 }
@@ -76,6 +110,13 @@ func init() {
 		Use:    "no-help",
 		Hidden: true,
 	})
+	RootCmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "kubeconfig file used out of cluster (default is $KUBECONFIG or $HOME/.kube/config)")
+	RootCmd.Flags().StringVar(&kubeContext, "context", "", "kubeconfig context to watch (default is the current context)")
+	RootCmd.Flags().StringVar(&logLevel, "log-level", "", "log level: debug, info, warn or error (default is info)")
+	RootCmd.Flags().StringVar(&logFormat, "log-format", "", "log format: text or json (default is text)")
+	RootCmd.Flags().IntVar(&pprofPort, "pprof-port", 0, "port serving the net/http/pprof handlers (default is disabled)")
+	RootCmd.Flags().Float32Var(&kubeAPIQPS, "kube-api-qps", 0, "queries per second to the API servers (default is client-go's 5)")
+	RootCmd.Flags().IntVar(&kubeAPIBurst, "kube-api-burst", 0, "burst of queries to the API servers (default is client-go's 10)")
 	//RootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.kubewatch.yaml)")
 }
 
@@ -0,0 +1,63 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/spf13/cobra"
+)
+
+// sentryConfigCmd represents the sentry subcommand
+var sentryConfigCmd = &cobra.Command{
+	Use:   "sentry",
+	Short: "specific sentry configuration",
+	Long:  `specific sentry configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		dsn, err := cmd.Flags().GetString("dsn")
+		if err == nil {
+			if len(dsn) > 0 {
+				conf.Handler.Sentry.DSN = dsn
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		environment, err := cmd.Flags().GetString("environment")
+		if err == nil {
+			if len(environment) > 0 {
+				conf.Handler.Sentry.Environment = environment
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	sentryConfigCmd.Flags().StringP("dsn", "d", "", "Specify Sentry dsn")
+	sentryConfigCmd.Flags().StringP("environment", "e", "", "Specify Sentry environment")
+}
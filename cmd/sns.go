@@ -0,0 +1,63 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/spf13/cobra"
+)
+
+// snsConfigCmd represents the sns subcommand
+var snsConfigCmd = &cobra.Command{
+	Use:   "sns",
+	Short: "specific SNS configuration",
+	Long:  `specific SNS configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		topicarn, err := cmd.Flags().GetString("topicarn")
+		if err == nil {
+			if len(topicarn) > 0 {
+				conf.Handler.SNS.TopicARN = topicarn
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		region, err := cmd.Flags().GetString("region")
+		if err == nil {
+			if len(region) > 0 {
+				conf.Handler.SNS.Region = region
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	snsConfigCmd.Flags().StringP("topicarn", "t", "", "Specify SNS topic ARN")
+	snsConfigCmd.Flags().StringP("region", "r", "", "Specify AWS region, defaults to the region of the topic")
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/spf13/cobra"
+)
+
+// syslogConfigCmd represents the syslog subcommand
+var syslogConfigCmd = &cobra.Command{
+	Use:   "syslog",
+	Short: "specific syslog configuration",
+	Long:  `writes events to the local syslog, or a remote one with --network and --address`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		conf.Handler.Syslog.Enabled = true
+		for flag, field := range map[string]*string{
+			"network":  &conf.Handler.Syslog.Network,
+			"address":  &conf.Handler.Syslog.Address,
+			"tag":      &conf.Handler.Syslog.Tag,
+			"severity": &conf.Handler.Syslog.Severity,
+			"facility": &conf.Handler.Syslog.Facility,
+		} {
+			value, err := cmd.Flags().GetString(flag)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			if len(value) > 0 {
+				*field = value
+			}
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	syslogConfigCmd.Flags().StringP("network", "n", "", "Specify the network of the remote syslog, tcp or udp")
+	syslogConfigCmd.Flags().StringP("address", "a", "", "Specify the host:port of the remote syslog")
+	syslogConfigCmd.Flags().StringP("tag", "t", "", "Specify the syslog tag, default kubewatch")
+	syslogConfigCmd.Flags().StringP("severity", "s", "", "Specify the syslog severity, default info")
+	syslogConfigCmd.Flags().StringP("facility", "f", "", "Specify the syslog facility, default daemon")
+}
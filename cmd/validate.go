@@ -0,0 +1,128 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/client"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "validate kubewatch configuration",
+	Long: `
+Validates a kubewatch configuration and prints the effective configuration,
+without connecting to a cluster. Exits non-zero on invalid configuration.`,
+	Run: runValidate,
+}
+
+// configValidateCmd validates the configuration like validateCmd, next to the other config commands
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "validate .kubewatch.yaml",
+	Long: `
+Validates a kubewatch configuration and prints the effective configuration,
+without connecting to a cluster. Exits non-zero on invalid configuration.`,
+	Run: runValidate,
+}
+
+// runValidate loads the configuration and checks it would notify events:
+// handler fields, selectors and templates are valid, and at least one resource
+// is watched and one handler configured
+func runValidate(cmd *cobra.Command, args []string) {
+	conf := &config.Config{}
+
+	path, err := cmd.Flags().GetString("config")
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	if path != "" {
+		err = conf.LoadFile(path)
+	} else {
+		err = conf.Load()
+	}
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	if err := conf.Validate(); err != nil {
+		logrus.Fatal(err)
+	}
+	conf.CheckMissingResourceEnvvars()
+	conf.UnmarshallConfig()
+	if err := client.CheckEnabled(conf); err != nil {
+		logrus.Fatal(err)
+	}
+
+	printEffectiveConfig(conf)
+}
+
+// printEffectiveConfig prints the resources, namespaces, handler and events
+// kubewatch would run with
+func printEffectiveConfig(conf *config.Config) {
+	namespaces := strings.Join(conf.Namespace, ", ")
+	if namespaces == "" {
+		namespaces = "all"
+	}
+
+	resources, err := yaml.Marshal(conf.Resource)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	fmt.Println("Configuration is valid")
+	fmt.Println("namespaces:", namespaces)
+	fmt.Println("handler:   ", client.HandlerName(conf))
+	for _, route := range conf.Routes {
+		events := "all events"
+		if len(route.EventTypes) > 0 {
+			events = strings.Join(route.EventTypes, ", ")
+		}
+		fmt.Printf("route:      %s (%s) -> %s\n", strings.Join(route.Resources, ", "), events, strings.Join(route.Handlers, ", "))
+	}
+	fmt.Println("events:")
+	fmt.Println("  global:  ", strings.Join(conf.Event.Global, ", "))
+	fmt.Println("  create:  ", strings.Join(conf.Event.Create, ", "))
+	fmt.Println("  update:  ", strings.Join(conf.Event.Update, ", "))
+	fmt.Println("  delete:  ", strings.Join(conf.Event.Delete, ", "))
+	fmt.Println("resource:")
+	fmt.Print(indent(string(resources), "  "))
+}
+
+func indent(s, prefix string) string {
+	lines := strings.SplitAfter(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+func init() {
+	RootCmd.AddCommand(validateCmd)
+	validateCmd.Flags().StringP("config", "c", "", "Specify config file, defaults to $HOME/.kubewatch.yaml")
+	configCmd.AddCommand(configValidateCmd)
+	configValidateCmd.Flags().StringP("config", "c", "", "Specify config file, defaults to $HOME/.kubewatch.yaml")
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/spf13/cobra"
+)
+
+// victoropsConfigCmd represents the victorops subcommand
+var victoropsConfigCmd = &cobra.Command{
+	Use:   "victorops",
+	Short: "specific victorops configuration",
+	Long:  `specific victorops configuration`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conf, err := config.New()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		apikey, err := cmd.Flags().GetString("apikey")
+		if err == nil {
+			if len(apikey) > 0 {
+				conf.Handler.VictorOps.APIKey = apikey
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		routingkey, err := cmd.Flags().GetString("routingkey")
+		if err == nil {
+			if len(routingkey) > 0 {
+				conf.Handler.VictorOps.RoutingKey = routingkey
+			}
+		} else {
+			logrus.Fatal(err)
+		}
+
+		if err = conf.Write(); err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+func init() {
+	victoropsConfigCmd.Flags().StringP("apikey", "k", "", "Specify VictorOps REST integration api key")
+	victoropsConfigCmd.Flags().StringP("routingkey", "r", "", "Specify VictorOps routing key")
+}
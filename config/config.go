@@ -17,26 +17,66 @@ limitations under the License.
 package config
 
 import (
+	"fmt"
 	"io/ioutil"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"gopkg.in/yaml.v2"
+	api_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // ConfigFileName stores file of config
 var ConfigFileName = ".kubewatch.yaml"
 
-// Handler contains handler configuration
+// Handler contains handler configuration. The File variants of secret fields,
+// e.g. TokenFile, read the value of the field from a file, like a mounted Secret.
 type Handler struct {
-	Slack      Slack      `json:"slack"`
-	Hipchat    Hipchat    `json:"hipchat"`
-	Mattermost Mattermost `json:"mattermost"`
-	Flock      Flock      `json:"flock"`
-	Webhook    Webhook    `json:"webhook"`
-	MSTeams    MSTeams    `json:"msteams"`
+	Slack         Slack         `json:"slack"`
+	Hipchat       Hipchat       `json:"hipchat"`
+	Mattermost    Mattermost    `json:"mattermost"`
+	Flock         Flock         `json:"flock"`
+	Webhook       Webhook       `json:"webhook"`
+	MSTeams       MSTeams       `json:"msteams"`
+	PagerDuty     PagerDuty     `json:"pagerduty"`
+	Discord       Discord       `json:"discord"`
+	Telegram      Telegram      `json:"telegram"`
+	Email         Email         `json:"email"`
+	SNS           SNS           `json:"sns"`
+	Opsgenie      Opsgenie      `json:"opsgenie"`
+	GoogleChat    GoogleChat    `json:"googlechat"`
+	Kafka         Kafka         `json:"kafka"`
+	Stdout        Stdout        `json:"stdout"`
+	Syslog        Syslog        `json:"syslog"`
+	Elasticsearch Elasticsearch `json:"elasticsearch"`
+	NATS          NATS          `json:"nats"`
+	MQTT          MQTT          `json:"mqtt"`
+	Sentry        Sentry        `json:"sentry"`
+	Gotify        Gotify        `json:"gotify"`
+	Pushover      Pushover      `json:"pushover"`
+	Webex         Webex         `json:"webex"`
+	VictorOps     VictorOps     `json:"victorops"`
+	Matrix        Matrix        `json:"matrix"`
+	Datadog       Datadog       `json:"datadog"`
+	TCP           TCP           `json:"tcp"`
+	Jira          Jira          `json:"jira"`
+	// HTTP settings shared by the HTTP based handlers
+	HTTP HTTP `json:"http,omitempty"`
+	// message templates per handler name, overriding the shared templates
+	Templates map[string]Templates `json:"templates,omitempty"`
 }
 
 // Resource contains resource configuration
@@ -45,14 +85,46 @@ type Resource struct {
 	ReplicationController bool `json:"rc"`
 	ReplicaSet            bool `json:"rs"`
 	DaemonSet             bool `json:"ds"`
-	Service              bool `json:"svc"`
+	StatefulSet           bool `json:"statefulset"`
+	Service               bool `json:"svc"`
 	Pod                   bool `json:"po"`
 	Job                   bool `json:"job"`
+	CronJob               bool `json:"cronjob"`
 	PersistentVolume      bool `json:"pv"`
 	Namespace             bool `json:"ns"`
+	Node                  bool `json:"node"`
 	Secret                bool `json:"secret"`
 	ConfigMap             bool `json:"configmap"`
 	Ingress               bool `json:"ing"`
+	// KubeEvent watches core Kubernetes Events, e.g. BackOff of a pod
+	KubeEvent               bool `json:"event"`
+	HorizontalPodAutoscaler bool `json:"hpa"`
+	ServiceAccount          bool `json:"sa"`
+	Role                    bool `json:"role"`
+	RoleBinding             bool `json:"rolebinding"`
+	StorageClass            bool `json:"sc"`
+	NetworkPolicy           bool `json:"netpol"`
+	ResourceQuota           bool `json:"quota"`
+	LimitRange              bool `json:"limits"`
+	PodDisruptionBudget     bool `json:"pdb"`
+}
+
+// CustomResource is a resource watched through the dynamic client, e.g. defined by a CRD
+type CustomResource struct {
+	Group    string `json:"group"`
+	Version  string `json:"version"`
+	Resource string `json:"resource"`
+	// Namespaced resources are watched in the configured namespaces
+	Namespaced bool `json:"namespaced"`
+}
+
+// ResourceType returns the resource type of the custom resource in the rest of
+// the config, e.g. in the event lists: the resource and its group, like widgets.example.com
+func (r CustomResource) ResourceType() string {
+	if r.Group == "" {
+		return r.Resource
+	}
+	return r.Resource + "." + r.Group
 }
 
 // Event struct for granular config
@@ -68,46 +140,735 @@ type Config struct {
 	Handler Handler `json:"handler"`
 	//Reason   []string `json:"reason"`
 	Resource Resource `json:"resource,omitempty"`
+	// resources watched through the dynamic client, e.g. custom resources of operators
+	CustomResources []CustomResource `json:"customresources,omitempty"`
 	// for watching specific namespace, leave it empty for watching all.
 	// this config is ignored when watching namespaces
 	Namespace []string `json:"namespace,omitempty"`
-	Event     Event  `json:"event,omitempty"`
+	// namespaces whose events are ignored, e.g. kube-system.
+	// Cluster scoped objects are never ignored.
+	NamespaceDenylist []string `json:"namespacedenylist,omitempty"`
+	// events of namespaces not matching this pattern are ignored, e.g. ^team-.
+	// Cluster scoped objects are never ignored.
+	NamespaceRegex string `json:"namespaceregex,omitempty"`
+	// above this count of namespaces, a single cluster wide watch per resource is
+	// filtered by namespace instead of a watch per namespace, 10 by default
+	NamespaceWatchLimit int   `json:"namespacewatchlimit,omitempty"`
+	Event               Event `json:"event,omitempty"`
+	// optional HTTP server for diagnostics, disabled when port is 0
+	Server Server `json:"server,omitempty"`
+	// Prometheus metrics endpoint, disabled when port is 0
+	Metrics Metrics `json:"metrics,omitempty"`
+	// condition based alerting inspecting the status of watched objects
+	Condition Condition `json:"condition,omitempty"`
+	// message templates shared by all handlers
+	Templates Templates `json:"templates,omitempty"`
+	// notify the objects created before the start with the existing reason, instead of dropping them
+	NotifyExisting bool `json:"notifyexisting,omitempty"`
+	// send a single notification once all watches are synced
+	NotifyOnReady bool `json:"notifyonready,omitempty"`
+	// handler receiving the ready notification, defaults to the configured handler
+	ReadyHandler string `json:"readyhandler,omitempty"`
+	// check each configured handler once initialized, by sending it a test message
+	SelfTest SelfTest `json:"selftest,omitempty"`
+	// stable logical names for objects with generated names
+	Normalize Normalize `json:"normalize,omitempty"`
+	// age window of notified objects per resource type, e.g. pod
+	Age map[string]Age `json:"age,omitempty"`
+	// name patterns of notified objects per resource type, e.g. pod
+	Names map[string]Names `json:"names,omitempty"`
+	// kind shown in notifications per resource type, e.g. replicationcontroller: replication controller
+	DisplayNames map[string]string `json:"displaynames,omitempty"`
+	// severity of event types, create, update or delete, per resource type overriding the
+	// default ones, e.g. secret: {delete: critical}
+	Severities map[string]map[string]string `json:"severities,omitempty"`
+	// events less severe than MinSeverity, info, warning or critical, are not notified
+	MinSeverity string `json:"minseverity,omitempty"`
+	// kubeconfig contexts to watch, each cluster runs the full set of watches
+	Contexts []string `json:"contexts,omitempty"`
+	// kubeconfig file used out of cluster, defaults to $KUBECONFIG or $HOME/.kube/config
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+	// kubeconfig context to watch, defaults to the current context
+	Context string `json:"context,omitempty"`
+	// client side rate limits of the requests to the API servers, large clusters may need
+	// more than client-go's defaults of 5 queries per second with bursts of 10
+	QPS   float32 `json:"qps,omitempty"`
+	Burst int     `json:"burst,omitempty"`
+	// name of the watched cluster in notifications, defaults to the UID of its kube-system
+	// namespace or the host of its API server. Watched contexts are named after themselves
+	ClusterName string `json:"clustername,omitempty"`
+	// coalescing of events per resource type, e.g. pod
+	Coalesce map[string]Coalesce `json:"coalesce,omitempty"`
+	// throttling of the repeated events of an object
+	Throttle Throttle `json:"throttle,omitempty"`
+	// periodic digests of the events sent instead of a message per event
+	Digest Digest `json:"digest,omitempty"`
+	// changes of updated objects notified per resource type, e.g. deployment
+	Changes map[string]Changes `json:"changes,omitempty"`
+	// filters applied by the API server when listing and watching, per resource type, e.g. pod
+	Filter map[string]Filter `json:"filter,omitempty"`
+	// reasons of the notified core Events, e.g. BackOff, all reasons when empty
+	EventReasons []string `json:"eventreasons,omitempty"`
+	// updates of pods are notified once a container restarted at least
+	// MinRestartCount times, e.g. crashlooping pods, all updates when 0
+	MinRestartCount int32 `json:"minrestartcount,omitempty"`
+	// updates of pods are notified when their phase changes to one of
+	// PodPhases, e.g. Failed or Unknown, all updates when empty
+	PodPhases []string `json:"podphases,omitempty"`
+	// annotation of objects opting out of notifications, or in with OptIn
+	NotifyAnnotation NotifyAnnotation `json:"notifyannotation,omitempty"`
+	// period of the informers resyncs, e.g. 5m, which notify every watched
+	// object as updated. 0 disables resyncs
+	ResyncPeriod time.Duration `json:"resyncperiod,omitempty"`
+	// bounds the handling of each event, a handler hanging on a slow endpoint
+	// is cancelled and the event retried. 30s by default
+	HandlerTimeout time.Duration `json:"handlertimeout,omitempty"`
+	// bounds closing the handlers on exit, once the queues drained, which
+	// flushes their buffered events. 30s by default
+	ShutdownTimeout time.Duration `json:"shutdowntimeout,omitempty"`
+	// handlers receiving the events of resource types, all handlers receive
+	// the events matching no route
+	Routes []Route `json:"routes,omitempty"`
+	// retries of events the handler failed to send
+	Retry Retry `json:"retry,omitempty"`
+	// only the replica holding a Lease lock runs the watches
+	LeaderElection LeaderElection `json:"leaderelection,omitempty"`
+	// level and format of the logs of kubewatch itself
+	Log Log `json:"log,omitempty"`
+
+	// envRefs are the environment references of the loaded handler config, by field path
+	envRefs map[string]string
+	// fileValues are the values read from the File variants of handler fields, by field path
+	fileValues map[string]string
 }
 
 // Slack contains slack configuration
 type Slack struct {
-	Token   string `json:"token"`
-	Channel string `json:"channel"`
-	Title   string `json:"title"`
+	Token     string `json:"token"`
+	TokenFile string `json:"tokenfile,omitempty"`
+	Channel   string `json:"channel"`
+	Title     string `json:"title"`
+	// Threads posts the events of an object as replies to its first message
+	Threads bool `json:"threads,omitempty"`
+	// ThreadTTL is how long events are threaded under a message, defaults to 1h
+	ThreadTTL time.Duration `json:"threadttl,omitempty"`
 }
 
 // Hipchat contains hipchat configuration
 type Hipchat struct {
-	Token string `json:"token"`
-	Room  string `json:"room"`
-	Url   string `json:"url"`
+	Token     string `json:"token"`
+	TokenFile string `json:"tokenfile,omitempty"`
+	Room      string `json:"room"`
+	Url       string `json:"url"`
 }
 
 // Mattermost contains mattermost configuration
 type Mattermost struct {
 	Channel  string `json:"room"`
 	Url      string `json:"url"`
+	UrlFile  string `json:"urlfile,omitempty"`
 	Username string `json:"username"`
+	// Token of a bot posting to ChannelID through the REST API, instead of the
+	// incoming webhook, Url being the server url, e.g. https://mattermost.example.com
+	Token     string `json:"token,omitempty"`
+	TokenFile string `json:"tokenfile,omitempty"`
+	ChannelID string `json:"channelid,omitempty"`
 }
 
 // Flock contains flock configuration
 type Flock struct {
-	Url string `json:"url"`
+	Url     string `json:"url"`
+	UrlFile string `json:"urlfile,omitempty"`
+}
+
+// Changes selects the updates of a resource type which are notified,
+// both spec (including metadata) and status changes are watched by default
+type Changes struct {
+	WatchSpec   *bool `json:"watchspec,omitempty"`
+	WatchStatus *bool `json:"watchstatus,omitempty"`
+}
+
+// Spec reports whether spec changes are watched
+func (c Changes) Spec() bool {
+	return c.WatchSpec == nil || *c.WatchSpec
+}
+
+// Status reports whether status changes are watched
+func (c Changes) Status() bool {
+	return c.WatchStatus == nil || *c.WatchStatus
+}
+
+// Coalescing key strategies
+const (
+	// CoalesceObject merges the events of an object
+	CoalesceObject = "object"
+	// CoalesceOwner merges the events of the objects sharing a controller, e.g. the pods of a replica set
+	CoalesceOwner = "owner"
+	// CoalesceNone disables coalescing
+	CoalesceNone = "none"
+)
+
+// Coalesce contains the coalescing config of a resource type,
+// the events sharing a key within Window are sent as one.
+// Key defaults to CoalesceObject.
+type Coalesce struct {
+	Window time.Duration `json:"window"`
+	Key    string        `json:"key"`
+}
+
+// Throttle contains the throttling config of the repeated events of objects,
+// the events of an object and event type within Window of the first one are
+// suppressed. Window defaults to 0, which disables throttling.
+type Throttle struct {
+	Window time.Duration `json:"window"`
+	// Summary sends the number of suppressed events once the window elapses
+	Summary bool `json:"summary"`
+}
+
+// Digest contains the config of the digests sent by each handler instead of a message per event,
+// the events are buffered and summarized once per Interval. Interval defaults to 0, which disables digests.
+type Digest struct {
+	Interval time.Duration `json:"interval"`
+	// MaxObjects caps the objects listed in a digest, 20 by default
+	MaxObjects int `json:"maxobjects"`
 }
 
 // Webhook contains webhook configuration
 type Webhook struct {
-	Url string `json:"url"`
+	Url     string `json:"url"`
+	UrlFile string `json:"urlfile,omitempty"`
+	// BatchSize sends buffered events as a JSON array once this many are buffered
+	BatchSize int `json:"batchsize"`
+	// FlushInterval sends buffered events as a JSON array periodically, e.g. 10s
+	FlushInterval time.Duration `json:"flushinterval"`
+	// Headers are set on every request, e.g. X-Api-Key
+	Headers map[string]string `json:"headers,omitempty"`
+	// BearerToken is sent in the Authorization header, exclusive with basic auth
+	BearerToken     string `json:"bearertoken,omitempty"`
+	BearerTokenFile string `json:"bearertokenfile,omitempty"`
+	// Username and Password enable basic auth
+	Username     string `json:"username,omitempty"`
+	Password     string `json:"password,omitempty"`
+	PasswordFile string `json:"passwordfile,omitempty"`
+	// Timeout of a request, defaults to 30s
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// Secret signs the requests with HMAC-SHA256, see the README for the signing string
+	Secret     string `json:"secret,omitempty"`
+	SecretFile string `json:"secretfile,omitempty"`
+	// SignatureHeader holds the signature, defaults to X-Kubewatch-Signature
+	SignatureHeader string `json:"signatureheader,omitempty"`
+	// SignTimestamp signs the timestamp header with the body, for receivers to reject replays
+	SignTimestamp bool `json:"signtimestamp,omitempty"`
 }
 
 // MSTeams contains MSTeams configuration
 type MSTeams struct {
-	WebhookURL string `json:"webhookurl"`
+	WebhookURL     string `json:"webhookurl"`
+	WebhookURLFile string `json:"webhookurlfile,omitempty"`
+	// AdaptiveCard posts Adaptive Cards for Power Automate Workflows instead of connector MessageCards
+	AdaptiveCard bool `json:"adaptivecard,omitempty"`
+}
+
+// PagerDuty contains PagerDuty configuration
+type PagerDuty struct {
+	IntegrationKey     string `json:"integrationkey"`
+	IntegrationKeyFile string `json:"integrationkeyfile,omitempty"`
+	// severity of triggered incidents, overridden per resource type by Severities
+	Severity   string            `json:"severity,omitempty"`
+	Severities map[string]string `json:"severities,omitempty"`
+	// Events API v2 url, defaults to https://events.pagerduty.com/v2/enqueue
+	Url string `json:"url,omitempty"`
+}
+
+// Discord contains Discord configuration
+type Discord struct {
+	WebhookURL     string `json:"webhookurl"`
+	WebhookURLFile string `json:"webhookurlfile,omitempty"`
+	Username       string `json:"username,omitempty"`
+	AvatarURL      string `json:"avatarurl,omitempty"`
+}
+
+// Telegram contains Telegram configuration
+type Telegram struct {
+	BotToken     string `json:"bottoken"`
+	BotTokenFile string `json:"bottokenfile,omitempty"`
+	// numeric chat id or @username of a channel
+	ChatID string `json:"chatid"`
+	// Bot API url, defaults to https://api.telegram.org
+	Url string `json:"url,omitempty"`
+}
+
+// Email contains SMTP email configuration
+type Email struct {
+	Host string `json:"host"`
+	// port 465 uses implicit TLS, other ports STARTTLS when supported, defaults to 587
+	Port         int      `json:"port,omitempty"`
+	Username     string   `json:"username,omitempty"`
+	Password     string   `json:"password,omitempty"`
+	PasswordFile string   `json:"passwordfile,omitempty"`
+	From         string   `json:"from"`
+	To           []string `json:"to"`
+	// subject template rendered with the event, e.g. "[{{.Cluster}}] {{.Reason}} {{.Name}}"
+	Subject string `json:"subject,omitempty"`
+}
+
+// SNS contains AWS SNS configuration, the AWS credential chain
+// is used unless an access key is set
+type SNS struct {
+	Region              string `json:"region,omitempty"`
+	TopicARN            string `json:"topicarn"`
+	AccessKeyID         string `json:"accesskeyid,omitempty"`
+	SecretAccessKey     string `json:"secretaccesskey,omitempty"`
+	SecretAccessKeyFile string `json:"secretaccesskeyfile,omitempty"`
+}
+
+// Opsgenie contains Opsgenie configuration
+type Opsgenie struct {
+	APIKey     string `json:"apikey"`
+	APIKeyFile string `json:"apikeyfile,omitempty"`
+	// us or eu, selects the API base url, defaults to us
+	Region     string              `json:"region,omitempty"`
+	Responders []OpsgenieResponder `json:"responders,omitempty"`
+}
+
+// OpsgenieResponder is notified of Opsgenie alerts
+type OpsgenieResponder struct {
+	// team, user, escalation or schedule
+	Type string `json:"type"`
+	// name of the responder, the username of users
+	Name string `json:"name"`
+}
+
+// GoogleChat contains Google Chat configuration
+type GoogleChat struct {
+	WebhookURL     string `json:"webhookurl"`
+	WebhookURLFile string `json:"webhookurlfile,omitempty"`
+}
+
+// Kafka contains Kafka configuration
+type Kafka struct {
+	Brokers []string  `json:"brokers"`
+	Topic   string    `json:"topic"`
+	SASL    KafkaSASL `json:"sasl,omitempty"`
+	TLS     KafkaTLS  `json:"tls,omitempty"`
+}
+
+// Kafka SASL mechanisms
+const (
+	KafkaSASLPlain       = "plain"
+	KafkaSASLScramSHA256 = "scram-sha-256"
+	KafkaSASLScramSHA512 = "scram-sha-512"
+)
+
+// KafkaSASL contains Kafka SASL authentication configuration
+type KafkaSASL struct {
+	// plain, scram-sha-256 or scram-sha-512, authentication is disabled when empty
+	Mechanism    string `json:"mechanism,omitempty"`
+	Username     string `json:"username,omitempty"`
+	Password     string `json:"password,omitempty"`
+	PasswordFile string `json:"passwordfile,omitempty"`
+}
+
+// KafkaTLS contains Kafka TLS configuration
+type KafkaTLS struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// CA bundle verifying the brokers, defaults to the system roots
+	CAFile             string `json:"cafile,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureskipverify,omitempty"`
+}
+
+// Stdout contains configuration of the handler printing events as JSON lines
+type Stdout struct {
+	Enabled bool `json:"enabled"`
+}
+
+// Syslog contains syslog configuration, events go to the local syslog daemon
+// unless a remote address is set
+type Syslog struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Network is tcp or udp, Address the host:port of the remote syslog
+	Network string `json:"network,omitempty"`
+	Address string `json:"address,omitempty"`
+	// Tag defaults to kubewatch
+	Tag string `json:"tag,omitempty"`
+	// Severity defaults to info, Facility to daemon
+	Severity string `json:"severity,omitempty"`
+	Facility string `json:"facility,omitempty"`
+}
+
+// SyslogSeverities and SyslogFacilities are the names of the syslog priorities
+var (
+	SyslogSeverities = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+	SyslogFacilities = []string{
+		"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news", "uucp", "cron", "authpriv", "ftp",
+		"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+	}
+)
+
+// Elasticsearch contains Elasticsearch configuration
+type Elasticsearch struct {
+	// Addresses are tried in order, e.g. https://elasticsearch:9200
+	Addresses []string `json:"addresses"`
+	// Index prefixes the daily indices, e.g. kubewatch-2024.01.02, defaults to kubewatch
+	Index string `json:"index,omitempty"`
+	// Username and Password enable basic auth
+	Username     string           `json:"username,omitempty"`
+	Password     string           `json:"password,omitempty"`
+	PasswordFile string           `json:"passwordfile,omitempty"`
+	TLS          ElasticsearchTLS `json:"tls,omitempty"`
+}
+
+// ElasticsearchTLS contains the TLS configuration of https addresses
+type ElasticsearchTLS struct {
+	// CA bundle verifying the nodes, defaults to the system roots
+	CAFile             string `json:"cafile,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureskipverify,omitempty"`
+}
+
+// NATS contains NATS configuration
+type NATS struct {
+	// URL of the servers, comma separated, e.g. nats://nats:4222
+	URL string `json:"url"`
+	// Subject prefixes the subjects of the events, e.g. kubewatch.pod.create, defaults to kubewatch
+	Subject string `json:"subject,omitempty"`
+	// Token, Username and Password, or CredsFile authenticate the connection
+	Token        string  `json:"token,omitempty"`
+	TokenFile    string  `json:"tokenfile,omitempty"`
+	Username     string  `json:"username,omitempty"`
+	Password     string  `json:"password,omitempty"`
+	PasswordFile string  `json:"passwordfile,omitempty"`
+	CredsFile    string  `json:"credsfile,omitempty"`
+	TLS          NATSTLS `json:"tls,omitempty"`
+}
+
+// NATSTLS contains the TLS configuration of tls servers
+type NATSTLS struct {
+	// CA bundle verifying the servers, defaults to the system roots
+	CAFile             string `json:"cafile,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureskipverify,omitempty"`
+}
+
+// MQTT contains MQTT configuration
+type MQTT struct {
+	// Broker URL, e.g. tcp://mqtt:1883 or ssl://mqtt:8883
+	Broker string `json:"broker"`
+	// Topic is a template of the topic of an event, given its Kind, EventType, Namespace,
+	// Name and Cluster, defaults to kubewatch/{{.Kind}}/{{.EventType}}
+	Topic string `json:"topic,omitempty"`
+	// ClientID identifies the session at the broker, defaults to kubewatch-<hostname>
+	ClientID string `json:"clientid,omitempty"`
+	// QoS of the published messages, 0, 1 or 2
+	QoS int `json:"qos,omitempty"`
+	// Retained messages are kept by the broker for new subscribers
+	Retained bool `json:"retained,omitempty"`
+	// Username and Password authenticate the client
+	Username     string  `json:"username,omitempty"`
+	Password     string  `json:"password,omitempty"`
+	PasswordFile string  `json:"passwordfile,omitempty"`
+	TLS          MQTTTLS `json:"tls,omitempty"`
+}
+
+// MQTTTLS contains the TLS configuration of ssl brokers
+type MQTTTLS struct {
+	// CA bundle verifying the broker, defaults to the system roots
+	CAFile             string `json:"cafile,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureskipverify,omitempty"`
+}
+
+// Sentry contains Sentry configuration
+type Sentry struct {
+	DSN     string `json:"dsn"`
+	DSNFile string `json:"dsnfile,omitempty"`
+	// Environment of the issues, e.g. production
+	Environment string `json:"environment,omitempty"`
+	// EventTypes captured as issues, create, update or delete. All when empty
+	EventTypes []string `json:"eventtypes,omitempty"`
+}
+
+// Gotify contains Gotify configuration
+type Gotify struct {
+	// Url of the Gotify server, e.g. https://gotify.example.com
+	Url       string `json:"url"`
+	Token     string `json:"token"`
+	TokenFile string `json:"tokenfile,omitempty"`
+	// Priority of all messages, 0 to 10. By default deletions are sent with
+	// priority 8, updates 5 and creations 2
+	Priority int `json:"priority,omitempty"`
+}
+
+// Pushover contains Pushover configuration
+type Pushover struct {
+	// Token of the Pushover application
+	Token     string `json:"token"`
+	TokenFile string `json:"tokenfile,omitempty"`
+	// User or group key receiving the messages
+	User string `json:"user"`
+	// Device of the user receiving the messages, all devices when empty
+	Device string `json:"device,omitempty"`
+	// Priority of the messages, -2 to 2. Emergency messages, priority 2, are repeated
+	// every Retry, 1m by default, until acknowledged or Expire, 1h by default, elapsed
+	Priority int           `json:"priority,omitempty"`
+	Retry    time.Duration `json:"retry,omitempty"`
+	Expire   time.Duration `json:"expire,omitempty"`
+	// messages API url, defaults to https://api.pushover.net/1/messages.json
+	Url string `json:"url,omitempty"`
+}
+
+// Webex contains Webex configuration
+type Webex struct {
+	BotToken     string `json:"bottoken"`
+	BotTokenFile string `json:"bottokenfile,omitempty"`
+	// RoomID of the space the bot posts to
+	RoomID string `json:"roomid"`
+	// messages API url, defaults to https://webexapis.com/v1/messages
+	Url string `json:"url,omitempty"`
+}
+
+// VictorOps contains VictorOps (Splunk On-Call) configuration
+type VictorOps struct {
+	APIKey     string `json:"apikey"`
+	APIKeyFile string `json:"apikeyfile,omitempty"`
+	// RoutingKey routes the incidents to an escalation policy
+	RoutingKey string `json:"routingkey"`
+	// ResolveOnDelete recovers the incident of deleted objects instead of raising a critical one
+	ResolveOnDelete bool `json:"resolveondelete,omitempty"`
+	// REST integration url, defaults to https://alert.victorops.com/integrations/generic/20131114/alert
+	Url string `json:"url,omitempty"`
+}
+
+// HTTP contains the proxy and TLS settings of the HTTP based handlers, e.g. behind a corporate
+// proxy. The proxies default to the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+type HTTP struct {
+	HTTPProxy  string `json:"httpproxy,omitempty"`
+	HTTPSProxy string `json:"httpsproxy,omitempty"`
+	// NoProxy lists the hosts, domains and CIDRs reached directly, comma separated
+	NoProxy string `json:"noproxy,omitempty"`
+	// CACertFile is a PEM file of CAs trusted in addition to the system ones, e.g. a private CA
+	CACertFile         string `json:"cacertfile,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureskipverify,omitempty"`
+}
+
+// Matrix contains Matrix configuration
+type Matrix struct {
+	// Homeserver is the url of the homeserver, e.g. https://matrix.example.com
+	Homeserver      string `json:"homeserver"`
+	AccessToken     string `json:"accesstoken"`
+	AccessTokenFile string `json:"accesstokenfile,omitempty"`
+	// RoomID of the room the messages are sent to, e.g. !abcdef:example.com
+	RoomID string `json:"roomid"`
+}
+
+// Datadog contains Datadog configuration
+type Datadog struct {
+	APIKey     string `json:"apikey"`
+	APIKeyFile string `json:"apikeyfile,omitempty"`
+	// Site of the Datadog account, e.g. datadoghq.eu, defaults to datadoghq.com
+	Site string `json:"site,omitempty"`
+	// events API url, defaults to https://api.<site>/api/v1/events
+	Url string `json:"url,omitempty"`
+}
+
+// TCP contains configuration of the handler writing events as lines of JSON to a TCP socket
+type TCP struct {
+	// Address is the host:port of the collector
+	Address string `json:"address"`
+	TLS     TCPTLS `json:"tls,omitempty"`
+}
+
+// TCPTLS contains the TLS configuration of the connection
+type TCPTLS struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// CA bundle verifying the collector, defaults to the system roots
+	CAFile             string `json:"cafile,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureskipverify,omitempty"`
+}
+
+// Jira contains Jira configuration
+type Jira struct {
+	// BaseURL of the Jira site, e.g. https://example.atlassian.net
+	BaseURL      string `json:"baseurl"`
+	Username     string `json:"username"`
+	APIToken     string `json:"apitoken"`
+	APITokenFile string `json:"apitokenfile,omitempty"`
+	// ProjectKey of the created issues, e.g. OPS
+	ProjectKey string `json:"projectkey"`
+	// IssueType of the created issues, defaults to Task
+	IssueType string `json:"issuetype,omitempty"`
+	// EventTypes creating issues, create, update or delete. All when empty
+	EventTypes []string `json:"eventtypes,omitempty"`
+}
+
+// Server contains configuration of the optional HTTP server
+type Server struct {
+	Port int `json:"port"`
+	// EnablePprof exposes net/http/pprof handlers, which leak process internals
+	EnablePprof bool   `json:"enablepprof"`
+	PprofPath   string `json:"pprofpath"`
+	// PprofPort serves the pprof handlers on their own port, enabling them
+	// without the rest of the server. Disabled when 0
+	PprofPort int `json:"pprofport,omitempty"`
+	// StaleAfter is how long /healthz tolerates a watch or work queue
+	// making no progress, 10 minutes by default
+	StaleAfter time.Duration `json:"staleafter"`
+}
+
+// Route sends the events of resource types to handlers
+type Route struct {
+	// Resources matched by the route, e.g. pod
+	Resources []string `json:"resources"`
+	// EventTypes matched by the route, create, update or delete. All when empty
+	EventTypes []string `json:"eventtypes"`
+	// Handlers receiving the matched events, e.g. slack
+	Handlers []string `json:"handlers"`
+}
+
+// Matches reports whether the route matches the resource type and event type
+func (r Route) Matches(resourceType, eventType string) bool {
+	return contains(r.Resources, resourceType) && (len(r.EventTypes) == 0 || contains(r.EventTypes, eventType))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Log formats
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// LogLevels are the accepted log levels, from the most verbose
+var LogLevels = []string{"debug", "info", "warn", "error"}
+
+// Log contains configuration of the logs of kubewatch
+type Log struct {
+	// Level is one of LogLevels, defaults to info
+	Level string `json:"level,omitempty"`
+	// Format is text or json, defaults to text
+	Format string `json:"format,omitempty"`
+}
+
+// Validate checks the level and the format
+func (l Log) Validate() error {
+	if l.Level != "" && !contains(LogLevels, l.Level) {
+		return fmt.Errorf("log: invalid level %q, must be one of %s", l.Level, strings.Join(LogLevels, ", "))
+	}
+	if l.Format != "" && l.Format != LogFormatText && l.Format != LogFormatJSON {
+		return fmt.Errorf("log: invalid format %q, must be %s or %s", l.Format, LogFormatText, LogFormatJSON)
+	}
+	return nil
+}
+
+// Retry contains configuration of the retries of events the handler failed to send.
+// Retries back off exponentially from BaseDelay up to MaxDelay.
+type Retry struct {
+	// MaxRetries defaults to 5
+	MaxRetries int `json:"maxretries"`
+	// BaseDelay and MaxDelay default to 5ms and 1000s
+	BaseDelay time.Duration `json:"basedelay"`
+	MaxDelay  time.Duration `json:"maxdelay"`
+	// DeadLetterFile is appended the events given up on as JSON lines
+	DeadLetterFile string `json:"deadletterfile,omitempty"`
+}
+
+// SelfTest contains configuration of the check of the handlers on startup and reload.
+// Handlers whose API supports it check their credentials, the other ones are sent a test message.
+type SelfTest struct {
+	Enabled bool `json:"enabled"`
+	// FailOnError fails the startup, or rejects the reloaded config, when a handler fails its check.
+	// Failures are only logged otherwise
+	FailOnError bool `json:"failonerror"`
+}
+
+// LeaderElection contains configuration of the election of the replica running the watches.
+// The Lease lock is held in the first of the watched clusters.
+type LeaderElection struct {
+	Enabled bool `json:"enabled"`
+	// Namespace of the Lease lock, default by default
+	Namespace string `json:"namespace"`
+	// Name of the Lease lock, kubewatch by default
+	Name string `json:"name"`
+	// LeaseDuration, RenewDeadline and RetryPeriod default to 15s, 10s and 2s
+	LeaseDuration time.Duration `json:"leaseduration"`
+	RenewDeadline time.Duration `json:"renewdeadline"`
+	RetryPeriod   time.Duration `json:"retryperiod"`
+}
+
+// NotifyAnnotation contains configuration of the annotation letting the owners
+// of objects opt them out of notifications, or in
+type NotifyAnnotation struct {
+	// Name of the annotation, kubewatch.io/notify by default.
+	// Objects annotated with "false" are not notified
+	Name string `json:"name,omitempty"`
+	// OptIn notifies only the objects annotated with "true"
+	OptIn bool `json:"optin,omitempty"`
+}
+
+// Metrics contains configuration of the Prometheus metrics endpoint.
+// It is served by the HTTP server when both use the same port.
+type Metrics struct {
+	Port int `json:"port"`
+	// Path defaults to /metrics
+	Path string `json:"path"`
+}
+
+// Condition contains configuration of condition based alerting
+type Condition struct {
+	// UnavailableReplicas alerts when a deployment or statefulset wants replicas
+	// but has none available, and resolves the alert once availability recovers.
+	// Updates of healthy objects are not notified while enabled.
+	UnavailableReplicas bool `json:"unavailablereplicas"`
+	// IngressBackend alerts when a created or updated ingress references a
+	// service missing from the service watch. It requires watching services.
+	IngressBackend bool `json:"ingressbackend"`
+}
+
+// Templates contains Go text/template strings rendering notification messages.
+// Event types without a template use the Default one.
+type Templates struct {
+	Default string `json:"default"`
+	Create  string `json:"create"`
+	Update  string `json:"update"`
+	Delete  string `json:"delete"`
+}
+
+// Normalize contains configuration of object name normalization
+type Normalize struct {
+	Enabled bool `json:"enabled"`
+	// Pattern matches the generated suffix stripped from object names,
+	// defaults to pod-template-hash and generate-name suffixes
+	Pattern string `json:"pattern"`
+	// ShowInAlerts replaces object names in notifications with their normalized name
+	ShowInAlerts bool `json:"showinalerts"`
+}
+
+// Age bounds the age of notified objects, computed from their creation timestamp.
+// Zero bounds are ignored.
+type Age struct {
+	MinAge time.Duration `json:"minage"`
+	MaxAge time.Duration `json:"maxage"`
+}
+
+// Names selects the notified objects of a resource type by their name, matched against
+// regular expressions. Exclude wins over Include, which matches all names when empty
+type Names struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// Filter restricts the objects of a resource type listed and watched from the API server
+type Filter struct {
+	// LabelSelector selects objects by label, e.g. team=payments,tier!=frontend
+	LabelSelector string `json:"labelselector"`
+	// FieldSelector selects objects by field, e.g. spec.nodeName=node-1.
+	// Supported fields depend on the resource type, metadata.name and
+	// metadata.namespace are supported by all of them.
+	FieldSelector string `json:"fieldselector"`
 }
 
 // New creates new config object
@@ -145,7 +906,12 @@ func (c *Config) Load() error {
 		return err
 	}
 
-	file, err := os.Open(getConfigFile())
+	return c.LoadFile(getConfigFile())
+}
+
+// LoadFile loads configuration from the given file
+func (c *Config) LoadFile(path string) error {
+	file, err := os.Open(path)
 	if err != nil {
 		return err
 	}
@@ -156,7 +922,11 @@ func (c *Config) Load() error {
 	}
 
 	if len(b) != 0 {
-		return yaml.Unmarshal(b, c)
+		if err := yaml.Unmarshal(b, c); err != nil {
+			return err
+		}
+		c.expandEnv()
+		return c.readSecretFiles()
 	}
 
 	return nil
@@ -186,9 +956,18 @@ func (c *Config) CheckMissingResourceEnvvars() {
 	if !c.Resource.Service && os.Getenv("KW_SERVICE") == "true" {
 		c.Resource.Service = true
 	}
+	if !c.Resource.StatefulSet && os.Getenv("KW_STATEFULSET") == "true" {
+		c.Resource.StatefulSet = true
+	}
 	if !c.Resource.Job && os.Getenv("KW_JOB") == "true" {
 		c.Resource.Job = true
 	}
+	if !c.Resource.CronJob && os.Getenv("KW_CRONJOB") == "true" {
+		c.Resource.CronJob = true
+	}
+	if !c.Resource.Node && os.Getenv("KW_NODE") == "true" {
+		c.Resource.Node = true
+	}
 	if !c.Resource.PersistentVolume && os.Getenv("KW_PERSISTENT_VOLUME") == "true" {
 		c.Resource.PersistentVolume = true
 	}
@@ -201,6 +980,47 @@ func (c *Config) CheckMissingResourceEnvvars() {
 	if !c.Resource.Ingress && os.Getenv("KW_INGRESS") == "true" {
 		c.Resource.Ingress = true
 	}
+	if !c.Resource.KubeEvent && os.Getenv("KW_EVENT") == "true" {
+		c.Resource.KubeEvent = true
+	}
+	if !c.Resource.HorizontalPodAutoscaler && os.Getenv("KW_HPA") == "true" {
+		c.Resource.HorizontalPodAutoscaler = true
+	}
+	if !c.Resource.ServiceAccount && os.Getenv("KW_SERVICEACCOUNT") == "true" {
+		c.Resource.ServiceAccount = true
+	}
+	if !c.Resource.Role && os.Getenv("KW_ROLE") == "true" {
+		c.Resource.Role = true
+	}
+	if !c.Resource.RoleBinding && os.Getenv("KW_ROLEBINDING") == "true" {
+		c.Resource.RoleBinding = true
+	}
+	if !c.Resource.StorageClass && os.Getenv("KW_STORAGE_CLASS") == "true" {
+		c.Resource.StorageClass = true
+	}
+	if !c.Resource.NetworkPolicy && os.Getenv("KW_NETWORKPOLICY") == "true" {
+		c.Resource.NetworkPolicy = true
+	}
+	if !c.Resource.ResourceQuota && os.Getenv("KW_RESOURCEQUOTA") == "true" {
+		c.Resource.ResourceQuota = true
+	}
+	if !c.Resource.LimitRange && os.Getenv("KW_LIMITRANGE") == "true" {
+		c.Resource.LimitRange = true
+	}
+	if !c.Resource.PodDisruptionBudget && os.Getenv("KW_PDB") == "true" {
+		c.Resource.PodDisruptionBudget = true
+	}
+	if c.Server.PprofPort == 0 && os.Getenv("KW_PPROF_PORT") != "" {
+		port, err := strconv.Atoi(os.Getenv("KW_PPROF_PORT"))
+		if err != nil {
+			logrus.Warnf("Ignoring invalid KW_PPROF_PORT %q", os.Getenv("KW_PPROF_PORT"))
+		} else {
+			c.Server.PprofPort = port
+		}
+	}
+	if (c.ClusterName == "") && (os.Getenv("KW_CLUSTER_NAME") != "") {
+		c.ClusterName = os.Getenv("KW_CLUSTER_NAME")
+	}
 	if (c.Handler.Slack.Channel == "") && (os.Getenv("SLACK_CHANNEL") != "") {
 		c.Handler.Slack.Channel = os.Getenv("SLACK_CHANNEL")
 	}
@@ -215,7 +1035,7 @@ func (c *Config) UnmarshallConfig() {
 	if c.Resource != (Resource{}) {
 		logrus.Info("Configuring Resources For Global Events")
 		if c.Resource.DaemonSet {
-			c.Event.Global = append(c.Event.Global, "demonset")
+			c.Event.Global = append(c.Event.Global, "daemonset")
 		}
 		if c.Resource.ReplicaSet {
 			c.Event.Global = append(c.Event.Global, "replicaset")
@@ -235,9 +1055,18 @@ func (c *Config) UnmarshallConfig() {
 		if c.Resource.Service {
 			c.Event.Global = append(c.Event.Global, "service")
 		}
+		if c.Resource.StatefulSet {
+			c.Event.Global = append(c.Event.Global, "statefulset")
+		}
 		if c.Resource.Job {
 			c.Event.Global = append(c.Event.Global, "job")
 		}
+		if c.Resource.CronJob {
+			c.Event.Global = append(c.Event.Global, "cronjob")
+		}
+		if c.Resource.Node {
+			c.Event.Global = append(c.Event.Global, "node")
+		}
 		if c.Resource.PersistentVolume {
 			c.Event.Global = append(c.Event.Global, "persistentvolume")
 		}
@@ -250,6 +1079,36 @@ func (c *Config) UnmarshallConfig() {
 		if c.Resource.Ingress {
 			c.Event.Global = append(c.Event.Global, "ingress")
 		}
+		if c.Resource.KubeEvent {
+			c.Event.Global = append(c.Event.Global, "event")
+		}
+		if c.Resource.HorizontalPodAutoscaler {
+			c.Event.Global = append(c.Event.Global, "horizontalpodautoscaler")
+		}
+		if c.Resource.ServiceAccount {
+			c.Event.Global = append(c.Event.Global, "serviceaccount")
+		}
+		if c.Resource.Role {
+			c.Event.Global = append(c.Event.Global, "role")
+		}
+		if c.Resource.RoleBinding {
+			c.Event.Global = append(c.Event.Global, "rolebinding")
+		}
+		if c.Resource.StorageClass {
+			c.Event.Global = append(c.Event.Global, "storageclass")
+		}
+		if c.Resource.NetworkPolicy {
+			c.Event.Global = append(c.Event.Global, "networkpolicy")
+		}
+		if c.Resource.ResourceQuota {
+			c.Event.Global = append(c.Event.Global, "resourcequota")
+		}
+		if c.Resource.LimitRange {
+			c.Event.Global = append(c.Event.Global, "limitrange")
+		}
+		if c.Resource.PodDisruptionBudget {
+			c.Event.Global = append(c.Event.Global, "poddisruptionbudget")
+		}
 	} else {
 		// Configured using Events Config
 		logrus.Info("Configuring Resources Based on Events Config")
@@ -258,6 +1117,12 @@ func (c *Config) UnmarshallConfig() {
 		c.configureEvents(c.Event.Update)
 		c.configureEvents(c.Event.Delete)
 	}
+
+	// custom resources are enabled by their own list, and notified
+	// on all events unless listed under an event type
+	for _, r := range c.CustomResources {
+		c.Event.Global = append(c.Event.Global, r.ResourceType())
+	}
 }
 
 func (c *Config) configureEvents(s []string) {
@@ -287,10 +1152,22 @@ func (c *Config) configureEvents(s []string) {
 			{
 				c.Resource.Pod = true
 			}
+		case "statefulset":
+			{
+				c.Resource.StatefulSet = true
+			}
 		case "job":
 			{
 				c.Resource.Job = true
 			}
+		case "cronjob":
+			{
+				c.Resource.CronJob = true
+			}
+		case "node":
+			{
+				c.Resource.Node = true
+			}
 		case "persistentvolume":
 			{
 				c.Resource.PersistentVolume = true
@@ -311,12 +1188,777 @@ func (c *Config) configureEvents(s []string) {
 			{
 				c.Resource.Ingress = true
 			}
+		case "event":
+			{
+				c.Resource.KubeEvent = true
+			}
+		case "horizontalpodautoscaler":
+			{
+				c.Resource.HorizontalPodAutoscaler = true
+			}
+		case "serviceaccount":
+			{
+				c.Resource.ServiceAccount = true
+			}
+		case "role":
+			{
+				c.Resource.Role = true
+			}
+		case "rolebinding":
+			{
+				c.Resource.RoleBinding = true
+			}
+		case "storageclass":
+			{
+				c.Resource.StorageClass = true
+			}
+		case "networkpolicy":
+			{
+				c.Resource.NetworkPolicy = true
+			}
+		case "resourcequota":
+			{
+				c.Resource.ResourceQuota = true
+			}
+		case "limitrange":
+			{
+				c.Resource.LimitRange = true
+			}
+		case "poddisruptionbudget":
+			{
+				c.Resource.PodDisruptionBudget = true
+			}
+		}
+	}
+}
+
+// Validate checks the configuration for values which would fail at runtime
+func (c *Config) Validate() error {
+	var errs []string
+
+	if c.Server.Port < 0 || c.Server.Port > 65535 {
+		errs = append(errs, fmt.Sprintf("server: invalid port %d", c.Server.Port))
+	}
+	if c.Server.PprofPort < 0 || c.Server.PprofPort > 65535 {
+		errs = append(errs, fmt.Sprintf("server: invalid pprofport %d", c.Server.PprofPort))
+	}
+	if c.Server.StaleAfter < 0 {
+		errs = append(errs, fmt.Sprintf("server: invalid staleafter %s", c.Server.StaleAfter))
+	}
+	if c.NamespaceWatchLimit < 0 {
+		errs = append(errs, fmt.Sprintf("namespacewatchlimit: invalid value %d", c.NamespaceWatchLimit))
+	}
+	if c.Metrics.Port < 0 || c.Metrics.Port > 65535 {
+		errs = append(errs, fmt.Sprintf("metrics: invalid port %d", c.Metrics.Port))
+	}
+	for i, route := range c.Routes {
+		errs = append(errs, route.validate(fmt.Sprintf("routes[%d]", i))...)
+	}
+	if c.Retry.MaxRetries < 0 || c.Retry.BaseDelay < 0 || c.Retry.MaxDelay < 0 {
+		errs = append(errs, "retry: values must not be negative")
+	}
+	if c.Retry.BaseDelay > 0 && c.Retry.MaxDelay > 0 && c.Retry.BaseDelay > c.Retry.MaxDelay {
+		errs = append(errs, fmt.Sprintf("retry: basedelay %s exceeds maxdelay %s", c.Retry.BaseDelay, c.Retry.MaxDelay))
+	}
+	if c.ResyncPeriod < 0 {
+		errs = append(errs, fmt.Sprintf("resyncperiod: invalid period %s", c.ResyncPeriod))
+	}
+	if c.ShutdownTimeout < 0 {
+		errs = append(errs, fmt.Sprintf("shutdowntimeout: invalid timeout %s", c.ShutdownTimeout))
+	}
+	if c.HandlerTimeout < 0 {
+		errs = append(errs, fmt.Sprintf("handlertimeout: invalid timeout %s", c.HandlerTimeout))
+	}
+	if le := c.LeaderElection; le.LeaseDuration < 0 || le.RenewDeadline < 0 || le.RetryPeriod < 0 {
+		errs = append(errs, "leaderelection: durations must not be negative")
+	}
+	if c.MinRestartCount < 0 {
+		errs = append(errs, fmt.Sprintf("minrestartcount: invalid count %d", c.MinRestartCount))
+	}
+	if c.NotifyAnnotation.Name != "" {
+		for _, msg := range validation.IsQualifiedName(c.NotifyAnnotation.Name) {
+			errs = append(errs, fmt.Sprintf("notifyannotation: invalid name %q: %s", c.NotifyAnnotation.Name, msg))
+		}
+	}
+	for _, phase := range c.PodPhases {
+		switch api_v1.PodPhase(phase) {
+		case api_v1.PodPending, api_v1.PodRunning, api_v1.PodSucceeded, api_v1.PodFailed, api_v1.PodUnknown:
+		default:
+			errs = append(errs, fmt.Sprintf("podphases: unknown phase %q", phase))
+		}
+	}
+	if c.Throttle.Window < 0 {
+		errs = append(errs, fmt.Sprintf("throttle: invalid window %s", c.Throttle.Window))
+	}
+	if c.QPS < 0 {
+		errs = append(errs, fmt.Sprintf("qps: invalid rate %v", c.QPS))
+	}
+	if c.Burst < 0 {
+		errs = append(errs, fmt.Sprintf("burst: invalid burst %d", c.Burst))
+	}
+	if c.Digest.Interval < 0 {
+		errs = append(errs, fmt.Sprintf("digest: invalid interval %s", c.Digest.Interval))
+	}
+	if c.Digest.MaxObjects < 0 {
+		errs = append(errs, fmt.Sprintf("digest: invalid maxobjects %d", c.Digest.MaxObjects))
+	}
+	if err := c.Log.Validate(); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if c.NamespaceRegex != "" {
+		if _, err := regexp.Compile(c.NamespaceRegex); err != nil {
+			errs = append(errs, fmt.Sprintf("namespaceregex: invalid pattern: %v", err))
+		}
+	}
+
+	if c.Normalize.Enabled && c.Normalize.Pattern != "" {
+		if _, err := regexp.Compile(c.Normalize.Pattern); err != nil {
+			errs = append(errs, fmt.Sprintf("normalize: invalid pattern: %v", err))
+		}
+	}
+
+	for resource, age := range c.Age {
+		if age.MinAge < 0 || age.MaxAge < 0 || (age.MaxAge > 0 && age.MinAge > age.MaxAge) {
+			errs = append(errs, fmt.Sprintf("age.%s: invalid window %s to %s", resource, age.MinAge, age.MaxAge))
+		}
+	}
+
+	severities := []string{"info", "warning", "critical"}
+	if c.MinSeverity != "" && !contains(severities, c.MinSeverity) {
+		errs = append(errs, fmt.Sprintf("minseverity: invalid severity %q", c.MinSeverity))
+	}
+	for resource, eventTypes := range c.Severities {
+		for eventType, severity := range eventTypes {
+			if !contains([]string{"create", "update", "delete"}, eventType) {
+				errs = append(errs, fmt.Sprintf("severities.%s: invalid event type %q", resource, eventType))
+			} else if !contains(severities, severity) {
+				errs = append(errs, fmt.Sprintf("severities.%s.%s: invalid severity %q", resource, eventType, severity))
+			}
+		}
+	}
+
+	for resource, names := range c.Names {
+		for _, pattern := range append(append([]string{}, names.Include...), names.Exclude...) {
+			if _, err := regexp.Compile(pattern); err != nil {
+				errs = append(errs, fmt.Sprintf("names.%s: invalid pattern: %v", resource, err))
+			}
+		}
+	}
+
+	for resource, coalesce := range c.Coalesce {
+		switch coalesce.Key {
+		case "", CoalesceObject, CoalesceOwner, CoalesceNone:
+		default:
+			errs = append(errs, fmt.Sprintf("coalesce.%s: unknown key %q", resource, coalesce.Key))
+		}
+		if coalesce.Window < 0 {
+			errs = append(errs, fmt.Sprintf("coalesce.%s: invalid window %s", resource, coalesce.Window))
+		}
+	}
+
+	customResources := make(map[string]bool)
+	for i, r := range c.CustomResources {
+		if r.Version == "" || r.Resource == "" {
+			errs = append(errs, fmt.Sprintf("customresources[%d]: version and resource are required", i))
+		} else if customResources[r.ResourceType()] {
+			errs = append(errs, fmt.Sprintf("customresources[%d]: duplicate resource %s", i, r.ResourceType()))
+		}
+		customResources[r.ResourceType()] = true
+	}
+
+	for resource, filter := range c.Filter {
+		if _, err := labels.Parse(filter.LabelSelector); err != nil {
+			errs = append(errs, fmt.Sprintf("filter.%s: invalid label selector: %v", resource, err))
+		}
+		if _, err := fields.ParseSelector(filter.FieldSelector); err != nil {
+			errs = append(errs, fmt.Sprintf("filter.%s: invalid field selector: %v", resource, err))
+		}
+	}
+
+	errs = append(errs, c.Handler.Validate()...)
+	errs = append(errs, validateTemplates("templates", c.Templates)...)
+	for name, t := range c.Handler.Templates {
+		errs = append(errs, validateTemplates("handler.templates."+name, t)...)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("Invalid configuration:\n - %s", strings.Join(errs, "\n - "))
+	}
+	return nil
+}
+
+// Validate checks the required fields of each configured handler.
+// A handler is configured when any of its fields is set, either in the
+// config file or through its environment variables.
+func (h *Handler) Validate() []string {
+	var errs []string
+	for _, err := range []error{
+		h.Slack.Validate(),
+		h.Hipchat.Validate(),
+		h.Mattermost.Validate(),
+		h.Flock.Validate(),
+		h.Webhook.Validate(),
+		h.MSTeams.Validate(),
+		h.PagerDuty.Validate(),
+		h.Discord.Validate(),
+		h.Telegram.Validate(),
+		h.Email.Validate(),
+		h.SNS.Validate(),
+		h.Opsgenie.Validate(),
+		h.GoogleChat.Validate(),
+		h.Kafka.Validate(),
+		h.Syslog.Validate(),
+		h.Elasticsearch.Validate(),
+		h.NATS.Validate(),
+		h.MQTT.Validate(),
+		h.Sentry.Validate(),
+		h.Gotify.Validate(),
+		h.Pushover.Validate(),
+		h.Webex.Validate(),
+		h.VictorOps.Validate(),
+		h.HTTP.Validate(),
+		h.Matrix.Validate(),
+		h.Datadog.Validate(),
+		h.TCP.Validate(),
+		h.Jira.Validate(),
+	} {
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	return errs
+}
+
+// Validate checks that token and channel are both set
+func (s *Slack) Validate() error {
+	return requireAll("slack", []field{
+		{"token", s.Token, "KW_SLACK_TOKEN"},
+		{"channel", s.Channel, "KW_SLACK_CHANNEL"},
+	})
+}
+
+// Validate checks that token and room are both set
+func (h *Hipchat) Validate() error {
+	if err := requireAll("hipchat", []field{
+		{"token", h.Token, "KW_HIPCHAT_TOKEN"},
+		{"room", h.Room, "KW_HIPCHAT_ROOM"},
+	}); err != nil {
+		return err
+	}
+	return validateURL("hipchat", "url", h.Url)
+}
+
+// Validate checks that channel, url and username are all set, or url, token and channelid for a bot
+func (m *Mattermost) Validate() error {
+	if m.Token != "" || m.ChannelID != "" || os.Getenv("KW_MATTERMOST_TOKEN") != "" || os.Getenv("KW_MATTERMOST_CHANNELID") != "" {
+		if err := requireAll("mattermost", []field{
+			{"url", m.Url, "KW_MATTERMOST_URL"},
+			{"token", m.Token, "KW_MATTERMOST_TOKEN"},
+			{"channelid", m.ChannelID, "KW_MATTERMOST_CHANNELID"},
+		}); err != nil {
+			return err
+		}
+		return validateURL("mattermost", "url", m.Url)
+	}
+	if err := requireAll("mattermost", []field{
+		{"channel", m.Channel, "KW_MATTERMOST_CHANNEL"},
+		{"url", m.Url, "KW_MATTERMOST_URL"},
+		{"username", m.Username, "KW_MATTERMOST_USERNAME"},
+	}); err != nil {
+		return err
+	}
+	return validateURL("mattermost", "url", m.Url)
+}
+
+// Validate checks the url
+func (f *Flock) Validate() error {
+	return validateURL("flock", "url", f.Url)
+}
+
+// Validate checks the url and batching settings
+func (w *Webhook) Validate() error {
+	if w.BatchSize < 0 || w.FlushInterval < 0 {
+		return fmt.Errorf("webhook: batchsize and flushinterval can't be negative")
+	}
+	if (w.BatchSize > 0 || w.FlushInterval > 0) && w.Url == "" && os.Getenv("KW_WEBHOOK_URL") == "" {
+		return fmt.Errorf("webhook: batching set but url missing")
+	}
+	if w.Timeout < 0 {
+		return fmt.Errorf("webhook: invalid timeout %s", w.Timeout)
+	}
+	for name := range w.Headers {
+		if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+			return fmt.Errorf("webhook: invalid header name %q", name)
+		}
+	}
+	if (w.Username == "") != (w.Password == "") {
+		return fmt.Errorf("webhook: username and password must be set together")
+	}
+	if w.BearerToken != "" && w.Username != "" {
+		return fmt.Errorf("webhook: bearertoken and basic auth are exclusive")
+	}
+	if strings.ContainsAny(w.SignatureHeader, " \t\r\n:") {
+		return fmt.Errorf("webhook: invalid signatureheader %q", w.SignatureHeader)
+	}
+	if (w.SignatureHeader != "" || w.SignTimestamp) && w.Secret == "" && os.Getenv("KW_WEBHOOK_SECRET") == "" {
+		return fmt.Errorf("webhook: signing set but secret missing")
+	}
+	return validateURL("webhook", "url", w.Url)
+}
+
+// Validate checks the webhook url
+func (ms *MSTeams) Validate() error {
+	return validateURL("msteams", "webhookurl", ms.WebhookURL)
+}
+
+// Validate checks the integration key and severities
+func (p *PagerDuty) Validate() error {
+	configured := p.IntegrationKey != "" || os.Getenv("KW_PAGERDUTY_INTEGRATIONKEY") != ""
+	if !configured && (p.Severity != "" || len(p.Severities) > 0 || p.Url != "") {
+		return fmt.Errorf("pagerduty: integrationkey missing")
+	}
+
+	var invalid []string
+	for resource, severity := range p.Severities {
+		if !validSeverity(severity) {
+			invalid = append(invalid, resource)
+		}
+	}
+	if p.Severity != "" && !validSeverity(p.Severity) {
+		invalid = append(invalid, "default")
+	}
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		return fmt.Errorf("pagerduty: invalid severity for %s, must be one of critical, error, warning, info",
+			strings.Join(invalid, ", "))
+	}
+	return validateURL("pagerduty", "url", p.Url)
+}
+
+func validSeverity(severity string) bool {
+	switch severity {
+	case "critical", "error", "warning", "info":
+		return true
+	}
+	return false
+}
+
+// Validate checks that the webhook url is set along with the optional fields
+func (d *Discord) Validate() error {
+	if (d.Username != "" || d.AvatarURL != "") && d.WebhookURL == "" && os.Getenv("KW_DISCORD_WEBHOOKURL") == "" {
+		return fmt.Errorf("discord: username or avatarurl set but webhookurl missing")
+	}
+	if err := validateURL("discord", "webhookurl", d.WebhookURL); err != nil {
+		return err
+	}
+	return validateURL("discord", "avatarurl", d.AvatarURL)
+}
+
+// Validate checks that bot token and chat id are both set and the chat id format
+func (t *Telegram) Validate() error {
+	if err := requireAll("telegram", []field{
+		{"bottoken", t.BotToken, "KW_TELEGRAM_BOTTOKEN"},
+		{"chatid", t.ChatID, "KW_TELEGRAM_CHATID"},
+	}); err != nil {
+		return err
+	}
+	if t.ChatID != "" && !strings.HasPrefix(t.ChatID, "@") {
+		if _, err := strconv.ParseInt(t.ChatID, 10, 64); err != nil {
+			return fmt.Errorf("telegram: chatid %q must be numeric or a @channelusername", t.ChatID)
+		}
+	}
+	return validateURL("telegram", "url", t.Url)
+}
+
+// Validate checks that host, from and to are all set, and the subject template
+func (m *Email) Validate() error {
+	if err := requireAll("email", []field{
+		{"host", m.Host, "KW_EMAIL_HOST"},
+		{"from", m.From, "KW_EMAIL_FROM"},
+		{"to", strings.Join(m.To, ","), "KW_EMAIL_TO"},
+	}); err != nil {
+		return err
+	}
+	if m.Port < 0 || m.Port > 65535 {
+		return fmt.Errorf("email: invalid port %d", m.Port)
+	}
+	if _, err := template.New("subject").Parse(m.Subject); err != nil {
+		return fmt.Errorf("email: invalid subject: %v", err)
+	}
+	return nil
+}
+
+// Validate checks the topic ARN and that access keys are set in pairs
+func (s *SNS) Validate() error {
+	if err := requireAll("sns", []field{
+		{"accesskeyid", s.AccessKeyID, ""},
+		{"secretaccesskey", s.SecretAccessKey, ""},
+	}); err != nil {
+		return err
+	}
+	if (s.Region != "" || s.AccessKeyID != "") && s.TopicARN == "" && os.Getenv("KW_SNS_TOPICARN") == "" {
+		return fmt.Errorf("sns: topicarn missing")
+	}
+	if s.TopicARN != "" && !strings.HasPrefix(s.TopicARN, "arn:") {
+		return fmt.Errorf("sns: invalid topicarn %q", s.TopicARN)
+	}
+	return nil
+}
+
+// Validate checks the API key, region and responders
+func (o *Opsgenie) Validate() error {
+	configured := o.APIKey != "" || os.Getenv("KW_OPSGENIE_APIKEY") != ""
+	if !configured && (o.Region != "" || len(o.Responders) > 0) {
+		return fmt.Errorf("opsgenie: apikey missing")
+	}
+	switch strings.ToLower(o.Region) {
+	case "", "us", "eu":
+	default:
+		return fmt.Errorf("opsgenie: invalid region %q, must be us or eu", o.Region)
+	}
+	for _, r := range o.Responders {
+		switch r.Type {
+		case "team", "user", "escalation", "schedule":
+		default:
+			return fmt.Errorf("opsgenie: invalid responder type %q", r.Type)
+		}
+		if r.Name == "" {
+			return fmt.Errorf("opsgenie: %s responder name missing", r.Type)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the webhook url is valid
+func (g *GoogleChat) Validate() error {
+	return validateURL("googlechat", "webhookurl", g.WebhookURL)
+}
+
+// Validate checks that brokers and topic are both set and the SASL settings
+func (k *Kafka) Validate() error {
+	if err := requireAll("kafka", []field{
+		{"brokers", strings.Join(k.Brokers, ","), "KW_KAFKA_BROKERS"},
+		{"topic", k.Topic, "KW_KAFKA_TOPIC"},
+	}); err != nil {
+		return err
+	}
+	switch strings.ToLower(k.SASL.Mechanism) {
+	case "":
+		if k.SASL.Username != "" || k.SASL.Password != "" {
+			return fmt.Errorf("kafka: sasl username or password set but mechanism missing")
+		}
+	case KafkaSASLPlain, KafkaSASLScramSHA256, KafkaSASLScramSHA512:
+		if k.SASL.Username == "" || k.SASL.Password == "" {
+			return fmt.Errorf("kafka: sasl mechanism set but username or password missing")
 		}
+	default:
+		return fmt.Errorf("kafka: invalid sasl mechanism %q, must be plain, scram-sha-256 or scram-sha-512", k.SASL.Mechanism)
 	}
+	return nil
+}
+
+// Validate checks the network, address and priority names
+func (s *Syslog) Validate() error {
+	if (s.Network == "") != (s.Address == "") {
+		return fmt.Errorf("syslog: network and address must be set together")
+	}
+	if s.Network != "" && s.Network != "tcp" && s.Network != "udp" {
+		return fmt.Errorf("syslog: invalid network %q, must be tcp or udp", s.Network)
+	}
+	if s.Severity != "" && !contains(SyslogSeverities, s.Severity) {
+		return fmt.Errorf("syslog: invalid severity %q, must be one of %s", s.Severity, strings.Join(SyslogSeverities, ", "))
+	}
+	if s.Facility != "" && !contains(SyslogFacilities, s.Facility) {
+		return fmt.Errorf("syslog: invalid facility %q, must be one of %s", s.Facility, strings.Join(SyslogFacilities, ", "))
+	}
+	return nil
+}
+
+// Validate checks the addresses and that username and password are both set
+func (e *Elasticsearch) Validate() error {
+	configured := len(e.Addresses) > 0 || os.Getenv("KW_ELASTICSEARCH_ADDRESSES") != ""
+	if !configured && (e.Index != "" || e.Username != "" || e.Password != "") {
+		return fmt.Errorf("elasticsearch: addresses missing")
+	}
+	for _, address := range e.Addresses {
+		if err := validateURL("elasticsearch", "address", address); err != nil {
+			return err
+		}
+	}
+	if (e.Username == "") != (e.Password == "") {
+		return fmt.Errorf("elasticsearch: username and password must be set together")
+	}
+	return nil
+}
+
+// Validate checks the servers, the subject and that username and password are both set
+func (n *NATS) Validate() error {
+	configured := n.URL != "" || os.Getenv("KW_NATS_URL") != ""
+	if !configured && (n.Subject != "" || n.Token != "" || n.Username != "" || n.CredsFile != "") {
+		return fmt.Errorf("nats: url missing")
+	}
+	for _, server := range strings.Split(n.URL, ",") {
+		if u, err := url.Parse(strings.TrimSpace(server)); n.URL != "" && (err != nil || u.Host == "") {
+			return fmt.Errorf("nats: invalid url %q", server)
+		}
+	}
+	// events are published to subjects below the subject, wildcards would never match
+	if strings.ContainsAny(n.Subject, "*> \t") || strings.HasPrefix(n.Subject, ".") || strings.HasSuffix(n.Subject, ".") {
+		return fmt.Errorf("nats: invalid subject %q", n.Subject)
+	}
+	if (n.Username == "") != (n.Password == "") {
+		return fmt.Errorf("nats: username and password must be set together")
+	}
+	return nil
+}
+
+// Validate checks the broker, the topic template, the QoS and that username and password are both set
+func (m *MQTT) Validate() error {
+	configured := m.Broker != "" || os.Getenv("KW_MQTT_BROKER") != ""
+	if !configured && (m.Topic != "" || m.ClientID != "" || m.Username != "") {
+		return fmt.Errorf("mqtt: broker missing")
+	}
+	if m.Broker != "" {
+		u, err := url.Parse(m.Broker)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("mqtt: invalid broker %q", m.Broker)
+		}
+		switch u.Scheme {
+		case "tcp", "mqtt", "ssl", "tls", "mqtts", "ws", "wss":
+		default:
+			return fmt.Errorf("mqtt: invalid broker %q, scheme must be one of tcp, mqtt, ssl, tls, mqtts, ws, wss", m.Broker)
+		}
+	}
+	if _, err := template.New("topic").Parse(m.Topic); err != nil {
+		return fmt.Errorf("mqtt: invalid topic: %v", err)
+	}
+	if m.QoS < 0 || m.QoS > 2 {
+		return fmt.Errorf("mqtt: invalid qos %d, must be 0, 1 or 2", m.QoS)
+	}
+	if (m.Username == "") != (m.Password == "") {
+		return fmt.Errorf("mqtt: username and password must be set together")
+	}
+	return nil
+}
+
+// Validate checks that the dsn is set along with the other fields, and the event types
+func (s *Sentry) Validate() error {
+	configured := s.DSN != "" || os.Getenv("KW_SENTRY_DSN") != ""
+	if !configured && (s.Environment != "" || len(s.EventTypes) > 0) {
+		return fmt.Errorf("sentry: dsn missing")
+	}
+	if u, err := url.Parse(s.DSN); s.DSN != "" && (err != nil || u.Host == "" || u.User == nil) {
+		return fmt.Errorf("sentry: invalid dsn")
+	}
+	for _, eventType := range s.EventTypes {
+		if !contains([]string{"create", "update", "delete"}, eventType) {
+			return fmt.Errorf("sentry: invalid event type %q", eventType)
+		}
+	}
+	return nil
+}
+
+// Validate checks that url and token are set together, and the priority
+func (g *Gotify) Validate() error {
+	if err := requireAll("gotify", []field{
+		{"url", g.Url, "KW_GOTIFY_URL"},
+		{"token", g.Token, "KW_GOTIFY_TOKEN"},
+	}); err != nil {
+		return err
+	}
+	if g.Priority < 0 || g.Priority > 10 {
+		return fmt.Errorf("gotify: invalid priority %d", g.Priority)
+	}
+	return validateURL("gotify", "url", g.Url)
+}
+
+// Validate checks that token and user are set together, the priority and the
+// bounds of the retries of emergency messages
+func (p *Pushover) Validate() error {
+	if err := requireAll("pushover", []field{
+		{"token", p.Token, "KW_PUSHOVER_TOKEN"},
+		{"user", p.User, "KW_PUSHOVER_USER"},
+	}); err != nil {
+		return err
+	}
+	if p.Priority < -2 || p.Priority > 2 {
+		return fmt.Errorf("pushover: invalid priority %d", p.Priority)
+	}
+	if p.Retry != 0 && p.Retry < 30*time.Second {
+		return fmt.Errorf("pushover: retry %s must be at least 30s", p.Retry)
+	}
+	if p.Expire < 0 || p.Expire > 3*time.Hour {
+		return fmt.Errorf("pushover: expire %s must be at most 3h", p.Expire)
+	}
+	return validateURL("pushover", "url", p.Url)
+}
+
+// Validate checks that bottoken and roomid are set together, and the url
+func (w *Webex) Validate() error {
+	if err := requireAll("webex", []field{
+		{"bottoken", w.BotToken, "KW_WEBEX_BOTTOKEN"},
+		{"roomid", w.RoomID, "KW_WEBEX_ROOMID"},
+	}); err != nil {
+		return err
+	}
+	return validateURL("webex", "url", w.Url)
+}
+
+// Validate checks that apikey and routingkey are set together, and the url
+func (v *VictorOps) Validate() error {
+	if err := requireAll("victorops", []field{
+		{"apikey", v.APIKey, "KW_VICTOROPS_APIKEY"},
+		{"routingkey", v.RoutingKey, "KW_VICTOROPS_ROUTINGKEY"},
+	}); err != nil {
+		return err
+	}
+	return validateURL("victorops", "url", v.Url)
+}
+
+// Validate checks the proxy urls
+func (h *HTTP) Validate() error {
+	for _, proxy := range []struct{ name, value string }{
+		{"httpproxy", h.HTTPProxy},
+		{"httpsproxy", h.HTTPSProxy},
+	} {
+		if proxy.value == "" {
+			continue
+		}
+		u, err := url.Parse(proxy.value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") || u.Host == "" {
+			return fmt.Errorf("http: invalid %s %q, must be an http, https or socks5 url", proxy.name, proxy.value)
+		}
+	}
+	return nil
+}
+
+// Validate checks that homeserver, accesstoken and roomid are set together, and the homeserver url
+func (m *Matrix) Validate() error {
+	if err := requireAll("matrix", []field{
+		{"homeserver", m.Homeserver, "KW_MATRIX_HOMESERVER"},
+		{"accesstoken", m.AccessToken, "KW_MATRIX_ACCESSTOKEN"},
+		{"roomid", m.RoomID, "KW_MATRIX_ROOMID"},
+	}); err != nil {
+		return err
+	}
+	return validateURL("matrix", "homeserver", m.Homeserver)
+}
+
+// Validate checks that site is a domain and the url
+func (d *Datadog) Validate() error {
+	if strings.ContainsAny(d.Site, ":/") {
+		return fmt.Errorf("datadog: invalid site %q, must be a domain, e.g. datadoghq.eu", d.Site)
+	}
+	return validateURL("datadog", "url", d.Url)
+}
+
+// Validate checks that address is a host:port, and set when tls is
+func (t *TCP) Validate() error {
+	if t.Address == "" {
+		if t.TLS != (TCPTLS{}) && os.Getenv("KW_TCP_ADDRESS") == "" {
+			return fmt.Errorf("tcp: tls set but address missing")
+		}
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(t.Address); err != nil {
+		return fmt.Errorf("tcp: invalid address %q, must be host:port", t.Address)
+	}
+	return nil
+}
+
+// Validate checks that baseurl, username, apitoken and projectkey are set together, the base url and the event types
+func (j *Jira) Validate() error {
+	if err := requireAll("jira", []field{
+		{"baseurl", j.BaseURL, "KW_JIRA_BASEURL"},
+		{"username", j.Username, "KW_JIRA_USERNAME"},
+		{"apitoken", j.APIToken, "KW_JIRA_APITOKEN"},
+		{"projectkey", j.ProjectKey, "KW_JIRA_PROJECTKEY"},
+	}); err != nil {
+		return err
+	}
+	for _, eventType := range j.EventTypes {
+		if !contains([]string{"create", "update", "delete"}, eventType) {
+			return fmt.Errorf("jira: invalid event type %q", eventType)
+		}
+	}
+	return validateURL("jira", "baseurl", j.BaseURL)
+}
+
+// field is a handler config field, which may be set through an environment variable
+type field struct {
+	name  string
+	value string
+	env   string
+}
+
+// requireAll reports the missing fields of a handler once any of its fields is set
+func requireAll(handler string, fields []field) error {
+	var set, missing []string
+	for _, f := range fields {
+		if f.value != "" || os.Getenv(f.env) != "" {
+			set = append(set, f.name)
+		} else {
+			missing = append(missing, f.name)
+		}
+	}
+	if len(set) == 0 || len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s: %s set but %s missing", handler, strings.Join(set, ", "), strings.Join(missing, ", "))
+}
+
+// validateURL checks that a set url is an absolute http(s) url
+func validateURL(handler, name, value string) error {
+	if value == "" {
+		return nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s: invalid %s %q", handler, name, value)
+	}
+	return nil
+}
+
+func validateTemplates(prefix string, t Templates) []string {
+	var errs []string
+	for name, text := range map[string]string{
+		"default": t.Default,
+		"create":  t.Create,
+		"update":  t.Update,
+		"delete":  t.Delete,
+	} {
+		if _, err := template.New(name).Parse(text); err != nil {
+			errs = append(errs, fmt.Sprintf("%s.%s: %v", prefix, name, err))
+		}
+	}
+	sort.Strings(errs)
+	return errs
+}
+
+func (r Route) validate(prefix string) []string {
+	var errs []string
+	if len(r.Resources) == 0 {
+		errs = append(errs, prefix+": resources are required")
+	}
+	if len(r.Handlers) == 0 {
+		errs = append(errs, prefix+": handlers are required")
+	}
+	for _, eventType := range r.EventTypes {
+		if !contains([]string{"create", "update", "delete"}, eventType) {
+			errs = append(errs, fmt.Sprintf("%s: invalid event type %q", prefix, eventType))
+		}
+	}
+	return errs
 }
 
 func (c *Config) Write() error {
-	b, err := yaml.Marshal(c)
+	// environment references and secret files are written back rather than their values
+	refs, err := c.withRefs()
+	if err != nil {
+		return err
+	}
+	b, err := yaml.Marshal(refs)
 	if err != nil {
 		return err
 	}
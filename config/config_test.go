@@ -17,9 +17,15 @@ limitations under the License.
 package config
 
 import (
-//"io/ioutil"
-//"os"
-//"testing"
+	//"io/ioutil"
+	//"os"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
 )
 
 var configStr = `
@@ -75,3 +81,352 @@ var configStr = `
 //		t.Fatalf("TestLoad(): %+v", err)
 //	}
 //}
+
+func TestValidate(t *testing.T) {
+	var Tests = []struct {
+		config Config
+		valid  bool
+	}{
+		{Config{}, true},
+		{Config{Server: Server{Port: 8080}}, true},
+		{Config{Server: Server{Port: 70000}}, false},
+		{Config{Server: Server{Port: 8080, StaleAfter: 5 * time.Minute}}, true},
+		{Config{Server: Server{Port: 8080, StaleAfter: -time.Minute}}, false},
+		{Config{Server: Server{PprofPort: 6060}}, true},
+		{Config{Server: Server{PprofPort: -1}}, false},
+		{Config{NotifyAnnotation: NotifyAnnotation{Name: "example.com/notify", OptIn: true}}, true},
+		{Config{NotifyAnnotation: NotifyAnnotation{Name: "kubewatch notify"}}, false},
+		{Config{Names: map[string]Names{"pod": {Include: []string{"^web-"}, Exclude: []string{"^pr-"}}}}, true},
+		{Config{Names: map[string]Names{"pod": {Exclude: []string{"pr-("}}}}, false},
+		{Config{MinSeverity: "warning", Severities: map[string]map[string]string{"secret": {"update": "critical"}}}, true},
+		{Config{MinSeverity: "high"}, false},
+		{Config{Severities: map[string]map[string]string{"secret": {"deleted": "critical"}}}, false},
+		{Config{Severities: map[string]map[string]string{"secret": {"delete": "high"}}}, false},
+		{Config{ShutdownTimeout: time.Minute}, true},
+		{Config{ShutdownTimeout: -time.Second}, false},
+		{Config{HandlerTimeout: 10 * time.Second}, true},
+		{Config{HandlerTimeout: -time.Second}, false},
+		{Config{NamespaceWatchLimit: 50}, true},
+		{Config{NamespaceWatchLimit: -1}, false},
+		{Config{Metrics: Metrics{Port: 9090}}, true},
+		{Config{Metrics: Metrics{Port: -1}}, false},
+		{Config{ResyncPeriod: 5 * time.Minute}, true},
+		{Config{ResyncPeriod: -time.Second}, false},
+		{Config{Retry: Retry{MaxRetries: 10, BaseDelay: time.Second, MaxDelay: time.Hour}}, true},
+		{Config{Retry: Retry{MaxRetries: -1}}, false},
+		{Config{Routes: []Route{{Resources: []string{"pod"}, EventTypes: []string{"delete"}, Handlers: []string{"slack"}}}}, true},
+		{Config{Routes: []Route{{Resources: []string{"pod"}}}}, false},
+		{Config{Routes: []Route{{Resources: []string{"pod"}, EventTypes: []string{"deleted"}, Handlers: []string{"slack"}}}}, false},
+		{Config{Retry: Retry{BaseDelay: time.Hour, MaxDelay: time.Second}}, false},
+		{Config{LeaderElection: LeaderElection{Enabled: true, Namespace: "kubewatch", LeaseDuration: 30 * time.Second}}, true},
+		{Config{LeaderElection: LeaderElection{Enabled: true, RetryPeriod: -time.Second}}, false},
+		{Config{Normalize: Normalize{Enabled: true, Pattern: "-[a-z]+$"}}, true},
+		{Config{Normalize: Normalize{Enabled: true, Pattern: "-[a-z+$"}}, false},
+		{Config{Templates: Templates{Default: "{{.Kind}} {{.Name}}"}}, true},
+		{Config{Templates: Templates{Delete: "{{.Kind"}}, false},
+		{Config{Handler: Handler{Templates: map[string]Templates{"slack": {Update: "{{end}}"}}}}, false},
+		{Config{Coalesce: map[string]Coalesce{"pod": {Window: 10 * time.Second, Key: CoalesceOwner}}}, true},
+		{Config{Coalesce: map[string]Coalesce{"pod": {Window: 10 * time.Second}}}, true},
+		{Config{Coalesce: map[string]Coalesce{"pod": {Window: 10 * time.Second, Key: "label"}}}, false},
+		{Config{Coalesce: map[string]Coalesce{"pod": {Window: -time.Second}}}, false},
+		{Config{Filter: map[string]Filter{"pod": {LabelSelector: "team=payments,tier!=frontend"}}}, true},
+		{Config{Filter: map[string]Filter{"pod": {LabelSelector: "team in (payments"}}}, false},
+		{Config{Filter: map[string]Filter{"pod": {FieldSelector: "status.phase=Running,spec.nodeName!=node-1"}}}, true},
+		{Config{Filter: map[string]Filter{"pod": {FieldSelector: "status.phase"}}}, false},
+		{Config{Throttle: Throttle{Window: time.Minute, Summary: true}}, true},
+		{Config{Throttle: Throttle{Window: -time.Minute}}, false},
+		{Config{Digest: Digest{Interval: time.Hour, MaxObjects: 10}}, true},
+		{Config{Digest: Digest{Interval: -time.Hour}}, false},
+		{Config{Digest: Digest{Interval: time.Hour, MaxObjects: -1}}, false},
+		{Config{QPS: 50, Burst: 100}, true},
+		{Config{QPS: -1}, false},
+		{Config{Burst: -1}, false},
+		{Config{NamespaceRegex: "^team-"}, true},
+		{Config{NamespaceRegex: "team-("}, false},
+		{Config{MinRestartCount: 3}, true},
+		{Config{MinRestartCount: -1}, false},
+		{Config{PodPhases: []string{"Failed", "Unknown"}}, true},
+		{Config{PodPhases: []string{"failed"}}, false},
+		{Config{Log: Log{Level: "debug", Format: LogFormatJSON}}, true},
+		{Config{Log: Log{Level: "trace"}}, false},
+		{Config{Log: Log{Format: "logfmt"}}, false},
+		{Config{CustomResources: []CustomResource{{Group: "example.com", Version: "v1", Resource: "widgets", Namespaced: true}}}, true},
+		{Config{CustomResources: []CustomResource{{Group: "example.com", Resource: "widgets"}}}, false},
+		{Config{CustomResources: []CustomResource{{Group: "example.com", Version: "v1", Resource: "widgets"}, {Group: "example.com", Version: "v1beta1", Resource: "widgets"}}}, false},
+	}
+
+	for i, tt := range Tests {
+		if err := tt.config.Validate(); (err == nil) != tt.valid {
+			t.Fatalf("%d: Validate(): %v", i, err)
+		}
+	}
+}
+
+func TestHandlerValidate(t *testing.T) {
+	var Tests = []struct {
+		handler  Handler
+		expected []string
+	}{
+		{Handler{}, nil},
+		{Handler{Slack: Slack{Token: "foo", Channel: "bar"}}, nil},
+		{Handler{Slack: Slack{Token: "foo"}}, []string{"slack: token set but channel missing"}},
+		{Handler{Slack: Slack{Channel: "bar"}}, []string{"slack: channel set but token missing"}},
+		{Handler{Hipchat: Hipchat{Token: "foo", Room: "bar"}}, nil},
+		{Handler{Hipchat: Hipchat{Token: "foo", Room: "bar", Url: "https://api.hipchat.com/v2"}}, nil},
+		{Handler{Hipchat: Hipchat{Room: "bar"}}, []string{"hipchat: room set but token missing"}},
+		{Handler{Hipchat: Hipchat{Token: "foo", Room: "bar", Url: "api.hipchat.com"}}, []string{`hipchat: invalid url "api.hipchat.com"`}},
+		{Handler{Mattermost: Mattermost{Channel: "foo", Url: "http://mattermost", Username: "bar"}}, nil},
+		{Handler{Mattermost: Mattermost{Url: "http://mattermost"}}, []string{"mattermost: url set but channel, username missing"}},
+		{Handler{Mattermost: Mattermost{Channel: "foo", Username: "bar"}}, []string{"mattermost: channel, username set but url missing"}},
+		{Handler{Mattermost: Mattermost{Url: "http://mattermost", Token: "foo", ChannelID: "bar"}}, nil},
+		{Handler{Mattermost: Mattermost{Url: "http://mattermost", Token: "foo"}}, []string{"mattermost: url, token set but channelid missing"}},
+		{Handler{Flock: Flock{Url: "https://api.flock.com/hooks/sendMessage/foo"}}, nil},
+		{Handler{Flock: Flock{Url: "foo"}}, []string{`flock: invalid url "foo"`}},
+		{Handler{Webhook: Webhook{Url: "http://localhost:8080", BatchSize: 10}}, nil},
+		{Handler{Webhook: Webhook{BatchSize: 10}}, []string{"webhook: batching set but url missing"}},
+		{Handler{Webhook: Webhook{Url: "http://localhost:8080", BatchSize: -1}}, []string{"webhook: batchsize and flushinterval can't be negative"}},
+		{Handler{Webhook: Webhook{Url: "https://api.example.com/events", Headers: map[string]string{"X-Api-Key": "foo"}, BearerToken: "bar", Timeout: 5 * time.Second}}, nil},
+		{Handler{Webhook: Webhook{Url: "https://api.example.com/events", Headers: map[string]string{"X Api Key": "foo"}}}, []string{`webhook: invalid header name "X Api Key"`}},
+		{Handler{Webhook: Webhook{Url: "https://api.example.com/events", Username: "foo"}}, []string{"webhook: username and password must be set together"}},
+		{Handler{Webhook: Webhook{Url: "https://api.example.com/events", Secret: "foo", SignatureHeader: "X-Signature", SignTimestamp: true}}, nil},
+		{Handler{Webhook: Webhook{Url: "https://api.example.com/events", SignTimestamp: true}}, []string{"webhook: signing set but secret missing"}},
+		{Handler{Webhook: Webhook{Url: "https://api.example.com/events", BearerToken: "bar", Username: "foo", Password: "bar"}}, []string{"webhook: bearertoken and basic auth are exclusive"}},
+		{Handler{MSTeams: MSTeams{WebhookURL: "https://outlook.office.com/webhook/foo"}}, nil},
+		{Handler{MSTeams: MSTeams{WebhookURL: "outlook"}}, []string{`msteams: invalid webhookurl "outlook"`}},
+		{Handler{PagerDuty: PagerDuty{IntegrationKey: "foo", Severities: map[string]string{"pod": "critical"}}}, nil},
+		{Handler{PagerDuty: PagerDuty{Severity: "critical"}}, []string{"pagerduty: integrationkey missing"}},
+		{
+			Handler{PagerDuty: PagerDuty{IntegrationKey: "foo", Severity: "high", Severities: map[string]string{"pod": "low"}}},
+			[]string{"pagerduty: invalid severity for default, pod, must be one of critical, error, warning, info"},
+		},
+		{Handler{Discord: Discord{WebhookURL: "https://discord.com/api/webhooks/foo", Username: "kubewatch"}}, nil},
+		{Handler{Discord: Discord{Username: "kubewatch"}}, []string{"discord: username or avatarurl set but webhookurl missing"}},
+		{Handler{Telegram: Telegram{BotToken: "foo", ChatID: "@kubewatch"}}, nil},
+		{Handler{Telegram: Telegram{BotToken: "foo"}}, []string{"telegram: bottoken set but chatid missing"}},
+		{Handler{Telegram: Telegram{BotToken: "foo", ChatID: "kubewatch"}}, []string{`telegram: chatid "kubewatch" must be numeric or a @channelusername`}},
+		{Handler{Email: Email{Host: "smtp", Port: 465, From: "kubewatch@example.com", To: []string{"ops@example.com"}}}, nil},
+		{Handler{Email: Email{Host: "smtp", From: "kubewatch@example.com"}}, []string{"email: host, from set but to missing"}},
+		{
+			Handler{Email: Email{Host: "smtp", From: "kubewatch@example.com", To: []string{"ops@example.com"}, Subject: "{{.Kind"}},
+			[]string{`email: invalid subject: template: subject:1: unclosed action`},
+		},
+		{Handler{SNS: SNS{TopicARN: "arn:aws:sns:eu-west-1:123456789012:kubewatch"}}, nil},
+		{Handler{SNS: SNS{TopicARN: "arn:aws:sns:eu-west-1:123456789012:kubewatch", AccessKeyID: "foo"}}, []string{"sns: accesskeyid set but secretaccesskey missing"}},
+		{Handler{SNS: SNS{Region: "eu-west-1"}}, []string{"sns: topicarn missing"}},
+		{Handler{Opsgenie: Opsgenie{APIKey: "foo", Region: "eu", Responders: []OpsgenieResponder{{Type: "team", Name: "ops"}}}}, nil},
+		{Handler{Opsgenie: Opsgenie{APIKey: "foo", Region: "asia"}}, []string{`opsgenie: invalid region "asia", must be us or eu`}},
+		{Handler{Opsgenie: Opsgenie{APIKey: "foo", Responders: []OpsgenieResponder{{Type: "group", Name: "ops"}}}}, []string{`opsgenie: invalid responder type "group"`}},
+		{Handler{GoogleChat: GoogleChat{WebhookURL: "chat.googleapis.com"}}, []string{`googlechat: invalid webhookurl "chat.googleapis.com"`}},
+		{Handler{Kafka: Kafka{Brokers: []string{"localhost:9092"}, Topic: "kubewatch", SASL: KafkaSASL{Mechanism: "plain", Username: "foo", Password: "bar"}}}, nil},
+		{Handler{Kafka: Kafka{Brokers: []string{"localhost:9092"}}}, []string{"kafka: brokers set but topic missing"}},
+		{Handler{Kafka: Kafka{Brokers: []string{"localhost:9092"}, Topic: "kubewatch", SASL: KafkaSASL{Mechanism: "gssapi"}}}, []string{`kafka: invalid sasl mechanism "gssapi", must be plain, scram-sha-256 or scram-sha-512`}},
+		{Handler{Kafka: Kafka{Brokers: []string{"localhost:9092"}, Topic: "kubewatch", SASL: KafkaSASL{Mechanism: "plain"}}}, []string{"kafka: sasl mechanism set but username or password missing"}},
+		{Handler{Syslog: Syslog{Network: "udp", Address: "syslog:514", Severity: "warning", Facility: "local0"}}, nil},
+		{Handler{Syslog: Syslog{Address: "syslog:514"}}, []string{"syslog: network and address must be set together"}},
+		{Handler{Syslog: Syslog{Network: "unix", Address: "/dev/log"}}, []string{`syslog: invalid network "unix", must be tcp or udp`}},
+		{Handler{TCP: TCP{Address: "collector:9000", TLS: TCPTLS{Enabled: true}}}, nil},
+		{Handler{TCP: TCP{Address: "collector"}}, []string{`tcp: invalid address "collector", must be host:port`}},
+		{Handler{TCP: TCP{TLS: TCPTLS{Enabled: true}}}, []string{"tcp: tls set but address missing"}},
+		{
+			Handler{Syslog: Syslog{Enabled: true, Severity: "fatal"}},
+			[]string{`syslog: invalid severity "fatal", must be one of emerg, alert, crit, err, warning, notice, info, debug`},
+		},
+		{Handler{Elasticsearch: Elasticsearch{Addresses: []string{"https://elasticsearch:9200"}, Username: "foo", Password: "bar"}}, nil},
+		{Handler{Elasticsearch: Elasticsearch{Index: "kubewatch"}}, []string{"elasticsearch: addresses missing"}},
+		{Handler{Elasticsearch: Elasticsearch{Addresses: []string{"elasticsearch:9200"}}}, []string{`elasticsearch: invalid address "elasticsearch:9200"`}},
+		{Handler{Elasticsearch: Elasticsearch{Addresses: []string{"https://elasticsearch:9200"}, Username: "foo"}}, []string{"elasticsearch: username and password must be set together"}},
+		{Handler{NATS: NATS{URL: "nats://nats-1:4222,nats://nats-2:4222", Subject: "events.kubewatch"}}, nil},
+		{Handler{NATS: NATS{Subject: "kubewatch"}}, []string{"nats: url missing"}},
+		{Handler{NATS: NATS{URL: "nats:4222"}}, []string{`nats: invalid url "nats:4222"`}},
+		{Handler{NATS: NATS{URL: "nats://nats:4222", Subject: "kubewatch.>"}}, []string{`nats: invalid subject "kubewatch.>"`}},
+		{Handler{Sentry: Sentry{DSN: "https://key@sentry.example.com/1", EventTypes: []string{"delete"}}}, nil},
+		{Handler{Sentry: Sentry{Environment: "production"}}, []string{"sentry: dsn missing"}},
+		{Handler{Sentry: Sentry{DSN: "sentry.example.com/1"}}, []string{"sentry: invalid dsn"}},
+		{Handler{Sentry: Sentry{DSN: "https://key@sentry.example.com/1", EventTypes: []string{"deleted"}}}, []string{`sentry: invalid event type "deleted"`}},
+		{Handler{Gotify: Gotify{Url: "https://gotify.example.com", Token: "foo", Priority: 8}}, nil},
+		{Handler{Gotify: Gotify{Url: "https://gotify.example.com"}}, []string{"gotify: url set but token missing"}},
+		{Handler{Gotify: Gotify{Url: "https://gotify.example.com", Token: "foo", Priority: 11}}, []string{"gotify: invalid priority 11"}},
+		{Handler{Pushover: Pushover{Token: "foo", User: "bar", Priority: 2, Retry: time.Minute}}, nil},
+		{Handler{Pushover: Pushover{Token: "foo"}}, []string{"pushover: token set but user missing"}},
+		{Handler{Pushover: Pushover{Token: "foo", User: "bar", Priority: 3}}, []string{"pushover: invalid priority 3"}},
+		{Handler{Pushover: Pushover{Token: "foo", User: "bar", Retry: 10 * time.Second}}, []string{"pushover: retry 10s must be at least 30s"}},
+		{Handler{Pushover: Pushover{Token: "foo", User: "bar", Expire: 4 * time.Hour}}, []string{"pushover: expire 4h0m0s must be at most 3h"}},
+		{Handler{Webex: Webex{BotToken: "foo", RoomID: "bar"}}, nil},
+		{Handler{Webex: Webex{RoomID: "bar"}}, []string{"webex: roomid set but bottoken missing"}},
+		{Handler{HTTP: HTTP{HTTPSProxy: "http://proxy.example.com:3128", NoProxy: ".svc,10.0.0.0/8"}}, nil},
+		{Handler{HTTP: HTTP{HTTPProxy: "proxy.example.com:3128"}}, []string{`http: invalid httpproxy "proxy.example.com:3128", must be an http, https or socks5 url`}},
+		{Handler{VictorOps: VictorOps{APIKey: "foo", RoutingKey: "bar", ResolveOnDelete: true}}, nil},
+		{Handler{VictorOps: VictorOps{RoutingKey: "bar"}}, []string{"victorops: routingkey set but apikey missing"}},
+		{Handler{VictorOps: VictorOps{APIKey: "foo", RoutingKey: "bar", Url: "alert.victorops.com"}}, []string{`victorops: invalid url "alert.victorops.com"`}},
+		{Handler{Matrix: Matrix{Homeserver: "https://matrix.example.com", AccessToken: "foo", RoomID: "!bar:example.com"}}, nil},
+		{Handler{Matrix: Matrix{Homeserver: "https://matrix.example.com", RoomID: "!bar:example.com"}}, []string{"matrix: homeserver, roomid set but accesstoken missing"}},
+		{Handler{Matrix: Matrix{Homeserver: "matrix.example.com", AccessToken: "foo", RoomID: "!bar:example.com"}}, []string{`matrix: invalid homeserver "matrix.example.com"`}},
+		{Handler{Datadog: Datadog{APIKey: "foo", Site: "datadoghq.eu"}}, nil},
+		{Handler{Datadog: Datadog{APIKey: "foo", Site: "https://api.datadoghq.eu"}}, []string{`datadog: invalid site "https://api.datadoghq.eu", must be a domain, e.g. datadoghq.eu`}},
+		{Handler{Jira: Jira{BaseURL: "https://example.atlassian.net", Username: "bot", APIToken: "foo", ProjectKey: "OPS", EventTypes: []string{"delete"}}}, nil},
+		{Handler{Jira: Jira{BaseURL: "https://example.atlassian.net", Username: "bot", APIToken: "foo"}}, []string{"jira: baseurl, username, apitoken set but projectkey missing"}},
+		{Handler{Jira: Jira{BaseURL: "https://example.atlassian.net", Username: "bot", APIToken: "foo", ProjectKey: "OPS", EventTypes: []string{"deleted"}}}, []string{`jira: invalid event type "deleted"`}},
+		{Handler{MQTT: MQTT{Broker: "ssl://mqtt:8883", Topic: "edge/{{.Cluster}}/{{.Kind}}", QoS: 1}}, nil},
+		{Handler{MQTT: MQTT{Topic: "kubewatch"}}, []string{"mqtt: broker missing"}},
+		{Handler{MQTT: MQTT{Broker: "http://mqtt:1883"}}, []string{`mqtt: invalid broker "http://mqtt:1883", scheme must be one of tcp, mqtt, ssl, tls, mqtts, ws, wss`}},
+		{Handler{MQTT: MQTT{Broker: "tcp://mqtt:1883", QoS: 3}}, []string{"mqtt: invalid qos 3, must be 0, 1 or 2"}},
+		{
+			Handler{Slack: Slack{Token: "foo"}, Flock: Flock{Url: "foo"}},
+			[]string{"slack: token set but channel missing", `flock: invalid url "foo"`},
+		},
+	}
+
+	for i, tt := range Tests {
+		if errs := tt.handler.Validate(); !reflect.DeepEqual(errs, tt.expected) {
+			t.Errorf("%d: Validate(): expected %v, got %v", i, tt.expected, errs)
+		}
+	}
+}
+
+func TestUnmarshallConfigResourceRoundTrip(t *testing.T) {
+	fields := reflect.TypeOf(Resource{}).NumField()
+	for i := 0; i < fields; i++ {
+		var resource Resource
+		reflect.ValueOf(&resource).Elem().Field(i).SetBool(true)
+		name := reflect.TypeOf(resource).Field(i).Name
+
+		c := &Config{Resource: resource}
+		c.UnmarshallConfig()
+		if len(c.Event.Global) != 1 {
+			t.Fatalf("%s: expected a global event, got %v", name, c.Event.Global)
+		}
+
+		// the resource string is the one configured by the events config
+		events := &Config{}
+		events.configureEvents(c.Event.Global)
+		if events.Resource != resource {
+			t.Errorf("%s: %q configures %+v", name, c.Event.Global[0], events.Resource)
+		}
+	}
+}
+
+func TestLoadFileExpandEnv(t *testing.T) {
+	t.Setenv("KW_CONFIG", t.TempDir())
+	t.Setenv("TEST_SLACK_TOKEN", "xoxb-secret")
+	t.Setenv("TEST_WEBHOOK_HOST", "hooks.example.com")
+
+	path := filepath.Join(os.Getenv("KW_CONFIG"), ConfigFileName)
+	content := `handler:
+  slack:
+    token: ${TEST_SLACK_TOKEN}
+    channel: "#alerts"
+  webhook:
+    url: https://$TEST_WEBHOOK_HOST/kubewatch
+    password: pa$$word
+    headers:
+      Authorization: Bearer ${TEST_SLACK_TOKEN}
+  templates:
+    slack:
+      default: '{{ $name := .Name }}{{ $name }}'
+`
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{}
+	if err := c.LoadFile(path); err != nil {
+		t.Fatalf("LoadFile(): %v", err)
+	}
+	if c.Handler.Slack.Token != "xoxb-secret" || c.Handler.Slack.Channel != "#alerts" {
+		t.Errorf("expected the slack token to be expanded, got %+v", c.Handler.Slack)
+	}
+	if c.Handler.Webhook.Url != "https://hooks.example.com/kubewatch" || c.Handler.Webhook.Password != "pa$word" {
+		t.Errorf("expected the webhook url to be expanded and $$ escaped, got %+v", c.Handler.Webhook)
+	}
+	if auth := c.Handler.Webhook.Headers["Authorization"]; auth != "Bearer xoxb-secret" {
+		t.Errorf("expected the webhook header to be expanded, got %q", auth)
+	}
+	if tmpl := c.Handler.Templates["slack"].Default; tmpl != "{{ $name := .Name }}{{ $name }}" {
+		t.Errorf("expected templates to be left as is, got %q", tmpl)
+	}
+
+	// the references are written back, not the secrets
+	c.Handler.Slack.Channel = "#ops"
+	if err := c.Write(); err != nil {
+		t.Fatalf("Write(): %v", err)
+	}
+	written, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(written), "xoxb-secret") || !strings.Contains(string(written), "${TEST_SLACK_TOKEN}") {
+		t.Errorf("expected the token reference to be written, got:\n%s", written)
+	}
+	if !strings.Contains(string(written), "'#ops'") {
+		t.Errorf("expected the updated channel to be written, got:\n%s", written)
+	}
+}
+
+func TestLoadFileSecretFiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("KW_CONFIG", dir)
+	t.Setenv("TEST_SECRETS_DIR", dir)
+
+	secrets := map[string]string{"slack-token": "xoxb-secret\n", "kafka-password": "hunter2 \r\n"}
+	for name, content := range secrets {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(dir, ConfigFileName)
+	content := `handler:
+  slack:
+    tokenfile: ${TEST_SECRETS_DIR}/slack-token
+    channel: "#alerts"
+  kafka:
+    sasl:
+      username: kubewatch
+      passwordfile: ${TEST_SECRETS_DIR}/kafka-password
+`
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Config{}
+	if err := c.LoadFile(path); err != nil {
+		t.Fatalf("LoadFile(): %v", err)
+	}
+	if c.Handler.Slack.Token != "xoxb-secret" {
+		t.Errorf("expected the slack token to be read from its file, got %q", c.Handler.Slack.Token)
+	}
+	if c.Handler.Kafka.SASL.Password != "hunter2" {
+		t.Errorf("expected the kafka password to be read from its file, got %q", c.Handler.Kafka.SASL.Password)
+	}
+
+	// the files are written back, not the secrets
+	if err := c.Write(); err != nil {
+		t.Fatalf("Write(): %v", err)
+	}
+	written, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(written), "xoxb-secret") || strings.Contains(string(written), "hunter2") {
+		t.Errorf("expected the secrets not to be written, got:\n%s", written)
+	}
+	if err := (&Config{}).LoadFile(path); err != nil {
+		t.Errorf("LoadFile() of the written config: %v", err)
+	}
+
+	var Tests = []struct {
+		content  string
+		expected string
+	}{
+		{"handler:\n  slack:\n    token: xoxb\n    tokenfile: " + filepath.Join(dir, "slack-token"), "slack: token and tokenfile are exclusive"},
+		{"handler:\n  webhook:\n    secretfile: " + filepath.Join(dir, "missing"), "webhook: secretfile: open "},
+	}
+	for _, tt := range Tests {
+		if err := ioutil.WriteFile(path, []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := (&Config{}).LoadFile(path); err == nil || !strings.HasPrefix(err.Error(), tt.expected) {
+			t.Errorf("LoadFile(%q): expected error %q, got %v", tt.content, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v2"
+)
+
+var templatesType = reflect.TypeOf(Templates{})
+
+// expandEnv expands the ${VAR} and $VAR references in the string fields of the
+// handler config, e.g. token: ${SLACK_TOKEN}, $$ being a literal $. Templates are
+// left as is, their $ are template variables. The references are kept to be
+// written back instead of the values of the environment.
+func (c *Config) expandEnv() {
+	c.envRefs = make(map[string]string)
+	walkStrings(reflect.ValueOf(&c.Handler).Elem(), "handler", func(path, s string) string {
+		expanded := os.Expand(s, getenv)
+		if expanded != s {
+			c.envRefs[path] = s
+		}
+		return expanded
+	})
+}
+
+// readSecretFiles sets the handler fields with a File variant, e.g. Token and
+// TokenFile, to the content of the file, without trailing whitespace
+func (c *Config) readSecretFiles() error {
+	c.fileValues = make(map[string]string)
+	return c.readFiles(reflect.ValueOf(&c.Handler).Elem(), "handler")
+}
+
+func (c *Config) readFiles(v reflect.Value, path string) error {
+	if v.Type() == templatesType {
+		return nil
+	}
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if f.Type.Kind() == reflect.Struct {
+			if err := c.readFiles(v.Field(i), path+"."+f.Name); err != nil {
+				return err
+			}
+			continue
+		}
+		file := v.Field(i)
+		target := v.FieldByName(strings.TrimSuffix(f.Name, "File"))
+		if f.Type.Kind() != reflect.String || !strings.HasSuffix(f.Name, "File") || file.String() == "" ||
+			!target.IsValid() || target.Kind() != reflect.String {
+			continue
+		}
+
+		// errors name the fields like the config file, e.g. slack: tokenfile
+		handler := strings.ToLower(strings.TrimPrefix(path, "handler."))
+		name := strings.ToLower(f.Name)
+		if target.String() != "" {
+			return fmt.Errorf("%s: %s and %s are exclusive", handler, strings.TrimSuffix(name, "file"), name)
+		}
+		b, err := ioutil.ReadFile(file.String())
+		if err != nil {
+			return fmt.Errorf("%s: %s: %v", handler, name, err)
+		}
+		value := strings.TrimRightFunc(string(b), unicode.IsSpace)
+		target.SetString(value)
+		c.fileValues[path+"."+strings.TrimSuffix(f.Name, "File")] = value
+	}
+	return nil
+}
+
+// withRefs returns a copy of the config with the expanded values replaced by their
+// references and the values read from files cleared, unless they were changed since
+func (c *Config) withRefs() (*Config, error) {
+	b, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	refs := &Config{}
+	if err := yaml.Unmarshal(b, refs); err != nil {
+		return nil, err
+	}
+	walkStrings(reflect.ValueOf(&refs.Handler).Elem(), "handler", func(path, s string) string {
+		if value, ok := c.fileValues[path]; ok && value == s {
+			return ""
+		}
+		if ref, ok := c.envRefs[path]; ok && os.Expand(ref, getenv) == s {
+			return ref
+		}
+		return s
+	})
+	return refs, nil
+}
+
+// getenv maps the references of os.Expand to the environment, $$ to a literal $
+func getenv(name string) string {
+	if name == "$" {
+		return "$"
+	}
+	return os.Getenv(name)
+}
+
+// walkStrings replaces the strings of v, including the ones in slices and maps,
+// by fn of their path and value. Templates are skipped.
+func walkStrings(v reflect.Value, path string, fn func(path, s string) string) {
+	switch v.Kind() {
+	case reflect.String:
+		if v.CanSet() {
+			v.SetString(fn(path, v.String()))
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			walkStrings(v.Elem(), path, fn)
+		}
+	case reflect.Struct:
+		if v.Type() == templatesType {
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Type().Field(i); f.PkgPath == "" {
+				walkStrings(v.Field(i), path+"."+f.Name, fn)
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			walkStrings(v.Index(i), fmt.Sprintf("%s[%d]", path, i), fn)
+		}
+	case reflect.Map:
+		if v.Type().Elem() == templatesType {
+			return
+		}
+		for _, key := range v.MapKeys() {
+			// map values aren't addressable, they are replaced by an updated copy
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			walkStrings(elem, fmt.Sprintf("%s[%v]", path, key), fn)
+			v.SetMapIndex(key, elem)
+		}
+	}
+}
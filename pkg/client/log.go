@@ -0,0 +1,54 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"log"
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+)
+
+// SetupLogging applies the configured log level and format at startup.
+// In JSON format the handlers' logs, written with the standard logger,
+// are written through logrus too, for every line to be JSON.
+func SetupLogging(l config.Log) error {
+	if err := l.Validate(); err != nil {
+		return err
+	}
+
+	level := logrus.InfoLevel
+	if l.Level != "" {
+		var err error
+		if level, err = logrus.ParseLevel(l.Level); err != nil {
+			return err
+		}
+	}
+	logrus.SetLevel(level)
+
+	if l.Format == config.LogFormatJSON {
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+		log.SetFlags(0)
+		log.SetOutput(logrus.StandardLogger().Writer())
+		return nil
+	}
+	logrus.SetFormatter(&logrus.TextFormatter{})
+	log.SetFlags(log.LstdFlags)
+	log.SetOutput(os.Stderr)
+	return nil
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+)
+
+func TestSetupLogging(t *testing.T) {
+	defer SetupLogging(config.Log{})
+
+	if err := SetupLogging(config.Log{Level: "verbose"}); err == nil {
+		t.Fatal("SetupLogging(): expected an error for an unknown level")
+	}
+
+	if err := SetupLogging(config.Log{Level: "warn", Format: config.LogFormatJSON}); err != nil {
+		t.Fatalf("SetupLogging(): %v", err)
+	}
+	var out bytes.Buffer
+	logrus.SetOutput(&out)
+	defer logrus.SetOutput(os.Stderr)
+
+	logrus.Info("Processing add to pod: default/foo")
+	if out.Len() != 0 {
+		t.Fatalf("expected info to be dropped at warn level, got %s", out.String())
+	}
+	logrus.Warn("Falling back to the deprecated apps/v1beta1 group")
+	var line map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &line); err != nil {
+		t.Fatalf("expected a JSON line, got %s", out.String())
+	}
+	if line["level"] != "warning" || line["msg"] != "Falling back to the deprecated apps/v1beta1 group" {
+		t.Fatalf("unexpected line %v", line)
+	}
+}
@@ -17,48 +17,264 @@ limitations under the License.
 package client
 
 import (
+	"fmt"
 	"log"
+	"strings"
+	"time"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/mudasirmirza/kubewatch/config"
 	"github.com/mudasirmirza/kubewatch/pkg/controller"
+	"github.com/mudasirmirza/kubewatch/pkg/event"
 	"github.com/mudasirmirza/kubewatch/pkg/handlers"
-	"github.com/mudasirmirza/kubewatch/pkg/handlers/flock"
-	"github.com/mudasirmirza/kubewatch/pkg/handlers/hipchat"
-	"github.com/mudasirmirza/kubewatch/pkg/handlers/mattermost"
-	"github.com/mudasirmirza/kubewatch/pkg/handlers/msteam"
-	"github.com/mudasirmirza/kubewatch/pkg/handlers/slack"
-	"github.com/mudasirmirza/kubewatch/pkg/handlers/webhook"
+	"github.com/mudasirmirza/kubewatch/pkg/server"
 )
 
 // Run runs the event loop processing with given handler
 func Run(conf *config.Config) {
 
 	var eventHandler = ParseEventHandler(conf)
-	controller.Start(conf, eventHandler)
+	readyHandler, err := NewReadyHandler(conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	server.Start(conf)
+	controller.Start(conf, eventHandler, readyHandler, reload)
+}
+
+// LoadConfig loads the config file, validates it and completes it with the environment
+func LoadConfig() (*config.Config, error) {
+	conf := &config.Config{}
+	if err := conf.Load(); err != nil {
+		return nil, err
+	}
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	conf.CheckMissingResourceEnvvars()
+	conf.UnmarshallConfig()
+	return conf, nil
+}
+
+// reload loads the config file and a new handler for it, e.g. on SIGHUP
+func reload() (*config.Config, handlers.Handler, error) {
+	conf, err := LoadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	eventHandler, err := NewEventHandler(conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conf, eventHandler, nil
+}
+
+// HandlerName returns the name of the handler specified in the config file.
+func HandlerName(conf *config.Config) string {
+	if names := HandlerNames(conf); len(names) > 0 {
+		return names[0]
+	}
+	return "default"
+}
+
+// HandlerNames returns the names of the handlers configured in the config file.
+func HandlerNames(conf *config.Config) []string {
+	var names []string
+	if len(conf.Handler.Slack.Channel) > 0 || len(conf.Handler.Slack.Token) > 0 {
+		names = append(names, "slack")
+	}
+	if len(conf.Handler.Hipchat.Room) > 0 || len(conf.Handler.Hipchat.Token) > 0 {
+		names = append(names, "hipchat")
+	}
+	if len(conf.Handler.Mattermost.Channel) > 0 || len(conf.Handler.Mattermost.Url) > 0 ||
+		len(conf.Handler.Mattermost.Token) > 0 {
+		names = append(names, "mattermost")
+	}
+	if len(conf.Handler.Flock.Url) > 0 {
+		names = append(names, "flock")
+	}
+	if len(conf.Handler.Webhook.Url) > 0 {
+		names = append(names, "webhook")
+	}
+	if len(conf.Handler.MSTeams.WebhookURL) > 0 {
+		names = append(names, "ms-teams")
+	}
+	if len(conf.Handler.PagerDuty.IntegrationKey) > 0 {
+		names = append(names, "pagerduty")
+	}
+	if len(conf.Handler.Discord.WebhookURL) > 0 {
+		names = append(names, "discord")
+	}
+	if len(conf.Handler.Telegram.BotToken) > 0 || len(conf.Handler.Telegram.ChatID) > 0 {
+		names = append(names, "telegram")
+	}
+	if len(conf.Handler.Email.Host) > 0 {
+		names = append(names, "email")
+	}
+	if len(conf.Handler.SNS.TopicARN) > 0 {
+		names = append(names, "sns")
+	}
+	if len(conf.Handler.Opsgenie.APIKey) > 0 {
+		names = append(names, "opsgenie")
+	}
+	if len(conf.Handler.GoogleChat.WebhookURL) > 0 {
+		names = append(names, "googlechat")
+	}
+	if len(conf.Handler.Kafka.Brokers) > 0 {
+		names = append(names, "kafka")
+	}
+	if conf.Handler.Stdout.Enabled {
+		names = append(names, "stdout")
+	}
+	if conf.Handler.Syslog.Enabled || len(conf.Handler.Syslog.Address) > 0 {
+		names = append(names, "syslog")
+	}
+	if len(conf.Handler.Elasticsearch.Addresses) > 0 {
+		names = append(names, "elasticsearch")
+	}
+	if len(conf.Handler.NATS.URL) > 0 {
+		names = append(names, "nats")
+	}
+	if len(conf.Handler.MQTT.Broker) > 0 {
+		names = append(names, "mqtt")
+	}
+	if len(conf.Handler.Sentry.DSN) > 0 {
+		names = append(names, "sentry")
+	}
+	if len(conf.Handler.Gotify.Token) > 0 {
+		names = append(names, "gotify")
+	}
+	if len(conf.Handler.Pushover.Token) > 0 {
+		names = append(names, "pushover")
+	}
+	if len(conf.Handler.Webex.BotToken) > 0 {
+		names = append(names, "webex")
+	}
+	if len(conf.Handler.VictorOps.APIKey) > 0 {
+		names = append(names, "victorops")
+	}
+	if len(conf.Handler.Matrix.AccessToken) > 0 {
+		names = append(names, "matrix")
+	}
+	if len(conf.Handler.Datadog.APIKey) > 0 {
+		names = append(names, "datadog")
+	}
+	if len(conf.Handler.TCP.Address) > 0 {
+		names = append(names, "tcp")
+	}
+	if len(conf.Handler.Jira.BaseURL) > 0 {
+		names = append(names, "jira")
+	}
+	return names
+}
+
+// CheckEnabled reports configs which would run without notifying anything:
+// without watched resources, or without a handler besides the default one logging events
+func CheckEnabled(conf *config.Config) error {
+	var errs []string
+	if conf.Resource == (config.Resource{}) && len(conf.CustomResources) == 0 {
+		errs = append(errs, "resource: no resource is watched, enable one under resource or event")
+	}
+	if len(HandlerNames(conf)) == 0 && len(conf.Routes) == 0 {
+		errs = append(errs, "handler: no handler is configured, events would only be logged")
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("Invalid configuration:\n - %s", strings.Join(errs, "\n - "))
+	}
+	return nil
 }
 
 // ParseEventHandler returns the respective handler object specified in the config file.
 func ParseEventHandler(conf *config.Config) handlers.Handler {
+	eventHandler, err := NewEventHandler(conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return eventHandler
+}
 
-	var eventHandler handlers.Handler
-	switch {
-	case len(conf.Handler.Slack.Channel) > 0 || len(conf.Handler.Slack.Token) > 0:
-		eventHandler = new(slack.Slack)
-	case len(conf.Handler.Hipchat.Room) > 0 || len(conf.Handler.Hipchat.Token) > 0:
-		eventHandler = new(hipchat.Hipchat)
-	case len(conf.Handler.Mattermost.Channel) > 0 || len(conf.Handler.Mattermost.Url) > 0:
-		eventHandler = new(mattermost.Mattermost)
-	case len(conf.Handler.Flock.Url) > 0:
-		eventHandler = new(flock.Flock)
-	case len(conf.Handler.Webhook.Url) > 0:
-		eventHandler = new(webhook.Webhook)
-	case len(conf.Handler.MSTeams.WebhookURL) > 0:
-		eventHandler = new(msteam.MSTeams)
-	default:
-		eventHandler = new(handlers.Default)
+// NewEventHandler returns a new handler for the config file.
+// With routes, the configured handlers and the ones of the routes are all created.
+func NewEventHandler(conf *config.Config) (handlers.Handler, error) {
+	if len(conf.Routes) == 0 {
+		return newHandler(conf, HandlerName(conf))
+	}
+
+	routed := &handlers.Routed{Routes: conf.Routes, Handlers: make(map[string]handlers.Handler)}
+	names := HandlerNames(conf)
+	for _, route := range conf.Routes {
+		names = append(names, route.Handlers...)
+	}
+	for _, name := range names {
+		if _, ok := routed.Handlers[name]; ok {
+			continue
+		}
+		eventHandler, err := newHandler(conf, name)
+		if err != nil {
+			return nil, err
+		}
+		routed.Handlers[name] = eventHandler
+	}
+	return routed, nil
+}
+
+// NewReadyHandler returns a new handler for the ready notification when it has its own,
+// nil when the notification goes to the event handler
+func NewReadyHandler(conf *config.Config) (handlers.Handler, error) {
+	if !conf.NotifyOnReady || conf.ReadyHandler == "" {
+		return nil, nil
+	}
+	return newHandler(conf, conf.ReadyHandler)
+}
+
+// newHandler initializes the handler of the given name with its digests, templates, coalescing,
+// throttling and minimum severity
+func newHandler(conf *config.Config, name string) (handlers.Handler, error) {
+	eventHandler, ok := handlers.New(name)
+	if !ok {
+		return nil, fmt.Errorf("Unknown handler %q", name)
 	}
 	if err := eventHandler.Init(conf); err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	return eventHandler
+	// the handler itself is tested, test messages are neither counted nor filtered
+	if conf.SelfTest.Enabled {
+		if err := selfTest(name, eventHandler, conf.HandlerTimeout); err != nil && conf.SelfTest.FailOnError {
+			return nil, err
+		}
+	}
+	eventHandler = &handlers.Instrumented{Handler: eventHandler, Name: name}
+	// digests summarize the events once rendered, throttled and coalesced
+	if conf.Digest.Interval > 0 {
+		eventHandler = &handlers.Digested{Handler: eventHandler, Config: conf.Digest, Timeout: conf.HandlerTimeout}
+	}
+
+	// templates are parsed once at startup so that bad ones fail fast
+	templates, err := event.ParseTemplates(conf.Handler.Templates[name], conf.Templates)
+	if err != nil {
+		return nil, err
+	}
+	if !templates.Empty() {
+		eventHandler = &handlers.Templated{Handler: eventHandler, Templates: templates}
+	}
+	if len(conf.Coalesce) > 0 {
+		eventHandler = &handlers.Coalesced{Handler: eventHandler, Config: conf.Coalesce, Timeout: conf.HandlerTimeout}
+	}
+	if conf.Throttle.Window > 0 {
+		eventHandler = &handlers.Throttled{Handler: eventHandler, Config: conf.Throttle, Timeout: conf.HandlerTimeout}
+	}
+	if conf.MinSeverity != "" {
+		eventHandler = &handlers.Severe{Handler: eventHandler, MinSeverity: conf.MinSeverity}
+	}
+	return eventHandler, nil
+}
+
+// selfTest checks an initialized handler and logs the result
+func selfTest(name string, eventHandler handlers.Handler, timeout time.Duration) error {
+	if err := handlers.SelfTest(eventHandler, timeout); err != nil {
+		logrus.Errorf("Self-test of handler %s failed: %v", name, err)
+		return fmt.Errorf("Self-test of handler %q failed: %v", name, err)
+	}
+	logrus.Infof("Self-test of handler %s succeeded", name)
+	return nil
 }
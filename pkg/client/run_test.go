@@ -0,0 +1,99 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers"
+)
+
+func TestCheckEnabled(t *testing.T) {
+	slack := config.Handler{Slack: config.Slack{Token: "token", Channel: "#alerts"}}
+
+	var Tests = []struct {
+		conf     config.Config
+		expected []string
+	}{
+		{config.Config{Resource: config.Resource{Pod: true}, Handler: slack}, nil},
+		{config.Config{CustomResources: []config.CustomResource{{Version: "v1", Resource: "widgets"}}, Handler: slack}, nil},
+		{config.Config{Resource: config.Resource{Pod: true}}, []string{"no handler is configured"}},
+		{config.Config{Handler: slack}, []string{"no resource is watched"}},
+		{config.Config{}, []string{"no resource is watched", "no handler is configured"}},
+	}
+
+	for i, tt := range Tests {
+		err := CheckEnabled(&tt.conf)
+		if (err == nil) != (tt.expected == nil) {
+			t.Fatalf("%d: CheckEnabled(): expected errors %q, got %v", i, tt.expected, err)
+		}
+		for _, expected := range tt.expected {
+			if !strings.Contains(err.Error(), expected) {
+				t.Errorf("%d: CheckEnabled(): expected %q in %v", i, expected, err)
+			}
+		}
+	}
+}
+
+func TestNewEventHandlerSelfTest(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	conf := &config.Config{Handler: config.Handler{Webhook: config.Webhook{Url: ts.URL}}}
+	if _, err := NewEventHandler(conf); err != nil || requests != 0 {
+		t.Fatalf("expected no self-test by default, got %d requests: %v", requests, err)
+	}
+
+	conf.SelfTest.Enabled = true
+	if _, err := NewEventHandler(conf); err != nil || requests != 1 {
+		t.Fatalf("expected a logged self-test failure, got %d requests: %v", requests, err)
+	}
+
+	conf.SelfTest.FailOnError = true
+	if _, err := NewEventHandler(conf); err == nil || !strings.Contains(err.Error(), `handler "webhook"`) {
+		t.Fatalf("expected the self-test of the webhook to fail, got %v", err)
+	}
+}
+
+func TestNewReadyHandler(t *testing.T) {
+	conf := &config.Config{NotifyOnReady: true, MinSeverity: "warning"}
+	if h, err := NewReadyHandler(conf); h != nil || err != nil {
+		t.Fatalf("expected no ready handler of its own, got %v: %v", h, err)
+	}
+
+	conf.ReadyHandler = "unknown"
+	if _, err := NewReadyHandler(conf); err == nil {
+		t.Fatal("expected an unknown ready handler to fail")
+	}
+
+	conf.ReadyHandler = "stdout"
+	h, err := NewReadyHandler(conf)
+	if err != nil {
+		t.Fatalf("NewReadyHandler(): %v", err)
+	}
+	if _, ok := h.(*handlers.Severe); !ok {
+		t.Fatalf("expected the ready handler to be wrapped as the event handlers, got %T", h)
+	}
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+
+	"github.com/mudasirmirza/kubewatch/config"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// changeFilters holds the watched changes of updated objects per resource type
+var changeFilters map[string]config.Changes
+
+// objectChanges tells which parts of an object an update changed
+type objectChanges struct {
+	spec     bool
+	status   bool
+	metadata bool
+}
+
+// changedParts categorizes the changes between two versions of an object.
+// Fields other than metadata and status, like the data of a configmap, count as spec.
+func changedParts(oldObj, newObj interface{}) objectChanges {
+	var changes objectChanges
+	if oldU, ok := oldObj.(*unstructured.Unstructured); ok {
+		if newU, ok := newObj.(*unstructured.Unstructured); ok {
+			return changedUnstructuredParts(oldU, newU)
+		}
+		return changes
+	}
+	oldValue, newValue := reflect.ValueOf(oldObj), reflect.ValueOf(newObj)
+	if oldValue.Kind() != reflect.Ptr || newValue.Kind() != reflect.Ptr || oldValue.Type() != newValue.Type() {
+		return changes
+	}
+	oldValue, newValue = oldValue.Elem(), newValue.Elem()
+	if oldValue.Kind() != reflect.Struct {
+		return changes
+	}
+
+	for i := 0; i < oldValue.NumField(); i++ {
+		oldField, newField := oldValue.Field(i).Interface(), newValue.Field(i).Interface()
+		switch oldValue.Type().Field(i).Name {
+		case "TypeMeta":
+		case "ObjectMeta":
+			changes.metadata = !equality.Semantic.DeepEqual(comparableMeta(oldField), comparableMeta(newField))
+		case "Status":
+			changes.status = !equality.Semantic.DeepEqual(oldField, newField)
+		default:
+			changes.spec = changes.spec || !equality.Semantic.DeepEqual(oldField, newField)
+		}
+	}
+	return changes
+}
+
+// changedUnstructuredParts categorizes the changes between two versions of an unstructured
+// object like changedParts, top level fields other than metadata and status count as spec
+func changedUnstructuredParts(oldObj, newObj *unstructured.Unstructured) objectChanges {
+	var changes objectChanges
+	fields := make(map[string]bool)
+	for field := range oldObj.Object {
+		fields[field] = true
+	}
+	for field := range newObj.Object {
+		fields[field] = true
+	}
+
+	for field := range fields {
+		oldField, newField := oldObj.Object[field], newObj.Object[field]
+		switch field {
+		case "apiVersion", "kind":
+		case "metadata":
+			changes.metadata = !equality.Semantic.DeepEqual(comparableUnstructuredMeta(oldField), comparableUnstructuredMeta(newField))
+		case "status":
+			changes.status = !equality.Semantic.DeepEqual(oldField, newField)
+		default:
+			changes.spec = changes.spec || !equality.Semantic.DeepEqual(oldField, newField)
+		}
+	}
+	return changes
+}
+
+// comparableUnstructuredMeta drops the unstructured metadata fields bumped by every write
+func comparableUnstructuredMeta(obj interface{}) map[string]interface{} {
+	objectMeta, _ := obj.(map[string]interface{})
+	kept := make(map[string]interface{}, len(objectMeta))
+	for field, value := range objectMeta {
+		if field != "resourceVersion" && field != "managedFields" {
+			kept[field] = value
+		}
+	}
+	return kept
+}
+
+// comparableMeta drops the metadata fields bumped by every write
+func comparableMeta(obj interface{}) meta_v1.ObjectMeta {
+	objectMeta := obj.(meta_v1.ObjectMeta)
+	objectMeta.ResourceVersion = ""
+	objectMeta.ManagedFields = nil
+	return objectMeta
+}
+
+// watchedChange reports whether an update touched a part of the object watched
+// for its resource type. Metadata changes count as spec changes.
+func watchedChange(resourceType string, changes objectChanges) bool {
+	filter, ok := changeFilters[resourceType]
+	if !ok {
+		return true
+	}
+	if (changes.spec || changes.metadata) && filter.Spec() {
+		return true
+	}
+	return changes.status && filter.Status()
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	apps_v1beta1 "k8s.io/api/apps/v1beta1"
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestChangedParts(t *testing.T) {
+	old := deployment(3, 3)
+	old.Name, old.Namespace, old.ResourceVersion = "foo", "default", "1"
+
+	spec := old.DeepCopy()
+	spec.Spec.Template.Spec.Containers = []api_v1.Container{{Name: "app", Image: "app:v2"}}
+	status := old.DeepCopy()
+	status.Status.AvailableReplicas = 2
+	labels := old.DeepCopy()
+	labels.Labels = map[string]string{"team": "foo"}
+	resync := old.DeepCopy()
+	resync.ResourceVersion = "2"
+
+	oldConfigMap := &api_v1.ConfigMap{Data: map[string]string{"foo": "bar"}}
+	newConfigMap := &api_v1.ConfigMap{Data: map[string]string{"foo": "baz"}}
+
+	oldWidget := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata":   map[string]interface{}{"name": "foo", "resourceVersion": "1"},
+		"spec":       map[string]interface{}{"size": "small"},
+	}}
+	specWidget := oldWidget.DeepCopy()
+	specWidget.Object["spec"] = map[string]interface{}{"size": "large"}
+	statusWidget := oldWidget.DeepCopy()
+	statusWidget.Object["status"] = map[string]interface{}{"phase": "Ready"}
+	resyncWidget := oldWidget.DeepCopy()
+	resyncWidget.SetResourceVersion("2")
+
+	var Tests = []struct {
+		old, new interface{}
+		expected objectChanges
+	}{
+		{old, spec, objectChanges{spec: true}},
+		{old, status, objectChanges{status: true}},
+		{old, labels, objectChanges{metadata: true}},
+		{old, resync, objectChanges{}},
+		{oldConfigMap, newConfigMap, objectChanges{spec: true}},
+		{old, newConfigMap, objectChanges{}},
+		{oldWidget, specWidget, objectChanges{spec: true}},
+		{oldWidget, statusWidget, objectChanges{status: true}},
+		{oldWidget, resyncWidget, objectChanges{}},
+	}
+
+	for i, tt := range Tests {
+		if changes := changedParts(tt.old, tt.new); changes != tt.expected {
+			t.Fatalf("%d: changedParts(): expected %+v, got %+v", i, tt.expected, changes)
+		}
+	}
+}
+
+func TestProcessItemStatusOnlyChange(t *testing.T) {
+	watchStatus := false
+	changeFilters = map[string]config.Changes{"deployment": {WatchStatus: &watchStatus}}
+	global = map[string]uint8{"deployment": 0}
+	defer func() { changeFilters, global = nil, nil }()
+
+	d := deployment(3, 2)
+	d.ObjectMeta = meta_v1.ObjectMeta{Name: "foo", Namespace: "default"}
+	c := newTestController("deployment", &apps_v1beta1.Deployment{}, d)
+	handler := &recordingHandler{}
+	c.eventHandler = handler
+
+	update := Event{key: "default/foo", eventType: "update", resourceType: "deployment"}
+	update.changes = objectChanges{status: true}
+	if err := c.processItem(context.Background(), update); err != nil {
+		t.Fatalf("processItem(): %v", err)
+	}
+	if len(handler.updated) != 0 {
+		t.Fatalf("expected the status change to be filtered, got %v", handler.updated)
+	}
+
+	update.changes = objectChanges{spec: true, status: true}
+	if err := c.processItem(context.Background(), update); err != nil {
+		t.Fatalf("processItem(): %v", err)
+	}
+	if len(handler.updated) != 1 {
+		t.Fatalf("expected the spec change to be sent, got %v", handler.updated)
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	apps_v1beta1 "k8s.io/api/apps/v1beta1"
+	ext_v1beta1 "k8s.io/api/extensions/v1beta1"
+	networking_v1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+)
+
+// serviceInformers holds the running service informers, used to look up
+// services referenced by ingresses
+var serviceInformers []cache.SharedIndexInformer
+
+// replicaAvailability returns the desired and available replicas of the
+// workloads supported by condition based alerting
+func replicaAvailability(obj interface{}) (desired, available int32, ok bool) {
+	switch object := obj.(type) {
+	case *apps_v1beta1.Deployment:
+		return specReplicas(object.Spec.Replicas), object.Status.AvailableReplicas, true
+	case *apps_v1.Deployment:
+		return specReplicas(object.Spec.Replicas), object.Status.AvailableReplicas, true
+	case *apps_v1beta1.StatefulSet:
+		return specReplicas(object.Spec.Replicas), object.Status.ReadyReplicas, true
+	case *apps_v1.StatefulSet:
+		return specReplicas(object.Spec.Replicas), object.Status.ReadyReplicas, true
+	}
+	return 0, 0, false
+}
+
+// specReplicas defaults unset spec.replicas to 1 like the API server does
+func specReplicas(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
+
+// unavailableReplicasEvent evaluates the unavailable replicas condition of an updated object.
+// ok is false when the condition doesn't apply to the object. Otherwise notify tells
+// whether the returned event has to be sent: once when the object loses all its
+// available replicas and once when it recovers. Updates in between are suppressed.
+func (c *Controller) unavailableReplicasEvent(key string, obj interface{}, kbEvent event.Event) (e event.Event, notify, ok bool) {
+	desired, available, ok := replicaAvailability(obj)
+	if !ok {
+		return kbEvent, false, false
+	}
+
+	switch {
+	case desired > 0 && available == 0 && !c.unavailable.Has(key):
+		c.unavailable.Insert(key)
+		kbEvent.Reason = fmt.Sprintf("0 of %d replicas available", desired)
+		kbEvent.Status = "Danger"
+		return kbEvent, true, true
+	case (desired == 0 || available > 0) && c.unavailable.Has(key):
+		c.unavailable.Delete(key)
+		kbEvent.Reason = fmt.Sprintf("%d of %d replicas available", available, desired)
+		kbEvent.Status = "Normal"
+		return kbEvent, true, true
+	}
+	return kbEvent, false, true
+}
+
+// resetUnavailable undoes the state change of a condition event which couldn't be sent,
+// so that the condition triggers again when the event is retried
+func (c *Controller) resetUnavailable(key string, e event.Event) {
+	if e.Status == "Danger" {
+		c.unavailable.Delete(key)
+	} else {
+		c.unavailable.Insert(key)
+	}
+}
+
+// ingressServices returns the names of the services referenced by the backends
+// of an ingress, ok is false when obj isn't an ingress
+func ingressServices(obj interface{}) (names []string, ok bool) {
+	services := sets.NewString()
+	switch ingress := obj.(type) {
+	case *networking_v1.Ingress:
+		if backend := ingress.Spec.DefaultBackend; backend != nil && backend.Service != nil {
+			services.Insert(backend.Service.Name)
+		}
+		for _, rule := range ingress.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+			for _, path := range rule.HTTP.Paths {
+				if path.Backend.Service != nil {
+					services.Insert(path.Backend.Service.Name)
+				}
+			}
+		}
+	case *ext_v1beta1.Ingress:
+		if ingress.Spec.Backend != nil {
+			services.Insert(ingress.Spec.Backend.ServiceName)
+		}
+		for _, rule := range ingress.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+			for _, path := range rule.HTTP.Paths {
+				services.Insert(path.Backend.ServiceName)
+			}
+		}
+	default:
+		return nil, false
+	}
+	services.Delete("")
+	return services.List(), true
+}
+
+// serviceExists looks up a service in the service informers caches.
+// known is false when no synced service informer is running.
+func serviceExists(namespace, name string) (exists, known bool) {
+	key := namespace + "/" + name
+	for _, informer := range serviceInformers {
+		if !informer.HasSynced() {
+			continue
+		}
+		known = true
+		if _, found, err := informer.GetIndexer().GetByKey(key); err == nil && found {
+			return true, true
+		}
+	}
+	return false, known
+}
+
+// missingBackendEvents returns an event per service referenced by an ingress
+// which doesn't exist. Nothing is returned when services aren't watched.
+func missingBackendEvents(obj interface{}, kbEvent event.Event) []event.Event {
+	services, ok := ingressServices(obj)
+	if !ok {
+		return nil
+	}
+	namespace := utils.GetObjectMetaData(obj).Namespace
+
+	var events []event.Event
+	for _, service := range services {
+		exists, known := serviceExists(namespace, service)
+		if !known {
+			return nil
+		}
+		if !exists {
+			e := kbEvent
+			e.Reason = "ingress references missing service: " + service
+			e.Status = "Danger"
+			events = append(events, e)
+		}
+	}
+	return events
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+	apps_v1beta1 "k8s.io/api/apps/v1beta1"
+	api_v1 "k8s.io/api/core/v1"
+	ext_v1beta1 "k8s.io/api/extensions/v1beta1"
+	networking_v1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func deployment(desired, available int32) *apps_v1beta1.Deployment {
+	d := &apps_v1beta1.Deployment{}
+	d.Spec.Replicas = &desired
+	d.Status.AvailableReplicas = available
+	return d
+}
+
+func TestUnavailableReplicasEvent(t *testing.T) {
+	c := &Controller{unavailable: sets.NewString()}
+	kbEvent := event.Event{Kind: "deployment", Name: "default/foo", Namespace: "default"}
+
+	var Tests = []struct {
+		obj    interface{}
+		notify bool
+		reason string
+		ok     bool
+	}{
+		{deployment(3, 3), false, "", true},
+		{deployment(3, 0), true, "0 of 3 replicas available", true},
+		{deployment(3, 0), false, "", true},
+		{deployment(3, 1), true, "1 of 3 replicas available", true},
+		{deployment(0, 0), false, "", true},
+		{&api_v1.Pod{}, false, "", false},
+	}
+
+	for i, tt := range Tests {
+		e, notify, ok := c.unavailableReplicasEvent("default/foo", tt.obj, kbEvent)
+		if notify != tt.notify || ok != tt.ok {
+			t.Fatalf("%d: unavailableReplicasEvent(): notify %v ok %v", i, notify, ok)
+		}
+		if notify && e.Reason != tt.reason {
+			t.Fatalf("%d: unavailableReplicasEvent(): reason %q, expected %q", i, e.Reason, tt.reason)
+		}
+	}
+}
+
+func TestIngressServices(t *testing.T) {
+	ingress := &ext_v1beta1.Ingress{
+		Spec: ext_v1beta1.IngressSpec{
+			Backend: &ext_v1beta1.IngressBackend{ServiceName: "default-backend"},
+			Rules: []ext_v1beta1.IngressRule{
+				{
+					IngressRuleValue: ext_v1beta1.IngressRuleValue{
+						HTTP: &ext_v1beta1.HTTPIngressRuleValue{
+							Paths: []ext_v1beta1.HTTPIngressPath{
+								{Backend: ext_v1beta1.IngressBackend{ServiceName: "web"}},
+								{Backend: ext_v1beta1.IngressBackend{ServiceName: "api"}},
+								{Backend: ext_v1beta1.IngressBackend{ServiceName: "web"}},
+							},
+						},
+					},
+				},
+				{Host: "no-http.example.com"},
+			},
+		},
+	}
+
+	expected := []string{"api", "default-backend", "web"}
+	if services, ok := ingressServices(ingress); !ok || !reflect.DeepEqual(services, expected) {
+		t.Fatalf("ingressServices(): expected %v, got %v", expected, services)
+	}
+}
+
+func TestIngressServicesNetworkingV1(t *testing.T) {
+	backend := func(name string) networking_v1.IngressBackend {
+		return networking_v1.IngressBackend{Service: &networking_v1.IngressServiceBackend{Name: name}}
+	}
+	defaultBackend := backend("default-backend")
+	ingress := &networking_v1.Ingress{
+		Spec: networking_v1.IngressSpec{
+			DefaultBackend: &defaultBackend,
+			Rules: []networking_v1.IngressRule{
+				{
+					IngressRuleValue: networking_v1.IngressRuleValue{
+						HTTP: &networking_v1.HTTPIngressRuleValue{
+							Paths: []networking_v1.HTTPIngressPath{
+								{Backend: backend("web")},
+								{Backend: networking_v1.IngressBackend{}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	expected := []string{"default-backend", "web"}
+	if services, ok := ingressServices(ingress); !ok || !reflect.DeepEqual(services, expected) {
+		t.Fatalf("ingressServices(): expected %v, got %v", expected, services)
+	}
+	if _, ok := ingressServices(&api_v1.Service{}); ok {
+		t.Fatal("ingressServices(): expected a service not to be an ingress")
+	}
+}
+
+func TestMissingBackendEventsWithoutServiceWatch(t *testing.T) {
+	serviceInformers = nil
+	ingress := &ext_v1beta1.Ingress{
+		Spec: ext_v1beta1.IngressSpec{
+			Backend: &ext_v1beta1.IngressBackend{ServiceName: "web"},
+		},
+	}
+	if events := missingBackendEvents(ingress, event.Event{}); len(events) != 0 {
+		t.Fatalf("missingBackendEvents(): expected no events, got %v", events)
+	}
+}
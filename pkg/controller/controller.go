@@ -17,10 +17,14 @@ limitations under the License.
 package controller
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -28,39 +32,71 @@ import (
 	"github.com/mudasirmirza/kubewatch/config"
 	"github.com/mudasirmirza/kubewatch/pkg/event"
 	"github.com/mudasirmirza/kubewatch/pkg/handlers"
+	"github.com/mudasirmirza/kubewatch/pkg/metrics"
 	"github.com/mudasirmirza/kubewatch/pkg/utils"
+	"golang.org/x/time/rate"
 
 	apps_v1beta1 "k8s.io/api/apps/v1beta1"
+	autoscaling_v1 "k8s.io/api/autoscaling/v1"
 	batch_v1 "k8s.io/api/batch/v1"
+	batch_v1beta1 "k8s.io/api/batch/v1beta1"
 	api_v1 "k8s.io/api/core/v1"
-	ext_v1beta1 "k8s.io/api/extensions/v1beta1"
+	networking_v1 "k8s.io/api/networking/v1"
+	rbac_v1 "k8s.io/api/rbac/v1"
+	storage_v1 "k8s.io/api/storage/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 )
 
-const maxRetries = 5
+const (
+	defaultMaxRetries = 5
+	defaultBaseDelay  = 5 * time.Millisecond
+	defaultMaxDelay   = 1000 * time.Second
+)
 
+// serverStartTime is when the first controller of the process started,
+// objects created before it exist already
 var serverStartTime time.Time
 
+var serverStart sync.Once
+
+// notifyExisting notifies the objects created before the start instead of dropping them
+var notifyExisting bool
+
+// handlerTimeout bounds the processing of each event, guarded by configMu
+var handlerTimeout = handlers.DefaultTimeout
+
 // Maps for holding events config
 var global map[string]uint8
 var create map[string]uint8
 var delete map[string]uint8
 var update map[string]uint8
 
+// conditions holds the condition based alerting config
+var conditions config.Condition
+
 // Event indicate the informerEvent
 type Event struct {
 	key          string
 	eventType    string
 	namespace    string
 	resourceType string
+	// parts of the object changed by an update
+	changes objectChanges
+	// versions of the object before and after an update,
+	// the old one is the last known state of a deleted object
+	oldObj, newObj interface{}
 }
 
 // Controller object
@@ -68,381 +104,631 @@ type Controller struct {
 	logger       *logrus.Entry
 	clientset    kubernetes.Interface
 	queue        workqueue.RateLimitingInterface
+	rateLimiter  workqueue.RateLimiter
+	maxRetries   int
 	informer     cache.SharedIndexInformer
 	eventHandler handlers.Handler
+	resourceType string
+	// lowercased kind of watched custom resources, notified instead of their resource type
+	kind string
+	// kubeconfig context of the watched cluster, empty for the default cluster
+	context string
+	// watched namespace, empty for all namespaces and cluster scoped resources
+	namespace string
+	// scope of the watched resource type
+	scope scope
+	// clock provides the current time to time based logic, faked in tests
+	clock clock.Clock
+	// keys of objects currently alerted for having no available replicas,
+	// only accessed by the worker goroutine
+	unavailable sets.String
+	// controllers of the replica sets and jobs owning the notified objects by uid,
+	// only accessed by the worker goroutine
+	owners map[types.UID]*meta_v1.OwnerReference
+	// sync state and progress reported by the probes
+	progress progress
+	// reloaded controllers were started by a reload, they don't notify the objects
+	// existing before their start as these were notified with the process start
+	reloaded bool
+	// startTime is when the controller started
+	startTime time.Time
+	// settings the informer was created with, the controller is restarted when they change
+	settings informerSettings
 }
 
-// Start prepares watchers and run their controllers, then waits for process termination signals
-func Start(conf *config.Config, eventHandler handlers.Handler) {
+// informerSettings are the settings of the config an informer is created with
+type informerSettings struct {
+	filter       config.Filter
+	resyncPeriod time.Duration
+}
 
-	// loads events config into memory for granular alerting
-	loadEventConfig(conf)
+// defaultShutdownTimeout bounds closing the handlers on exit
+const defaultShutdownTimeout = 30 * time.Second
 
-	var kubeClient kubernetes.Interface
-	_, err := rest.InClusterConfig()
-	if err != nil {
-		kubeClient = utils.GetClientOutOfCluster()
-	} else {
-		kubeClient = utils.GetClient()
+// Start prepares watchers and run their controllers, then waits for process termination signals.
+// On SIGHUP the config is reloaded with load, unless it is nil. The ready notification
+// goes to readyHandler, or to eventHandler when it is nil.
+func Start(conf *config.Config, eventHandler, readyHandler handlers.Handler, load Loader) {
+	if err := applyConfig(conf); err != nil {
+		logrus.Fatal(err)
 	}
 
-	if len(conf.Namespace) == 0 {
-		conf.Namespace = append(conf.Namespace, "")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		sigterm := make(chan os.Signal, 1)
+		signal.Notify(sigterm, syscall.SIGTERM)
+		signal.Notify(sigterm, syscall.SIGINT)
+		<-sigterm
+		cancel()
+	}()
+
+	m := newManager(conf, eventHandler, kubeClients(conf))
+	m.readyHandler = readyHandler
+	if load != nil {
+		go m.watchReloads(ctx, load)
 	}
 
-	if conf.Resource.Pod {
-		for _, ns := range conf.Namespace {
-			fmt.Println(ns)
-			informer := cache.NewSharedIndexInformer(
-				&cache.ListWatch{
-					ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
-						return kubeClient.CoreV1().Pods(ns).List(options)
-					},
-					WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
-						return kubeClient.CoreV1().Pods(ns).Watch(options)
-					},
-				},
-				&api_v1.Pod{},
-				0, //Skip resync
-				cache.Indexers{},
-			)
+	if conf.LeaderElection.Enabled {
+		runLeaderElection(ctx, conf.LeaderElection, m.clusters[0].client, m.run)
+	} else {
+		m.run(ctx)
+	}
+	m.wait()
+
+	// flush handlers buffering events, a stuck handler doesn't block the exit
+	configMu.RLock()
+	shutdownTimeout := durationOrDefault(m.conf.ShutdownTimeout, defaultShutdownTimeout)
+	configMu.RUnlock()
+	closeHandlerWithin(m.handler, shutdownTimeout)
+	if readyHandler != nil {
+		closeHandlerWithin(readyHandler, shutdownTimeout)
+	}
+}
 
-			c := newResourceController(kubeClient, eventHandler, informer, "pod")
-			stopCh := make(chan struct{})
-			defer close(stopCh)
+// clusterClient is a client for the cluster of a kubeconfig context,
+// the context is empty for the default cluster
+type clusterClient struct {
+	context string
+	client  kubernetes.Interface
+	// dynamic watches the custom resources
+	dynamic dynamic.Interface
+}
+
+// newClusterClient returns the clients of the cluster of a client config
+func newClusterClient(kubeContext string, restConfig *rest.Config) clusterClient {
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		logrus.Fatalf("Can not create kubernetes client: %v", err)
+	}
+	return clusterClient{context: kubeContext, client: client, dynamic: utils.GetDynamicClient(restConfig)}
+}
 
-			go c.Run(stopCh)
+// kubeClients returns a client per configured kubeconfig context,
+// or a single client for the default cluster
+func kubeClients(conf *config.Config) []clusterClient {
+	if len(conf.Contexts) > 0 {
+		var clients []clusterClient
+		for _, kubeContext := range conf.Contexts {
+			restConfig := utils.GetConfigForKubeconfig(conf.Kubeconfig, kubeContext)
+			utils.SetRateLimits(restConfig, conf.QPS, conf.Burst)
+			clients = append(clients, newClusterClient(kubeContext, restConfig))
 		}
+		return clients
 	}
 
-	if conf.Resource.DaemonSet {
-		for _, ns := range conf.Namespace {
+	var restConfig *rest.Config
+	if conf.Kubeconfig != "" || conf.Context != "" {
+		restConfig = utils.GetConfigForKubeconfig(conf.Kubeconfig, conf.Context)
+	} else if _, err := rest.InClusterConfig(); err != nil {
+		restConfig = utils.GetConfigOutOfCluster()
+	} else {
+		restConfig = utils.GetConfig()
+	}
+	utils.SetRateLimits(restConfig, conf.QPS, conf.Burst)
+	cluster := newClusterClient("", restConfig)
+	cluster.context = clusterName(conf.ClusterName, cluster.client, restConfig.Host)
+	return []clusterClient{cluster}
+}
+
+// clusterName returns the name of the single watched cluster in notifications: the configured
+// name, else the UID of its kube-system namespace, else the host of its API server
+func clusterName(name string, client kubernetes.Interface, host string) string {
+	if name != "" {
+		return name
+	}
+	ns, err := client.CoreV1().Namespaces().Get(context.Background(), meta_v1.NamespaceSystem, meta_v1.GetOptions{})
+	if err == nil && ns.UID != "" {
+		return string(ns.UID)
+	}
+	logrus.Infof("Naming the cluster after its API server, the UID of %s is unavailable: %v", meta_v1.NamespaceSystem, err)
+	if u, err := url.Parse(host); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return host
+}
+
+// newControllers creates a controller per watched resource and namespace of a cluster
+func newControllers(conf *config.Config, kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, eventHandler handlers.Handler, kubeContext string) []*Controller {
+	return newDiscoveredControllers(conf, discoverAPIVersions(conf, kubeClient), kubeClient, dynamicClient, eventHandler, kubeContext)
+}
+
+// newDiscoveredControllers creates the controllers of a cluster serving the API versions api
+func newDiscoveredControllers(conf *config.Config, api apiVersions, kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, eventHandler handlers.Handler, kubeContext string) []*Controller {
+	var controllers []*Controller
+
+	namespaces := watchedNamespaces(conf)
+
+	// watchResource creates the controllers of a resource type, one per watched namespace
+	// or a single one for cluster scoped resources
+	watchResource := func(resourceType string, listWatch func(ns string) (cache.ListerWatcher, runtime.Object)) {
+		for _, ns := range resourceScope(resourceType).namespaces(namespaces) {
+			lw, objType := listWatch(ns)
 			informer := cache.NewSharedIndexInformer(
-				&cache.ListWatch{
-					ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
-						return kubeClient.ExtensionsV1beta1().DaemonSets(ns).List(options)
-					},
-					WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
-						return kubeClient.ExtensionsV1beta1().DaemonSets(ns).Watch(options)
-					},
-				},
-				&ext_v1beta1.DaemonSet{},
-				0, //Skip resync
+				filterListWatch(resourceType, lw),
+				objType,
+				conf.ResyncPeriod, // 0 skips resync
 				cache.Indexers{},
 			)
 
-			c := newResourceController(kubeClient, eventHandler, informer, "daemonset")
-			stopCh := make(chan struct{})
-			defer close(stopCh)
-
-			go c.Run(stopCh)
+			c := newResourceController(kubeClient, eventHandler, informer, resourceType, conf.Retry)
+			c.namespace = ns
+			c.settings = informerSettings{filter: listFilters[resourceType], resyncPeriod: conf.ResyncPeriod}
+			controllers = append(controllers, c)
 		}
 	}
 
-	if conf.Resource.ReplicaSet {
-		for _, ns := range conf.Namespace {
-			informer := cache.NewSharedIndexInformer(
-				&cache.ListWatch{
-					ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
-						return kubeClient.ExtensionsV1beta1().ReplicaSets(ns).List(options)
-					},
-					WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
-						return kubeClient.ExtensionsV1beta1().ReplicaSets(ns).Watch(options)
-					},
-				},
-				&ext_v1beta1.ReplicaSet{},
-				0, //Skip resync
-				cache.Indexers{},
-			)
+	if conf.Resource.Pod {
+		watchResource("pod", func(ns string) (cache.ListerWatcher, runtime.Object) {
+			return &cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.CoreV1().Pods(ns).List(context.Background(), options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.CoreV1().Pods(ns).Watch(context.Background(), options)
+				},
+			}, &api_v1.Pod{}
+		})
+	}
 
-			c := newResourceController(kubeClient, eventHandler, informer, "replicaset")
-			stopCh := make(chan struct{})
-			defer close(stopCh)
+	if conf.Resource.DaemonSet {
+		watchResource("daemonset", func(ns string) (cache.ListerWatcher, runtime.Object) {
+			return daemonSetListWatch(kubeClient, api.appsV1, ns)
+		})
+	}
 
-			go c.Run(stopCh)
-		}
+	if conf.Resource.ReplicaSet {
+		watchResource("replicaset", func(ns string) (cache.ListerWatcher, runtime.Object) {
+			return replicaSetListWatch(kubeClient, api.appsV1, ns)
+		})
 	}
 
 	if conf.Resource.Service {
-		for _, ns := range conf.Namespace {
-			informer := cache.NewSharedIndexInformer(
-				&cache.ListWatch{
-					ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
-						return kubeClient.CoreV1().Services(ns).List(options)
-					},
-					WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
-						return kubeClient.CoreV1().Services(ns).Watch(options)
-					},
-				},
-				&api_v1.Service{},
-				0, //Skip resync
-				cache.Indexers{},
-			)
+		watchResource("service", func(ns string) (cache.ListerWatcher, runtime.Object) {
+			return &cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.CoreV1().Services(ns).List(context.Background(), options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.CoreV1().Services(ns).Watch(context.Background(), options)
+				},
+			}, &api_v1.Service{}
+		})
+	}
 
-			c := newResourceController(kubeClient, eventHandler, informer, "service")
-			stopCh := make(chan struct{})
-			defer close(stopCh)
+	if conf.Resource.Deployment {
+		watchResource("deployment", func(ns string) (cache.ListerWatcher, runtime.Object) {
+			return deploymentListWatch(kubeClient, api.appsV1, ns)
+		})
+	}
 
-			go c.Run(stopCh)
-		}
+	if conf.Resource.StatefulSet {
+		watchResource("statefulset", func(ns string) (cache.ListerWatcher, runtime.Object) {
+			return &cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.AppsV1beta1().StatefulSets(ns).List(context.Background(), options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.AppsV1beta1().StatefulSets(ns).Watch(context.Background(), options)
+				},
+			}, &apps_v1beta1.StatefulSet{}
+		})
 	}
 
-	if conf.Resource.Deployment {
-		for _, ns := range conf.Namespace {
-			informer := cache.NewSharedIndexInformer(
-				&cache.ListWatch{
-					ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
-						return kubeClient.AppsV1beta1().Deployments(ns).List(options)
-					},
-					WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
-						return kubeClient.AppsV1beta1().Deployments(ns).Watch(options)
-					},
-				},
-				&apps_v1beta1.Deployment{},
-				0, //Skip resync
-				cache.Indexers{},
-			)
+	if conf.Resource.Namespace {
+		watchResource("namespace", func(string) (cache.ListerWatcher, runtime.Object) {
+			return &cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.CoreV1().Namespaces().List(context.Background(), options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.CoreV1().Namespaces().Watch(context.Background(), options)
+				},
+			}, &api_v1.Namespace{}
+		})
+	}
 
-			c := newResourceController(kubeClient, eventHandler, informer, "deployment")
-			stopCh := make(chan struct{})
-			defer close(stopCh)
+	if conf.Resource.ReplicationController {
+		watchResource("replicationcontroller", func(ns string) (cache.ListerWatcher, runtime.Object) {
+			return &cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.CoreV1().ReplicationControllers(ns).List(context.Background(), options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.CoreV1().ReplicationControllers(ns).Watch(context.Background(), options)
+				},
+			}, &api_v1.ReplicationController{}
+		})
+	}
 
-			go c.Run(stopCh)
-		}
+	if conf.Resource.Job {
+		watchResource("job", func(ns string) (cache.ListerWatcher, runtime.Object) {
+			return &cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.BatchV1().Jobs(ns).List(context.Background(), options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.BatchV1().Jobs(ns).Watch(context.Background(), options)
+				},
+			}, &batch_v1.Job{}
+		})
 	}
 
-	if conf.Resource.Namespace {
-		informer := cache.NewSharedIndexInformer(
-			&cache.ListWatch{
+	if conf.Resource.CronJob {
+		watchResource("cronjob", func(ns string) (cache.ListerWatcher, runtime.Object) {
+			return &cache.ListWatch{
 				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
-					return kubeClient.CoreV1().Namespaces().List(options)
+					return kubeClient.BatchV1beta1().CronJobs(ns).List(context.Background(), options)
 				},
 				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
-					return kubeClient.CoreV1().Namespaces().Watch(options)
+					return kubeClient.BatchV1beta1().CronJobs(ns).Watch(context.Background(), options)
 				},
-			},
-			&api_v1.Namespace{},
-			0, //Skip resync
-			cache.Indexers{},
-		)
+			}, &batch_v1beta1.CronJob{}
+		})
+	}
 
-		c := newResourceController(kubeClient, eventHandler, informer, "namespace")
-		stopCh := make(chan struct{})
-		defer close(stopCh)
+	if conf.Resource.PersistentVolume {
+		watchResource("persistentvolume", func(string) (cache.ListerWatcher, runtime.Object) {
+			return &cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.CoreV1().PersistentVolumes().List(context.Background(), options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.CoreV1().PersistentVolumes().Watch(context.Background(), options)
+				},
+			}, &api_v1.PersistentVolume{}
+		})
+	}
 
-		go c.Run(stopCh)
+	if conf.Resource.Node {
+		watchResource("node", func(string) (cache.ListerWatcher, runtime.Object) {
+			return &cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.CoreV1().Nodes().List(context.Background(), options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.CoreV1().Nodes().Watch(context.Background(), options)
+				},
+			}, &api_v1.Node{}
+		})
 	}
 
-	if conf.Resource.ReplicationController {
-		for _, ns := range conf.Namespace {
-			informer := cache.NewSharedIndexInformer(
-				&cache.ListWatch{
-					ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
-						return kubeClient.CoreV1().ReplicationControllers(ns).List(options)
-					},
-					WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
-						return kubeClient.CoreV1().ReplicationControllers(ns).Watch(options)
-					},
-				},
-				&api_v1.ReplicationController{},
-				0, //Skip resync
-				cache.Indexers{},
-			)
+	if conf.Resource.Secret {
+		watchResource("secret", func(ns string) (cache.ListerWatcher, runtime.Object) {
+			return &cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.CoreV1().Secrets(ns).List(context.Background(), options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.CoreV1().Secrets(ns).Watch(context.Background(), options)
+				},
+			}, &api_v1.Secret{}
+		})
+	}
 
-			c := newResourceController(kubeClient, eventHandler, informer, "replication controller")
-			stopCh := make(chan struct{})
-			defer close(stopCh)
+	if conf.Resource.ConfigMap {
+		watchResource("configmap", func(ns string) (cache.ListerWatcher, runtime.Object) {
+			return &cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.CoreV1().ConfigMaps(ns).List(context.Background(), options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.CoreV1().ConfigMaps(ns).Watch(context.Background(), options)
+				},
+			}, &api_v1.ConfigMap{}
+		})
+	}
 
-			go c.Run(stopCh)
-		}
+	if conf.Resource.KubeEvent {
+		watchResource("event", func(ns string) (cache.ListerWatcher, runtime.Object) {
+			return &cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.CoreV1().Events(ns).List(context.Background(), options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.CoreV1().Events(ns).Watch(context.Background(), options)
+				},
+			}, &api_v1.Event{}
+		})
 	}
 
-	if conf.Resource.Job {
-		for _, ns := range conf.Namespace {
-			informer := cache.NewSharedIndexInformer(
-				&cache.ListWatch{
-					ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
-						return kubeClient.BatchV1().Jobs(ns).List(options)
-					},
-					WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
-						return kubeClient.BatchV1().Jobs(ns).Watch(options)
-					},
-				},
-				&batch_v1.Job{},
-				0, //Skip resync
-				cache.Indexers{},
-			)
+	if conf.Resource.HorizontalPodAutoscaler {
+		watchResource("horizontalpodautoscaler", func(ns string) (cache.ListerWatcher, runtime.Object) {
+			return &cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.AutoscalingV1().HorizontalPodAutoscalers(ns).List(context.Background(), options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.AutoscalingV1().HorizontalPodAutoscalers(ns).Watch(context.Background(), options)
+				},
+			}, &autoscaling_v1.HorizontalPodAutoscaler{}
+		})
+	}
 
-			c := newResourceController(kubeClient, eventHandler, informer, "job")
-			stopCh := make(chan struct{})
-			defer close(stopCh)
+	if conf.Resource.Ingress {
+		watchResource("ingress", func(ns string) (cache.ListerWatcher, runtime.Object) {
+			return ingressListWatch(kubeClient, api.networkingV1, ns)
+		})
+	}
 
-			go c.Run(stopCh)
-		}
+	if conf.Resource.ServiceAccount {
+		watchResource("serviceaccount", func(ns string) (cache.ListerWatcher, runtime.Object) {
+			return &cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.CoreV1().ServiceAccounts(ns).List(context.Background(), options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.CoreV1().ServiceAccounts(ns).Watch(context.Background(), options)
+				},
+			}, &api_v1.ServiceAccount{}
+		})
 	}
 
-	if conf.Resource.PersistentVolume {
-		informer := cache.NewSharedIndexInformer(
-			&cache.ListWatch{
+	if conf.Resource.Role {
+		watchResource("role", func(ns string) (cache.ListerWatcher, runtime.Object) {
+			return &cache.ListWatch{
 				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
-					return kubeClient.CoreV1().PersistentVolumes().List(options)
+					return kubeClient.RbacV1().Roles(ns).List(context.Background(), options)
 				},
 				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
-					return kubeClient.CoreV1().PersistentVolumes().Watch(options)
+					return kubeClient.RbacV1().Roles(ns).Watch(context.Background(), options)
 				},
-			},
-			&api_v1.PersistentVolume{},
-			0, //Skip resync
-			cache.Indexers{},
-		)
+			}, &rbac_v1.Role{}
+		})
+	}
 
-		c := newResourceController(kubeClient, eventHandler, informer, "persistent volume")
-		stopCh := make(chan struct{})
-		defer close(stopCh)
+	if conf.Resource.RoleBinding {
+		watchResource("rolebinding", func(ns string) (cache.ListerWatcher, runtime.Object) {
+			return &cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.RbacV1().RoleBindings(ns).List(context.Background(), options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.RbacV1().RoleBindings(ns).Watch(context.Background(), options)
+				},
+			}, &rbac_v1.RoleBinding{}
+		})
+	}
 
-		go c.Run(stopCh)
+	if conf.Resource.StorageClass {
+		watchResource("storageclass", func(string) (cache.ListerWatcher, runtime.Object) {
+			return &cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.StorageV1().StorageClasses().List(context.Background(), options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.StorageV1().StorageClasses().Watch(context.Background(), options)
+				},
+			}, &storage_v1.StorageClass{}
+		})
 	}
 
-	if conf.Resource.Secret {
-		for _, ns := range conf.Namespace {
-			informer := cache.NewSharedIndexInformer(
-				&cache.ListWatch{
-					ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
-						return kubeClient.CoreV1().Secrets(ns).List(options)
-					},
-					WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
-						return kubeClient.CoreV1().Secrets(ns).Watch(options)
-					},
-				},
-				&api_v1.Secret{},
-				0, //Skip resync
-				cache.Indexers{},
-			)
+	if conf.Resource.NetworkPolicy {
+		watchResource("networkpolicy", func(ns string) (cache.ListerWatcher, runtime.Object) {
+			return &cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.NetworkingV1().NetworkPolicies(ns).List(context.Background(), options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.NetworkingV1().NetworkPolicies(ns).Watch(context.Background(), options)
+				},
+			}, &networking_v1.NetworkPolicy{}
+		})
+	}
 
-			c := newResourceController(kubeClient, eventHandler, informer, "secret")
-			stopCh := make(chan struct{})
-			defer close(stopCh)
+	if conf.Resource.ResourceQuota {
+		watchResource("resourcequota", func(ns string) (cache.ListerWatcher, runtime.Object) {
+			return &cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.CoreV1().ResourceQuotas(ns).List(context.Background(), options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.CoreV1().ResourceQuotas(ns).Watch(context.Background(), options)
+				},
+			}, &api_v1.ResourceQuota{}
+		})
+	}
 
-			go c.Run(stopCh)
-		}
+	if conf.Resource.LimitRange {
+		watchResource("limitrange", func(ns string) (cache.ListerWatcher, runtime.Object) {
+			return &cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return kubeClient.CoreV1().LimitRanges(ns).List(context.Background(), options)
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return kubeClient.CoreV1().LimitRanges(ns).Watch(context.Background(), options)
+				},
+			}, &api_v1.LimitRange{}
+		})
 	}
 
-	if conf.Resource.ConfigMap {
-		for _, ns := range conf.Namespace {
+	if conf.Resource.PodDisruptionBudget {
+		watchResource("poddisruptionbudget", func(ns string) (cache.ListerWatcher, runtime.Object) {
+			return podDisruptionBudgetListWatch(kubeClient, api.policyV1, ns)
+		})
+	}
+
+	for _, r := range conf.CustomResources {
+		resourceType := r.ResourceType()
+		kind := api.kinds[resourceType]
+		crScope := customResourceScope(r.Namespaced)
+		for _, ns := range crScope.namespaces(namespaces) {
 			informer := cache.NewSharedIndexInformer(
-				&cache.ListWatch{
-					ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
-						return kubeClient.CoreV1().ConfigMaps(ns).List(options)
-					},
-					WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
-						return kubeClient.CoreV1().ConfigMaps(ns).Watch(options)
-					},
-				},
-				&api_v1.ConfigMap{},
-				0, //Skip resync
+				filterListWatch(resourceType, customResourceListWatch(dynamicClient, r, ns)),
+				&unstructured.Unstructured{},
+				conf.ResyncPeriod, // 0 skips resync
 				cache.Indexers{},
 			)
 
-			c := newResourceController(kubeClient, eventHandler, informer, "configmap")
-			stopCh := make(chan struct{})
-			defer close(stopCh)
-
-			go c.Run(stopCh)
+			c := newResourceController(kubeClient, eventHandler, informer, resourceType, conf.Retry)
+			c.namespace = ns
+			c.kind = kind
+			c.scope = crScope
+			c.settings = informerSettings{filter: listFilters[resourceType], resyncPeriod: conf.ResyncPeriod}
+			controllers = append(controllers, c)
 		}
 	}
 
-	if conf.Resource.Ingress {
-		for _, ns := range conf.Namespace {
-			informer := cache.NewSharedIndexInformer(
-				&cache.ListWatch{
-					ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
-						return kubeClient.ExtensionsV1beta1().Ingresses(ns).List(options)
-					},
-					WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
-						return kubeClient.ExtensionsV1beta1().Ingresses(ns).Watch(options)
-					},
-				},
-				&ext_v1beta1.Ingress{},
-				0, //Skip resync
-				cache.Indexers{},
-			)
+	for _, c := range controllers {
+		c.context = kubeContext
+	}
+	return controllers
+}
 
-			c := newResourceController(kubeClient, eventHandler, informer, "ingress")
-			stopCh := make(chan struct{})
-			defer close(stopCh)
+// notifyReady sends a single notification to readyHandler once all controllers are synced
+func notifyReady(conf *config.Config, readyHandler handlers.Handler, controllers []*Controller, stopCh <-chan struct{}) {
+	var synced []cache.InformerSynced
+	for _, c := range controllers {
+		synced = append(synced, c.HasSynced)
+	}
+	if !cache.WaitForCacheSync(stopCh, synced...) {
+		return
+	}
 
-			go c.Run(stopCh)
-		}
+	ctx, cancel := context.WithTimeout(context.Background(), durationOrDefault(conf.HandlerTimeout, handlers.DefaultTimeout))
+	defer cancel()
+	if err := readyHandler.ObjectCreated(ctx, readyEvent(conf.Namespace, controllers)); err != nil {
+		logrus.Errorf("Error sending ready notification: %v", err)
+	}
+}
+
+// readyEvent summarizes the watched resources and namespaces of synced controllers
+func readyEvent(namespaces []string, controllers []*Controller) event.Event {
+	resources := sets.NewString()
+	count := 0
+	for _, c := range controllers {
+		resources.Insert(c.resourceType)
+		count += len(c.informer.GetStore().ListKeys())
 	}
 
-	sigterm := make(chan os.Signal, 1)
-	signal.Notify(sigterm, syscall.SIGTERM)
-	signal.Notify(sigterm, syscall.SIGINT)
-	<-sigterm
+	watched := strings.Join(namespaces, ", ")
+	if watched == "" {
+		watched = "all namespaces"
+	}
+
+	return event.Event{
+		Kind:   "kubewatch",
+		Reason: "ready",
+		Status: "Normal",
+		Text: fmt.Sprintf("kubewatch ready: watching %s across %s, %d objects in cache",
+			strings.Join(resources.List(), ", "), watched, count),
+	}
 }
 
-func newResourceController(client kubernetes.Interface, eventHandler handlers.Handler, informer cache.SharedIndexInformer, resourceType string) *Controller {
-	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
-	var newEvent Event
-	var err error
+func newResourceController(client kubernetes.Interface, eventHandler handlers.Handler, informer cache.SharedIndexInformer, resourceType string, retry config.Retry) *Controller {
+	rateLimiter := newRateLimiter(retry)
+	queue := workqueue.NewRateLimitingQueue(rateLimiter)
+	// each callback queues its own event, the fields of an event never leak into the next ones
 	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
-			newEvent.key, err = cache.MetaNamespaceKeyFunc(obj)
-			newEvent.eventType = "create"
-			newEvent.resourceType = resourceType
-			logrus.WithField("pkg", "kubewatch-"+resourceType).Infof("Processing add to %v: %s", resourceType, newEvent.key)
+			key, err := cache.MetaNamespaceKeyFunc(obj)
+			logrus.WithField("pkg", "kubewatch-"+resourceType).Debugf("Processing add to %v: %s", resourceType, key)
 			if err == nil {
-				queue.Add(newEvent)
+				queue.Add(Event{key: key, eventType: "create", resourceType: resourceType})
 			}
 		},
 		UpdateFunc: func(old, new interface{}) {
-			newEvent.key, err = cache.MetaNamespaceKeyFunc(old)
-			newEvent.eventType = "update"
-			newEvent.resourceType = resourceType
-			logrus.WithField("pkg", "kubewatch-"+resourceType).Infof("Processing update to %v: %s", resourceType, newEvent.key)
+			key, err := cache.MetaNamespaceKeyFunc(old)
+			logrus.WithField("pkg", "kubewatch-"+resourceType).Debugf("Processing update to %v: %s", resourceType, key)
 			if err == nil {
-				queue.Add(newEvent)
+				queue.Add(Event{
+					key:          key,
+					eventType:    "update",
+					resourceType: resourceType,
+					changes:      changedParts(old, new),
+					oldObj:       old,
+					newObj:       new,
+				})
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
-			newEvent.key, err = cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
-			newEvent.eventType = "delete"
-			newEvent.resourceType = resourceType
-			newEvent.namespace = utils.GetObjectMetaData(obj).Namespace
-			logrus.WithField("pkg", "kubewatch-"+resourceType).Infof("Processing delete to %v: %s", resourceType, newEvent.key)
+			key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+			// objects deleted while the watch was down come from relists as tombstones
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			logrus.WithField("pkg", "kubewatch-"+resourceType).Debugf("Processing delete to %v: %s", resourceType, key)
 			if err == nil {
-				queue.Add(newEvent)
+				queue.Add(Event{
+					key:          key,
+					eventType:    "delete",
+					resourceType: resourceType,
+					namespace:    utils.GetObjectMetaData(obj).Namespace,
+					oldObj:       obj,
+				})
 			}
 		},
 	})
 
+	now := clock.RealClock{}.Now()
 	return &Controller{
 		logger:       logrus.WithField("pkg", "kubewatch-"+resourceType),
+		resourceType: resourceType,
+		scope:        resourceScope(resourceType),
+		clock:        clock.RealClock{},
 		clientset:    client,
 		informer:     informer,
 		queue:        queue,
+		rateLimiter:  rateLimiter,
+		maxRetries:   maxRetries(retry),
 		eventHandler: eventHandler,
+		unavailable:  sets.NewString(),
+		progress:     progress{versionChanged: now, lastProcessed: now},
 	}
 }
 
-// Run starts the kubewatch controller
+// Run starts the kubewatch controller. Once stopCh is closed the queue stops
+// accepting events, and Run returns after the worker processed the queued ones.
 func (c *Controller) Run(stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer c.queue.ShutDown()
 
 	c.logger.Info("Starting kubewatch controller")
-	serverStartTime = time.Now().Local()
+	c.startTime = c.clock.Now().Local()
+	serverStart.Do(func() { serverStartTime = c.startTime })
+
+	unregisterQueue := metrics.RegisterQueue(c.resourceType, c.queue.Len)
+	defer unregisterQueue()
 
 	go c.informer.Run(stopCh)
 
 	if !cache.WaitForCacheSync(stopCh, c.HasSynced) {
+		c.queue.ShutDown()
 		utilruntime.HandleError(fmt.Errorf("Timed out waiting for caches to sync"))
 		return
 	}
 
+	c.markSynced()
 	c.logger.Info("Kubewatch controller synced and ready")
 
-	wait.Until(c.runWorker, time.Second, stopCh)
+	go func() {
+		<-stopCh
+		c.queue.ShutDown()
+	}()
+
+	// the queue hands out the events added before it shut down, then quits
+	c.runWorker()
+	c.logger.Info("Kubewatch controller stopped")
 }
 
 // HasSynced is required for the cache.Controller interface.
@@ -467,17 +753,37 @@ func (c *Controller) processNextItem() bool {
 	if quit {
 		return false
 	}
+	c.markProcessed()
 	defer c.queue.Done(newEvent)
-	err := c.processItem(newEvent.(Event))
+
+	// the event is prepared with the settings of the config, the handlers are called
+	// without holding configMu for reloads not to wait for slow handlers
+	configMu.RLock()
+	send, err := c.prepareItem(newEvent.(Event))
+	timeout, deadLetters := handlerTimeout, deadLetterFile
+	configMu.RUnlock()
+	if err == nil && send != nil {
+		// a handler hanging on a slow endpoint is cancelled instead of blocking the worker
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err = send(ctx)
+		cancel()
+	}
 	if err == nil {
 		// No error, reset the ratelimit counters
 		c.queue.Forget(newEvent)
-	} else if c.queue.NumRequeues(newEvent) < maxRetries {
+	} else if c.queue.NumRequeues(newEvent) < c.maxRetries {
 		c.logger.Errorf("Error processing %s (will retry): %v", newEvent.(Event).key, err)
-		c.queue.AddRateLimited(newEvent)
+		metrics.QueueRetries.WithLabelValues(c.resourceType).Inc()
+		c.requeue(newEvent, err)
 	} else {
 		// err != nil and too many retries
 		c.logger.Errorf("Error processing %s (giving up): %v", newEvent.(Event).key, err)
+		metrics.QueueDrops.WithLabelValues(c.resourceType).Inc()
+		if deadLetters != "" {
+			if err := c.writeDeadLetter(deadLetters, newEvent.(Event), err); err != nil {
+				c.logger.Errorf("Error writing dead letter of %s: %v", newEvent.(Event).key, err)
+			}
+		}
 		c.queue.Forget(newEvent)
 		utilruntime.HandleError(err)
 	}
@@ -485,101 +791,310 @@ func (c *Controller) processNextItem() bool {
 	return true
 }
 
+// newRateLimiter backs off the retries of each event exponentially between the
+// configured delays, with the overall limit of workqueue.DefaultControllerRateLimiter
+func newRateLimiter(retry config.Retry) workqueue.RateLimiter {
+	return workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(
+			durationOrDefault(retry.BaseDelay, defaultBaseDelay),
+			durationOrDefault(retry.MaxDelay, defaultMaxDelay),
+		),
+		// 10 qps, 100 bucket size
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(10), 100)},
+	)
+}
+
+func maxRetries(retry config.Retry) int {
+	if retry.MaxRetries == 0 {
+		return defaultMaxRetries
+	}
+	return retry.MaxRetries
+}
+
+// handlerFor returns the handler of the routes matching the event, if any
+func (c *Controller) handlerFor(newEvent Event) handlers.Handler {
+	if router, ok := c.eventHandler.(handlers.Router); ok {
+		return router.Route(newEvent.resourceType, newEvent.eventType)
+	}
+	return c.eventHandler
+}
+
+// retryAfter is implemented by handler errors asking to retry after a delay, e.g. when rate limited
+type retryAfter interface {
+	RetryAfter() time.Duration
+}
+
+// requeue retries a failed event, no sooner than the delay asked for by the handler
+func (c *Controller) requeue(item interface{}, err error) {
+	var retry retryAfter
+	if !errors.As(err, &retry) {
+		c.queue.AddRateLimited(item)
+		return
+	}
+
+	// the rate limiter still counts the retry towards the max retries
+	delay := c.rateLimiter.When(item)
+	if retry.RetryAfter() > delay {
+		delay = retry.RetryAfter()
+	}
+	c.queue.AddAfter(item, delay)
+}
+
 /* TODOs
 - Enhance event creation using client-side cacheing machanisms - pending
 - Enhance the processItem to classify events - done
 - Send alerts correspoding to events - done
 */
 
-func (c *Controller) processItem(newEvent Event) error {
+func (c *Controller) processItem(ctx context.Context, newEvent Event) error {
+	send, err := c.prepareItem(newEvent)
+	if err != nil || send == nil {
+		return err
+	}
+	return send(ctx)
+}
+
+// prepareItem filters an event and builds what is sent for it with the settings of the
+// config, it returns nil when nothing is sent. The handlers are only called by the
+// returned func, the callers don't hold configMu meanwhile.
+func (c *Controller) prepareItem(newEvent Event) (send func(ctx context.Context) error, err error) {
+	eventHandler := c.handlerFor(newEvent)
+	metrics.EventsProcessed.WithLabelValues(newEvent.resourceType, newEvent.eventType).Inc()
+
 	obj, _, err := c.informer.GetIndexer().GetByKey(newEvent.key)
 	if err != nil {
-		return fmt.Errorf("Error fetching object with key %s from store: %v", newEvent.key, err)
+		return nil, fmt.Errorf("Error fetching object with key %s from store: %v", newEvent.key, err)
 	}
+	// the values of secrets and configmaps never reach the handlers, only their keys
+	obj = redact(obj)
+	newEvent.oldObj, newEvent.newObj = redact(newEvent.oldObj), redact(newEvent.newObj)
 	// get object's metedata
 	objectMeta := utils.GetObjectMetaData(obj)
 
-	// namespace retrived from event key incase namespace value is empty
-	if newEvent.namespace == "" {
-		newEvent.namespace = strings.Split(newEvent.key, "/")[0]
+	// namespace retrived from event key incase namespace value is empty,
+	// cluster scoped objects have no namespace and ignore the namespace filters
+	if newEvent.namespace == "" && c.scope == namespaceScoped {
+		newEvent.namespace, _, _ = cache.SplitMetaNamespaceKey(newEvent.key)
+	}
+	if newEvent.namespace != "" && (namespaceDenylist.Has(newEvent.namespace) || !allowedNamespace(newEvent.namespace)) {
+		return nil, nil
+	}
+
+	// deleted objects are gone from the store, their reason and annotations are in their last known state
+	known := obj
+	if known == nil {
+		known = newEvent.oldObj
+	}
+	if !allowedReason(known) || !notifyAnnotated(known) {
+		return nil, nil
+	}
+	if _, name, _ := cache.SplitMetaNamespaceKey(newEvent.key); !allowedName(newEvent.resourceType, name) {
+		return nil, nil
+	}
+
+	// drop events of objects outside the configured age window,
+	// deleted objects are gone from the store and can't be filtered
+	if obj != nil && !withinAge(objectMeta.CreationTimestamp.Time, c.clock.Now(), ageFilters[newEvent.resourceType]) {
+		return nil, nil
 	}
 
+	// cross reference ingress backends with the watched services
+	var backendEvents []event.Event
+	if conditions.IngressBackend && (newEvent.eventType == "create" || newEvent.eventType == "update") {
+		kbEvent := normalizeEvent(event.Event{
+			Kind:      c.displayKind(newEvent.resourceType),
+			Name:      newEvent.key,
+			Namespace: newEvent.namespace,
+			Cluster:   c.context,
+		})
+		backendEvents = missingBackendEvents(obj, kbEvent)
+	}
+
+	sendEvent := c.prepareEvent(eventHandler, newEvent, obj, known, objectMeta)
+	if len(backendEvents) == 0 {
+		return sendEvent, nil
+	}
+	// the missing backend events are sent whether the event itself is or not
+	return func(ctx context.Context) error {
+		for _, e := range backendEvents {
+			if err := eventHandler.ObjectUpdated(ctx, obj, e); err != nil {
+				c.logger.Errorf("Error sending missing backend event of %s: %v", newEvent.key, err)
+			}
+		}
+		if sendEvent == nil {
+			return nil
+		}
+		return sendEvent(ctx)
+	}, nil
+}
+
+// prepareEvent builds what is sent for an event of obj, known is obj or the last
+// known state of deleted objects. It returns nil when nothing is sent.
+func (c *Controller) prepareEvent(eventHandler handlers.Handler, newEvent Event, obj, known interface{}, objectMeta meta_v1.ObjectMeta) func(ctx context.Context) error {
 	// process events based on its type
 	switch newEvent.eventType {
 	case "create":
-		// compare CreationTimestamp and serverStartTime and alert only on latest events
+		// the object was deleted before its create was processed, its delete is notified
+		if obj == nil {
+			return nil
+		}
+		// compare CreationTimestamp and serverStartTime and alert only on latest events,
+		// unless objects existing before the start are notified as such
 		// Could be Replaced by using Delta or DeltaFIFO
-		if objectMeta.CreationTimestamp.Sub(serverStartTime).Seconds() > 0 {
-			if _, ok := global[newEvent.resourceType]; ok {
-				c.eventHandler.ObjectCreated(obj)
-			} else if _, ok := create[newEvent.resourceType]; ok {
-				c.eventHandler.ObjectCreated(obj)
-			}
+		startTime := serverStartTime
+		if c.reloaded {
+			startTime = c.startTime
+		}
+		existing := objectMeta.CreationTimestamp.Sub(startTime).Seconds() <= 0
+		if existing && (!notifyExisting || c.reloaded) {
+			return nil
+		}
+		if !notifies(create, newEvent.resourceType) {
 			return nil
 		}
+		reason := "created"
+		if existing {
+			reason = event.Existing
+		}
+		e := normalizeEvent(event.New(obj, reason))
+		e.Cluster = c.context
+		// objects are sent as is unless their event carries more
+		asEvent := existing || nameNormalizer != nil || c.context != ""
+		return func(ctx context.Context) error {
+			// the owner lookup reads the API server, it is done for the sent events only
+			e.Owner = c.owner(ctx, obj)
+			if asEvent || e.Owner != "" {
+				return eventHandler.ObjectCreated(ctx, e)
+			}
+			return eventHandler.ObjectCreated(ctx, obj)
+		}
 	case "update":
 		/* TODOs
 		- enahace update event processing in such a way that, it send alerts about what got changed.
 		*/
-		kbEvent := event.Event{
-			Kind:      newEvent.resourceType,
-			Name:      newEvent.key,
-			Namespace: newEvent.namespace,
+		kbEvent := normalizeEvent(event.Event{
+			Kind:            c.displayKind(newEvent.resourceType),
+			Name:            newEvent.key,
+			Namespace:       newEvent.namespace,
+			Cluster:         c.context,
+			Diff:            objectDiff(newEvent.oldObj, newEvent.newObj),
+			Labels:          objectMeta.Labels,
+			Annotations:     objectMeta.Annotations,
+			KubeEvent:       event.NewKubeEvent(obj),
+			Access:          event.NewAccess(obj),
+			Budget:          event.NewBudget(obj),
+			DataKeys:        event.NewDataKeys(obj),
+			ResourceVersion: objectMeta.ResourceVersion,
+		})
+		conditionEvent := false
+		if conditions.UnavailableReplicas {
+			if e, notify, ok := c.unavailableReplicasEvent(newEvent.key, obj, kbEvent); ok {
+				if !notify {
+					return nil
+				}
+				kbEvent = e
+				conditionEvent = true
+			}
 		}
-		if _, ok := global[newEvent.resourceType]; ok {
-			c.eventHandler.ObjectUpdated(obj, kbEvent)
-		} else if _, ok := update[newEvent.resourceType]; ok {
-			c.eventHandler.ObjectUpdated(obj, kbEvent)
+		// condition based alerts are sent whatever changes are watched
+		if !conditionEvent && !watchedChange(newEvent.resourceType, newEvent.changes) {
+			return nil
+		}
+		// condition based alerts keep the severity of their status
+		if !conditionEvent {
+			kbEvent.Severity = event.Severity(newEvent.resourceType, "updated")
+		}
+		if !restartedEnough(obj) || !phaseTransition(newEvent.oldObj, newEvent.newObj) {
+			return nil
+		}
+		if !notifies(update, newEvent.resourceType) {
+			return nil
+		}
+		return func(ctx context.Context) error {
+			kbEvent.Owner = c.owner(ctx, obj)
+			err := eventHandler.ObjectUpdated(ctx, obj, kbEvent)
+			if err != nil && conditionEvent {
+				c.resetUnavailable(newEvent.key, kbEvent)
+			}
+			return err
 		}
-		return nil
 	case "delete":
-		kbEvent := event.Event{
-			Kind:      newEvent.resourceType,
-			Name:      newEvent.key,
-			Namespace: newEvent.namespace,
+		c.unavailable.Delete(newEvent.key)
+		if !notifies(delete, newEvent.resourceType) {
+			return nil
 		}
-		if _, ok := global[newEvent.resourceType]; ok {
-			c.eventHandler.ObjectDeleted(kbEvent)
-		} else if _, ok := delete[newEvent.resourceType]; ok {
-			c.eventHandler.ObjectDeleted(kbEvent)
+		deletedMeta := utils.GetObjectMetaData(newEvent.oldObj)
+		kbEvent := normalizeEvent(event.Event{
+			Kind:            c.displayKind(newEvent.resourceType),
+			Name:            newEvent.key,
+			Namespace:       newEvent.namespace,
+			Cluster:         c.context,
+			Labels:          deletedMeta.Labels,
+			Annotations:     deletedMeta.Annotations,
+			KubeEvent:       event.NewKubeEvent(newEvent.oldObj),
+			Budget:          event.NewBudget(newEvent.oldObj),
+			DataKeys:        event.NewDataKeys(newEvent.oldObj),
+			Severity:        event.Severity(newEvent.resourceType, "deleted"),
+			ResourceVersion: deletedMeta.ResourceVersion,
+		})
+		return func(ctx context.Context) error {
+			kbEvent.Owner = c.owner(ctx, known)
+			return eventHandler.ObjectDeleted(ctx, kbEvent)
 		}
-		return nil
 	}
 	return nil
 }
 
-// loadEventConfig loads event list from Event config for granular alerting
-func loadEventConfig(c *config.Config) {
-
-	// Load Global events
-	if len(c.Event.Global) > 0 {
-		global = make(map[string]uint8)
-		for _, r := range c.Event.Global {
-			global[r] = 0
-		}
+// displayKind returns the kind shown in notifications of the events of a resource type
+func (c *Controller) displayKind(resourceType string) string {
+	if c.kind != "" {
+		return event.DisplayName(c.kind)
 	}
+	return event.DisplayName(resourceType)
+}
 
-	// Load Create events
-	if len(c.Event.Create) > 0 {
-		create = make(map[string]uint8)
-		for _, r := range c.Event.Create {
-			create[r] = 0
+// loadEventConfig loads event list from Event config for granular alerting,
+// event types without resources are reset for reloaded configs.
+// Global resources notify all three event types, unless they are listed under
+// an event type: the create, update and delete lists are authoritative for
+// their resources, e.g. a resource listed only under delete is notified on delete.
+func loadEventConfig(c *config.Config) {
+	var listed []string
+	listed = append(listed, c.Event.Create...)
+	listed = append(listed, c.Event.Update...)
+	listed = append(listed, c.Event.Delete...)
+	granular := eventResources(listed)
+
+	var unlisted []string
+	for _, r := range c.Event.Global {
+		if _, ok := granular[r]; !ok {
+			unlisted = append(unlisted, r)
 		}
 	}
+	global = eventResources(unlisted)
+	create = eventResources(c.Event.Create)
+	update = eventResources(c.Event.Update)
+	delete = eventResources(c.Event.Delete)
+}
 
-	// Load Update events
-	if len(c.Event.Update) > 0 {
-		update = make(map[string]uint8)
-		for _, r := range c.Event.Update {
-			update[r] = 0
-		}
+// notifies reports whether events of a resource type are notified,
+// either globally or through the resources of the event type
+func notifies(events map[string]uint8, resourceType string) bool {
+	if _, ok := global[resourceType]; ok {
+		return true
 	}
+	_, ok := events[resourceType]
+	return ok
+}
 
-	// Load Delete events
-	if len(c.Event.Delete) > 0 {
-		delete = make(map[string]uint8)
-		for _, r := range c.Event.Delete {
-			delete[r] = 0
-		}
+// eventResources returns the set of resources of an event type, nil when empty
+func eventResources(resources []string) map[string]uint8 {
+	if len(resources) == 0 {
+		return nil
+	}
+	m := make(map[string]uint8)
+	for _, r := range resources {
+		m[r] = 0
 	}
+	return m
 }
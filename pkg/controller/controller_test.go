@@ -0,0 +1,698 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers"
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+// newTestController returns a controller whose informer store holds objs
+func newTestController(resourceType string, objType runtime.Object, objs ...interface{}) *Controller {
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, objType, 0, cache.Indexers{})
+	for _, obj := range objs {
+		informer.GetStore().Add(obj)
+	}
+	return newResourceController(nil, nil, informer, resourceType, config.Retry{})
+}
+
+// recordingHandler records the events it receives
+type recordingHandler struct {
+	created, updated, deleted []interface{}
+}
+
+func (r *recordingHandler) Init(c *config.Config) error { return nil }
+func (r *recordingHandler) TestHandler()                {}
+
+func (r *recordingHandler) ObjectCreated(ctx context.Context, obj interface{}) error {
+	r.created = append(r.created, obj)
+	return nil
+}
+
+func (r *recordingHandler) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	r.deleted = append(r.deleted, obj)
+	return nil
+}
+
+func (r *recordingHandler) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	r.updated = append(r.updated, newObj)
+	return nil
+}
+
+func pod(name string, created time.Time) *api_v1.Pod {
+	return &api_v1.Pod{ObjectMeta: meta_v1.ObjectMeta{
+		Name:              name,
+		Namespace:         "default",
+		CreationTimestamp: meta_v1.NewTime(created),
+	}}
+}
+
+func TestProcessItemCreateSuppression(t *testing.T) {
+	start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFakeClock(start)
+
+	c := newTestController("pod", &api_v1.Pod{},
+		pod("existing", start.Add(-time.Minute)),
+		pod("same-second", start),
+		pod("new", start.Add(time.Minute)),
+	)
+	handler := &recordingHandler{}
+	c.eventHandler = handler
+	c.clock = fakeClock
+
+	global, ageFilters = map[string]uint8{"pod": 0}, nil
+	defer func() { global = nil }()
+	serverStartTime = c.clock.Now()
+	fakeClock.Step(2 * time.Minute)
+
+	for _, key := range []string{"default/existing", "default/same-second", "default/new"} {
+		if err := c.processItem(context.Background(), Event{key: key, eventType: "create", resourceType: "pod"}); err != nil {
+			t.Fatalf("processItem(%s): %v", key, err)
+		}
+	}
+	if len(handler.created) != 1 || handler.created[0].(*api_v1.Pod).Name != "new" {
+		t.Fatalf("expected only objects created after start to be sent, got %v", handler.created)
+	}
+
+	// objects age with the clock and leave the configured window
+	ageFilters = map[string]config.Age{"pod": {MaxAge: 5 * time.Minute}}
+	defer func() { ageFilters = nil }()
+	fakeClock.Step(5 * time.Minute)
+	if err := c.processItem(context.Background(), Event{key: "default/new", eventType: "create", resourceType: "pod"}); err != nil {
+		t.Fatalf("processItem(): %v", err)
+	}
+	if len(handler.created) != 1 {
+		t.Fatalf("expected objects older than the age window to be dropped, got %v", handler.created)
+	}
+}
+
+func TestProcessItemNotifyExisting(t *testing.T) {
+	start := time.Now()
+	c := newTestController("pod", &api_v1.Pod{},
+		pod("existing", start.Add(-time.Minute)),
+		pod("new", start.Add(time.Minute)),
+	)
+	handler := &recordingHandler{}
+	c.eventHandler = handler
+
+	global = map[string]uint8{"pod": 0}
+	notifyExisting = true
+	defer func() { global, notifyExisting = nil, false }()
+	serverStartTime = start
+
+	// deleted is gone from the store before its create is processed
+	for _, key := range []string{"default/existing", "default/new", "default/deleted"} {
+		if err := c.processItem(context.Background(), Event{key: key, eventType: "create", resourceType: "pod"}); err != nil {
+			t.Fatalf("processItem(%s): %v", key, err)
+		}
+	}
+	if len(handler.created) != 2 {
+		t.Fatalf("expected both objects in the store to be sent, got %v", handler.created)
+	}
+	if e, ok := handler.created[0].(event.Event); !ok || e.Reason != event.Existing || e.Name != "existing" {
+		t.Errorf("expected the object created before start to be sent as existing, got %v", handler.created[0])
+	}
+	if p, ok := handler.created[1].(*api_v1.Pod); !ok || p.Name != "new" {
+		t.Errorf("expected the object created after start to be sent as created, got %v", handler.created[1])
+	}
+}
+
+func TestReadyEvent(t *testing.T) {
+	controllers := []*Controller{
+		newTestController("pod", &api_v1.Pod{},
+			&api_v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Name: "foo", Namespace: "default"}},
+			&api_v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Name: "bar", Namespace: "default"}},
+		),
+		newTestController("service", &api_v1.Service{},
+			&api_v1.Service{ObjectMeta: meta_v1.ObjectMeta{Name: "foo", Namespace: "default"}},
+		),
+	}
+
+	var Tests = []struct {
+		namespaces []string
+		expected   string
+	}{
+		{[]string{""}, "kubewatch ready: watching pod, service across all namespaces, 3 objects in cache"},
+		{[]string{"one", "two"}, "kubewatch ready: watching pod, service across one, two, 3 objects in cache"},
+	}
+
+	for _, tt := range Tests {
+		e := readyEvent(tt.namespaces, controllers)
+		if e.Message() != tt.expected {
+			t.Fatalf("readyEvent(): expected %q, got %q", tt.expected, e.Message())
+		}
+	}
+}
+
+func TestProcessItemReplicationControllerEvents(t *testing.T) {
+	conf := &config.Config{}
+	conf.Event.Update = []string{"replicationcontroller"}
+	conf.UnmarshallConfig()
+	loadEventConfig(conf)
+	defer func() { global, create, update, delete = nil, nil, nil, nil }()
+
+	rc := &api_v1.ReplicationController{ObjectMeta: meta_v1.ObjectMeta{Name: "foo", Namespace: "default"}}
+	c := newTestController("replicationcontroller", &api_v1.ReplicationController{}, rc)
+	handler := &recordingHandler{}
+	c.eventHandler = handler
+
+	if err := c.processItem(context.Background(), Event{key: "default/foo", eventType: "update", resourceType: "replicationcontroller"}); err != nil {
+		t.Fatalf("processItem(): %v", err)
+	}
+	if len(handler.updated) != 1 {
+		t.Fatalf("expected the configured update event to be sent, got %v", handler.updated)
+	}
+	if kind := handler.updated[0].(event.Event).Kind; kind != "ReplicationController" {
+		t.Fatalf("expected kind %q, got %q", "ReplicationController", kind)
+	}
+}
+
+func TestProcessItemContext(t *testing.T) {
+	start := time.Now()
+	c := newTestController("pod", &api_v1.Pod{}, pod("foo", start.Add(time.Minute)))
+	handler := &recordingHandler{}
+	c.eventHandler = handler
+	c.context = "prod"
+
+	global = map[string]uint8{"pod": 0}
+	defer func() { global = nil }()
+	serverStartTime = start
+
+	for _, eventType := range []string{"create", "update"} {
+		if err := c.processItem(context.Background(), Event{key: "default/foo", eventType: eventType, resourceType: "pod"}); err != nil {
+			t.Fatalf("processItem(%s): %v", eventType, err)
+		}
+	}
+	events := append(handler.created, handler.updated...)
+	if len(events) != 2 {
+		t.Fatalf("expected a created and an updated event, got %v", events)
+	}
+	for _, e := range events {
+		if cluster := e.(event.Event).Cluster; cluster != "prod" {
+			t.Fatalf("expected events tagged with context %q, got %q", "prod", cluster)
+		}
+	}
+}
+
+// retryError asks to retry after a delay
+type retryError struct{}
+
+func (retryError) Error() string             { return "rate limited" }
+func (retryError) RetryAfter() time.Duration { return time.Hour }
+
+// failingHandler fails every event with err
+type failingHandler struct {
+	recordingHandler
+	err error
+}
+
+func (f *failingHandler) ObjectCreated(ctx context.Context, obj interface{}) error { return f.err }
+
+func TestProcessNextItemRetryAfter(t *testing.T) {
+	c := newTestController("pod", &api_v1.Pod{}, pod("foo", time.Now().Add(time.Minute)))
+	c.eventHandler = &failingHandler{err: fmt.Errorf("Failed sending: %w", retryError{})}
+	defer c.queue.ShutDown()
+
+	global = map[string]uint8{"pod": 0}
+	defer func() { global = nil }()
+	serverStartTime = time.Now()
+
+	item := Event{key: "default/foo", eventType: "create", resourceType: "pod"}
+	c.queue.Add(item)
+	c.processNextItem()
+
+	if c.queue.NumRequeues(item) != 1 {
+		t.Fatalf("expected the retry to be counted, got %d requeues", c.queue.NumRequeues(item))
+	}
+	if c.queue.Len() != 0 {
+		t.Fatal("expected the retry to wait for the requested delay")
+	}
+}
+
+// blockingHandler hangs until the event is cancelled, like a slow endpoint
+type blockingHandler struct {
+	recordingHandler
+}
+
+func (b *blockingHandler) ObjectCreated(ctx context.Context, obj interface{}) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestProcessNextItemTimeout(t *testing.T) {
+	c := newTestController("pod", &api_v1.Pod{}, pod("foo", time.Now().Add(time.Minute)))
+	c.eventHandler = &blockingHandler{}
+	defer c.queue.ShutDown()
+
+	global = map[string]uint8{"pod": 0}
+	defer func() { global = nil }()
+	serverStartTime = time.Now()
+	handlerTimeout = 10 * time.Millisecond
+	defer func() { handlerTimeout = handlers.DefaultTimeout }()
+
+	item := Event{key: "default/foo", eventType: "create", resourceType: "pod"}
+	c.queue.Add(item)
+	done := make(chan struct{})
+	go func() {
+		c.processNextItem()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the hanging handler to be cancelled")
+	}
+	if c.queue.NumRequeues(item) != 1 {
+		t.Fatalf("expected the timed out event to be retried, got %d requeues", c.queue.NumRequeues(item))
+	}
+}
+
+func TestProcessNextItemMaxRetries(t *testing.T) {
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &api_v1.Pod{}, 0, cache.Indexers{})
+	informer.GetStore().Add(pod("foo", time.Now().Add(time.Minute)))
+	retry := config.Retry{MaxRetries: 2, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	c := newResourceController(nil, &failingHandler{err: fmt.Errorf("Failed sending")}, informer, "pod", retry)
+	defer c.queue.ShutDown()
+
+	global = map[string]uint8{"pod": 0}
+	defer func() { global = nil }()
+	serverStartTime = time.Now()
+
+	item := Event{key: "default/foo", eventType: "create", resourceType: "pod"}
+	c.queue.Add(item)
+	for i := 1; i <= retry.MaxRetries; i++ {
+		c.processNextItem()
+		if c.queue.NumRequeues(item) != i {
+			t.Fatalf("expected %d requeues, got %d", i, c.queue.NumRequeues(item))
+		}
+	}
+
+	// the event is dropped once it failed max retries times
+	c.processNextItem()
+	if c.queue.NumRequeues(item) != 0 {
+		t.Fatalf("expected the event to be dropped, got %d requeues", c.queue.NumRequeues(item))
+	}
+	time.Sleep(10 * time.Millisecond)
+	if c.queue.Len() != 0 {
+		t.Fatal("expected the dropped event not to be requeued")
+	}
+}
+
+func TestProcessItemNode(t *testing.T) {
+	c := newTestController("node", &api_v1.Node{})
+	handler := &recordingHandler{}
+	c.eventHandler = handler
+
+	global = map[string]uint8{"node": 0}
+	defer func() { global = nil }()
+
+	if err := c.processItem(context.Background(), Event{key: "node-1", eventType: "delete", resourceType: "node"}); err != nil {
+		t.Fatalf("processItem(): %v", err)
+	}
+	if len(handler.deleted) != 1 {
+		t.Fatalf("expected a deleted event, got %v", handler.deleted)
+	}
+	e := handler.deleted[0].(event.Event)
+	if e.Namespace != "" {
+		t.Fatalf("expected no namespace for a cluster scoped object, got %q", e.Namespace)
+	}
+	deleted := event.New(e, "deleted")
+	if msg, expected := deleted.Message(), "A `Node` `node-1` has been `deleted`"; msg != expected {
+		t.Fatalf("Message(): expected %q, got %q", expected, msg)
+	}
+}
+
+func TestProcessItemSeverity(t *testing.T) {
+	c := newTestController("namespace", &api_v1.Namespace{})
+	handler := &recordingHandler{}
+	c.eventHandler = &handlers.Severe{Handler: handler, MinSeverity: event.SeverityCritical}
+
+	global = map[string]uint8{"namespace": 0}
+	defer func() { global = nil }()
+
+	if err := c.processItem(context.Background(), Event{key: "staging", eventType: "delete", resourceType: "namespace"}); err != nil {
+		t.Fatalf("processItem(): %v", err)
+	}
+	if len(handler.deleted) != 1 {
+		t.Fatalf("expected the delete of a namespace to be critical, got %v", handler.deleted)
+	}
+	if severity := handler.deleted[0].(event.Event).Severity; severity != event.SeverityCritical {
+		t.Fatalf("expected a %s severity, got %q", event.SeverityCritical, severity)
+	}
+}
+
+func TestProcessItemNamespaceDenylist(t *testing.T) {
+	start := time.Now()
+	c := newTestController("pod", &api_v1.Pod{}, pod("foo", start.Add(time.Minute)))
+	handler := &recordingHandler{}
+	c.eventHandler = handler
+
+	global = map[string]uint8{"pod": 0, "namespace": 0}
+	namespaceDenylist = sets.NewString("default", "kube-system")
+	defer func() { global, namespaceDenylist = nil, nil }()
+	serverStartTime = start
+
+	for _, item := range []Event{
+		{key: "default/foo", eventType: "create", resourceType: "pod"},
+		{key: "default/foo", eventType: "delete", resourceType: "pod", namespace: "default"},
+		{key: "kube-system", eventType: "delete", resourceType: "namespace"},
+	} {
+		if err := c.processItem(context.Background(), item); err != nil {
+			t.Fatalf("processItem(%s): %v", item.key, err)
+		}
+	}
+	if len(handler.created) != 0 {
+		t.Fatalf("expected events of denied namespaces to be dropped, got %v", handler.created)
+	}
+	if len(handler.deleted) != 1 || handler.deleted[0].(event.Event).Name != "kube-system" {
+		t.Fatalf("expected cluster scoped objects to be kept, got %v", handler.deleted)
+	}
+}
+
+func TestQueuedEventsNamespace(t *testing.T) {
+	start := time.Now()
+	old := &api_v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Name: "old", Namespace: "kube-system"}}
+	kubeClient := fake.NewSimpleClientset(old)
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			return kubeClient.CoreV1().Pods("").List(context.Background(), options)
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			return kubeClient.CoreV1().Pods("").Watch(context.Background(), options)
+		},
+	}, &api_v1.Pod{}, 0, cache.Indexers{})
+	handler := &recordingHandler{}
+	c := newResourceController(kubeClient, handler, informer, "pod", config.Retry{})
+
+	global = map[string]uint8{"pod": 0}
+	namespaceDenylist = sets.NewString("kube-system")
+	defer func() { global, namespaceDenylist = nil, nil }()
+	serverStartTime = start
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go informer.Run(stopCh)
+	if !cache.WaitForCacheSync(stopCh, informer.HasSynced) {
+		t.Fatal("expected the cache to sync")
+	}
+	// next returns the next queued event once processed
+	next := func() Event {
+		item, _ := c.queue.Get()
+		c.queue.Done(item)
+		if err := c.processItem(context.Background(), item.(Event)); err != nil {
+			t.Fatalf("processItem(%v): %v", item, err)
+		}
+		return item.(Event)
+	}
+	next()
+
+	if err := kubeClient.CoreV1().Pods("kube-system").Delete(context.Background(), "old", meta_v1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if e := next(); e.eventType != "delete" || e.namespace != "kube-system" {
+		t.Fatalf("expected the delete of kube-system/old, got %+v", e)
+	}
+
+	web := pod("web", start.Add(time.Minute))
+	if _, err := kubeClient.CoreV1().Pods("default").Create(context.Background(), web, meta_v1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if e := next(); e.eventType != "create" || e.namespace != "" {
+		t.Fatalf("expected the create of default/web without the namespace of the delete, got %+v", e)
+	}
+	if len(handler.deleted) != 0 || len(handler.created) != 1 {
+		t.Fatalf("expected the create of default/web only to be sent, got %v created and %v deleted", handler.created, handler.deleted)
+	}
+}
+
+// gatedHandler records deleted events once the gate is open
+type gatedHandler struct {
+	recordingHandler
+	started chan struct{}
+	gate    chan struct{}
+}
+
+func (g *gatedHandler) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	g.started <- struct{}{}
+	<-g.gate
+	return g.recordingHandler.ObjectDeleted(context.Background(), obj)
+}
+
+func TestProcessNextItemReleasesConfig(t *testing.T) {
+	c := newTestController("pod", &api_v1.Pod{})
+	handler := &gatedHandler{started: make(chan struct{}, 1), gate: make(chan struct{})}
+	c.eventHandler = handler
+
+	global = map[string]uint8{"pod": 0}
+	defer func() { global = nil }()
+
+	c.queue.Add(Event{key: "default/foo", eventType: "delete", namespace: "default", resourceType: "pod"})
+	done := make(chan struct{})
+	go func() {
+		c.processNextItem()
+		close(done)
+	}()
+	<-handler.started
+
+	// a reload takes the config while the handler is sending
+	locked := make(chan struct{})
+	go func() {
+		configMu.Lock()
+		configMu.Unlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("expected the config not to be held while the handler sends")
+	}
+	close(handler.gate)
+	<-done
+	if len(handler.deleted) != 1 {
+		t.Fatalf("expected the deleted event to be sent, got %v", handler.deleted)
+	}
+}
+
+func TestRunDrainsQueue(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			return kubeClient.CoreV1().Pods("").List(context.Background(), options)
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			return kubeClient.CoreV1().Pods("").Watch(context.Background(), options)
+		},
+	}, &api_v1.Pod{}, 0, cache.Indexers{})
+	handler := &gatedHandler{started: make(chan struct{}, 3), gate: make(chan struct{})}
+	c := newResourceController(kubeClient, handler, informer, "pod", config.Retry{})
+
+	global = map[string]uint8{"pod": 0}
+	defer func() { global = nil }()
+
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		c.Run(stopCh)
+		close(done)
+	}()
+	if !cache.WaitForCacheSync(nil, c.HasSynced) {
+		t.Fatal("expected the cache to sync")
+	}
+
+	for _, key := range []string{"default/a", "default/b", "default/c"} {
+		c.queue.Add(Event{key: key, eventType: "delete", namespace: "default", resourceType: "pod"})
+	}
+	<-handler.started
+
+	// queued events are processed after the stop, new ones are not accepted
+	close(stopCh)
+	select {
+	case <-done:
+		t.Fatal("expected Run to wait for the queued events")
+	case <-time.After(100 * time.Millisecond):
+	}
+	c.queue.Add(Event{key: "default/d", eventType: "delete", namespace: "default", resourceType: "pod"})
+
+	close(handler.gate)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected Run to return once the queue drained")
+	}
+	if len(handler.deleted) != 3 {
+		t.Errorf("expected the 3 events queued before the stop to be sent, got %v", handler.deleted)
+	}
+}
+
+func TestProcessItemDeleteMetadata(t *testing.T) {
+	c := newTestController("pod", &api_v1.Pod{})
+	handler := &recordingHandler{}
+	c.eventHandler = handler
+
+	global = map[string]uint8{"pod": 0}
+	defer func() { global = nil }()
+
+	deleted := pod("foo", time.Now())
+	deleted.Labels = map[string]string{"app": "web"}
+	err := c.processItem(context.Background(), Event{key: "default/foo", eventType: "delete", namespace: "default", resourceType: "pod", oldObj: deleted})
+	if err != nil {
+		t.Fatalf("processItem(): %v", err)
+	}
+	if len(handler.deleted) != 1 || handler.deleted[0].(event.Event).Labels["app"] != "web" {
+		t.Fatalf("expected the labels of the deleted object, got %v", handler.deleted)
+	}
+}
+
+// capturingInformer keeps the event handler added by the controller
+type capturingInformer struct {
+	cache.SharedIndexInformer
+	handler cache.ResourceEventHandler
+}
+
+func (i *capturingInformer) AddEventHandler(handler cache.ResourceEventHandler) {
+	i.handler = handler
+}
+
+func TestDeleteTombstone(t *testing.T) {
+	informer := &capturingInformer{
+		SharedIndexInformer: cache.NewSharedIndexInformer(&cache.ListWatch{}, &api_v1.Pod{}, 0, cache.Indexers{}),
+	}
+	c := newResourceController(nil, nil, informer, "pod", config.Retry{})
+	defer c.queue.ShutDown()
+	handler := &recordingHandler{}
+	c.eventHandler = handler
+
+	global = map[string]uint8{"pod": 0}
+	defer func() { global = nil }()
+
+	deleted := pod("foo", time.Now())
+	deleted.Labels = map[string]string{"app": "web"}
+	informer.handler.OnDelete(cache.DeletedFinalStateUnknown{Key: "default/foo", Obj: deleted})
+
+	item, _ := c.queue.Get()
+	queued := item.(Event)
+	if queued.key != "default/foo" || queued.namespace != "default" {
+		t.Fatalf("expected the key and namespace of the deleted object, got %+v", queued)
+	}
+	if err := c.processItem(context.Background(), queued); err != nil {
+		t.Fatalf("processItem(): %v", err)
+	}
+	if len(handler.deleted) != 1 {
+		t.Fatalf("expected a deleted event, got %v", handler.deleted)
+	}
+	e := handler.deleted[0].(event.Event)
+	if e.Name != "default/foo" || e.Namespace != "default" || e.Labels["app"] != "web" {
+		t.Errorf("expected the metadata of the deleted object, got %+v", e)
+	}
+}
+
+func TestProcessItemGranularEvents(t *testing.T) {
+	// pod is enabled for all events through the resources, and listed only under delete
+	conf := &config.Config{Resource: config.Resource{Pod: true, Service: true}}
+	conf.Event.Delete = []string{"pod"}
+	conf.UnmarshallConfig()
+	loadEventConfig(conf)
+	defer func() { global, create, update, delete = nil, nil, nil, nil }()
+
+	pod := &api_v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Name: "foo", Namespace: "default", CreationTimestamp: meta_v1.Now()}}
+	c := newTestController("pod", &api_v1.Pod{}, pod)
+	handler := &recordingHandler{}
+	c.eventHandler = handler
+
+	for _, eventType := range []string{"create", "update", "delete"} {
+		if err := c.processItem(context.Background(), Event{key: "default/foo", eventType: eventType, resourceType: "pod", oldObj: pod}); err != nil {
+			t.Fatalf("processItem(%s): %v", eventType, err)
+		}
+	}
+	if len(handler.created) != 0 || len(handler.updated) != 0 {
+		t.Errorf("expected no create or update events, got %v and %v", handler.created, handler.updated)
+	}
+	if len(handler.deleted) != 1 {
+		t.Errorf("expected the delete event to be sent, got %v", handler.deleted)
+	}
+
+	// global resources not listed under an event type get all three
+	for eventType, events := range map[string]map[string]uint8{"create": create, "update": update, "delete": delete} {
+		if !notifies(events, "service") {
+			t.Errorf("expected global services to be notified on %s", eventType)
+		}
+	}
+}
+
+func TestProcessItemKubeEvent(t *testing.T) {
+	backOff := &api_v1.Event{
+		ObjectMeta:     meta_v1.ObjectMeta{Name: "foo.1", Namespace: "default"},
+		InvolvedObject: api_v1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "foo"},
+		Reason:         "BackOff",
+		Message:        "Back-off restarting failed container",
+		Count:          2,
+	}
+	c := newTestController("event", &api_v1.Event{}, backOff)
+	handler := &recordingHandler{}
+	c.eventHandler = handler
+
+	global = map[string]uint8{"event": 0}
+	defer func() { global = nil }()
+
+	if err := c.processItem(context.Background(), Event{key: "default/foo.1", eventType: "update", resourceType: "event"}); err != nil {
+		t.Fatalf("processItem(): %v", err)
+	}
+	if len(handler.updated) != 1 {
+		t.Fatalf("expected the update of the event to be sent, got %v", handler.updated)
+	}
+	e := event.New(handler.updated[0], "updated")
+	expected := "A `pod` `default/foo` reports `BackOff`:\nBack-off restarting failed container"
+	if msg := e.Message(); msg != expected {
+		t.Errorf("Message(): expected %q, got %q", expected, msg)
+	}
+}
+
+func TestClusterName(t *testing.T) {
+	kubeSystem := &api_v1.Namespace{ObjectMeta: meta_v1.ObjectMeta{Name: "kube-system", UID: "0b9e3f54-6d5c-4ec4-9d1a-2f1a7e0d7c11"}}
+
+	var Tests = []struct {
+		name     string
+		client   *fake.Clientset
+		expected string
+	}{
+		{"prod", fake.NewSimpleClientset(kubeSystem), "prod"},
+		{"", fake.NewSimpleClientset(kubeSystem), "0b9e3f54-6d5c-4ec4-9d1a-2f1a7e0d7c11"},
+		// the API server host names clusters whose kube-system namespace can't be read
+		{"", fake.NewSimpleClientset(), "10.0.0.1:6443"},
+	}
+
+	for i, tt := range Tests {
+		if name := clusterName(tt.name, tt.client, "https://10.0.0.1:6443"); name != tt.expected {
+			t.Errorf("%d: clusterName(): expected %q, got %q", i, tt.expected, name)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// groupVersionResource returns the API resource of a custom resource
+func groupVersionResource(r config.CustomResource) schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: r.Group, Version: r.Version, Resource: r.Resource}
+}
+
+// customResourceListWatch returns the list watch of a custom resource in ns,
+// objects are listed and watched as unstructured objects
+func customResourceListWatch(dynamicClient dynamic.Interface, r config.CustomResource, ns string) cache.ListerWatcher {
+	resource := dynamicClient.Resource(groupVersionResource(r))
+	return &cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			return resource.Namespace(ns).List(context.Background(), options)
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			return resource.Namespace(ns).Watch(context.Background(), options)
+		},
+	}
+}
+
+// customResourceKind returns the lowercased kind of a custom resource, e.g. widget
+// for widgets.example.com, falling back to the resource when discovery fails
+func customResourceKind(kubeClient kubernetes.Interface, r config.CustomResource) string {
+	groupVersion := groupVersionResource(r).GroupVersion().String()
+	resources, err := kubeClient.Discovery().ServerResourcesForGroupVersion(groupVersion)
+	if err != nil {
+		logrus.Warnf("Error discovering %s, notifying its %s as such: %v", groupVersion, r.Resource, err)
+		return r.Resource
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Name == r.Resource {
+			return strings.ToLower(resource.Kind)
+		}
+	}
+	logrus.Warnf("%s isn't served by %s", r.Resource, groupVersion)
+	return r.Resource
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestNewControllersCustomResources(t *testing.T) {
+	widget := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata":   map[string]interface{}{"name": "foo", "namespace": "default"},
+	}}
+	widgets := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+	gadgets := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "gadgets"}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{widgets: "WidgetList", gadgets: "GadgetList"}, widget)
+
+	kubeClient := fake.NewSimpleClientset()
+	kubeClient.Fake.Resources = []*meta_v1.APIResourceList{{
+		GroupVersion: "example.com/v1",
+		APIResources: []meta_v1.APIResource{{Name: "widgets", Kind: "Widget", Namespaced: true}},
+	}}
+
+	conf := &config.Config{
+		Namespace: []string{"default"},
+		CustomResources: []config.CustomResource{
+			{Group: "example.com", Version: "v1", Resource: "widgets", Namespaced: true},
+			{Group: "example.com", Version: "v1", Resource: "gadgets"},
+		},
+	}
+	handler := &recordingHandler{}
+	controllers := newControllers(conf, kubeClient, dynamicClient, handler, "")
+	if len(controllers) != 2 {
+		t.Fatalf("expected a controller per custom resource, got %d", len(controllers))
+	}
+	// kinds missing from discovery fall back to the resource
+	gadget := controllers[1]
+	if gadget.resourceType != "gadgets.example.com" || gadget.kind != "gadgets" || gadget.namespace != "" {
+		t.Errorf("expected cluster wide gadgets, got %s of kind %s in %q", gadget.resourceType, gadget.kind, gadget.namespace)
+	}
+
+	c := controllers[0]
+	if c.resourceType != "widgets.example.com" || c.kind != "widget" || c.namespace != "default" {
+		t.Fatalf("expected widgets in default, got %s of kind %s in %q", c.resourceType, c.kind, c.namespace)
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go c.informer.Run(stopCh)
+	if !cache.WaitForCacheSync(stopCh, c.informer.HasSynced) {
+		t.Fatal("widgets informer didn't sync")
+	}
+
+	global = map[string]uint8{"widgets.example.com": 0}
+	defer func() { global = nil }()
+
+	if err := c.processItem(context.Background(), Event{key: "default/foo", eventType: "update", resourceType: c.resourceType}); err != nil {
+		t.Fatalf("processItem(): %v", err)
+	}
+	if len(handler.updated) != 1 {
+		t.Fatalf("expected the update of the widget to be sent, got %v", handler.updated)
+	}
+	if e := event.New(handler.updated[0], "updated"); e.Kind != "widget" || e.Name != "default/foo" {
+		t.Errorf("expected an updated widget default/foo, got %+v", e)
+	}
+}
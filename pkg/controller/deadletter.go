@@ -0,0 +1,74 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/tools/cache"
+)
+
+// deadLetterFile is the file events given up on are appended to, guarded by configMu.
+// Dropped events are only logged when it is empty
+var deadLetterFile string
+
+// deadLetterMu serializes the writes of the workers of all controllers
+var deadLetterMu sync.Mutex
+
+// deadLetter is the JSON record of an event given up on
+type deadLetter struct {
+	Timestamp time.Time `json:"timestamp"`
+	Cluster   string    `json:"cluster,omitempty"`
+	Kind      string    `json:"kind"`
+	Namespace string    `json:"namespace,omitempty"`
+	Name      string    `json:"name"`
+	EventType string    `json:"eventType"`
+	Error     string    `json:"error"`
+}
+
+// writeDeadLetter appends an event given up on with its last error to path
+func (c *Controller) writeDeadLetter(path string, e Event, err error) error {
+	namespace, name, _ := cache.SplitMetaNamespaceKey(e.key)
+	letter := deadLetter{
+		Timestamp: c.clock.Now().UTC(),
+		Cluster:   c.context,
+		Kind:      c.displayKind(e.resourceType),
+		Namespace: namespace,
+		Name:      name,
+		EventType: e.eventType,
+		Error:     err.Error(),
+	}
+	b, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+
+	deadLetterMu.Lock()
+	defer deadLetterMu.Unlock()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	api_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/mudasirmirza/kubewatch/config"
+)
+
+func TestProcessNextItemDeadLetter(t *testing.T) {
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &api_v1.Pod{}, 0, cache.Indexers{})
+	informer.GetStore().Add(pod("foo", time.Now().Add(time.Minute)))
+	informer.GetStore().Add(pod("bar", time.Now().Add(time.Minute)))
+	retry := config.Retry{MaxRetries: 1, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	c := newResourceController(nil, &failingHandler{err: fmt.Errorf("Failed sending")}, informer, "pod", retry)
+	defer c.queue.ShutDown()
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	c.clock = clock.NewFakeClock(now)
+
+	global = map[string]uint8{"pod": 0}
+	deadLetterFile = filepath.Join(t.TempDir(), "dead-letters.json")
+	defer func() { global, deadLetterFile = nil, "" }()
+	serverStartTime = now.Add(-time.Hour)
+
+	// the event is retried once, then appended to the dead letters
+	for _, key := range []string{"default/bar", "default/foo"} {
+		c.queue.Add(Event{key: key, eventType: "create", resourceType: "pod"})
+		c.processNextItem()
+		c.processNextItem()
+	}
+
+	b, err := ioutil.ReadFile(deadLetterFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a dead letter per dropped event, got %q", b)
+	}
+	var letter deadLetter
+	if err := json.Unmarshal([]byte(lines[1]), &letter); err != nil {
+		t.Fatal(err)
+	}
+	want := deadLetter{
+		Timestamp: now,
+		Kind:      "Pod",
+		Namespace: "default",
+		Name:      "foo",
+		EventType: "create",
+		Error:     "Failed sending",
+	}
+	if letter != want {
+		t.Errorf("got dead letter %+v, want %+v", letter, want)
+	}
+}
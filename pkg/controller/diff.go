@@ -0,0 +1,300 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	autoscaling_v1 "k8s.io/api/autoscaling/v1"
+	api_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var podSpecType = reflect.TypeOf(api_v1.PodSpec{})
+
+// lastAppliedAnnotation holds the whole object as applied by kubectl, too large to be diffed
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// podSpecPaths are the paths of the pod spec in the watched workloads,
+// e.g. Spec.Template.Spec for deployments and Spec for pods
+var podSpecPaths = [][]string{
+	{"Spec"},
+	{"Spec", "Template", "Spec"},
+	{"Spec", "JobTemplate", "Spec", "Template", "Spec"},
+}
+
+// bound tells which way a change of a resource limit tightens it
+type bound int
+
+const (
+	// noBound limits, e.g. the defaults of limit ranges, neither tighten nor loosen
+	noBound bound = iota
+	// upperBound limits, e.g. quotas and max limits, tighten when lowered
+	upperBound
+	// lowerBound limits, e.g. min limits, tighten when raised
+	lowerBound
+)
+
+// objectDiff describes the changes of an updated object worth notifying: replica counts,
+// pod phases, container images, quota and limit range limits, labels and annotations
+func objectDiff(oldObj, newObj interface{}) []string {
+	if oldObj == nil || newObj == nil || reflect.TypeOf(oldObj) != reflect.TypeOf(newObj) {
+		return nil
+	}
+
+	var diff []string
+	if hpa, ok := newObj.(*autoscaling_v1.HorizontalPodAutoscaler); ok {
+		diff = autoscalerDiff(oldObj.(*autoscaling_v1.HorizontalPodAutoscaler), hpa)
+	}
+	if quota, ok := newObj.(*api_v1.ResourceQuota); ok {
+		diff = resourceListDiff("hard", oldObj.(*api_v1.ResourceQuota).Spec.Hard, quota.Spec.Hard, upperBound)
+	}
+	if limitRange, ok := newObj.(*api_v1.LimitRange); ok {
+		diff = limitRangeDiff(oldObj.(*api_v1.LimitRange), limitRange)
+	}
+	if oldReplicas, ok := replicas(oldObj); ok {
+		if newReplicas, _ := replicas(newObj); oldReplicas != newReplicas {
+			diff = append(diff, fmt.Sprintf("replicas: %d -> %d", oldReplicas, newReplicas))
+		}
+	}
+	if oldPod, ok := oldObj.(*api_v1.Pod); ok && oldPod.Status.Phase != newObj.(*api_v1.Pod).Status.Phase {
+		diff = append(diff, fmt.Sprintf("phase: %s -> %s", oldPod.Status.Phase, newObj.(*api_v1.Pod).Status.Phase))
+	}
+	diff = append(diff, imageDiff(containerImages(oldObj), containerImages(newObj))...)
+
+	oldMeta, err := meta.Accessor(oldObj)
+	if err != nil {
+		return diff
+	}
+	newMeta, err := meta.Accessor(newObj)
+	if err != nil {
+		return diff
+	}
+	diff = append(diff, mapDiff("label", oldMeta.GetLabels(), newMeta.GetLabels())...)
+	diff = append(diff, mapDiff("annotation", oldMeta.GetAnnotations(), newMeta.GetAnnotations())...)
+	return diff
+}
+
+// field returns the field of obj at path, following pointers. The returned
+// value is invalid when the path doesn't exist.
+func field(obj interface{}, path ...string) reflect.Value {
+	v := reflect.ValueOf(obj)
+	for _, name := range path {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}
+		}
+		if v = v.FieldByName(name); !v.IsValid() {
+			return v
+		}
+	}
+	return v
+}
+
+// replicas returns the desired replicas of a workload, ok is false for objects without replicas
+func replicas(obj interface{}) (count int32, ok bool) {
+	v := field(obj, "Spec", "Replicas")
+	if !v.IsValid() {
+		return 0, false
+	}
+	switch replicas := v.Interface().(type) {
+	case *int32:
+		return specReplicas(replicas), true
+	case int32:
+		return replicas, true
+	}
+	return 0, false
+}
+
+// autoscalerDiff describes the changed replica bounds of a horizontal pod autoscaler,
+// followed by its current and desired replicas which tell what the update is about
+func autoscalerDiff(oldHPA, newHPA *autoscaling_v1.HorizontalPodAutoscaler) []string {
+	var diff []string
+	if oldMin, newMin := specReplicas(oldHPA.Spec.MinReplicas), specReplicas(newHPA.Spec.MinReplicas); oldMin != newMin {
+		diff = append(diff, fmt.Sprintf("min replicas: %d -> %d", oldMin, newMin))
+	}
+	if oldHPA.Spec.MaxReplicas != newHPA.Spec.MaxReplicas {
+		diff = append(diff, fmt.Sprintf("max replicas: %d -> %d", oldHPA.Spec.MaxReplicas, newHPA.Spec.MaxReplicas))
+	}
+	return append(diff, fmt.Sprintf("replicas: %d current, %d desired",
+		newHPA.Status.CurrentReplicas, newHPA.Status.DesiredReplicas))
+}
+
+// limitRangeDiff describes the changed limits of a limit range by limit type,
+// e.g. "container max memory: 1Gi -> 512Mi (tightened)"
+func limitRangeDiff(oldLimitRange, newLimitRange *api_v1.LimitRange) []string {
+	oldItems, newItems := limitRangeItems(oldLimitRange), limitRangeItems(newLimitRange)
+	var types []string
+	for limitType := range oldItems {
+		types = append(types, limitType)
+	}
+	for limitType := range newItems {
+		if _, ok := oldItems[limitType]; !ok {
+			types = append(types, limitType)
+		}
+	}
+	sort.Strings(types)
+
+	var diff []string
+	for _, limitType := range types {
+		oldItem, newItem := oldItems[limitType], newItems[limitType]
+		diff = append(diff, resourceListDiff(limitType+" max", oldItem.Max, newItem.Max, upperBound)...)
+		diff = append(diff, resourceListDiff(limitType+" min", oldItem.Min, newItem.Min, lowerBound)...)
+		diff = append(diff, resourceListDiff(limitType+" default", oldItem.Default, newItem.Default, noBound)...)
+		diff = append(diff, resourceListDiff(limitType+" default request",
+			oldItem.DefaultRequest, newItem.DefaultRequest, noBound)...)
+		diff = append(diff, resourceListDiff(limitType+" max limit/request ratio",
+			oldItem.MaxLimitRequestRatio, newItem.MaxLimitRequestRatio, upperBound)...)
+	}
+	return diff
+}
+
+// limitRangeItems returns the limits of a limit range by lowercased limit type, e.g. container
+func limitRangeItems(limitRange *api_v1.LimitRange) map[string]api_v1.LimitRangeItem {
+	items := make(map[string]api_v1.LimitRangeItem)
+	for _, item := range limitRange.Spec.Limits {
+		items[strings.ToLower(string(item.Type))] = item
+	}
+	return items
+}
+
+// resourceListDiff describes the added, removed and changed quantities of a resource list,
+// and whether the change tightened or loosened the limit
+func resourceListDiff(kind string, oldList, newList api_v1.ResourceList, b bound) []string {
+	names := make(map[string]string)
+	for name := range oldList {
+		names[string(name)] = ""
+	}
+	for name := range newList {
+		names[string(name)] = ""
+	}
+
+	var diff []string
+	for _, name := range sortedKeys(names) {
+		oldQuantity, inOld := oldList[api_v1.ResourceName(name)]
+		newQuantity, inNew := newList[api_v1.ResourceName(name)]
+		switch {
+		case !inOld:
+			diff = append(diff, fmt.Sprintf("%s %s added: %s%s", kind, name, newQuantity.String(), tightening(b, 1)))
+		case !inNew:
+			diff = append(diff, fmt.Sprintf("%s %s removed%s", kind, name, tightening(b, -1)))
+		case oldQuantity.Cmp(newQuantity) != 0:
+			diff = append(diff, fmt.Sprintf("%s %s: %s -> %s%s", kind, name, oldQuantity.String(), newQuantity.String(),
+				tightening(b, compare(b, oldQuantity, newQuantity))))
+		}
+	}
+	return diff
+}
+
+// compare returns 1 when the new quantity tightens a limit of bound b, -1 when it loosens it
+func compare(b bound, oldQuantity, newQuantity resource.Quantity) int {
+	if b == lowerBound {
+		return newQuantity.Cmp(oldQuantity)
+	}
+	return oldQuantity.Cmp(newQuantity)
+}
+
+func tightening(b bound, direction int) string {
+	switch {
+	case b == noBound:
+		return ""
+	case direction > 0:
+		return " (tightened)"
+	default:
+		return " (loosened)"
+	}
+}
+
+// containerImages returns the images of the containers of a pod or of the
+// pod template of a workload, by container name
+func containerImages(obj interface{}) map[string]string {
+	for _, path := range podSpecPaths {
+		v := field(obj, path...)
+		if !v.IsValid() || v.Type() != podSpecType {
+			continue
+		}
+		spec := v.Interface().(api_v1.PodSpec)
+		images := make(map[string]string)
+		for _, container := range append(spec.InitContainers, spec.Containers...) {
+			images[container.Name] = container.Image
+		}
+		return images
+	}
+	return nil
+}
+
+func imageDiff(oldImages, newImages map[string]string) []string {
+	var diff []string
+	for _, name := range sortedKeys(oldImages, newImages) {
+		oldImage, inOld := oldImages[name]
+		newImage, inNew := newImages[name]
+		switch {
+		case !inOld:
+			diff = append(diff, fmt.Sprintf("container %s added: %s", name, newImage))
+		case !inNew:
+			diff = append(diff, fmt.Sprintf("container %s removed", name))
+		case oldImage != newImage:
+			diff = append(diff, fmt.Sprintf("image of container %s: %s -> %s", name, oldImage, newImage))
+		}
+	}
+	return diff
+}
+
+// mapDiff describes the added, removed and changed keys of labels or annotations
+func mapDiff(kind string, oldMap, newMap map[string]string) []string {
+	var diff []string
+	for _, key := range sortedKeys(oldMap, newMap) {
+		if key == lastAppliedAnnotation {
+			continue
+		}
+		oldValue, inOld := oldMap[key]
+		newValue, inNew := newMap[key]
+		switch {
+		case !inOld:
+			diff = append(diff, fmt.Sprintf("%s %s added: %s", kind, key, newValue))
+		case !inNew:
+			diff = append(diff, fmt.Sprintf("%s %s removed", kind, key))
+		case oldValue != newValue:
+			diff = append(diff, fmt.Sprintf("%s %s: %s -> %s", kind, key, oldValue, newValue))
+		}
+	}
+	return diff
+}
+
+func sortedKeys(maps ...map[string]string) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+	apps_v1 "k8s.io/api/apps/v1"
+	autoscaling_v1 "k8s.io/api/autoscaling/v1"
+	api_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func appsDeployment(replicas int32, labels map[string]string, images ...string) *apps_v1.Deployment {
+	d := &apps_v1.Deployment{ObjectMeta: meta_v1.ObjectMeta{Name: "foo", Namespace: "default", Labels: labels}}
+	d.Spec.Replicas = &replicas
+	for i, image := range images {
+		d.Spec.Template.Spec.Containers = append(d.Spec.Template.Spec.Containers,
+			api_v1.Container{Name: []string{"web", "sidecar"}[i], Image: image})
+	}
+	return d
+}
+
+func autoscaler(minReplicas, maxReplicas, current, desired int32) *autoscaling_v1.HorizontalPodAutoscaler {
+	hpa := &autoscaling_v1.HorizontalPodAutoscaler{ObjectMeta: meta_v1.ObjectMeta{Name: "foo", Namespace: "default"}}
+	hpa.Spec.MinReplicas = &minReplicas
+	hpa.Spec.MaxReplicas = maxReplicas
+	hpa.Status.CurrentReplicas, hpa.Status.DesiredReplicas = current, desired
+	return hpa
+}
+
+func resourceQuota(hard map[string]string) *api_v1.ResourceQuota {
+	quota := &api_v1.ResourceQuota{ObjectMeta: meta_v1.ObjectMeta{Name: "compute", Namespace: "default"}}
+	quota.Spec.Hard = resourceList(hard)
+	return quota
+}
+
+func resourceList(quantities map[string]string) api_v1.ResourceList {
+	list := make(api_v1.ResourceList)
+	for name, quantity := range quantities {
+		list[api_v1.ResourceName(name)] = resource.MustParse(quantity)
+	}
+	return list
+}
+
+func TestObjectDiff(t *testing.T) {
+	var Tests = []struct {
+		oldObj, newObj interface{}
+		expected       []string
+	}{
+		{
+			appsDeployment(1, map[string]string{"team": "payments"}, "nginx:1.19"),
+			appsDeployment(1, map[string]string{"team": "payments"}, "nginx:1.19"),
+			nil,
+		},
+		{
+			appsDeployment(1, map[string]string{"team": "payments", "tier": "web"}, "nginx:1.19"),
+			appsDeployment(3, map[string]string{"team": "billing", "app": "foo"}, "nginx:1.21", "envoy:1.16"),
+			[]string{
+				"replicas: 1 -> 3",
+				"container sidecar added: envoy:1.16",
+				"image of container web: nginx:1.19 -> nginx:1.21",
+				"label app added: foo",
+				"label team: payments -> billing",
+				"label tier removed",
+			},
+		},
+		{
+			&api_v1.Pod{Spec: api_v1.PodSpec{Containers: []api_v1.Container{{Name: "web", Image: "nginx:1.19"}}}},
+			&api_v1.Pod{
+				ObjectMeta: meta_v1.ObjectMeta{Annotations: map[string]string{
+					"note":                "hotfix",
+					lastAppliedAnnotation: "{}",
+				}},
+				Spec: api_v1.PodSpec{Containers: []api_v1.Container{{Name: "web", Image: "nginx:1.21"}}},
+			},
+			[]string{"image of container web: nginx:1.19 -> nginx:1.21", "annotation note added: hotfix"},
+		},
+		{
+			autoscaler(2, 10, 2, 2),
+			autoscaler(2, 20, 2, 12),
+			[]string{"max replicas: 10 -> 20", "replicas: 2 current, 12 desired"},
+		},
+		{
+			resourceQuota(map[string]string{"limits.cpu": "4", "limits.memory": "8Gi", "pods": "10"}),
+			resourceQuota(map[string]string{"limits.cpu": "8", "limits.memory": "8192Mi", "services": "5"}),
+			[]string{
+				"hard limits.cpu: 4 -> 8 (loosened)",
+				"hard pods removed (loosened)",
+				"hard services added: 5 (tightened)",
+			},
+		},
+		{
+			&api_v1.LimitRange{Spec: api_v1.LimitRangeSpec{Limits: []api_v1.LimitRangeItem{{
+				Type:    api_v1.LimitTypeContainer,
+				Max:     resourceList(map[string]string{"memory": "1Gi"}),
+				Min:     resourceList(map[string]string{"memory": "64Mi"}),
+				Default: resourceList(map[string]string{"memory": "256Mi"}),
+			}}}},
+			&api_v1.LimitRange{Spec: api_v1.LimitRangeSpec{Limits: []api_v1.LimitRangeItem{{
+				Type:    api_v1.LimitTypeContainer,
+				Max:     resourceList(map[string]string{"memory": "512Mi"}),
+				Min:     resourceList(map[string]string{"memory": "128Mi"}),
+				Default: resourceList(map[string]string{"memory": "128Mi"}),
+			}, {
+				Type: api_v1.LimitTypePersistentVolumeClaim,
+				Max:  resourceList(map[string]string{"storage": "10Gi"}),
+			}}}},
+			[]string{
+				"container max memory: 1Gi -> 512Mi (tightened)",
+				"container min memory: 64Mi -> 128Mi (tightened)",
+				"container default memory: 256Mi -> 128Mi",
+				"persistentvolumeclaim max storage added: 10Gi (tightened)",
+			},
+		},
+		{&api_v1.Pod{}, &api_v1.Service{}, nil},
+		{nil, &api_v1.Pod{}, nil},
+	}
+
+	for i, tt := range Tests {
+		if diff := objectDiff(tt.oldObj, tt.newObj); !reflect.DeepEqual(diff, tt.expected) {
+			t.Fatalf("%d: objectDiff(): expected %q, got %q", i, tt.expected, diff)
+		}
+	}
+}
+
+func TestProcessItemUpdateDiff(t *testing.T) {
+	oldObj := appsDeployment(1, nil, "nginx:1.19")
+	newObj := appsDeployment(2, nil, "nginx:1.19")
+	c := newTestController("deployment", &apps_v1.Deployment{}, newObj)
+	handler := &recordingHandler{}
+	c.eventHandler = handler
+
+	global = map[string]uint8{"deployment": 0}
+	defer func() { global = nil }()
+
+	item := Event{key: "default/foo", eventType: "update", resourceType: "deployment", oldObj: oldObj, newObj: newObj}
+	if err := c.processItem(context.Background(), item); err != nil {
+		t.Fatalf("processItem(): %v", err)
+	}
+	if len(handler.updated) != 1 {
+		t.Fatalf("expected an updated event, got %v", handler.updated)
+	}
+	if diff := handler.updated[0].(event.Event).Diff; !reflect.DeepEqual(diff, []string{"replicas: 1 -> 2"}) {
+		t.Fatalf("expected the replicas change, got %q", diff)
+	}
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
+
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ageFilters holds the age window of notified objects per resource type
+var ageFilters map[string]config.Age
+
+// withinAge reports whether an object created at created is inside the age window at now
+func withinAge(created, now time.Time, age config.Age) bool {
+	objectAge := now.Sub(created)
+	if age.MinAge > 0 && objectAge < age.MinAge {
+		return false
+	}
+	if age.MaxAge > 0 && objectAge > age.MaxAge {
+		return false
+	}
+	return true
+}
+
+// nameFilter holds the compiled name patterns of a resource type
+type nameFilter struct {
+	include, exclude []*regexp.Regexp
+}
+
+// nameFilters holds the name patterns of notified objects per resource type
+var nameFilters map[string]nameFilter
+
+// loadNameFilters compiles the name patterns of the resource types, nothing is
+// changed when a pattern doesn't compile
+func loadNameFilters(names map[string]config.Names) error {
+	filters := make(map[string]nameFilter)
+	for resourceType, n := range names {
+		var f nameFilter
+		for _, pattern := range n.Include {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return err
+			}
+			f.include = append(f.include, re)
+		}
+		for _, pattern := range n.Exclude {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return err
+			}
+			f.exclude = append(f.exclude, re)
+		}
+		filters[resourceType] = f
+	}
+	nameFilters = filters
+	return nil
+}
+
+// allowedName reports whether the objects of a resource type named name are notified:
+// matching none of its exclude patterns, and one of its include patterns if any
+func allowedName(resourceType, name string) bool {
+	f := nameFilters[resourceType]
+	if matchesAny(f.exclude, name) {
+		return false
+	}
+	return len(f.include) == 0 || matchesAny(f.include, name)
+}
+
+func matchesAny(patterns []*regexp.Regexp, name string) bool {
+	for _, re := range patterns {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// namespaceDenylist holds the namespaces whose events are ignored
+var namespaceDenylist sets.String
+
+// defaultNamespaceWatchLimit is the count of namespaces watched one by one by default
+const defaultNamespaceWatchLimit = 10
+
+// namespaceAllowlist holds the watched namespaces when they are too many to watch
+// one by one, and are filtered out of a cluster wide watch instead
+var namespaceAllowlist sets.String
+
+// namespaceWatchLimit returns the count of namespaces watched one by one
+func namespaceWatchLimit(conf *config.Config) int {
+	if conf.NamespaceWatchLimit > 0 {
+		return conf.NamespaceWatchLimit
+	}
+	return defaultNamespaceWatchLimit
+}
+
+// watchedNamespaces returns the namespaces with a watch per resource, a single
+// cluster wide watch above the limit
+func watchedNamespaces(conf *config.Config) []string {
+	if len(conf.Namespace) > namespaceWatchLimit(conf) {
+		return []string{meta_v1.NamespaceAll}
+	}
+	return conf.Namespace
+}
+
+// namespaceRegex matches the namespaces whose events are notified, all namespaces when nil
+var namespaceRegex *regexp.Regexp
+
+// loadNamespaceRegex compiles the pattern of the notified namespaces, nothing is
+// changed when it doesn't compile
+func loadNamespaceRegex(pattern string) error {
+	if pattern == "" {
+		namespaceRegex = nil
+		return nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	namespaceRegex = re
+	return nil
+}
+
+// allowedNamespace reports whether the events of a namespace are notified: in the
+// allowlist if any, and matching the namespace pattern if any
+func allowedNamespace(namespace string) bool {
+	if namespaceAllowlist.Len() > 0 && !namespaceAllowlist.Has(namespace) {
+		return false
+	}
+	return namespaceRegex == nil || namespaceRegex.MatchString(namespace)
+}
+
+// defaultNotifyAnnotation is the annotation opting objects out of notifications by default
+const defaultNotifyAnnotation = "kubewatch.io/notify"
+
+// notifyAnnotation holds the annotation opting objects out of notifications, or in
+var notifyAnnotation config.NotifyAnnotation
+
+// notifyAnnotated reports whether the annotations of obj let it be notified: unless
+// annotated with "false", or only when annotated with "true" in opt in mode
+func notifyAnnotated(obj interface{}) bool {
+	name := notifyAnnotation.Name
+	if name == "" {
+		name = defaultNotifyAnnotation
+	}
+	value, ok := utils.GetObjectMetaData(obj).Annotations[name]
+	if notifyAnnotation.OptIn {
+		return ok && value == "true"
+	}
+	return !ok || value != "false"
+}
+
+// eventReasons holds the reasons of the notified core Events, all reasons when empty
+var eventReasons sets.String
+
+// allowedReason reports whether obj is not a core Event, or one of an allowed reason
+func allowedReason(obj interface{}) bool {
+	e, ok := obj.(*api_v1.Event)
+	if !ok || eventReasons.Len() == 0 {
+		return true
+	}
+	return eventReasons.Has(e.Reason)
+}
+
+// minRestartCount holds the restarts of a container notifying the updates of its pod, all updates when 0
+var minRestartCount int32
+
+// restartedEnough reports whether obj is not a pod, or one with a container
+// restarted at least minRestartCount times
+func restartedEnough(obj interface{}) bool {
+	pod, ok := obj.(*api_v1.Pod)
+	if !ok || minRestartCount == 0 {
+		return true
+	}
+	for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		if status.RestartCount >= minRestartCount {
+			return true
+		}
+	}
+	return false
+}
+
+// podPhases holds the phases notifying the updates of pods changing to them, all updates when empty
+var podPhases sets.String
+
+// phaseTransition reports whether an update is not the one of a pod, or the one
+// of a pod whose phase changed to one of podPhases
+func phaseTransition(oldObj, newObj interface{}) bool {
+	oldPod, oldOk := oldObj.(*api_v1.Pod)
+	newPod, newOk := newObj.(*api_v1.Pod)
+	if !oldOk || !newOk || podPhases.Len() == 0 {
+		return true
+	}
+	return oldPod.Status.Phase != newPod.Status.Phase && podPhases.Has(string(newPod.Status.Phase))
+}
+
+// listFilters holds the list and watch filters per resource type
+var listFilters map[string]config.Filter
+
+// filterListWatch applies the filter of a resource type to the options of its
+// list and watch calls, so that filtered out objects never reach the informer
+func filterListWatch(resourceType string, lw cache.ListerWatcher) cache.ListerWatcher {
+	filter, ok := listFilters[resourceType]
+	if !ok {
+		return lw
+	}
+	return &cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			options.LabelSelector = filter.LabelSelector
+			options.FieldSelector = filter.FieldSelector
+			return lw.List(options)
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			options.LabelSelector = filter.LabelSelector
+			options.FieldSelector = filter.FieldSelector
+			return lw.Watch(options)
+		},
+	}
+}
@@ -0,0 +1,356 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestWithinAge(t *testing.T) {
+	now := time.Date(2019, 6, 3, 12, 0, 0, 0, time.UTC)
+
+	var Tests = []struct {
+		age      time.Duration
+		window   config.Age
+		expected bool
+	}{
+		// no window
+		{5 * time.Hour, config.Age{}, true},
+		// max age only, to catch new objects
+		{30 * time.Minute, config.Age{MaxAge: time.Hour}, true},
+		{2 * time.Hour, config.Age{MaxAge: time.Hour}, false},
+		// min age only, to catch long-lived objects
+		{30 * time.Minute, config.Age{MinAge: time.Hour}, false},
+		{2 * time.Hour, config.Age{MinAge: time.Hour}, true},
+		// both bounds
+		{30 * time.Minute, config.Age{MinAge: time.Hour, MaxAge: 3 * time.Hour}, false},
+		{2 * time.Hour, config.Age{MinAge: time.Hour, MaxAge: 3 * time.Hour}, true},
+		{4 * time.Hour, config.Age{MinAge: time.Hour, MaxAge: 3 * time.Hour}, false},
+	}
+
+	for i, tt := range Tests {
+		if within := withinAge(now.Add(-tt.age), now, tt.window); within != tt.expected {
+			t.Errorf("%d: withinAge(%s, %+v): expected %v", i, tt.age, tt.window, tt.expected)
+		}
+	}
+}
+
+func TestFilterListWatch(t *testing.T) {
+	listFilters = map[string]config.Filter{"pod": {LabelSelector: "team=payments", FieldSelector: "spec.nodeName=node-1"}}
+	defer func() { listFilters = nil }()
+
+	var listed, watched meta_v1.ListOptions
+	lw := &cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			listed = options
+			return &api_v1.PodList{}, nil
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			watched = options
+			return watch.NewFake(), nil
+		},
+	}
+
+	if filterListWatch("service", lw) != cache.ListerWatcher(lw) {
+		t.Fatal("expected resources without filter to be listed unfiltered")
+	}
+
+	filtered := filterListWatch("pod", lw)
+	if _, err := filtered.List(meta_v1.ListOptions{}); err != nil {
+		t.Fatalf("List(): %v", err)
+	}
+	if _, err := filtered.Watch(meta_v1.ListOptions{ResourceVersion: "1"}); err != nil {
+		t.Fatalf("Watch(): %v", err)
+	}
+	if listed.LabelSelector != "team=payments" || watched.LabelSelector != "team=payments" {
+		t.Fatalf("expected the label selector in list and watch options, got %q and %q", listed.LabelSelector, watched.LabelSelector)
+	}
+	if listed.FieldSelector != "spec.nodeName=node-1" || watched.FieldSelector != "spec.nodeName=node-1" {
+		t.Fatalf("expected the field selector in list and watch options, got %q and %q", listed.FieldSelector, watched.FieldSelector)
+	}
+	if watched.ResourceVersion != "1" {
+		t.Fatalf("expected the watch options to be kept, got %+v", watched)
+	}
+}
+
+func TestProcessItemEventReasons(t *testing.T) {
+	backOff := &api_v1.Event{ObjectMeta: meta_v1.ObjectMeta{Name: "foo.1", Namespace: "default"}, Reason: "BackOff"}
+	scheduled := &api_v1.Event{ObjectMeta: meta_v1.ObjectMeta{Name: "foo.2", Namespace: "default"}, Reason: "Scheduled"}
+	c := newTestController("event", &api_v1.Event{}, backOff, scheduled)
+	handler := &recordingHandler{}
+	c.eventHandler = handler
+
+	global = map[string]uint8{"event": 0}
+	eventReasons = sets.NewString("BackOff", "OOMKilling")
+	defer func() { global, eventReasons = nil, nil }()
+
+	for _, e := range []Event{
+		{key: "default/foo.1", eventType: "update", resourceType: "event"},
+		{key: "default/foo.2", eventType: "update", resourceType: "event"},
+		{key: "default/foo.1", eventType: "delete", resourceType: "event", oldObj: backOff},
+		{key: "default/foo.3", eventType: "delete", resourceType: "event", oldObj: scheduled},
+	} {
+		if err := c.processItem(context.Background(), e); err != nil {
+			t.Fatalf("processItem(): %v", err)
+		}
+	}
+	if len(handler.updated) != 1 || len(handler.deleted) != 1 {
+		t.Errorf("expected only the BackOff events, got %v and %v", handler.updated, handler.deleted)
+	}
+
+	// all reasons are notified without a list
+	eventReasons = nil
+	if !allowedReason(scheduled) {
+		t.Error("expected all reasons to be allowed by an empty list")
+	}
+}
+
+func TestProcessItemMinRestartCount(t *testing.T) {
+	restarted := func(name string, restarts int32) *api_v1.Pod {
+		p := &api_v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Name: name, Namespace: "default"}}
+		p.Status.ContainerStatuses = []api_v1.ContainerStatus{{Name: "sidecar"}, {Name: "web", RestartCount: restarts}}
+		return p
+	}
+	c := newTestController("pod", &api_v1.Pod{}, restarted("stable", 1), restarted("crashlooping", 5))
+	handler := &recordingHandler{}
+	c.eventHandler = handler
+
+	global = map[string]uint8{"pod": 0}
+	minRestartCount = 3
+	defer func() { global, minRestartCount = nil, 0 }()
+
+	for _, key := range []string{"default/stable", "default/crashlooping"} {
+		if err := c.processItem(context.Background(), Event{key: key, eventType: "update", resourceType: "pod"}); err != nil {
+			t.Fatalf("processItem(%s): %v", key, err)
+		}
+	}
+	if len(handler.updated) != 1 || handler.updated[0].(event.Event).Name != "default/crashlooping" {
+		t.Errorf("expected only the update of the crashlooping pod, got %v", handler.updated)
+	}
+
+	// other objects and all pods without a minimum are notified
+	if !restartedEnough(&api_v1.Service{}) {
+		t.Error("expected other objects to be notified")
+	}
+	minRestartCount = 0
+	if !restartedEnough(restarted("stable", 0)) {
+		t.Error("expected all pods to be notified without a minimum")
+	}
+}
+
+func TestProcessItemPodPhases(t *testing.T) {
+	phased := func(phase api_v1.PodPhase) *api_v1.Pod {
+		return &api_v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Name: "foo", Namespace: "default"}, Status: api_v1.PodStatus{Phase: phase}}
+	}
+	c := newTestController("pod", &api_v1.Pod{}, phased(api_v1.PodFailed))
+	handler := &recordingHandler{}
+	c.eventHandler = handler
+
+	global = map[string]uint8{"pod": 0}
+	podPhases = sets.NewString("Failed", "Unknown")
+	defer func() { global, podPhases = nil, nil }()
+
+	for _, phases := range [][2]api_v1.PodPhase{
+		{api_v1.PodPending, api_v1.PodRunning},
+		{api_v1.PodRunning, api_v1.PodFailed},
+		{api_v1.PodFailed, api_v1.PodFailed},
+	} {
+		item := Event{key: "default/foo", eventType: "update", resourceType: "pod", oldObj: phased(phases[0]), newObj: phased(phases[1])}
+		if err := c.processItem(context.Background(), item); err != nil {
+			t.Fatalf("processItem(): %v", err)
+		}
+	}
+	if len(handler.updated) != 1 {
+		t.Fatalf("expected only the transition to Failed, got %v", handler.updated)
+	}
+	if diff := handler.updated[0].(event.Event).Diff; len(diff) != 1 || diff[0] != "phase: Running -> Failed" {
+		t.Errorf("expected the phase transition in the diff, got %q", diff)
+	}
+
+	// all updates are notified without phases
+	podPhases = nil
+	if !phaseTransition(phased(api_v1.PodPending), phased(api_v1.PodRunning)) {
+		t.Error("expected all updates without phases")
+	}
+}
+
+func TestWatchedNamespaces(t *testing.T) {
+	many := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"}
+
+	var Tests = []struct {
+		conf     config.Config
+		expected []string
+	}{
+		{config.Config{Namespace: []string{""}}, []string{""}},
+		{config.Config{Namespace: []string{"default", "web"}}, []string{"default", "web"}},
+		{config.Config{Namespace: many}, []string{meta_v1.NamespaceAll}},
+		{config.Config{Namespace: many, NamespaceWatchLimit: 20}, many},
+		{config.Config{Namespace: []string{"default", "web"}, NamespaceWatchLimit: 1}, []string{meta_v1.NamespaceAll}},
+	}
+
+	for _, tt := range Tests {
+		if got := watchedNamespaces(&tt.conf); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("watchedNamespaces(%v): expected %v, got %v", tt.conf.Namespace, tt.expected, got)
+		}
+	}
+}
+
+func TestProcessItemNamespaceAllowlist(t *testing.T) {
+	start := time.Now()
+	c := newTestController("pod", &api_v1.Pod{}, pod("foo", start.Add(time.Minute)))
+	handler := &recordingHandler{}
+	c.eventHandler = handler
+
+	global = map[string]uint8{"pod": 0}
+	namespaceAllowlist = sets.NewString("web")
+	defer func() { global, namespaceAllowlist = nil, nil }()
+	serverStartTime = start
+
+	for _, item := range []Event{
+		{key: "default/foo", eventType: "create", resourceType: "pod"},
+		{key: "web/foo", eventType: "delete", resourceType: "pod", namespace: "web"},
+	} {
+		if err := c.processItem(context.Background(), item); err != nil {
+			t.Fatalf("processItem(%s): %v", item.key, err)
+		}
+	}
+	if len(handler.created) != 0 {
+		t.Fatalf("expected events of other namespaces to be dropped, got %v", handler.created)
+	}
+	if len(handler.deleted) != 1 {
+		t.Fatalf("expected events of allowed namespaces to be kept, got %v", handler.deleted)
+	}
+}
+
+func TestProcessItemNamespaceRegex(t *testing.T) {
+	start := time.Now()
+	c := newTestController("pod", &api_v1.Pod{}, pod("foo", start.Add(time.Minute)))
+	handler := &recordingHandler{}
+	c.eventHandler = handler
+
+	global = map[string]uint8{"pod": 0, "namespace": 0}
+	if err := loadNamespaceRegex("^team-"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { global, namespaceRegex = nil, nil }()
+	serverStartTime = start
+
+	for _, item := range []Event{
+		{key: "default/foo", eventType: "create", resourceType: "pod"},
+		{key: "team-web/foo", eventType: "delete", resourceType: "pod", namespace: "team-web"},
+		{key: "default", eventType: "delete", resourceType: "namespace"},
+	} {
+		if err := c.processItem(context.Background(), item); err != nil {
+			t.Fatalf("processItem(%s): %v", item.key, err)
+		}
+	}
+	if len(handler.created) != 0 {
+		t.Fatalf("expected events of unmatched namespaces to be dropped, got %v", handler.created)
+	}
+	if len(handler.deleted) != 2 {
+		t.Fatalf("expected events of matched namespaces and cluster scoped objects to be kept, got %v", handler.deleted)
+	}
+
+	if err := loadNamespaceRegex("team-("); err == nil || namespaceRegex.String() != "^team-" {
+		t.Errorf("expected an invalid pattern to fail and keep the loaded one, got %v", err)
+	}
+}
+
+func TestNotifyAnnotated(t *testing.T) {
+	annotated := func(annotations map[string]string) *api_v1.Pod {
+		return &api_v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Name: "web", Annotations: annotations}}
+	}
+	annotatedConfigMap := func(annotations map[string]string) *api_v1.ConfigMap {
+		return &api_v1.ConfigMap{ObjectMeta: meta_v1.ObjectMeta{Name: "settings", Annotations: annotations}}
+	}
+	defer func() { notifyAnnotation = config.NotifyAnnotation{} }()
+
+	var Tests = []struct {
+		annotation config.NotifyAnnotation
+		obj        interface{}
+		expected   bool
+	}{
+		{config.NotifyAnnotation{}, annotated(nil), true},
+		{config.NotifyAnnotation{}, annotated(map[string]string{"kubewatch.io/notify": "false"}), false},
+		{config.NotifyAnnotation{}, annotated(map[string]string{"kubewatch.io/notify": "true"}), true},
+		{config.NotifyAnnotation{Name: "example.com/alerts"}, annotated(map[string]string{"kubewatch.io/notify": "false"}), true},
+		{config.NotifyAnnotation{Name: "example.com/alerts"}, annotated(map[string]string{"example.com/alerts": "false"}), false},
+		{config.NotifyAnnotation{OptIn: true}, annotated(nil), false},
+		{config.NotifyAnnotation{OptIn: true}, annotated(map[string]string{"kubewatch.io/notify": "yes"}), false},
+		{config.NotifyAnnotation{OptIn: true}, annotated(map[string]string{"kubewatch.io/notify": "true"}), true},
+		{config.NotifyAnnotation{}, annotatedConfigMap(map[string]string{"kubewatch.io/notify": "false"}), false},
+		{config.NotifyAnnotation{OptIn: true}, annotatedConfigMap(map[string]string{"kubewatch.io/notify": "true"}), true},
+	}
+
+	for _, tt := range Tests {
+		notifyAnnotation = tt.annotation
+		if got := notifyAnnotated(tt.obj); got != tt.expected {
+			t.Errorf("notifyAnnotated(%v) with %+v: expected %v, got %v", utils.GetObjectMetaData(tt.obj).Annotations, tt.annotation, tt.expected, got)
+		}
+	}
+}
+
+func TestAllowedName(t *testing.T) {
+	defer func() { nameFilters = nil }()
+	if err := loadNameFilters(map[string]config.Names{
+		"pod":       {Exclude: []string{`^pr-[0-9]+-[0-9a-f]{7}`}},
+		"namespace": {Include: []string{`^team-`, `^prod$`}, Exclude: []string{`^team-sandbox$`}},
+	}); err != nil {
+		t.Fatalf("loadNameFilters(): %v", err)
+	}
+
+	var Tests = []struct {
+		resourceType string
+		name         string
+		expected     bool
+	}{
+		{"pod", "web-7d4b9c8f5-x2k9p", true},
+		{"pod", "pr-1234-abcdef0-runner", false},
+		{"namespace", "team-payments", true},
+		{"namespace", "prod", true},
+		{"namespace", "production", false},
+		// exclude wins over include
+		{"namespace", "team-sandbox", false},
+		{"service", "pr-1234-abcdef0", true},
+	}
+
+	for _, tt := range Tests {
+		if got := allowedName(tt.resourceType, tt.name); got != tt.expected {
+			t.Errorf("allowedName(%s, %s): expected %v, got %v", tt.resourceType, tt.name, tt.expected, got)
+		}
+	}
+
+	if err := loadNameFilters(map[string]config.Names{"pod": {Include: []string{"("}}}); err == nil {
+		t.Fatalf("expected an invalid pattern to fail")
+	}
+	if allowedName("pod", "pr-1234-abcdef0-runner") {
+		t.Fatalf("expected the name patterns to be kept when a pattern is invalid")
+	}
+}
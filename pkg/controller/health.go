@@ -0,0 +1,98 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const defaultStaleAfter = 10 * time.Minute
+
+// staleAfter is how long a controller may make no progress before it is reported unhealthy
+var staleAfter = defaultStaleAfter
+
+// progress tracks the sync state and progress of a controller for the probes
+type progress struct {
+	mu     sync.Mutex
+	synced bool
+	// last resource version seen by the informer and when it changed
+	resourceVersion string
+	versionChanged  time.Time
+	// last time the worker took an event or had none to take
+	lastProcessed time.Time
+}
+
+// markSynced records that the informer cache of the controller synced
+func (c *Controller) markSynced() {
+	c.progress.mu.Lock()
+	defer c.progress.mu.Unlock()
+	c.progress.synced = true
+}
+
+// markProcessed records that the worker took an event from the queue
+func (c *Controller) markProcessed() {
+	c.progress.mu.Lock()
+	defer c.progress.mu.Unlock()
+	c.progress.lastProcessed = c.clock.Now()
+}
+
+// checkReady fails until the informer cache of the controller synced
+func (c *Controller) checkReady() error {
+	c.progress.mu.Lock()
+	defer c.progress.mu.Unlock()
+	if !c.progress.synced {
+		return fmt.Errorf("%s: cache not synced", c.name())
+	}
+	return nil
+}
+
+// checkHealth fails when the resource version of the informer did not advance,
+// or events waited in the queue without the worker taking any, for staleAfter.
+// The resource version advances with watch bookmarks even when nothing changes.
+func (c *Controller) checkHealth() error {
+	now := c.clock.Now()
+
+	c.progress.mu.Lock()
+	defer c.progress.mu.Unlock()
+
+	if version := c.informer.LastSyncResourceVersion(); version != c.progress.resourceVersion {
+		c.progress.resourceVersion = version
+		c.progress.versionChanged = now
+	}
+	if stale := now.Sub(c.progress.versionChanged); stale > staleAfter {
+		return fmt.Errorf("%s: resource version %q unchanged for %s", c.name(), c.progress.resourceVersion, stale.Round(time.Second))
+	}
+
+	depth := c.queue.Len()
+	if depth == 0 {
+		c.progress.lastProcessed = now
+	}
+	if stale := now.Sub(c.progress.lastProcessed); stale > staleAfter {
+		return fmt.Errorf("%s: %d events queued, none processed for %s", c.name(), depth, stale.Round(time.Second))
+	}
+	return nil
+}
+
+// name identifies the controller in probe failures
+func (c *Controller) name() string {
+	if c.context != "" {
+		return c.context + "/" + c.resourceType
+	}
+	return c.resourceType
+}
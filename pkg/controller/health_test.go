@@ -0,0 +1,95 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	api_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/cache"
+)
+
+// versionInformer reports a settable resource version
+type versionInformer struct {
+	cache.SharedIndexInformer
+	version string
+}
+
+func (i *versionInformer) LastSyncResourceVersion() string {
+	return i.version
+}
+
+func TestCheckReady(t *testing.T) {
+	c := newTestController("pod", &api_v1.Pod{})
+	if err := c.checkReady(); err == nil {
+		t.Error("expected a controller whose cache did not sync to be unready")
+	}
+	c.markSynced()
+	if err := c.checkReady(); err != nil {
+		t.Errorf("expected a synced controller to be ready, got %v", err)
+	}
+}
+
+func TestCheckHealth(t *testing.T) {
+	start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFakeClock(start)
+
+	c := newTestController("pod", &api_v1.Pod{})
+	informer := &versionInformer{SharedIndexInformer: c.informer, version: "1"}
+	c.informer = informer
+	c.clock = fakeClock
+	c.progress.versionChanged, c.progress.lastProcessed = start, start
+
+	if err := c.checkHealth(); err != nil {
+		t.Fatalf("expected a new controller to be healthy, got %v", err)
+	}
+
+	// the resource version must advance within staleAfter
+	fakeClock.Step(staleAfter / 2)
+	informer.version = "2"
+	if err := c.checkHealth(); err != nil {
+		t.Fatalf("expected an advancing resource version to be healthy, got %v", err)
+	}
+	fakeClock.Step(staleAfter + time.Second)
+	if err := c.checkHealth(); err == nil || !strings.Contains(err.Error(), "resource version") {
+		t.Fatalf("expected a stale resource version to be unhealthy, got %v", err)
+	}
+
+	// events must not wait in the queue for staleAfter without the worker taking any
+	informer.version = "3"
+	if err := c.checkHealth(); err != nil {
+		t.Fatalf("expected an idle queue to be healthy, got %v", err)
+	}
+	c.queue.Add(Event{key: "default/pod", eventType: "create", resourceType: "pod"})
+	fakeClock.Step(staleAfter / 2)
+	informer.version = "4"
+	if err := c.checkHealth(); err != nil {
+		t.Fatalf("expected a recently idle queue to be healthy, got %v", err)
+	}
+	fakeClock.Step(staleAfter/2 + time.Second)
+	informer.version = "5"
+	if err := c.checkHealth(); err == nil || !strings.Contains(err.Error(), "1 events queued") {
+		t.Fatalf("expected a wedged queue to be unhealthy, got %v", err)
+	}
+	c.markProcessed()
+	if err := c.checkHealth(); err != nil {
+		t.Fatalf("expected a queue the worker takes events from to be healthy, got %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/server"
+
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+	defaultLeaseNamespace = "default"
+	defaultLeaseName      = "kubewatch"
+	defaultLeaseDuration  = 15 * time.Second
+	defaultRenewDeadline  = 10 * time.Second
+	defaultRetryPeriod    = 2 * time.Second
+)
+
+// leaderElectionConfig builds the election config of a replica identified by id,
+// which runs the controllers while it holds the Lease lock
+func leaderElectionConfig(conf config.LeaderElection, client kubernetes.Interface, id string, run func(ctx context.Context)) leaderelection.LeaderElectionConfig {
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: meta_v1.ObjectMeta{
+			Namespace: orDefault(conf.Namespace, defaultLeaseNamespace),
+			Name:      orDefault(conf.Name, defaultLeaseName),
+		},
+		Client:     client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: id},
+	}
+
+	return leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   durationOrDefault(conf.LeaseDuration, defaultLeaseDuration),
+		RenewDeadline:   durationOrDefault(conf.RenewDeadline, defaultRenewDeadline),
+		RetryPeriod:     durationOrDefault(conf.RetryPeriod, defaultRetryPeriod),
+		ReleaseOnCancel: true,
+		Name:            lock.LeaseMeta.Name,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				logrus.Infof("Acquired lease %s/%s as %s", lock.LeaseMeta.Namespace, lock.LeaseMeta.Name, id)
+				run(ctx)
+			},
+			OnStoppedLeading: func() {
+				logrus.Infof("Released lease %s/%s as %s", lock.LeaseMeta.Namespace, lock.LeaseMeta.Name, id)
+			},
+			OnNewLeader: func(identity string) {
+				if identity != id {
+					logrus.Infof("Waiting for lease %s/%s held by %s", lock.LeaseMeta.Namespace, lock.LeaseMeta.Name, identity)
+				}
+			},
+		},
+	}
+}
+
+// runLeaderElection blocks until ctx is done or the replica loses the lease it acquired,
+// the controllers started by run stop when the context passed to it is done
+func runLeaderElection(ctx context.Context, conf config.LeaderElection, client kubernetes.Interface, run func(ctx context.Context)) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		logrus.Fatalf("Error getting hostname for leader election: %v", err)
+	}
+	id := hostname + "_" + string(uuid.NewUUID())
+
+	elector, err := leaderelection.NewLeaderElector(leaderElectionConfig(conf, client, id, run))
+	if err != nil {
+		logrus.Fatalf("Invalid leader election config: %v", err)
+	}
+
+	// replicas waiting for the lease are ready, the controllers add their
+	// own checks once the replica leads
+	server.AddReadyCheck(func() error { return nil })
+
+	elector.Run(ctx)
+	if ctx.Err() == nil {
+		logrus.Warn("Lost leader election lease, stopping")
+	}
+}
+
+func orDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+func durationOrDefault(value, def time.Duration) time.Duration {
+	if value == 0 {
+		return def
+	}
+	return value
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/leaderelection"
+)
+
+func TestLeaderElectionConfigDefaults(t *testing.T) {
+	lec := leaderElectionConfig(config.LeaderElection{Enabled: true, Namespace: "kubewatch"}, fake.NewSimpleClientset(), "a", nil)
+	if lec.Lock.Describe() != "kubewatch/kubewatch" {
+		t.Errorf("expected the kubewatch/kubewatch lease, got %s", lec.Lock.Describe())
+	}
+	if lec.LeaseDuration != 15*time.Second || lec.RenewDeadline != 10*time.Second || lec.RetryPeriod != 2*time.Second {
+		t.Errorf("expected default durations, got %s %s %s", lec.LeaseDuration, lec.RenewDeadline, lec.RetryPeriod)
+	}
+}
+
+func TestLeaderElection(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	conf := config.LeaderElection{
+		Enabled:       true,
+		LeaseDuration: 2 * time.Second,
+		RenewDeadline: time.Second,
+		RetryPeriod:   100 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	leading := make(chan context.Context)
+	run := func(ctx context.Context) { leading <- ctx }
+
+	elector, err := leaderelection.NewLeaderElector(leaderElectionConfig(conf, kubeClient, "leader", run))
+	if err != nil {
+		t.Fatal(err)
+	}
+	go elector.Run(ctx)
+
+	var runCtx context.Context
+	select {
+	case runCtx = <-leading:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the only replica to lead")
+	}
+
+	lease, err := kubeClient.CoordinationV1().Leases("default").Get(context.Background(), "kubewatch", meta_v1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *lease.Spec.HolderIdentity != "leader" {
+		t.Errorf("expected the lease to be held by leader, got %s", *lease.Spec.HolderIdentity)
+	}
+
+	// a second replica waits for the lease
+	follower, err := leaderelection.NewLeaderElector(leaderElectionConfig(conf, kubeClient, "follower", run))
+	if err != nil {
+		t.Fatal(err)
+	}
+	followerCtx, stopFollower := context.WithCancel(context.Background())
+	defer stopFollower()
+	go follower.Run(followerCtx)
+
+	select {
+	case <-leading:
+		t.Fatal("expected the follower to wait while the lease is held")
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	// the controllers of the leader stop when it stops
+	cancel()
+	select {
+	case <-runCtx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the controllers of the leader to stop")
+	}
+
+	// the released lease is acquired by the follower
+	select {
+	case <-leading:
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the follower to lead once the lease is released")
+	}
+}
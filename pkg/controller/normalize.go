@@ -0,0 +1,75 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"regexp"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+// DefaultNamePattern matches the suffixes Kubernetes appends to generated names:
+// a pod-template-hash or a cronjob schedule timestamp, followed by a generate-name suffix.
+// Both are built from the alphabet of k8s.io/apimachinery/pkg/util/rand, which has no vowels.
+const DefaultNamePattern = `(-[bcdfghjklmnpqrstvwxz2456789]{6,10}|-[0-9]{8,10})?(-[bcdfghjklmnpqrstvwxz2456789]{5})?$`
+
+var (
+	// nameNormalizer is nil unless name normalization is enabled
+	nameNormalizer *regexp.Regexp
+	showNormalized bool
+)
+
+// loadNormalizeConfig compiles the name normalization pattern
+func loadNormalizeConfig(c config.Normalize) error {
+	if !c.Enabled {
+		nameNormalizer = nil
+		return nil
+	}
+
+	pattern := c.Pattern
+	if pattern == "" {
+		pattern = DefaultNamePattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	nameNormalizer = re
+	showNormalized = c.ShowInAlerts
+	return nil
+}
+
+// normalizeName strips the generated suffix from name
+func normalizeName(re *regexp.Regexp, name string) string {
+	if normalized := re.ReplaceAllString(name, ""); normalized != "" {
+		return normalized
+	}
+	return name
+}
+
+// normalizeEvent sets the logical name of e when name normalization is enabled
+func normalizeEvent(e event.Event) event.Event {
+	if nameNormalizer == nil {
+		return e
+	}
+	e.LogicalName = normalizeName(nameNormalizer, e.Name)
+	if showNormalized {
+		e.Name = e.LogicalName
+	}
+	return e
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestNormalizeName(t *testing.T) {
+	re := regexp.MustCompile(DefaultNamePattern)
+
+	var Tests = []struct {
+		name     string
+		expected string
+	}{
+		// deployment pod and replicaset
+		{"my-deploy-7d9f8b6c4-x2k9p", "my-deploy"},
+		{"my-deploy-7d9f8b6c4", "my-deploy"},
+		// daemonset, job and statefulset pods
+		{"fluentd-8xz4q", "fluentd"},
+		{"migrate-db-vw7bp", "migrate-db"},
+		{"web-0", "web-0"},
+		// cronjob job and pod
+		{"backup-27890520", "backup"},
+		{"backup-27890520-p2l6m", "backup"},
+		// keys and plain names are kept
+		{"default/my-deploy-7d9f8b6c4-x2k9p", "default/my-deploy"},
+		{"kube-system/coredns", "kube-system/coredns"},
+		{"nginx", "nginx"},
+		{"mysql", "mysql"},
+		{"my-service", "my-service"},
+	}
+
+	for _, tt := range Tests {
+		if normalized := normalizeName(re, tt.name); normalized != tt.expected {
+			t.Errorf("normalizeName(%q): expected %q, got %q", tt.name, tt.expected, normalized)
+		}
+	}
+}
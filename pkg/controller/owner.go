@@ -0,0 +1,84 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// maxOwners bounds the cached controllers of replica sets and jobs
+const maxOwners = 10000
+
+// owner returns the top-level controller of obj as Kind/name, e.g. Deployment/web for the
+// pods of its replica sets, or an empty string for objects without a controller.
+// Replica sets and jobs are looked up for their own controller, falling back to them
+// when they can't be read.
+func (c *Controller) owner(ctx context.Context, obj interface{}) string {
+	objectMeta := utils.GetObjectMetaData(obj)
+	ref := meta_v1.GetControllerOf(&objectMeta)
+	if ref == nil {
+		return ""
+	}
+	for {
+		parent, ok := c.controllerOf(ctx, objectMeta.Namespace, *ref)
+		if !ok || parent == nil {
+			break
+		}
+		ref = parent
+	}
+	return ref.Kind + "/" + ref.Name
+}
+
+// controllerOf returns the controller of the owner ref, ok is false when it can't be looked up
+func (c *Controller) controllerOf(ctx context.Context, namespace string, ref meta_v1.OwnerReference) (*meta_v1.OwnerReference, bool) {
+	if parent, ok := c.owners[ref.UID]; ok {
+		return parent, true
+	}
+	if c.clientset == nil {
+		return nil, false
+	}
+
+	var owned meta_v1.Object
+	var err error
+	switch ref.Kind {
+	case "ReplicaSet":
+		owned, err = c.clientset.AppsV1().ReplicaSets(namespace).Get(ctx, ref.Name, meta_v1.GetOptions{})
+	case "Job":
+		owned, err = c.clientset.BatchV1().Jobs(namespace).Get(ctx, ref.Name, meta_v1.GetOptions{})
+	default:
+		return nil, false
+	}
+	if err != nil {
+		c.logger.Debugf("Error getting the controller of %s %s/%s: %v", ref.Kind, namespace, ref.Name, err)
+		return nil, false
+	}
+	// a recreated object of the same name may have another controller
+	if owned.GetUID() != ref.UID {
+		return nil, false
+	}
+
+	parent := meta_v1.GetControllerOf(owned)
+	if c.owners == nil || len(c.owners) >= maxOwners {
+		c.owners = make(map[types.UID]*meta_v1.OwnerReference)
+	}
+	c.owners[ref.UID] = parent
+	return parent, true
+}
@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+	apps_v1 "k8s.io/api/apps/v1"
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func controllerRef(kind, name string, uid types.UID) []meta_v1.OwnerReference {
+	controller := true
+	return []meta_v1.OwnerReference{{Kind: kind, Name: name, UID: uid, Controller: &controller}}
+}
+
+func ownedPod(name string, owners []meta_v1.OwnerReference) *api_v1.Pod {
+	return &api_v1.Pod{ObjectMeta: meta_v1.ObjectMeta{
+		Name:              name,
+		Namespace:         "default",
+		CreationTimestamp: meta_v1.NewTime(time.Now().Add(time.Minute)),
+		OwnerReferences:   owners,
+	}}
+}
+
+func TestOwner(t *testing.T) {
+	replicaSet := &apps_v1.ReplicaSet{ObjectMeta: meta_v1.ObjectMeta{
+		Name:            "web-5d9c8f7b6",
+		Namespace:       "default",
+		UID:             "rs-uid",
+		OwnerReferences: controllerRef("Deployment", "web", "deploy-uid"),
+	}}
+	c := newTestController("pod", &api_v1.Pod{})
+	c.clientset = fake.NewSimpleClientset(replicaSet)
+
+	var Tests = []struct {
+		pod      *api_v1.Pod
+		expected string
+	}{
+		{ownedPod("web-5d9c8f7b6-x2x7q", controllerRef("ReplicaSet", "web-5d9c8f7b6", "rs-uid")), "Deployment/web"},
+		// replica sets which can't be read, or were recreated, are the top-level owner
+		{ownedPod("api-7f6d-abcde", controllerRef("ReplicaSet", "api-7f6d", "other-uid")), "ReplicaSet/api-7f6d"},
+		{ownedPod("web-5d9c8f7b6-abcde", controllerRef("ReplicaSet", "web-5d9c8f7b6", "old-uid")), "ReplicaSet/web-5d9c8f7b6"},
+		{ownedPod("db-0", controllerRef("StatefulSet", "db", "sts-uid")), "StatefulSet/db"},
+		{ownedPod("debug", nil), ""},
+	}
+
+	for _, tt := range Tests {
+		if owner := c.owner(context.Background(), tt.pod); owner != tt.expected {
+			t.Fatalf("owner(%s): expected %q, got %q", tt.pod.Name, tt.expected, owner)
+		}
+	}
+
+	// the controllers of replica sets are cached
+	c.clientset = fake.NewSimpleClientset()
+	if owner := c.owner(context.Background(), Tests[0].pod); owner != "Deployment/web" {
+		t.Fatalf("expected the cached owner, got %q", owner)
+	}
+}
+
+func TestProcessItemOwner(t *testing.T) {
+	pod := ownedPod("web-5d9c8f7b6-x2x7q", controllerRef("ReplicaSet", "web-5d9c8f7b6", "rs-uid"))
+	c := newTestController("pod", &api_v1.Pod{}, pod)
+	c.clientset = fake.NewSimpleClientset(&apps_v1.ReplicaSet{ObjectMeta: meta_v1.ObjectMeta{
+		Name:            "web-5d9c8f7b6",
+		Namespace:       "default",
+		UID:             "rs-uid",
+		OwnerReferences: controllerRef("Deployment", "web", "deploy-uid"),
+	}})
+	handler := &recordingHandler{}
+	c.eventHandler = handler
+
+	global = map[string]uint8{"pod": 0}
+	defer func() { global = nil }()
+	serverStartTime = time.Now()
+
+	if err := c.processItem(context.Background(), Event{key: "default/web-5d9c8f7b6-x2x7q", eventType: "create", resourceType: "pod"}); err != nil {
+		t.Fatalf("processItem(): %v", err)
+	}
+	if len(handler.created) != 1 {
+		t.Fatalf("expected a created event, got %v", handler.created)
+	}
+	if e, ok := handler.created[0].(event.Event); !ok || e.Owner != "Deployment/web" {
+		t.Fatalf("expected an event owned by Deployment/web, got %v", handler.created[0])
+	}
+}
+
+func TestProcessItemOwnerDropped(t *testing.T) {
+	pod := ownedPod("web-5d9c8f7b6-x2x7q", controllerRef("ReplicaSet", "web-5d9c8f7b6", "rs-uid"))
+	c := newTestController("pod", &api_v1.Pod{}, pod)
+	client := fake.NewSimpleClientset()
+	c.clientset = client
+	handler := &recordingHandler{}
+	c.eventHandler = handler
+
+	global = map[string]uint8{"pod": 0}
+	defer func() { global = nil }()
+	// the pod exists before the start and is dropped
+	serverStartTime = time.Now().Add(time.Hour)
+
+	if err := c.processItem(context.Background(), Event{key: "default/web-5d9c8f7b6-x2x7q", eventType: "create", resourceType: "pod"}); err != nil {
+		t.Fatalf("processItem(): %v", err)
+	}
+	if len(handler.created) != 0 {
+		t.Fatalf("expected the existing pod to be dropped, got %v", handler.created)
+	}
+	if actions := client.Actions(); len(actions) != 0 || len(c.owners) != 0 {
+		t.Fatalf("expected no owner lookup for dropped events, got %v", actions)
+	}
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	api_v1 "k8s.io/api/core/v1"
+)
+
+// redact returns a copy of secrets and configmaps without the values of their
+// data, only the keys are kept, nor the last applied configuration holding the
+// data as well. Other objects are returned as is.
+func redact(obj interface{}) interface{} {
+	switch object := obj.(type) {
+	case *api_v1.Secret:
+		redacted := object.DeepCopy()
+		for key := range redacted.Data {
+			redacted.Data[key] = nil
+		}
+		for key := range redacted.StringData {
+			redacted.StringData[key] = ""
+		}
+		redacted.Annotations = withoutLastApplied(redacted.Annotations)
+		return redacted
+	case *api_v1.ConfigMap:
+		redacted := object.DeepCopy()
+		for key := range redacted.Data {
+			redacted.Data[key] = ""
+		}
+		for key := range redacted.BinaryData {
+			redacted.BinaryData[key] = nil
+		}
+		redacted.Annotations = withoutLastApplied(redacted.Annotations)
+		return redacted
+	}
+	return obj
+}
+
+// withoutLastApplied returns the annotations without the last applied configuration
+func withoutLastApplied(annotations map[string]string) map[string]string {
+	if _, ok := annotations[lastAppliedAnnotation]; !ok {
+		return annotations
+	}
+	kept := make(map[string]string, len(annotations)-1)
+	for key, value := range annotations {
+		if key != lastAppliedAnnotation {
+			kept[key] = value
+		}
+	}
+	return kept
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestProcessItemRedactsSecrets(t *testing.T) {
+	start := time.Now()
+	secret := &api_v1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:              "db",
+			Namespace:         "default",
+			CreationTimestamp: meta_v1.NewTime(start.Add(time.Minute)),
+			Annotations: map[string]string{
+				lastAppliedAnnotation: `{"data":{"password":"aHVudGVyMg=="}}`,
+				"team":                "payments",
+			},
+		},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+		StringData: map[string]string{"username": "admin"},
+	}
+	c := newTestController("secret", &api_v1.Secret{}, secret)
+	handler := &recordingHandler{}
+	c.eventHandler = handler
+
+	global = map[string]uint8{"secret": 0}
+	defer func() { global = nil }()
+	serverStartTime = start
+
+	if err := c.processItem(context.Background(), Event{key: "default/db", eventType: "create", resourceType: "secret"}); err != nil {
+		t.Fatalf("processItem(): %v", err)
+	}
+	item := Event{key: "default/db", eventType: "update", resourceType: "secret", oldObj: secret, newObj: secret}
+	if err := c.processItem(context.Background(), item); err != nil {
+		t.Fatalf("processItem(): %v", err)
+	}
+	if len(handler.created) != 1 || len(handler.updated) != 1 {
+		t.Fatalf("expected a created and an updated event, got %v and %v", handler.created, handler.updated)
+	}
+
+	for _, notified := range []interface{}{handler.created[0], handler.updated[0]} {
+		payload, err := json.Marshal(notified)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e := event.New(notified, "created")
+		body := e.Message() + string(payload)
+		for _, value := range []string{"hunter2", "aHVudGVyMg==", "admin"} {
+			if strings.Contains(body, value) {
+				t.Errorf("expected %q to be redacted, got %s", value, body)
+			}
+		}
+		if !strings.Contains(e.Message(), "keys: password, username") {
+			t.Errorf("expected the keys to be notified, got %q", e.Message())
+		}
+	}
+
+	// the cached object keeps its data
+	if string(secret.Data["password"]) != "hunter2" || secret.Annotations[lastAppliedAnnotation] == "" {
+		t.Errorf("expected the cached secret to be left as is, got %+v", secret)
+	}
+}
+
+func TestRedactConfigMap(t *testing.T) {
+	cm := &api_v1.ConfigMap{
+		Data:       map[string]string{"config.yaml": "password: hunter2"},
+		BinaryData: map[string][]byte{"keystore": []byte("hunter2")},
+	}
+	redacted := redact(cm).(*api_v1.ConfigMap)
+	if redacted.Data["config.yaml"] != "" || len(redacted.BinaryData["keystore"]) != 0 {
+		t.Errorf("expected the values to be redacted, got %+v", redacted)
+	}
+	if keys := event.NewDataKeys(redacted); strings.Join(keys, ",") != "config.yaml,keystore" {
+		t.Errorf("expected the keys to be kept, got %v", keys)
+	}
+
+	pod := &api_v1.Pod{}
+	if redact(pod) != interface{}(pod) {
+		t.Error("expected other objects to be returned as is")
+	}
+}
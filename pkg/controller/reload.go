@@ -0,0 +1,404 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers"
+	"github.com/mudasirmirza/kubewatch/pkg/server"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// Loader loads the config and a new handler for it, e.g. when reloading on SIGHUP
+type Loader func() (*config.Config, handlers.Handler, error)
+
+// configMu guards the settings applied from the config, which a reload replaces.
+// Workers hold it for reading while they process an event.
+var configMu sync.RWMutex
+
+// applyConfig loads the settings of the config into memory, nothing is
+// changed when the config is invalid
+func applyConfig(conf *config.Config) error {
+	if err := loadNormalizeConfig(conf.Normalize); err != nil {
+		return fmt.Errorf("Invalid name normalization pattern: %v", err)
+	}
+	if err := loadNameFilters(conf.Names); err != nil {
+		return fmt.Errorf("Invalid name pattern: %v", err)
+	}
+	if err := loadNamespaceRegex(conf.NamespaceRegex); err != nil {
+		return fmt.Errorf("Invalid namespace pattern: %v", err)
+	}
+
+	// loads events config into memory for granular alerting
+	loadEventConfig(conf)
+	conditions = conf.Condition
+	ageFilters = conf.Age
+	changeFilters = conf.Changes
+	listFilters = conf.Filter
+	namespaceDenylist = sets.NewString(conf.NamespaceDenylist...)
+	namespaceAllowlist = nil
+	if len(conf.Namespace) > namespaceWatchLimit(conf) {
+		namespaceAllowlist = sets.NewString(conf.Namespace...)
+	}
+	eventReasons = sets.NewString(conf.EventReasons...)
+	minRestartCount = conf.MinRestartCount
+	podPhases = sets.NewString(conf.PodPhases...)
+	notifyAnnotation = conf.NotifyAnnotation
+	notifyExisting = conf.NotifyExisting
+	deadLetterFile = conf.Retry.DeadLetterFile
+	handlerTimeout = durationOrDefault(conf.HandlerTimeout, handlers.DefaultTimeout)
+	staleAfter = durationOrDefault(conf.Server.StaleAfter, defaultStaleAfter)
+	event.SetDisplayNames(conf.DisplayNames)
+	event.SetSeverities(conf.Severities)
+
+	if len(conf.Namespace) == 0 {
+		conf.Namespace = append(conf.Namespace, "")
+	}
+	return nil
+}
+
+// controllerKey identifies the controller of a resource type in a namespace of a cluster
+type controllerKey struct {
+	context      string
+	resourceType string
+	namespace    string
+}
+
+func (c *Controller) key() controllerKey {
+	return controllerKey{c.context, c.resourceType, c.namespace}
+}
+
+// runningController is a running controller and its stop channel
+type runningController struct {
+	controller *Controller
+	stopCh     chan struct{}
+}
+
+// manager runs the controllers of the configured resources, and reconciles
+// them with the config when it is reloaded
+type manager struct {
+	clusters []clusterClient
+	// handler passes events to the handler of the current config
+	handler *reloadableHandler
+	// readyHandler is sent the ready notification instead of handler when set,
+	// it is the one of the config the process started with
+	readyHandler handlers.Handler
+
+	// conf and running are guarded by configMu,
+	// running is nil while the manager doesn't run
+	conf    *config.Config
+	running map[controllerKey]*runningController
+
+	// runs is done once run returned, controllers once all controllers returned
+	runs        sync.WaitGroup
+	controllers sync.WaitGroup
+	checks      sync.Once
+}
+
+func newManager(conf *config.Config, eventHandler handlers.Handler, clusters []clusterClient) *manager {
+	return &manager{
+		clusters: clusters,
+		handler:  &reloadableHandler{handler: eventHandler},
+		conf:     conf,
+	}
+}
+
+// run runs the controllers until ctx is done and they drained their queues
+func (m *manager) run(ctx context.Context) {
+	m.runs.Add(1)
+	defer m.runs.Done()
+
+	apis := m.lockDiscovered()
+	m.running = make(map[controllerKey]*runningController)
+	controllers := m.reconcile(apis, false)
+	conf := m.conf
+	configMu.Unlock()
+
+	m.checks.Do(func() {
+		server.AddReadyCheck(m.checkReady)
+		server.AddHealthCheck(m.checkHealth)
+	})
+	if conf.NotifyOnReady {
+		var readyHandler handlers.Handler = m.handler
+		if m.readyHandler != nil {
+			readyHandler = m.readyHandler
+		}
+		go notifyReady(conf, readyHandler, controllers, ctx.Done())
+	}
+
+	<-ctx.Done()
+
+	configMu.Lock()
+	for _, r := range m.running {
+		close(r.stopCh)
+	}
+	m.running = nil
+	configMu.Unlock()
+
+	m.controllers.Wait()
+	logrus.Info("All controllers stopped")
+}
+
+// wait returns once run returned, if it was called
+func (m *manager) wait() {
+	m.runs.Wait()
+}
+
+// discover discovers the API versions of the clusters for conf, by cluster index
+func (m *manager) discover(conf *config.Config) []apiVersions {
+	apis := make([]apiVersions, len(m.clusters))
+	for i, cluster := range m.clusters {
+		apis[i] = discoverAPIVersions(conf, cluster.client)
+	}
+	return apis
+}
+
+// lockDiscovered discovers the API versions of the clusters for the current config,
+// then takes configMu. Discovery calls the API servers, it runs without the lock and
+// again when a reload replaced the config meanwhile.
+func (m *manager) lockDiscovered() []apiVersions {
+	for {
+		configMu.RLock()
+		conf := m.conf
+		configMu.RUnlock()
+
+		apis := m.discover(conf)
+		configMu.Lock()
+		if m.conf == conf {
+			return apis
+		}
+		configMu.Unlock()
+	}
+}
+
+// reconcile starts the controllers of the config which aren't running and stops
+// the running ones which aren't part of it anymore, the ones whose informer settings
+// changed are restarted. It returns the started ones, they are marked as reloaded on
+// reload. apis are the API versions of the clusters, the caller holds configMu.
+func (m *manager) reconcile(apis []apiVersions, reloaded bool) []*Controller {
+	desired := make(map[controllerKey]*Controller)
+	for i, cluster := range m.clusters {
+		for _, c := range newDiscoveredControllers(m.conf, apis[i], cluster.client, cluster.dynamic, m.handler, cluster.context) {
+			desired[c.key()] = c
+		}
+	}
+
+	// the package level delete map shadows the builtin, so running is rebuilt
+	running := make(map[controllerKey]*runningController)
+	for key, r := range m.running {
+		c, ok := desired[key]
+		if ok && c.settings == r.controller.settings {
+			running[key] = r
+			continue
+		}
+		if ok {
+			r.controller.logger.Infof("Restarting controller of namespace %q with its new settings", key.namespace)
+		} else {
+			r.controller.logger.Infof("Stopping controller of namespace %q", key.namespace)
+		}
+		// the controller drains its queue once the lock is released
+		close(r.stopCh)
+	}
+	m.running = running
+
+	var started []*Controller
+	for key, c := range desired {
+		if _, ok := m.running[key]; ok {
+			continue
+		}
+		c.reloaded = reloaded
+		r := &runningController{controller: c, stopCh: make(chan struct{})}
+		m.running[key] = r
+		started = append(started, c)
+
+		m.controllers.Add(1)
+		go func() {
+			defer m.controllers.Done()
+			r.controller.Run(r.stopCh)
+		}()
+	}
+
+	serviceInformers = nil
+	for _, r := range m.running {
+		if r.controller.resourceType == "service" {
+			serviceInformers = append(serviceInformers, r.controller.informer)
+		}
+	}
+	return started
+}
+
+// reload loads the config, applies it and reconciles the running controllers.
+// The current config is kept when the new one can't be loaded.
+func (m *manager) reload(load Loader) {
+	conf, eventHandler, err := load()
+	if err != nil {
+		logrus.Errorf("Error reloading config, keeping the current one: %v", err)
+		return
+	}
+	// discovery calls the API servers, the workers aren't blocked meanwhile
+	apis := m.discover(conf)
+
+	configMu.Lock()
+	if err := applyConfig(conf); err != nil {
+		configMu.Unlock()
+		closeHandler(eventHandler)
+		logrus.Errorf("Error reloading config, keeping the current one: %v", err)
+		return
+	}
+	previous := m.handler.swap(eventHandler)
+	m.conf = conf
+	if m.running != nil {
+		m.reconcile(apis, true)
+	}
+	configMu.Unlock()
+
+	// flush the events buffered by the previous handler
+	closeHandler(previous)
+	logrus.Info("Config reloaded")
+}
+
+// watchReloads reloads the config on SIGHUP until ctx is done
+func (m *manager) watchReloads(ctx context.Context, load Loader) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	for {
+		select {
+		case <-sighup:
+			logrus.Info("Received SIGHUP, reloading config")
+			m.reload(load)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// checkReady fails until the caches of the running controllers synced
+func (m *manager) checkReady() error {
+	return m.check((*Controller).checkReady)
+}
+
+// checkHealth fails when a running controller makes no progress
+func (m *manager) checkHealth() error {
+	return m.check((*Controller).checkHealth)
+}
+
+func (m *manager) check(check func(c *Controller) error) error {
+	configMu.RLock()
+	defer configMu.RUnlock()
+
+	for _, r := range m.running {
+		if err := check(r.controller); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func closeHandler(eventHandler handlers.Handler) {
+	if closer, ok := eventHandler.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			logrus.Errorf("Error closing handler: %v", err)
+		}
+	}
+}
+
+// closeHandlerWithin closes a handler, giving up on it after timeout
+func closeHandlerWithin(eventHandler handlers.Handler, timeout time.Duration) {
+	closed := make(chan struct{})
+	go func() {
+		closeHandler(eventHandler)
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(timeout):
+		logrus.Errorf("Timed out closing handler after %s, its buffered events may be lost", timeout)
+	}
+}
+
+// reloadableHandler passes events to a handler which a reload replaces
+type reloadableHandler struct {
+	mu      sync.RWMutex
+	handler handlers.Handler
+}
+
+// swap replaces the handler and returns the previous one
+func (r *reloadableHandler) swap(eventHandler handlers.Handler) handlers.Handler {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	previous := r.handler
+	r.handler = eventHandler
+	return previous
+}
+
+func (r *reloadableHandler) current() handlers.Handler {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.handler
+}
+
+func (r *reloadableHandler) Init(c *config.Config) error {
+	return r.current().Init(c)
+}
+
+func (r *reloadableHandler) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return r.current().ObjectCreated(ctx, obj)
+}
+
+func (r *reloadableHandler) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return r.current().ObjectDeleted(ctx, obj)
+}
+
+func (r *reloadableHandler) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return r.current().ObjectUpdated(ctx, oldObj, newObj)
+}
+
+func (r *reloadableHandler) TestHandler() {
+	r.current().TestHandler()
+}
+
+// Route returns the handler of the routes of the current handler, if any
+func (r *reloadableHandler) Route(resourceType, eventType string) handlers.Handler {
+	current := r.current()
+	if router, ok := current.(handlers.Router); ok {
+		return router.Route(resourceType, eventType)
+	}
+	return current
+}
+
+// Close closes the current handler
+func (r *reloadableHandler) Close() error {
+	if closer, ok := r.current().(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
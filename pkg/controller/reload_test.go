@@ -0,0 +1,243 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+// closingHandler records whether it was closed
+type closingHandler struct {
+	recordingHandler
+	closed bool
+}
+
+func (h *closingHandler) Close() error {
+	h.closed = true
+	return nil
+}
+
+// runningKeys returns the sorted resource types and namespaces of the running controllers
+func runningKeys(m *manager) []string {
+	configMu.RLock()
+	defer configMu.RUnlock()
+
+	var keys []string
+	for key := range m.running {
+		keys = append(keys, key.resourceType+"/"+key.namespace)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func waitForKeys(t *testing.T, m *manager, expected []string) {
+	for i := 0; i < 50 && !reflect.DeepEqual(runningKeys(m), expected); i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if keys := runningKeys(m); !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("expected running controllers %v, got %v", expected, keys)
+	}
+}
+
+func resourceConfig(resource config.Resource, namespaces ...string) *config.Config {
+	conf := &config.Config{Resource: resource, Namespace: namespaces}
+	conf.UnmarshallConfig()
+	return conf
+}
+
+func TestManagerReload(t *testing.T) {
+	conf := resourceConfig(config.Resource{Pod: true})
+	if err := applyConfig(conf); err != nil {
+		t.Fatal(err)
+	}
+	defer applyConfig(&config.Config{})
+
+	first := &closingHandler{}
+	m := newManager(conf, first, []clusterClient{{context: "", client: fake.NewSimpleClientset()}})
+	ctx, cancel := context.WithCancel(context.Background())
+	go m.run(ctx)
+	waitForKeys(t, m, []string{"pod/"})
+
+	configMu.RLock()
+	pods := m.running[controllerKey{"", "pod", ""}].controller
+	configMu.RUnlock()
+
+	// newly enabled resources are started, the handler is replaced
+	second := &closingHandler{}
+	m.reload(func() (*config.Config, handlers.Handler, error) {
+		return resourceConfig(config.Resource{Pod: true, Service: true}), second, nil
+	})
+	waitForKeys(t, m, []string{"pod/", "service/"})
+	configMu.RLock()
+	kept := m.running[controllerKey{"", "pod", ""}].controller
+	configMu.RUnlock()
+	if kept != pods {
+		t.Error("expected the controller of a resource staying enabled to keep running")
+	}
+	if !first.closed || m.handler.current() != second {
+		t.Error("expected the previous handler to be closed and replaced")
+	}
+	if _, ok := global["service"]; !ok {
+		t.Error("expected the events of the reloaded config to be applied")
+	}
+
+	// disabled resources and namespaces are stopped
+	m.reload(func() (*config.Config, handlers.Handler, error) {
+		return resourceConfig(config.Resource{Service: true}, "default"), second, nil
+	})
+	waitForKeys(t, m, []string{"service/default"})
+
+	// invalid configs are ignored
+	m.reload(func() (*config.Config, handlers.Handler, error) {
+		return nil, nil, errors.New("invalid config")
+	})
+	waitForKeys(t, m, []string{"service/default"})
+
+	cancel()
+	m.wait()
+	if keys := runningKeys(m); keys != nil {
+		t.Errorf("expected no running controllers once stopped, got %v", keys)
+	}
+}
+
+func TestManagerReloadSettings(t *testing.T) {
+	conf := resourceConfig(config.Resource{Pod: true})
+	if err := applyConfig(conf); err != nil {
+		t.Fatal(err)
+	}
+	defer applyConfig(&config.Config{})
+
+	handler := &closingHandler{}
+	m := newManager(conf, handler, []clusterClient{{context: "", client: fake.NewSimpleClientset()}})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		cancel()
+		m.wait()
+	}()
+	go m.run(ctx)
+	waitForKeys(t, m, []string{"pod/"})
+
+	running := func() *Controller {
+		configMu.RLock()
+		defer configMu.RUnlock()
+		return m.running[controllerKey{"", "pod", ""}].controller
+	}
+	reload := func(conf *config.Config) {
+		m.reload(func() (*config.Config, handlers.Handler, error) {
+			return conf, handler, nil
+		})
+	}
+
+	for i, tt := range []struct {
+		filter    map[string]config.Filter
+		resync    time.Duration
+		restarted bool
+	}{
+		{filter: map[string]config.Filter{"pod": {LabelSelector: "app=web"}}, restarted: true},
+		{filter: map[string]config.Filter{"pod": {LabelSelector: "app=web"}}, restarted: false},
+		{filter: map[string]config.Filter{"pod": {LabelSelector: "app=web"}}, resync: time.Minute, restarted: true},
+		{filter: map[string]config.Filter{"service": {LabelSelector: "app=web"}}, resync: time.Minute, restarted: true},
+	} {
+		previous := running()
+		reloaded := resourceConfig(config.Resource{Pod: true})
+		reloaded.Filter, reloaded.ResyncPeriod = tt.filter, tt.resync
+		reload(reloaded)
+		waitForKeys(t, m, []string{"pod/"})
+		if restarted := running() != previous; restarted != tt.restarted {
+			t.Errorf("%d: expected the controller to be restarted %v, got %v", i, tt.restarted, restarted)
+		}
+	}
+}
+
+func TestManagerReloadNotifyExisting(t *testing.T) {
+	conf := resourceConfig(config.Resource{Pod: true})
+	conf.NotifyExisting = true
+	if err := applyConfig(conf); err != nil {
+		t.Fatal(err)
+	}
+	defer applyConfig(&config.Config{})
+
+	handler := &closingHandler{}
+	client := fake.NewSimpleClientset(pod("web", time.Now().Add(-time.Hour)))
+	m := newManager(conf, handler, []clusterClient{{context: "", client: client}})
+	ctx, cancel := context.WithCancel(context.Background())
+	go m.run(ctx)
+	waitForKeys(t, m, []string{"pod/"})
+	waitForSync(t, m, controllerKey{"", "pod", ""})
+
+	// the controller of the reloaded namespace lists the pod again
+	reloaded := resourceConfig(config.Resource{Pod: true}, "default")
+	reloaded.NotifyExisting = true
+	m.reload(func() (*config.Config, handlers.Handler, error) {
+		return reloaded, handler, nil
+	})
+	waitForKeys(t, m, []string{"pod/default"})
+	waitForSync(t, m, controllerKey{"", "pod", "default"})
+
+	cancel()
+	m.wait()
+	if len(handler.created) != 1 {
+		t.Fatalf("expected the existing pod to be notified once, got %v", handler.created)
+	}
+}
+
+// waitForSync waits for the cache of a running controller to sync
+func waitForSync(t *testing.T, m *manager, key controllerKey) {
+	configMu.RLock()
+	c := m.running[key].controller
+	configMu.RUnlock()
+	if !cache.WaitForCacheSync(nil, c.HasSynced) {
+		t.Fatalf("expected the cache of %v to sync", key)
+	}
+}
+
+// stuckHandler never returns from Close
+type stuckHandler struct {
+	recordingHandler
+	block chan struct{}
+}
+
+func (h *stuckHandler) Close() error {
+	<-h.block
+	return nil
+}
+
+func TestCloseHandlerWithin(t *testing.T) {
+	closing := &closingHandler{}
+	closeHandlerWithin(closing, time.Second)
+	if !closing.closed {
+		t.Fatalf("expected the handler to be closed")
+	}
+
+	stuck := &stuckHandler{block: make(chan struct{})}
+	defer close(stuck.block)
+	start := time.Now()
+	closeHandlerWithin(stuck, 50*time.Millisecond)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected a stuck handler to be given up on, waited %s", elapsed)
+	}
+}
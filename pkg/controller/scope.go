@@ -0,0 +1,80 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// scope tells whether the objects of a resource type live in namespaces or in the cluster
+type scope int
+
+const (
+	namespaceScoped scope = iota
+	clusterScoped
+)
+
+// resourceScopes holds the scope of the built-in resource types
+var resourceScopes = map[string]scope{
+	"configmap":               namespaceScoped,
+	"cronjob":                 namespaceScoped,
+	"daemonset":               namespaceScoped,
+	"deployment":              namespaceScoped,
+	"event":                   namespaceScoped,
+	"horizontalpodautoscaler": namespaceScoped,
+	"ingress":                 namespaceScoped,
+	"job":                     namespaceScoped,
+	"limitrange":              namespaceScoped,
+	"namespace":               clusterScoped,
+	"networkpolicy":           namespaceScoped,
+	"node":                    clusterScoped,
+	"persistentvolume":        clusterScoped,
+	"pod":                     namespaceScoped,
+	"poddisruptionbudget":     namespaceScoped,
+	"replicaset":              namespaceScoped,
+	"replicationcontroller":   namespaceScoped,
+	"resourcequota":           namespaceScoped,
+	"role":                    namespaceScoped,
+	"rolebinding":             namespaceScoped,
+	"secret":                  namespaceScoped,
+	"service":                 namespaceScoped,
+	"serviceaccount":          namespaceScoped,
+	"statefulset":             namespaceScoped,
+	"storageclass":            clusterScoped,
+}
+
+// resourceScope returns the scope of a resource type, unknown resource types are namespaced
+func resourceScope(resourceType string) scope {
+	return resourceScopes[resourceType]
+}
+
+// customResourceScope returns the scope of a custom resource
+func customResourceScope(namespaced bool) scope {
+	if namespaced {
+		return namespaceScoped
+	}
+	return clusterScoped
+}
+
+// namespaces returns the namespaces the resources of the scope are watched in,
+// cluster scoped resources are watched once whatever the watched namespaces
+func (s scope) namespaces(watched []string) []string {
+	if s == clusterScoped {
+		return []string{meta_v1.NamespaceAll}
+	}
+	return watched
+}
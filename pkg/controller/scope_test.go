@@ -0,0 +1,62 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestResourceScopes(t *testing.T) {
+	for resourceType := range event.DefaultDisplayNames {
+		if _, ok := resourceScopes[resourceType]; !ok {
+			t.Errorf("missing scope of %s", resourceType)
+		}
+	}
+	if resourceScope("widgets.example.com") != namespaceScoped {
+		t.Error("expected unknown resource types to be namespaced")
+	}
+}
+
+func TestNewControllersScope(t *testing.T) {
+	conf := &config.Config{
+		Namespace: []string{"a", "b"},
+		Resource:  config.Resource{Pod: true, Node: true},
+	}
+	controllers := newControllers(conf, fake.NewSimpleClientset(), nil, &recordingHandler{}, "")
+
+	var got []string
+	for _, c := range controllers {
+		got = append(got, c.resourceType+"/"+c.namespace)
+	}
+	want := []string{"pod/a", "pod/b", "node/"}
+	if len(got) != len(want) {
+		t.Fatalf("expected controllers %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected controllers %v, got %v", want, got)
+			break
+		}
+	}
+	if controllers[2].scope != clusterScoped {
+		t.Error("expected the node controller to be cluster scoped")
+	}
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	apps_v1beta1 "k8s.io/api/apps/v1beta1"
+	ext_v1beta1 "k8s.io/api/extensions/v1beta1"
+	networking_v1 "k8s.io/api/networking/v1"
+	policy_v1 "k8s.io/api/policy/v1"
+	policy_v1beta1 "k8s.io/api/policy/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// servesGroupVersion reports whether the cluster serves an API group version,
+// it is assumed to be served when discovery fails
+func servesGroupVersion(kubeClient kubernetes.Interface, groupVersion string) bool {
+	groups, err := kubeClient.Discovery().ServerGroups()
+	if err != nil {
+		logrus.Warnf("Error discovering API groups, assuming %s is served: %v", groupVersion, err)
+		return true
+	}
+	for _, group := range groups.Groups {
+		for _, version := range group.Versions {
+			if version.GroupVersion == groupVersion {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// apiVersions are the API groups served by a cluster for the watched resources
+// and the kinds of the watched custom resources
+type apiVersions struct {
+	appsV1, networkingV1, policyV1 bool
+	// lowercased kinds of the custom resources by resource type
+	kinds map[string]string
+}
+
+// discoverAPIVersions discovers the API versions the resources of conf are watched with,
+// the groups of resources which aren't watched are left undiscovered
+func discoverAPIVersions(conf *config.Config, kubeClient kubernetes.Interface) apiVersions {
+	api := apiVersions{appsV1: true, kinds: make(map[string]string)}
+	if conf.Resource.Deployment || conf.Resource.DaemonSet || conf.Resource.ReplicaSet {
+		api.appsV1 = appsV1Supported(kubeClient)
+	}
+	if conf.Resource.Ingress {
+		api.networkingV1 = networkingV1Supported(kubeClient)
+	}
+	if conf.Resource.PodDisruptionBudget {
+		api.policyV1 = policyV1Supported(kubeClient)
+	}
+	for _, r := range conf.CustomResources {
+		api.kinds[r.ResourceType()] = customResourceKind(kubeClient, r)
+	}
+	return api
+}
+
+// appsV1Supported reports whether the cluster serves the apps/v1 group.
+// Clusters older than 1.9 only serve deployments, daemonsets and replicasets
+// from the deprecated beta groups.
+func appsV1Supported(kubeClient kubernetes.Interface) bool {
+	if servesGroupVersion(kubeClient, apps_v1.SchemeGroupVersion.String()) {
+		return true
+	}
+	logrus.Warn("apps/v1 isn't served, falling back to the deprecated apps/v1beta1 and extensions/v1beta1 groups")
+	return false
+}
+
+// networkingV1Supported reports whether the cluster serves the networking.k8s.io/v1 group.
+// Clusters older than 1.19 only serve ingresses from extensions/v1beta1.
+func networkingV1Supported(kubeClient kubernetes.Interface) bool {
+	if servesGroupVersion(kubeClient, networking_v1.SchemeGroupVersion.String()) {
+		return true
+	}
+	logrus.Warn("networking.k8s.io/v1 isn't served, falling back to the deprecated extensions/v1beta1 group for ingresses")
+	return false
+}
+
+// policyV1Supported reports whether the cluster serves the policy/v1 group.
+// Clusters older than 1.21 only serve pod disruption budgets from policy/v1beta1.
+func policyV1Supported(kubeClient kubernetes.Interface) bool {
+	if servesGroupVersion(kubeClient, policy_v1.SchemeGroupVersion.String()) {
+		return true
+	}
+	logrus.Warn("policy/v1 isn't served, falling back to the deprecated policy/v1beta1 group for pod disruption budgets")
+	return false
+}
+
+// deploymentListWatch returns the list watch of the deployments in ns and their object type
+func deploymentListWatch(kubeClient kubernetes.Interface, appsV1 bool, ns string) (cache.ListerWatcher, runtime.Object) {
+	if !appsV1 {
+		return &cache.ListWatch{
+			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+				return kubeClient.AppsV1beta1().Deployments(ns).List(context.Background(), options)
+			},
+			WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+				return kubeClient.AppsV1beta1().Deployments(ns).Watch(context.Background(), options)
+			},
+		}, &apps_v1beta1.Deployment{}
+	}
+	return &cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			return kubeClient.AppsV1().Deployments(ns).List(context.Background(), options)
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			return kubeClient.AppsV1().Deployments(ns).Watch(context.Background(), options)
+		},
+	}, &apps_v1.Deployment{}
+}
+
+// daemonSetListWatch returns the list watch of the daemonsets in ns and their object type
+func daemonSetListWatch(kubeClient kubernetes.Interface, appsV1 bool, ns string) (cache.ListerWatcher, runtime.Object) {
+	if !appsV1 {
+		return &cache.ListWatch{
+			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+				return kubeClient.ExtensionsV1beta1().DaemonSets(ns).List(context.Background(), options)
+			},
+			WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+				return kubeClient.ExtensionsV1beta1().DaemonSets(ns).Watch(context.Background(), options)
+			},
+		}, &ext_v1beta1.DaemonSet{}
+	}
+	return &cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			return kubeClient.AppsV1().DaemonSets(ns).List(context.Background(), options)
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			return kubeClient.AppsV1().DaemonSets(ns).Watch(context.Background(), options)
+		},
+	}, &apps_v1.DaemonSet{}
+}
+
+// replicaSetListWatch returns the list watch of the replicasets in ns and their object type
+func replicaSetListWatch(kubeClient kubernetes.Interface, appsV1 bool, ns string) (cache.ListerWatcher, runtime.Object) {
+	if !appsV1 {
+		return &cache.ListWatch{
+			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+				return kubeClient.ExtensionsV1beta1().ReplicaSets(ns).List(context.Background(), options)
+			},
+			WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+				return kubeClient.ExtensionsV1beta1().ReplicaSets(ns).Watch(context.Background(), options)
+			},
+		}, &ext_v1beta1.ReplicaSet{}
+	}
+	return &cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			return kubeClient.AppsV1().ReplicaSets(ns).List(context.Background(), options)
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			return kubeClient.AppsV1().ReplicaSets(ns).Watch(context.Background(), options)
+		},
+	}, &apps_v1.ReplicaSet{}
+}
+
+// ingressListWatch returns the list watch of the ingresses in ns and their object type
+func ingressListWatch(kubeClient kubernetes.Interface, networkingV1 bool, ns string) (cache.ListerWatcher, runtime.Object) {
+	if !networkingV1 {
+		return &cache.ListWatch{
+			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+				return kubeClient.ExtensionsV1beta1().Ingresses(ns).List(context.Background(), options)
+			},
+			WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+				return kubeClient.ExtensionsV1beta1().Ingresses(ns).Watch(context.Background(), options)
+			},
+		}, &ext_v1beta1.Ingress{}
+	}
+	return &cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			return kubeClient.NetworkingV1().Ingresses(ns).List(context.Background(), options)
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			return kubeClient.NetworkingV1().Ingresses(ns).Watch(context.Background(), options)
+		},
+	}, &networking_v1.Ingress{}
+}
+
+// podDisruptionBudgetListWatch returns the list watch of the pod disruption budgets in ns and their object type
+func podDisruptionBudgetListWatch(kubeClient kubernetes.Interface, policyV1 bool, ns string) (cache.ListerWatcher, runtime.Object) {
+	if !policyV1 {
+		return &cache.ListWatch{
+			ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+				return kubeClient.PolicyV1beta1().PodDisruptionBudgets(ns).List(context.Background(), options)
+			},
+			WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+				return kubeClient.PolicyV1beta1().PodDisruptionBudgets(ns).Watch(context.Background(), options)
+			},
+		}, &policy_v1beta1.PodDisruptionBudget{}
+	}
+	return &cache.ListWatch{
+		ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+			return kubeClient.PolicyV1().PodDisruptionBudgets(ns).List(context.Background(), options)
+		},
+		WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+			return kubeClient.PolicyV1().PodDisruptionBudgets(ns).Watch(context.Background(), options)
+		},
+	}, &policy_v1.PodDisruptionBudget{}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	apps_v1 "k8s.io/api/apps/v1"
+	ext_v1beta1 "k8s.io/api/extensions/v1beta1"
+	policy_v1 "k8s.io/api/policy/v1"
+	policy_v1beta1 "k8s.io/api/policy/v1beta1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGroupVersionSupported(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	kubeClient.Resources = []*meta_v1.APIResourceList{{GroupVersion: "extensions/v1beta1"}}
+	if appsV1Supported(kubeClient) {
+		t.Fatal("expected apps/v1 to be unsupported")
+	}
+
+	if networkingV1Supported(kubeClient) {
+		t.Fatal("expected networking.k8s.io/v1 to be unsupported")
+	}
+	if policyV1Supported(kubeClient) {
+		t.Fatal("expected policy/v1 to be unsupported")
+	}
+
+	kubeClient.Resources = append(kubeClient.Resources,
+		&meta_v1.APIResourceList{GroupVersion: "apps/v1"},
+		&meta_v1.APIResourceList{GroupVersion: "networking.k8s.io/v1"},
+		&meta_v1.APIResourceList{GroupVersion: "policy/v1"},
+	)
+	if !appsV1Supported(kubeClient) {
+		t.Fatal("expected apps/v1 to be supported")
+	}
+	if !networkingV1Supported(kubeClient) {
+		t.Fatal("expected networking.k8s.io/v1 to be supported")
+	}
+	if !policyV1Supported(kubeClient) {
+		t.Fatal("expected policy/v1 to be supported")
+	}
+}
+
+func TestDaemonSetListWatch(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(
+		&apps_v1.DaemonSet{ObjectMeta: meta_v1.ObjectMeta{Name: "foo", Namespace: "default"}},
+	)
+
+	if _, objType := daemonSetListWatch(kubeClient, false, "default"); !isType(objType, &ext_v1beta1.DaemonSet{}) {
+		t.Fatalf("expected extensions/v1beta1 daemonsets, got %T", objType)
+	}
+
+	listWatch, objType := daemonSetListWatch(kubeClient, true, "default")
+	if !isType(objType, &apps_v1.DaemonSet{}) {
+		t.Fatalf("expected apps/v1 daemonsets, got %T", objType)
+	}
+	list, err := listWatch.List(meta_v1.ListOptions{})
+	if err != nil {
+		t.Fatalf("List(): %v", err)
+	}
+	if daemonSets := list.(*apps_v1.DaemonSetList); len(daemonSets.Items) != 1 {
+		t.Fatalf("expected the apps/v1 daemonset to be listed, got %v", daemonSets.Items)
+	}
+}
+
+func TestPodDisruptionBudgetListWatch(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(
+		&policy_v1beta1.PodDisruptionBudget{ObjectMeta: meta_v1.ObjectMeta{Name: "foo", Namespace: "default"}},
+	)
+
+	if _, objType := podDisruptionBudgetListWatch(kubeClient, true, "default"); !isType(objType, &policy_v1.PodDisruptionBudget{}) {
+		t.Fatalf("expected policy/v1 pod disruption budgets, got %T", objType)
+	}
+
+	listWatch, objType := podDisruptionBudgetListWatch(kubeClient, false, "default")
+	if !isType(objType, &policy_v1beta1.PodDisruptionBudget{}) {
+		t.Fatalf("expected policy/v1beta1 pod disruption budgets, got %T", objType)
+	}
+	list, err := listWatch.List(meta_v1.ListOptions{})
+	if err != nil {
+		t.Fatalf("List(): %v", err)
+	}
+	if budgets := list.(*policy_v1beta1.PodDisruptionBudgetList); len(budgets.Items) != 1 {
+		t.Fatalf("expected the policy/v1beta1 pod disruption budget to be listed, got %v", budgets.Items)
+	}
+}
+
+func isType(obj, expected interface{}) bool {
+	return reflect.TypeOf(obj) == reflect.TypeOf(expected)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+// DefaultDisplayNames maps resource types to the kind shown in notifications
+var DefaultDisplayNames = map[string]string{
+	"configmap":               "ConfigMap",
+	"cronjob":                 "CronJob",
+	"daemonset":               "DaemonSet",
+	"deployment":              "Deployment",
+	"event":                   "Event",
+	"horizontalpodautoscaler": "HorizontalPodAutoscaler",
+	"ingress":                 "Ingress",
+	"job":                     "Job",
+	"limitrange":              "LimitRange",
+	"namespace":               "Namespace",
+	"networkpolicy":           "NetworkPolicy",
+	"node":                    "Node",
+	"persistentvolume":        "PersistentVolume",
+	"pod":                     "Pod",
+	"poddisruptionbudget":     "PodDisruptionBudget",
+	"replicaset":              "ReplicaSet",
+	"replicationcontroller":   "ReplicationController",
+	"resourcequota":           "ResourceQuota",
+	"role":                    "Role",
+	"rolebinding":             "RoleBinding",
+	"secret":                  "Secret",
+	"service":                 "Service",
+	"serviceaccount":          "ServiceAccount",
+	"statefulset":             "StatefulSet",
+	"storageclass":            "StorageClass",
+}
+
+var displayNames = DefaultDisplayNames
+
+// SetDisplayNames overrides the display names of resource types,
+// resource types missing from names keep their default
+func SetDisplayNames(names map[string]string) {
+	merged := make(map[string]string, len(DefaultDisplayNames)+len(names))
+	for resourceType, name := range DefaultDisplayNames {
+		merged[resourceType] = name
+	}
+	for resourceType, name := range names {
+		if name != "" {
+			merged[resourceType] = name
+		}
+	}
+	displayNames = merged
+}
+
+// DisplayName returns the kind shown in notifications for a resource type
+func DisplayName(resourceType string) string {
+	if name, ok := displayNames[resourceType]; ok {
+		return name
+	}
+	return resourceType
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"testing"
+
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDisplayName(t *testing.T) {
+	SetDisplayNames(map[string]string{"replicationcontroller": "replication controller", "pod": ""})
+	defer SetDisplayNames(nil)
+
+	var Tests = []struct {
+		resourceType string
+		expected     string
+	}{
+		{"replicationcontroller", "replication controller"},
+		{"pod", "Pod"},
+		{"daemonset", "DaemonSet"},
+		{"unknown", "unknown"},
+	}
+
+	for _, tt := range Tests {
+		if name := DisplayName(tt.resourceType); name != tt.expected {
+			t.Fatalf("DisplayName(%s): expected %q, got %q", tt.resourceType, tt.expected, name)
+		}
+	}
+
+	rc := &api_v1.ReplicationController{ObjectMeta: meta_v1.ObjectMeta{Name: "foo", Namespace: "default"}}
+	e := New(rc, "created")
+	expected := "A `replication controller` in namespace `default` has been `created`:\n`foo`"
+	if msg := e.Message(); msg != expected {
+		t.Fatalf("Message(): expected %q, got %q", expected, msg)
+	}
+}
+
+func TestDisplayNameNamespace(t *testing.T) {
+	SetDisplayNames(map[string]string{"namespace": "project"})
+	defer SetDisplayNames(nil)
+
+	e := New(&api_v1.Namespace{ObjectMeta: meta_v1.ObjectMeta{Name: "foo"}}, "deleted")
+	expected := "A namespace `foo` has been `deleted`"
+	if msg := e.Message(); msg != expected {
+		t.Fatalf("Message(): expected %q, got %q", expected, msg)
+	}
+}
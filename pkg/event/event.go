@@ -15,12 +15,25 @@ package event
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/mudasirmirza/kubewatch/pkg/utils"
+	apps_v1 "k8s.io/api/apps/v1"
 	apps_v1beta1 "k8s.io/api/apps/v1beta1"
+	autoscaling_v1 "k8s.io/api/autoscaling/v1"
 	batch_v1 "k8s.io/api/batch/v1"
+	batch_v1beta1 "k8s.io/api/batch/v1beta1"
 	api_v1 "k8s.io/api/core/v1"
 	ext_v1beta1 "k8s.io/api/extensions/v1beta1"
+	networking_v1 "k8s.io/api/networking/v1"
+	policy_v1 "k8s.io/api/policy/v1"
+	policy_v1beta1 "k8s.io/api/policy/v1beta1"
+	rbac_v1 "k8s.io/api/rbac/v1"
+	storage_v1 "k8s.io/api/storage/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // Event represent an event got from k8s api server
@@ -34,9 +47,250 @@ type Event struct {
 	Reason    string
 	Status    string
 	Name      string
+	// Text overrides the standard message, e.g. when rendered from a template
+	Text string
+	// LogicalName is the name stripped of generated suffixes, stable across rollouts
+	LogicalName string
+	// Cluster identifies the cluster of the object, e.g. its kubeconfig context
+	Cluster string
+	// Owner is the top-level controller of the object as Kind/name, e.g. Deployment/web for its pods
+	Owner string
+	// Diff describes the changes of an updated object, e.g. "replicas: 1 -> 3"
+	Diff []string
+	// Labels and Annotations of the object, e.g. for templates
+	Labels      map[string]string
+	Annotations map[string]string
+	// KubeEvent details core Kubernetes Events, nil for other objects
+	KubeEvent *KubeEvent
+	// Access lists the rules of a role, the role and subjects of a role binding,
+	// or the selected pods and the peers of a network policy
+	Access []string
+	// Budget is the disruption budget of a pod disruption budget, nil for other objects
+	Budget *Budget
+	// DataKeys are the keys of the data of a secret or configmap, their values are never notified
+	DataKeys []string
+	// Severity is info, warning or critical, after the resource type and event type
+	Severity string
+	// ResourceVersion of the object, telling the events of an object apart
+	ResourceVersion string
 }
 
+// KubeEvent is the reason and message a core Kubernetes Event reports about an object
+type KubeEvent struct {
+	// Reason is the short machine readable reason, e.g. BackOff
+	Reason  string
+	Message string
+	// Type is Normal or Warning
+	Type           string
+	InvolvedObject api_v1.ObjectReference
+}
+
+// Budget is the disruption budget of a pod disruption budget
+type Budget struct {
+	// MinAvailable or MaxUnavailable pods, e.g. 2 or 50%, the other one is empty
+	MinAvailable   string
+	MaxUnavailable string
+	// Selector selects the pods of the budget, e.g. app=web
+	Selector string
+	// DisruptionsAllowed is the number of pods which can be evicted, 0 blocks node drains
+	DisruptionsAllowed int32
+}
+
+// NewBudget returns the disruption budget of a pod disruption budget, nil for other objects
+func NewBudget(obj interface{}) *Budget {
+	switch object := obj.(type) {
+	case *policy_v1.PodDisruptionBudget:
+		return newBudget(object.Spec.MinAvailable, object.Spec.MaxUnavailable, object.Spec.Selector,
+			object.Status.DisruptionsAllowed)
+	case *policy_v1beta1.PodDisruptionBudget:
+		return newBudget(object.Spec.MinAvailable, object.Spec.MaxUnavailable, object.Spec.Selector,
+			object.Status.DisruptionsAllowed)
+	}
+	return nil
+}
+
+func newBudget(minAvailable, maxUnavailable *intstr.IntOrString, s *meta_v1.LabelSelector, disruptionsAllowed int32) *Budget {
+	budget := &Budget{Selector: selector(s), DisruptionsAllowed: disruptionsAllowed}
+	if minAvailable != nil {
+		budget.MinAvailable = minAvailable.String()
+	}
+	if maxUnavailable != nil {
+		budget.MaxUnavailable = maxUnavailable.String()
+	}
+	return budget
+}
+
+// NewKubeEvent returns the details of a core Kubernetes Event, nil for other objects
+func NewKubeEvent(obj interface{}) *KubeEvent {
+	e, ok := obj.(*api_v1.Event)
+	if !ok {
+		return nil
+	}
+	return &KubeEvent{
+		Reason:         e.Reason,
+		Message:        e.Message,
+		Type:           e.Type,
+		InvolvedObject: e.InvolvedObject,
+	}
+}
+
+// NewAccess describes the access granted by a role, a role binding or a network policy, nil for other objects
+func NewAccess(obj interface{}) []string {
+	var access []string
+	switch object := obj.(type) {
+	case *rbac_v1.Role:
+		for _, rule := range object.Rules {
+			access = append(access, "rule: "+policyRule(rule))
+		}
+	case *rbac_v1.RoleBinding:
+		access = append(access, fmt.Sprintf("role: %s %s", object.RoleRef.Kind, object.RoleRef.Name))
+		for _, subject := range object.Subjects {
+			name := subject.Name
+			if subject.Namespace != "" {
+				name = subject.Namespace + "/" + name
+			}
+			access = append(access, fmt.Sprintf("subject: %s %s", subject.Kind, name))
+		}
+	case *networking_v1.NetworkPolicy:
+		access = networkPolicyAccess(object.Spec)
+	}
+	return access
+}
+
+// policyRule describes a rule of a role, e.g. "get, list pods, deployments.apps named foo"
+func policyRule(rule rbac_v1.PolicyRule) string {
+	var resources []string
+	for _, group := range rule.APIGroups {
+		for _, resource := range rule.Resources {
+			if group != "" {
+				resource += "." + group
+			}
+			resources = append(resources, resource)
+		}
+	}
+	resources = append(resources, rule.NonResourceURLs...)
+
+	s := strings.Join(rule.Verbs, ", ") + " " + strings.Join(resources, ", ")
+	if len(rule.ResourceNames) > 0 {
+		s += " named " + strings.Join(rule.ResourceNames, ", ")
+	}
+	return s
+}
+
+// networkPolicyAccess describes the pods selected by a network policy and the peers
+// allowed to reach them or be reached, e.g. "ingress from: pods role=frontend on TCP/80"
+func networkPolicyAccess(spec networking_v1.NetworkPolicySpec) []string {
+	access := []string{"pods: " + selector(&spec.PodSelector)}
+
+	ingress, egress := len(spec.Ingress) > 0, len(spec.Egress) > 0
+	for _, policyType := range spec.PolicyTypes {
+		ingress = ingress || policyType == networking_v1.PolicyTypeIngress
+		egress = egress || policyType == networking_v1.PolicyTypeEgress
+	}
+	// policies without types restrict ingress
+	if len(spec.PolicyTypes) == 0 {
+		ingress = true
+	}
+
+	if ingress && len(spec.Ingress) == 0 {
+		access = append(access, "ingress: denied")
+	}
+	for _, rule := range spec.Ingress {
+		access = append(access, "ingress from: "+networkPolicyRule(rule.From, rule.Ports))
+	}
+	if egress && len(spec.Egress) == 0 {
+		access = append(access, "egress: denied")
+	}
+	for _, rule := range spec.Egress {
+		access = append(access, "egress to: "+networkPolicyRule(rule.To, rule.Ports))
+	}
+	return access
+}
+
+// networkPolicyRule describes the peers and ports of a rule, e.g. "pods app=web in namespaces team=a on TCP/80"
+func networkPolicyRule(peers []networking_v1.NetworkPolicyPeer, ports []networking_v1.NetworkPolicyPort) string {
+	var peerNames []string
+	for _, peer := range peers {
+		switch {
+		case peer.IPBlock != nil:
+			block := "cidr " + peer.IPBlock.CIDR
+			if len(peer.IPBlock.Except) > 0 {
+				block += " except " + strings.Join(peer.IPBlock.Except, ", ")
+			}
+			peerNames = append(peerNames, block)
+		case peer.PodSelector != nil && peer.NamespaceSelector != nil:
+			peerNames = append(peerNames, fmt.Sprintf("pods %s in namespaces %s", selector(peer.PodSelector), selector(peer.NamespaceSelector)))
+		case peer.NamespaceSelector != nil:
+			peerNames = append(peerNames, "namespaces "+selector(peer.NamespaceSelector))
+		case peer.PodSelector != nil:
+			peerNames = append(peerNames, "pods "+selector(peer.PodSelector))
+		}
+	}
+	s := "all"
+	if len(peerNames) > 0 {
+		s = strings.Join(peerNames, ", ")
+	}
+
+	var portNames []string
+	for _, port := range ports {
+		protocol := api_v1.ProtocolTCP
+		if port.Protocol != nil {
+			protocol = *port.Protocol
+		}
+		p := string(protocol)
+		if port.Port != nil {
+			p += "/" + port.Port.String()
+		}
+		portNames = append(portNames, p)
+	}
+	if len(portNames) > 0 {
+		s += " on " + strings.Join(portNames, ", ")
+	}
+	return s
+}
+
+// selector describes a label selector, "all" when it selects everything
+func selector(s *meta_v1.LabelSelector) string {
+	if len(s.MatchLabels) == 0 && len(s.MatchExpressions) == 0 {
+		return "all"
+	}
+	return meta_v1.FormatLabelSelector(s)
+}
+
+// NewDataKeys returns the sorted keys of the data of a secret or configmap, nil for other objects
+func NewDataKeys(obj interface{}) []string {
+	keys := make(map[string]bool)
+	switch object := obj.(type) {
+	case *api_v1.Secret:
+		for key := range object.Data {
+			keys[key] = true
+		}
+		for key := range object.StringData {
+			keys[key] = true
+		}
+	case *api_v1.ConfigMap:
+		for key := range object.Data {
+			keys[key] = true
+		}
+		for key := range object.BinaryData {
+			keys[key] = true
+		}
+	default:
+		return nil
+	}
+	var sorted []string
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// Existing is the reason of events notifying objects created before kubewatch started
+const Existing = "existing"
+
 var m = map[string]string{
+	Existing:  "Normal",
 	"created": "Normal",
 	"deleted": "Danger",
 	"updated": "Warning",
@@ -44,55 +298,142 @@ var m = map[string]string{
 
 // New create new KubewatchEvent
 func New(obj interface{}, action string) Event {
-	var namespace, kind, component, host, reason, status, name string
+	var namespace, resourceType, kind, component, host, reason, status, name, text, logicalName, cluster, owner, severity, resourceVersion string
+	var diff, access, dataKeys []string
+	var kubeEvent *KubeEvent
+	var budget *Budget
 
 	objectMeta := utils.GetObjectMetaData(obj)
 	namespace = objectMeta.Namespace
 	name = objectMeta.Name
+	resourceVersion = objectMeta.ResourceVersion
+	labels, annotations := objectMeta.Labels, objectMeta.Annotations
+	if ref := meta_v1.GetControllerOf(&objectMeta); ref != nil {
+		owner = ref.Kind + "/" + ref.Name
+	}
 	reason = action
 	status = m[action]
+	access = NewAccess(obj)
+	dataKeys = NewDataKeys(obj)
+	budget = NewBudget(obj)
 
 	switch object := obj.(type) {
-	case *ext_v1beta1.DaemonSet:
-		kind = "daemon set"
-	case *apps_v1beta1.Deployment:
-		kind = "deployment"
+	case *apps_v1.DaemonSet, *ext_v1beta1.DaemonSet:
+		resourceType = "daemonset"
+	case *apps_v1.Deployment, *apps_v1beta1.Deployment:
+		resourceType = "deployment"
+	case *apps_v1beta1.StatefulSet:
+		resourceType = "statefulset"
 	case *batch_v1.Job:
-		kind = "job"
+		resourceType = "job"
+	case *batch_v1beta1.CronJob:
+		resourceType = "cronjob"
 	case *api_v1.Namespace:
-		kind = "namespace"
-	case *ext_v1beta1.Ingress:
-		kind = "ingress"
+		resourceType = "namespace"
+	case *networking_v1.Ingress, *ext_v1beta1.Ingress:
+		resourceType = "ingress"
+	case *api_v1.Node:
+		resourceType = "node"
 	case *api_v1.PersistentVolume:
-		kind = "persistent volume"
+		resourceType = "persistentvolume"
 	case *api_v1.Pod:
-		kind = "pod"
+		resourceType = "pod"
 		host = object.Spec.NodeName
 	case *api_v1.ReplicationController:
-		kind = "replication controller"
-	case *ext_v1beta1.ReplicaSet:
-		kind = "replica set"
+		resourceType = "replicationcontroller"
+	case *apps_v1.ReplicaSet, *ext_v1beta1.ReplicaSet:
+		resourceType = "replicaset"
 	case *api_v1.Service:
-		kind = "service"
+		resourceType = "service"
 		component = string(object.Spec.Type)
 	case *api_v1.Secret:
-		kind = "secret"
+		resourceType = "secret"
 	case *api_v1.ConfigMap:
-		kind = "configmap"
+		resourceType = "configmap"
+	case *api_v1.Event:
+		resourceType = "event"
+		kubeEvent = NewKubeEvent(object)
+	case *autoscaling_v1.HorizontalPodAutoscaler:
+		resourceType = "horizontalpodautoscaler"
+	case *api_v1.ServiceAccount:
+		resourceType = "serviceaccount"
+	case *rbac_v1.Role:
+		resourceType = "role"
+	case *rbac_v1.RoleBinding:
+		resourceType = "rolebinding"
+	case *storage_v1.StorageClass:
+		resourceType = "storageclass"
+	case *networking_v1.NetworkPolicy:
+		resourceType = "networkpolicy"
+	case *api_v1.ResourceQuota:
+		resourceType = "resourcequota"
+	case *api_v1.LimitRange:
+		resourceType = "limitrange"
+	case *policy_v1.PodDisruptionBudget, *policy_v1beta1.PodDisruptionBudget:
+		resourceType = "poddisruptionbudget"
+	case *unstructured.Unstructured:
+		resourceType = strings.ToLower(object.GetKind())
 	case Event:
 		name = object.Name
 		kind = object.Kind
 		namespace = object.Namespace
+		component = object.Component
+		host = object.Host
+		text = object.Text
+		logicalName = object.LogicalName
+		cluster = object.Cluster
+		owner = object.Owner
+		diff = object.Diff
+		labels, annotations = object.Labels, object.Annotations
+		kubeEvent = object.KubeEvent
+		access = object.Access
+		dataKeys = object.DataKeys
+		budget = object.Budget
+		severity = object.Severity
+		resourceVersion = object.ResourceVersion
+		// keep reasons set by the controller, e.g. for condition based alerts
+		if object.Reason != "" {
+			reason = object.Reason
+			status = object.Status
+			if severity == "" {
+				severity = statusSeverities[status]
+			}
+		}
+	}
+	if resourceType != "" {
+		kind = DisplayName(resourceType)
+	}
+	if severity == "" {
+		severity = Severity(resourceType, reason)
+	}
+
+	// warnings reported by core Events are notified as such
+	if kubeEvent != nil && kubeEvent.Type == api_v1.EventTypeWarning && isReport(reason) {
+		status = "Warning"
+		severity = atLeastWarning(severity)
 	}
 
 	kbEvent := Event{
-		Namespace: namespace,
-		Kind:      kind,
-		Component: component,
-		Host:      host,
-		Reason:    reason,
-		Status:    status,
-		Name:      name,
+		Namespace:       namespace,
+		Kind:            kind,
+		Component:       component,
+		Host:            host,
+		Reason:          reason,
+		Status:          status,
+		Name:            name,
+		Text:            text,
+		LogicalName:     logicalName,
+		Cluster:         cluster,
+		Owner:           owner,
+		Diff:            diff,
+		Labels:          labels,
+		Annotations:     annotations,
+		KubeEvent:       kubeEvent,
+		Access:          access,
+		Budget:          budget,
+		DataKeys:        dataKeys,
+		Severity:        severity,
+		ResourceVersion: resourceVersion,
 	}
 	return kbEvent
 }
@@ -100,14 +441,54 @@ func New(obj interface{}, action string) Event {
 // Message returns event message in standard format.
 // included as a part of event packege to enhance code resuablity across handlers.
 func (e *Event) Message() (msg string) {
+	if e.Text != "" {
+		return e.Text
+	}
 	// using switch over if..else, since the format could vary based on the kind of the object in future.
-	switch e.Kind {
-	case "namespace":
+	switch {
+	case e.Reason == Existing && e.Namespace == "":
+		msg = fmt.Sprintf(
+			"A `%s` `%s` exists",
+			e.Kind,
+			e.Name,
+		)
+	case e.Reason == Existing:
+		msg = fmt.Sprintf(
+			"A `%s` in namespace `%s` exists:\n`%s`",
+			e.Kind,
+			e.Namespace,
+			e.Name,
+		)
+	case e.KubeEvent != nil && isReport(e.Reason):
+		msg = fmt.Sprintf(
+			"A `%s` `%s` reports `%s`:\n%s",
+			strings.ToLower(e.KubeEvent.InvolvedObject.Kind),
+			involvedName(e.KubeEvent.InvolvedObject),
+			e.KubeEvent.Reason,
+			e.KubeEvent.Message,
+		)
+	case e.Kind == DisplayName("namespace"):
 		msg = fmt.Sprintf(
 			"A namespace `%s` has been `%s`",
 			e.Name,
 			e.Reason,
 		)
+	case e.Namespace == "" && isAction(e.Reason):
+		// cluster scoped objects, e.g. nodes
+		msg = fmt.Sprintf(
+			"A `%s` `%s` has been `%s`",
+			e.Kind,
+			e.Name,
+			e.Reason,
+		)
+	case !isAction(e.Reason):
+		msg = fmt.Sprintf(
+			"A `%s` in namespace `%s` reports `%s`:\n`%s`",
+			e.Kind,
+			e.Namespace,
+			e.Reason,
+			e.Name,
+		)
 	default:
 		msg = fmt.Sprintf(
 			"A `%s` in namespace `%s` has been `%s`:\n`%s`",
@@ -117,5 +498,61 @@ func (e *Event) Message() (msg string) {
 			e.Name,
 		)
 	}
+	for _, change := range e.Diff {
+		msg += "\n- " + change
+	}
+	for _, line := range e.Access {
+		msg += "\n- " + line
+	}
+	if e.Budget != nil {
+		if e.Budget.MinAvailable != "" {
+			msg += "\n- min available: " + e.Budget.MinAvailable
+		}
+		if e.Budget.MaxUnavailable != "" {
+			msg += "\n- max unavailable: " + e.Budget.MaxUnavailable
+		}
+		msg += "\n- pods: " + e.Budget.Selector
+		msg += fmt.Sprintf("\n- disruptions allowed: %d", e.Budget.DisruptionsAllowed)
+	}
+	if len(e.DataKeys) > 0 {
+		msg += "\n- keys: " + strings.Join(e.DataKeys, ", ")
+	}
+	if e.Cluster != "" {
+		msg = fmt.Sprintf("[%s] %s", e.Cluster, msg)
+	}
 	return msg
 }
+
+// Key identifies the object of the event, whatever the action. The event name is
+// the object name for creations and the namespace/name key for updates and deletions.
+func (e *Event) Key() string {
+	name := e.Name
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	key := e.Kind + "/" + e.Namespace + "/" + name
+	if e.Cluster != "" {
+		key = e.Cluster + "/" + key
+	}
+	return key
+}
+
+// isReport reports whether reason is the creation or update of an object, which
+// for core Events means reporting something new rather than expiring
+func isReport(reason string) bool {
+	return reason == "created" || reason == "updated"
+}
+
+// involvedName returns the namespace/name of the object of a core Event
+func involvedName(ref api_v1.ObjectReference) string {
+	if ref.Namespace == "" {
+		return ref.Name
+	}
+	return ref.Namespace + "/" + ref.Name
+}
+
+// isAction reports whether reason is one of the plain created/deleted/updated actions
+func isAction(reason string) bool {
+	_, ok := m[reason]
+	return ok
+}
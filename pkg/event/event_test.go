@@ -0,0 +1,282 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"reflect"
+	"testing"
+
+	apps_v1beta1 "k8s.io/api/apps/v1beta1"
+	autoscaling_v1 "k8s.io/api/autoscaling/v1"
+	batch_v1beta1 "k8s.io/api/batch/v1beta1"
+	api_v1 "k8s.io/api/core/v1"
+	networking_v1 "k8s.io/api/networking/v1"
+	policy_v1 "k8s.io/api/policy/v1"
+	policy_v1beta1 "k8s.io/api/policy/v1beta1"
+	rbac_v1 "k8s.io/api/rbac/v1"
+	storage_v1 "k8s.io/api/storage/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestMessageCluster(t *testing.T) {
+	e := New(Event{Kind: "pod", Name: "foo", Namespace: "default", Cluster: "prod"}, "created")
+	expected := "[prod] A `pod` in namespace `default` has been `created`:\n`foo`"
+	if msg := e.Message(); msg != expected {
+		t.Fatalf("Message(): expected %q, got %q", expected, msg)
+	}
+}
+
+func TestKey(t *testing.T) {
+	var Tests = []struct {
+		e        Event
+		expected string
+	}{
+		{Event{Kind: "pod", Name: "foo", Namespace: "default"}, "pod/default/foo"},
+		{Event{Kind: "pod", Name: "default/foo", Namespace: "default"}, "pod/default/foo"},
+		{Event{Kind: "namespace", Name: "foo"}, "namespace//foo"},
+		{Event{Kind: "pod", Name: "foo", Namespace: "default", Cluster: "prod"}, "prod/pod/default/foo"},
+	}
+
+	for _, tt := range Tests {
+		if key := tt.e.Key(); key != tt.expected {
+			t.Fatalf("Key(): expected %q, got %q", tt.expected, key)
+		}
+	}
+}
+
+func TestNewKind(t *testing.T) {
+	meta := meta_v1.ObjectMeta{Name: "foo", Namespace: "default"}
+
+	var Tests = []struct {
+		obj  interface{}
+		kind string
+	}{
+		{&batch_v1beta1.CronJob{ObjectMeta: meta}, "CronJob"},
+		{&apps_v1beta1.StatefulSet{ObjectMeta: meta}, "StatefulSet"},
+		{&autoscaling_v1.HorizontalPodAutoscaler{ObjectMeta: meta}, "HorizontalPodAutoscaler"},
+		{&api_v1.ServiceAccount{ObjectMeta: meta}, "ServiceAccount"},
+		{&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"metadata":   map[string]interface{}{"name": "foo", "namespace": "default"},
+		}}, "widget"},
+	}
+
+	for _, tt := range Tests {
+		e := New(tt.obj, "updated")
+		if e.Kind != tt.kind || e.Namespace != "default" {
+			t.Fatalf("New(): expected a %s in namespace default, got %+v", tt.kind, e)
+		}
+	}
+}
+
+func TestNewClusterScoped(t *testing.T) {
+	e := New(&storage_v1.StorageClass{ObjectMeta: meta_v1.ObjectMeta{Name: "fast"}}, "deleted")
+	expected := "A `StorageClass` `fast` has been `deleted`"
+	if e.Kind != "StorageClass" || e.Namespace != "" || e.Message() != expected {
+		t.Fatalf("New(): expected a storage class without namespace, got %+v", e)
+	}
+}
+
+func TestNewOwner(t *testing.T) {
+	controller := true
+	pod := &api_v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Name: "web-5d9c8f7b6-x2x7q", Namespace: "default",
+		OwnerReferences: []meta_v1.OwnerReference{{Kind: "ReplicaSet", Name: "web-5d9c8f7b6", Controller: &controller}}}}
+	if e := New(pod, "created"); e.Owner != "ReplicaSet/web-5d9c8f7b6" {
+		t.Fatalf("New(): expected the controller of the pod as owner, got %q", e.Owner)
+	}
+	if e := New(Event{Kind: "pod", Name: "default/web", Owner: "Deployment/web"}, "created"); e.Owner != "Deployment/web" {
+		t.Fatalf("New(): expected the owner of the event, got %q", e.Owner)
+	}
+}
+
+func TestNewResourceVersion(t *testing.T) {
+	pod := &api_v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Name: "web", Namespace: "default", ResourceVersion: "42"}}
+	if e := New(pod, "created"); e.ResourceVersion != "42" {
+		t.Fatalf("New(): expected the resource version of the pod, got %q", e.ResourceVersion)
+	}
+	if e := New(Event{Kind: "pod", Name: "default/web", ResourceVersion: "43"}, "updated"); e.ResourceVersion != "43" {
+		t.Fatalf("New(): expected the resource version of the event, got %q", e.ResourceVersion)
+	}
+}
+
+func TestNewBudget(t *testing.T) {
+	minAvailable, maxUnavailable := intstr.FromInt(2), intstr.FromString("25%")
+	meta := meta_v1.ObjectMeta{Name: "web", Namespace: "default"}
+	selector := &meta_v1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}
+
+	e := New(&policy_v1.PodDisruptionBudget{
+		ObjectMeta: meta,
+		Spec:       policy_v1.PodDisruptionBudgetSpec{MinAvailable: &minAvailable, Selector: selector},
+	}, "created")
+	expected := "A `PodDisruptionBudget` in namespace `default` has been `created`:\n`web`\n" +
+		"- min available: 2\n- pods: app=web\n- disruptions allowed: 0"
+	if msg := e.Message(); e.Kind != "PodDisruptionBudget" || msg != expected {
+		t.Fatalf("Message(): expected %q, got %q", expected, msg)
+	}
+
+	e = New(&policy_v1beta1.PodDisruptionBudget{
+		ObjectMeta: meta,
+		Spec:       policy_v1beta1.PodDisruptionBudgetSpec{MaxUnavailable: &maxUnavailable, Selector: selector},
+		Status:     policy_v1beta1.PodDisruptionBudgetStatus{DisruptionsAllowed: 1},
+	}, "updated")
+	if expected := (&Budget{MaxUnavailable: "25%", Selector: "app=web", DisruptionsAllowed: 1}); !reflect.DeepEqual(e.Budget, expected) {
+		t.Fatalf("New(): expected the budget %+v, got %+v", expected, e.Budget)
+	}
+}
+
+func TestMessageDiff(t *testing.T) {
+	e := New(Event{Kind: "deployment", Name: "default/foo", Namespace: "default", Diff: []string{"replicas: 1 -> 3", "label team removed"}}, "updated")
+	expected := "A `deployment` in namespace `default` has been `updated`:\n`default/foo`\n- replicas: 1 -> 3\n- label team removed"
+	if msg := e.Message(); msg != expected {
+		t.Fatalf("Message(): expected %q, got %q", expected, msg)
+	}
+}
+
+func TestMessageExisting(t *testing.T) {
+	var Tests = []struct {
+		e        Event
+		expected string
+	}{
+		{New(Event{Kind: "pod", Name: "foo", Namespace: "default"}, Existing), "A `pod` in namespace `default` exists:\n`foo`"},
+		{New(Event{Kind: "node", Name: "foo"}, Existing), "A `node` `foo` exists"},
+	}
+
+	for _, tt := range Tests {
+		if tt.e.Status != "Normal" {
+			t.Fatalf("New(): expected Normal status, got %q", tt.e.Status)
+		}
+		if msg := tt.e.Message(); msg != tt.expected {
+			t.Fatalf("Message(): expected %q, got %q", tt.expected, msg)
+		}
+	}
+}
+
+func TestNewKubeEvent(t *testing.T) {
+	obj := &api_v1.Event{
+		ObjectMeta:     meta_v1.ObjectMeta{Name: "foo.16f6e7a4", Namespace: "default"},
+		InvolvedObject: api_v1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "foo"},
+		Reason:         "BackOff",
+		Message:        "Back-off restarting failed container",
+		Type:           api_v1.EventTypeWarning,
+	}
+
+	e := New(obj, "created")
+	if e.Kind != "Event" || e.KubeEvent == nil || e.KubeEvent.Reason != "BackOff" {
+		t.Fatalf("expected the details of the event, got %+v", e)
+	}
+	if e.Status != "Warning" {
+		t.Errorf("expected warning events to be notified as warnings, got %s", e.Status)
+	}
+	expected := "A `pod` `default/foo` reports `BackOff`:\nBack-off restarting failed container"
+	if msg := e.Message(); msg != expected {
+		t.Errorf("Message(): expected %q, got %q", expected, msg)
+	}
+
+	// expired events are notified like other deleted objects
+	e = New(Event{Kind: "event", Name: "default/foo.16f6e7a4", Namespace: "default", KubeEvent: NewKubeEvent(obj)}, "deleted")
+	expected = "A `event` in namespace `default` has been `deleted`:\n`default/foo.16f6e7a4`"
+	if msg := e.Message(); msg != expected || e.Status != "Danger" {
+		t.Errorf("Message(): expected %q, got %q with status %s", expected, msg, e.Status)
+	}
+
+	if NewKubeEvent(&api_v1.Pod{}) != nil {
+		t.Error("expected no details for other objects")
+	}
+}
+
+func TestNewAccess(t *testing.T) {
+	role := &rbac_v1.Role{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "deployer", Namespace: "default"},
+		Rules: []rbac_v1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"pods", "secrets"}, Verbs: []string{"get", "list"}},
+			{APIGroups: []string{"apps"}, Resources: []string{"deployments"}, ResourceNames: []string{"web"}, Verbs: []string{"patch"}},
+		},
+	}
+	e := New(role, "created")
+	expected := "A `Role` in namespace `default` has been `created`:\n`deployer`" +
+		"\n- rule: get, list pods, secrets\n- rule: patch deployments.apps named web"
+	if msg := e.Message(); msg != expected {
+		t.Errorf("Message(): expected %q, got %q", expected, msg)
+	}
+
+	binding := &rbac_v1.RoleBinding{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "deployer", Namespace: "default"},
+		RoleRef:    rbac_v1.RoleRef{Kind: "Role", Name: "deployer"},
+		Subjects: []rbac_v1.Subject{
+			{Kind: "ServiceAccount", Namespace: "ci", Name: "builder"},
+			{Kind: "User", Name: "alice"},
+		},
+	}
+	// updates keep the access of the object they are built from
+	e = New(Event{Kind: "role binding", Name: "default/deployer", Namespace: "default", Access: NewAccess(binding)}, "updated")
+	expected = "A `role binding` in namespace `default` has been `updated`:\n`default/deployer`" +
+		"\n- role: Role deployer\n- subject: ServiceAccount ci/builder\n- subject: User alice"
+	if msg := e.Message(); msg != expected {
+		t.Errorf("Message(): expected %q, got %q", expected, msg)
+	}
+
+	if NewAccess(&api_v1.Pod{}) != nil {
+		t.Error("expected no access for other objects")
+	}
+}
+
+func TestNewAccessNetworkPolicy(t *testing.T) {
+	udp := api_v1.ProtocolUDP
+	dns := intstr.FromInt(53)
+	named := intstr.FromString("http")
+	policy := &networking_v1.NetworkPolicy{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: networking_v1.NetworkPolicySpec{
+			PodSelector: meta_v1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Ingress: []networking_v1.NetworkPolicyIngressRule{{
+				From: []networking_v1.NetworkPolicyPeer{
+					{
+						PodSelector:       &meta_v1.LabelSelector{MatchLabels: map[string]string{"role": "frontend"}},
+						NamespaceSelector: &meta_v1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+					},
+					{IPBlock: &networking_v1.IPBlock{CIDR: "10.0.0.0/8", Except: []string{"10.1.0.0/16"}}},
+				},
+				Ports: []networking_v1.NetworkPolicyPort{{Port: &named}},
+			}},
+			Egress: []networking_v1.NetworkPolicyEgressRule{{
+				To:    []networking_v1.NetworkPolicyPeer{{NamespaceSelector: &meta_v1.LabelSelector{}}},
+				Ports: []networking_v1.NetworkPolicyPort{{Protocol: &udp, Port: &dns}},
+			}},
+			PolicyTypes: []networking_v1.PolicyType{networking_v1.PolicyTypeIngress, networking_v1.PolicyTypeEgress},
+		},
+	}
+
+	expected := []string{
+		"pods: app=web",
+		"ingress from: pods role=frontend in namespaces team=a, cidr 10.0.0.0/8 except 10.1.0.0/16 on TCP/http",
+		"egress to: namespaces all on UDP/53",
+	}
+	if access := NewAccess(policy); !reflect.DeepEqual(access, expected) {
+		t.Errorf("NewAccess(): expected %q, got %q", expected, access)
+	}
+
+	denyAll := &networking_v1.NetworkPolicy{Spec: networking_v1.NetworkPolicySpec{
+		PolicyTypes: []networking_v1.PolicyType{networking_v1.PolicyTypeIngress, networking_v1.PolicyTypeEgress},
+	}}
+	expected = []string{"pods: all", "ingress: denied", "egress: denied"}
+	if access := NewAccess(denyAll); !reflect.DeepEqual(access, expected) {
+		t.Errorf("NewAccess(): expected %q, got %q", expected, access)
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+// Severities of events, from the least to the most severe
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+var severityRanks = map[string]int{
+	SeverityInfo:     0,
+	SeverityWarning:  1,
+	SeverityCritical: 2,
+}
+
+// eventTypeSeverities is the severity of the event types of resource types without their own
+var eventTypeSeverities = map[string]string{
+	"create": SeverityInfo,
+	"update": SeverityInfo,
+	"delete": SeverityWarning,
+}
+
+// statusSeverities is the severity of the events built by the controller with their own
+// reason and status, e.g. condition based alerts
+var statusSeverities = map[string]string{
+	"Normal":  SeverityInfo,
+	"Warning": SeverityWarning,
+	"Danger":  SeverityCritical,
+}
+
+// DefaultSeverities maps resource types to the severity of their event types, create,
+// update or delete. Missing event types have the severity of eventTypeSeverities
+var DefaultSeverities = map[string]map[string]string{
+	"namespace":        {"delete": SeverityCritical},
+	"node":             {"delete": SeverityCritical},
+	"persistentvolume": {"delete": SeverityCritical},
+	"secret":           {"delete": SeverityCritical},
+}
+
+var severities = DefaultSeverities
+
+// SetSeverities overrides the severities of the event types of resource types,
+// the ones missing from overrides keep their default
+func SetSeverities(overrides map[string]map[string]string) {
+	merged := make(map[string]map[string]string, len(DefaultSeverities)+len(overrides))
+	for resourceType, eventTypes := range DefaultSeverities {
+		merged[resourceType] = make(map[string]string, len(eventTypes))
+		for eventType, severity := range eventTypes {
+			merged[resourceType][eventType] = severity
+		}
+	}
+	for resourceType, eventTypes := range overrides {
+		if merged[resourceType] == nil {
+			merged[resourceType] = make(map[string]string, len(eventTypes))
+		}
+		for eventType, severity := range eventTypes {
+			merged[resourceType][eventType] = severity
+		}
+	}
+	severities = merged
+}
+
+// Severity returns the severity of an action on an object of a resource type
+func Severity(resourceType, action string) string {
+	eventType := eventTypes[action]
+	if severity, ok := severities[resourceType][eventType]; ok {
+		return severity
+	}
+	return eventTypeSeverities[eventType]
+}
+
+// eventTypes maps actions to the event types of the config
+var eventTypes = map[string]string{
+	Existing:  "create",
+	"created": "create",
+	"updated": "update",
+	"deleted": "delete",
+}
+
+// AtLeast reports whether severity is at least min, events without a severity are
+func AtLeast(severity, min string) bool {
+	if severity == "" {
+		return true
+	}
+	return severityRanks[severity] >= severityRanks[min]
+}
+
+// atLeastWarning raises severity to warning
+func atLeastWarning(severity string) string {
+	if severityRanks[severity] < severityRanks[SeverityWarning] {
+		return SeverityWarning
+	}
+	return severity
+}
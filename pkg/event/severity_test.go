@@ -0,0 +1,87 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"testing"
+
+	api_v1 "k8s.io/api/core/v1"
+)
+
+func TestSeverity(t *testing.T) {
+	SetSeverities(map[string]map[string]string{
+		"pod":    {"delete": SeverityCritical},
+		"secret": {"update": SeverityWarning},
+	})
+	defer SetSeverities(nil)
+
+	var Tests = []struct {
+		resourceType string
+		action       string
+		expected     string
+	}{
+		{"pod", "created", SeverityInfo},
+		{"pod", Existing, SeverityInfo},
+		{"pod", "deleted", SeverityCritical},
+		{"service", "updated", SeverityInfo},
+		{"service", "deleted", SeverityWarning},
+		{"secret", "updated", SeverityWarning},
+		// defaults are kept unless overridden
+		{"secret", "deleted", SeverityCritical},
+		{"persistentvolume", "deleted", SeverityCritical},
+	}
+
+	for _, tt := range Tests {
+		if got := Severity(tt.resourceType, tt.action); got != tt.expected {
+			t.Errorf("Severity(%s, %s): expected %s, got %s", tt.resourceType, tt.action, tt.expected, got)
+		}
+	}
+}
+
+func TestNewSeverity(t *testing.T) {
+	var Tests = []struct {
+		obj      interface{}
+		action   string
+		expected string
+	}{
+		{&api_v1.Pod{}, "created", SeverityInfo},
+		{&api_v1.Secret{}, "deleted", SeverityCritical},
+		{&api_v1.Event{Type: api_v1.EventTypeWarning, Reason: "BackOff"}, "created", SeverityWarning},
+		// events built by the controller keep their severity, or take it from their status
+		{Event{Kind: "secret", Severity: SeverityCritical}, "updated", SeverityCritical},
+		{Event{Kind: "deployment", Reason: "unavailable", Status: "Danger"}, "updated", SeverityCritical},
+		{Event{Kind: "pod", Name: "web"}, "deleted", SeverityWarning},
+	}
+
+	for _, tt := range Tests {
+		if got := New(tt.obj, tt.action).Severity; got != tt.expected {
+			t.Errorf("New(%T, %s): expected severity %s, got %s", tt.obj, tt.action, tt.expected, got)
+		}
+	}
+}
+
+func TestAtLeast(t *testing.T) {
+	if !AtLeast(SeverityCritical, SeverityWarning) || !AtLeast(SeverityWarning, SeverityWarning) {
+		t.Errorf("expected critical and warning to be at least warning")
+	}
+	if AtLeast(SeverityInfo, SeverityWarning) {
+		t.Errorf("expected info to be less than warning")
+	}
+	if !AtLeast("", SeverityCritical) {
+		t.Errorf("expected events without severity to pass")
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/mudasirmirza/kubewatch/config"
+)
+
+// Templates renders event messages with a Go template per action
+type Templates struct {
+	templates map[string]*template.Template
+}
+
+// ParseTemplates parses the templates of each action.
+// Templates unset in t fall back to t.Default, then to the shared templates.
+// Actions without any template keep the standard message.
+func ParseTemplates(t, shared config.Templates) (*Templates, error) {
+	texts := map[string]string{
+		"created": first(t.Create, t.Default, shared.Create, shared.Default),
+		"updated": first(t.Update, t.Default, shared.Update, shared.Default),
+		"deleted": first(t.Delete, t.Default, shared.Delete, shared.Default),
+	}
+
+	tmpl := &Templates{templates: make(map[string]*template.Template)}
+	for action, text := range texts {
+		if text == "" {
+			continue
+		}
+		parsed, err := template.New(action).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s template: %v", action, err)
+		}
+		tmpl.templates[action] = parsed
+	}
+	return tmpl, nil
+}
+
+// Empty reports whether no action has a template
+func (t *Templates) Empty() bool {
+	return len(t.templates) == 0
+}
+
+// Render renders the message of e for the given action.
+// The standard message is returned when the action has no template.
+func (t *Templates) Render(action string, e Event) (string, error) {
+	tmpl, ok := t.templates[action]
+	if !ok {
+		return e.Message(), nil
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, &e); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func first(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package event
+
+import (
+	"testing"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTemplatesRender(t *testing.T) {
+	handler := config.Templates{
+		Delete: "deleted {{.Kind}} {{.Name}}",
+	}
+	shared := config.Templates{
+		Default: "{{.Kind}} {{.Name}} was {{.Reason}}",
+		Update:  "updated {{.Name}}",
+	}
+	tmpl, err := ParseTemplates(handler, shared)
+	if err != nil {
+		t.Fatalf("ParseTemplates(): %v", err)
+	}
+
+	var Tests = []struct {
+		action   string
+		expected string
+	}{
+		{"created", "pod foo was created"},
+		{"updated", "updated foo"},
+		{"deleted", "deleted pod foo"},
+	}
+
+	for _, tt := range Tests {
+		e := Event{Kind: "pod", Name: "foo", Reason: tt.action}
+		msg, err := tmpl.Render(tt.action, e)
+		if err != nil {
+			t.Fatalf("Render(%s): %v", tt.action, err)
+		}
+		if msg != tt.expected {
+			t.Fatalf("Render(%s): expected %q, got %q", tt.action, tt.expected, msg)
+		}
+	}
+}
+
+func TestTemplatesRenderMetadata(t *testing.T) {
+	tmpl, err := ParseTemplates(config.Templates{
+		Default: `{{.Kind}} {{.Namespace}}/{{.Name}} {{.Reason}} app={{.Labels.app}} owner={{index .Annotations "example.com/owner"}}`,
+	}, config.Templates{})
+	if err != nil {
+		t.Fatalf("ParseTemplates(): %v", err)
+	}
+
+	obj := &api_v1.Pod{ObjectMeta: meta_v1.ObjectMeta{
+		Name:        "foo",
+		Namespace:   "default",
+		Labels:      map[string]string{"app": "web"},
+		Annotations: map[string]string{"example.com/owner": "team-a"},
+	}}
+	// events built by the controller keep the metadata through New
+	for _, e := range []Event{New(obj, "created"), New(New(obj, "deleted"), "deleted")} {
+		msg, err := tmpl.Render(e.Reason, e)
+		if err != nil {
+			t.Fatalf("Render(%s): %v", e.Reason, err)
+		}
+		expected := "Pod default/foo " + e.Reason + " app=web owner=team-a"
+		if msg != expected {
+			t.Fatalf("Render(%s): expected %q, got %q", e.Reason, expected, msg)
+		}
+	}
+}
+
+func TestTemplatesFallback(t *testing.T) {
+	tmpl, err := ParseTemplates(config.Templates{}, config.Templates{})
+	if err != nil {
+		t.Fatalf("ParseTemplates(): %v", err)
+	}
+	if !tmpl.Empty() {
+		t.Fatalf("Empty(): expected no templates")
+	}
+
+	e := Event{Kind: "pod", Name: "foo", Namespace: "new", Reason: "created"}
+	msg, err := tmpl.Render("created", e)
+	if err != nil {
+		t.Fatalf("Render(): %v", err)
+	}
+	if msg != e.Message() {
+		t.Fatalf("Render(): expected standard message, got %q", msg)
+	}
+}
+
+func TestParseTemplatesInvalid(t *testing.T) {
+	if _, err := ParseTemplates(config.Templates{Create: "{{.Kind"}, config.Templates{}); err == nil {
+		t.Fatalf("ParseTemplates(): expected error for invalid template")
+	}
+}
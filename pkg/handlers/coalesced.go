@@ -0,0 +1,186 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+// Coalesced wraps a handler and merges the events sharing a coalescing key
+// within the window of their resource type, only the latest one is passed on
+// once the window of the first one elapses
+type Coalesced struct {
+	Handler Handler
+	// Config holds the coalescing window and key per resource type
+	Config map[string]config.Coalesce
+	// Clock drives the coalescing windows, defaults to the real clock
+	Clock clock.Clock
+	// Timeout bounds sending a coalesced event, defaults to DefaultTimeout
+	Timeout time.Duration
+
+	mu      sync.Mutex
+	pending map[string]*coalescedEvent
+}
+
+// coalescedEvent is the latest event of a key, waiting for its window to elapse
+type coalescedEvent struct {
+	action      string
+	obj, newObj interface{}
+}
+
+// Init initializes the wrapped handler
+func (c *Coalesced) Init(conf *config.Config) error {
+	return c.Handler.Init(conf)
+}
+
+// ObjectCreated coalesces the created event
+func (c *Coalesced) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return c.add(ctx, &coalescedEvent{action: "created", obj: obj}, obj)
+}
+
+// ObjectDeleted coalesces the deleted event
+func (c *Coalesced) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return c.add(ctx, &coalescedEvent{action: "deleted", obj: obj}, obj)
+}
+
+// ObjectUpdated coalesces the updated event
+func (c *Coalesced) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return c.add(ctx, &coalescedEvent{action: "updated", obj: oldObj, newObj: newObj}, newObj)
+}
+
+// TestHandler tests the wrapped handler configuration
+func (c *Coalesced) TestHandler() {
+	c.Handler.TestHandler()
+}
+
+// Close sends the pending events and closes the wrapped handler
+func (c *Coalesced) Close() error {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = nil
+	c.mu.Unlock()
+
+	for _, e := range pending {
+		if err := c.sendDetached(e); err != nil {
+			logrus.Errorf("Error sending coalesced event: %v", err)
+		}
+	}
+	if closer, ok := c.Handler.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// add passes e on right away when its resource type isn't coalesced,
+// otherwise it replaces the pending event of its key. Errors of coalesced
+// events are logged as they are sent after the event was processed.
+func (c *Coalesced) add(ctx context.Context, e *coalescedEvent, eventObj interface{}) error {
+	kbEvent := event.New(eventObj, e.action)
+	coalesce, ok := c.resourceConfig(kbEvent.Kind)
+	if !ok || coalesce.Window <= 0 || coalesce.Key == config.CoalesceNone {
+		return c.send(ctx, e)
+	}
+
+	key := coalescingKey(coalesce.Key, kbEvent)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pending == nil {
+		c.pending = make(map[string]*coalescedEvent)
+	}
+	if _, waiting := c.pending[key]; waiting {
+		c.pending[key] = e
+		return nil
+	}
+	c.pending[key] = e
+
+	if c.Clock == nil {
+		c.Clock = clock.RealClock{}
+	}
+	timer := c.Clock.NewTimer(coalesce.Window)
+	go func() {
+		<-timer.C()
+		c.flush(key)
+	}()
+	return nil
+}
+
+// flush sends the pending event of key
+func (c *Coalesced) flush(key string) {
+	c.mu.Lock()
+	e, ok := c.pending[key]
+	delete(c.pending, key)
+	c.mu.Unlock()
+
+	if !ok {
+		return
+	}
+	if err := c.sendDetached(e); err != nil {
+		logrus.Errorf("Error sending coalesced event: %v", err)
+	}
+}
+
+// sendDetached sends e once the event handled by the controller returned
+func (c *Coalesced) sendDetached(e *coalescedEvent) error {
+	ctx, cancel := detached(c.Timeout)
+	defer cancel()
+	return c.send(ctx, e)
+}
+
+func (c *Coalesced) send(ctx context.Context, e *coalescedEvent) error {
+	switch e.action {
+	case "created":
+		return c.Handler.ObjectCreated(ctx, e.obj)
+	case "deleted":
+		return c.Handler.ObjectDeleted(ctx, e.obj)
+	}
+	return c.Handler.ObjectUpdated(ctx, e.obj, e.newObj)
+}
+
+// resourceConfig returns the coalescing config of the resource type displayed as kind
+func (c *Coalesced) resourceConfig(kind string) (config.Coalesce, bool) {
+	for resourceType, coalesce := range c.Config {
+		if event.DisplayName(resourceType) == kind {
+			return coalesce, true
+		}
+	}
+	return config.Coalesce{}, false
+}
+
+// coalescingKey returns the key grouping events with the given strategy, the owner
+// strategy groups them by their top-level controller, e.g. the pods of a deployment
+// across its replica sets. Events of objects without a controller fall back to the object key.
+func coalescingKey(strategy string, e event.Event) string {
+	if strategy == config.CoalesceOwner && e.Owner != "" {
+		return e.Cluster + "/" + e.Namespace + "/" + e.Owner
+	}
+
+	name := e.Name
+	if !strings.Contains(name, "/") && e.Namespace != "" {
+		name = e.Namespace + "/" + name
+	}
+	return e.Cluster + "/" + e.Kind + "/" + name
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+// channelHandler passes the events it receives to a channel
+type channelHandler struct {
+	Default
+	events chan event.Event
+}
+
+func (h *channelHandler) ObjectCreated(ctx context.Context, obj interface{}) error {
+	h.events <- event.New(obj, "created")
+	return nil
+}
+
+func (h *channelHandler) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	h.events <- event.New(obj, "deleted")
+	return nil
+}
+
+func (h *channelHandler) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	h.events <- event.New(newObj, "updated")
+	return nil
+}
+
+// receive returns the next n events sent to the handler
+func (h *channelHandler) receive(t *testing.T, n int) []event.Event {
+	var events []event.Event
+	for i := 0; i < n; i++ {
+		select {
+		case e := <-h.events:
+			events = append(events, e)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected %d events, got %v", n, events)
+		}
+	}
+	select {
+	case e := <-h.events:
+		t.Fatalf("expected %d events, got an extra %v", n, e)
+	default:
+	}
+	return events
+}
+
+func ownedPod(name, owner string) *api_v1.Pod {
+	controller := true
+	return &api_v1.Pod{ObjectMeta: meta_v1.ObjectMeta{
+		Name:            name,
+		Namespace:       "default",
+		OwnerReferences: []meta_v1.OwnerReference{{Kind: "ReplicaSet", Name: owner, Controller: &controller}},
+	}}
+}
+
+func newCoalesced(key string) (*Coalesced, *channelHandler, *clock.FakeClock) {
+	h := &channelHandler{events: make(chan event.Event, 10)}
+	fakeClock := clock.NewFakeClock(time.Now())
+	c := &Coalesced{
+		Handler: h,
+		Config:  map[string]config.Coalesce{"pod": {Window: 10 * time.Second, Key: key}},
+		Clock:   fakeClock,
+	}
+	return c, h, fakeClock
+}
+
+// step moves the clock past the coalescing window once the windows are started
+func step(fakeClock *clock.FakeClock) {
+	for !fakeClock.HasWaiters() {
+		time.Sleep(time.Millisecond)
+	}
+	fakeClock.Step(10 * time.Second)
+}
+
+func TestCoalescedObject(t *testing.T) {
+	c, h, fakeClock := newCoalesced(config.CoalesceObject)
+
+	pod := ownedPod("foo", "rs")
+	c.ObjectUpdated(context.Background(), pod, event.Event{Kind: "Pod", Name: "default/foo", Namespace: "default", Reason: "first"})
+	c.ObjectUpdated(context.Background(), pod, event.Event{Kind: "Pod", Name: "default/foo", Namespace: "default", Reason: "second"})
+	c.ObjectCreated(context.Background(), ownedPod("bar", "rs"))
+	h.receive(t, 0)
+
+	step(fakeClock)
+	events := h.receive(t, 2)
+	reasons := map[string]string{}
+	for _, e := range events {
+		reasons[e.Name] = e.Reason
+	}
+	if reasons["default/foo"] != "second" || reasons["bar"] != "created" {
+		t.Fatalf("expected the latest event per object, got %v", events)
+	}
+}
+
+func TestCoalescedOwner(t *testing.T) {
+	c, h, fakeClock := newCoalesced(config.CoalesceOwner)
+
+	c.ObjectCreated(context.Background(), ownedPod("foo", "rs"))
+	c.ObjectCreated(context.Background(), ownedPod("bar", "rs"))
+	c.ObjectCreated(context.Background(), ownedPod("baz", "other"))
+	h.receive(t, 0)
+
+	step(fakeClock)
+	events := h.receive(t, 2)
+	names := map[string]bool{}
+	for _, e := range events {
+		names[e.Name] = true
+	}
+	if !names["bar"] || !names["baz"] {
+		t.Fatalf("expected the latest event per owner, got %v", events)
+	}
+}
+
+func TestCoalescedTopLevelOwner(t *testing.T) {
+	c, h, fakeClock := newCoalesced(config.CoalesceOwner)
+
+	// the pods of the old and new replica sets of a rollout, resolved to their deployment
+	c.ObjectDeleted(context.Background(), event.Event{Kind: "Pod", Name: "default/web-1", Namespace: "default", Owner: "Deployment/web"})
+	c.ObjectCreated(context.Background(), event.Event{Kind: "Pod", Name: "default/web-2", Namespace: "default", Owner: "Deployment/web"})
+	h.receive(t, 0)
+
+	step(fakeClock)
+	if events := h.receive(t, 1); events[0].Name != "default/web-2" {
+		t.Fatalf("expected the latest event of the deployment, got %v", events)
+	}
+}
+
+func TestCoalescedNone(t *testing.T) {
+	c, h, _ := newCoalesced(config.CoalesceNone)
+
+	c.ObjectCreated(context.Background(), ownedPod("foo", "rs"))
+	c.ObjectCreated(context.Background(), ownedPod("foo", "rs"))
+	h.receive(t, 2)
+}
+
+func TestCoalescedUnconfiguredResource(t *testing.T) {
+	c, h, _ := newCoalesced(config.CoalesceObject)
+
+	c.ObjectDeleted(context.Background(), event.Event{Kind: "Service", Name: "default/foo", Namespace: "default"})
+	h.receive(t, 1)
+}
+
+func TestCoalescedClose(t *testing.T) {
+	c, h, _ := newCoalesced(config.CoalesceObject)
+
+	c.ObjectCreated(context.Background(), ownedPod("foo", "rs"))
+	h.receive(t, 0)
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+	h.receive(t, 1)
+}
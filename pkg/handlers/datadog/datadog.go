@@ -0,0 +1,272 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datadog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
+)
+
+// DefaultSite is the site of Datadog accounts in the US
+const DefaultSite = "datadoghq.com"
+
+const (
+	// maxTitleLength and maxTextLength are the limits of the events API
+	maxTitleLength = 100
+	maxTextLength  = 4000
+
+	// markdownStart and markdownEnd mark the text rendered as markdown
+	markdownStart = "%%% \n"
+	markdownEnd   = "\n %%%"
+)
+
+// alertTypes maps the status of events to the Datadog alert types
+var alertTypes = map[string]string{
+	"Normal":  "info",
+	"Warning": "warning",
+	"Danger":  "error",
+}
+
+var datadogErrMsg = `
+%s
+
+You need to set the Datadog api key,
+using "--apikey/-k", or using environment variables:
+
+export KW_DATADOG_APIKEY=datadog_api_key
+
+Command line flags will override environment variables
+
+`
+
+// Datadog handler implements handler.Handler interface,
+// Post events to the Datadog event stream
+type Datadog struct {
+	APIKey string
+	Site   string
+	Url    string
+
+	client *http.Client
+}
+
+// DatadogEvent is the payload of the events API
+type DatadogEvent struct {
+	Title          string   `json:"title"`
+	Text           string   `json:"text"`
+	AlertType      string   `json:"alert_type"`
+	AggregationKey string   `json:"aggregation_key,omitempty"`
+	SourceTypeName string   `json:"source_type_name"`
+	Tags           []string `json:"tags,omitempty"`
+}
+
+// DatadogError is a failed request, asking to retry after a delay when rate limited
+type DatadogError struct {
+	Status string
+	Body   string
+	Retry  time.Duration
+}
+
+func (e *DatadogError) Error() string {
+	return fmt.Sprintf("Failed sending to Datadog, got %s: %s", e.Status, e.Body)
+}
+
+// RetryAfter returns the delay until the rate limit of Datadog resets
+func (e *DatadogError) RetryAfter() time.Duration {
+	return e.Retry
+}
+
+// Init prepares Datadog configuration
+func (d *Datadog) Init(c *config.Config) error {
+	apiKey := c.Handler.Datadog.APIKey
+	site := c.Handler.Datadog.Site
+	url := c.Handler.Datadog.Url
+
+	if apiKey == "" {
+		apiKey = os.Getenv("KW_DATADOG_APIKEY")
+	}
+
+	if site == "" {
+		site = os.Getenv("KW_DATADOG_SITE")
+	}
+	if site == "" {
+		site = DefaultSite
+	}
+
+	if url == "" {
+		url = "https://api." + site + "/api/v1/events"
+	}
+
+	d.APIKey = apiKey
+	d.Site = site
+	d.Url = url
+
+	client, err := utils.NewHTTPClient(c.Handler.HTTP)
+	if err != nil {
+		return err
+	}
+	d.client = client
+
+	return checkMissingDatadogVars(d)
+}
+
+// ObjectCreated calls notifyDatadog on event creation
+func (d *Datadog) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifyDatadog(ctx, d, obj, "created")
+}
+
+// ObjectDeleted calls notifyDatadog on event deletion
+func (d *Datadog) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifyDatadog(ctx, d, obj, "deleted")
+}
+
+// ObjectUpdated calls notifyDatadog on event update
+func (d *Datadog) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifyDatadog(ctx, d, newObj, "updated")
+}
+
+// TestHandler tests the handler configurarion by sending test messages.
+func (d *Datadog) TestHandler() {
+	datadogEvent := &DatadogEvent{
+		Title:          "kubewatch test",
+		Text:           "Testing Handler Configuration. This is a Test message.",
+		AlertType:      "info",
+		SourceTypeName: "kubernetes",
+	}
+
+	if err := postEvent(context.Background(), d, datadogEvent); err != nil {
+		log.Printf("%s\n", err)
+		return
+	}
+
+	log.Printf("Test event successfully sent to Datadog site %s", d.Site)
+}
+
+func notifyDatadog(ctx context.Context, d *Datadog, obj interface{}, action string) error {
+	e := kbEvent.New(obj, action)
+
+	datadogEvent := prepareDatadogEvent(e)
+	if err := postEvent(ctx, d, datadogEvent); err != nil {
+		return err
+	}
+
+	log.Printf("Event successfully sent to Datadog for %s", datadogEvent.AggregationKey)
+	return nil
+}
+
+func checkMissingDatadogVars(d *Datadog) error {
+	if d.APIKey == "" {
+		return fmt.Errorf(datadogErrMsg, "Missing Datadog api key")
+	}
+
+	return nil
+}
+
+// prepareDatadogEvent groups the events of an object by its key, the event types
+// are told apart by their alert type and tags
+func prepareDatadogEvent(e kbEvent.Event) *DatadogEvent {
+	name := e.Name
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	title := fmt.Sprintf("%s %s %s", e.Kind, name, e.Reason)
+	if e.Cluster != "" {
+		title = fmt.Sprintf("[%s] %s", e.Cluster, title)
+	}
+
+	alertType, ok := alertTypes[e.Status]
+	if !ok {
+		alertType = "info"
+	}
+
+	tags := []string{"kube_kind:" + e.Kind, "event_type:" + e.Reason}
+	if e.Namespace != "" {
+		tags = append(tags, "kube_namespace:"+e.Namespace)
+	}
+	if e.Cluster != "" {
+		tags = append(tags, "kube_cluster_name:"+e.Cluster)
+	}
+
+	return &DatadogEvent{
+		Title:          truncate(title, maxTitleLength),
+		Text:           markdownStart + truncate(e.Message(), maxTextLength-len(markdownStart)-len(markdownEnd)) + markdownEnd,
+		AlertType:      alertType,
+		AggregationKey: e.Key(),
+		SourceTypeName: "kubernetes",
+		Tags:           tags,
+	}
+}
+
+// truncate shortens s to max characters, marking the cut with an ellipsis
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	if max <= 1 {
+		return "…"
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+func postEvent(ctx context.Context, d *Datadog, datadogEvent *DatadogEvent) error {
+	message, err := json.Marshal(datadogEvent)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", d.Url, bytes.NewBuffer(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("DD-API-KEY", d.APIKey)
+
+	client := d.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		datadogErr := &DatadogError{Status: resp.Status, Body: string(body)}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			if seconds, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Reset")); err == nil {
+				datadogErr.Retry = time.Duration(seconds) * time.Second
+			}
+		}
+		return datadogErr
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package datadog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+func TestDatadogInit(t *testing.T) {
+	s := &Datadog{}
+	expectedError := fmt.Errorf(datadogErrMsg, "Missing Datadog api key")
+
+	var Tests = []struct {
+		datadog config.Datadog
+		err     error
+	}{
+		{config.Datadog{APIKey: "foo"}, nil},
+		{config.Datadog{APIKey: "foo", Site: "datadoghq.eu"}, nil},
+		{config.Datadog{Site: "datadoghq.eu"}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Datadog = tt.datadog
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestDatadogSite(t *testing.T) {
+	c := &config.Config{}
+	c.Handler.Datadog = config.Datadog{APIKey: "foo", Site: "datadoghq.eu"}
+	d := &Datadog{}
+	if err := d.Init(c); err != nil {
+		t.Fatalf("Init(): %v", err)
+	}
+	if d.Url != "https://api.datadoghq.eu/api/v1/events" {
+		t.Fatalf("expected the events API of the site, got %s", d.Url)
+	}
+}
+
+func TestDatadogEvent(t *testing.T) {
+	var events []DatadogEvent
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("DD-API-KEY") != "foo" {
+			t.Errorf("unexpected api key %q", r.Header.Get("DD-API-KEY"))
+		}
+		var e DatadogEvent
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			t.Errorf("expected a Datadog event: %v", err)
+		}
+		events = append(events, e)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	d := &Datadog{APIKey: "foo", Url: ts.URL}
+	if err := d.ObjectDeleted(context.Background(), kbEvent.Event{Kind: "pod", Name: "default/web", Namespace: "default", Cluster: "prod"}); err != nil {
+		t.Fatalf("ObjectDeleted(): %v", err)
+	}
+
+	if len(events) != 1 {
+		t.Fatalf("expected an event, got %v", events)
+	}
+	e := events[0]
+	if e.AlertType != "error" || e.AggregationKey != "prod/pod/default/web" || e.Title != "[prod] pod web deleted" {
+		t.Errorf("unexpected event %+v", e)
+	}
+	expected := []string{"kube_kind:pod", "event_type:deleted", "kube_namespace:default", "kube_cluster_name:prod"}
+	if !reflect.DeepEqual(e.Tags, expected) {
+		t.Errorf("expected tags %v, got %v", expected, e.Tags)
+	}
+	if !strings.HasPrefix(e.Text, "%%% \n") || !strings.HasSuffix(e.Text, "\n %%%") {
+		t.Errorf("expected a markdown text, got %q", e.Text)
+	}
+}
+
+func TestDatadogError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Reset", "12")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	d := &Datadog{APIKey: "foo", Url: ts.URL}
+	err := d.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "web"})
+	var datadogErr *DatadogError
+	if !errors.As(err, &datadogErr) || datadogErr.RetryAfter() != 12*time.Second {
+		t.Fatalf("expected a rate limit error retrying after 12s, got %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+// defaultDigestObjects caps the objects listed in a digest unless configured otherwise
+const defaultDigestObjects = 20
+
+// digestActions orders the event types counted in a digest
+var digestActions = []string{"created", "updated", "deleted"}
+
+// statusRanks orders the statuses of events, a digest has the most serious status of its events
+var statusRanks = map[string]int{"Normal": 1, "Warning": 2, "Danger": 3}
+
+// Digested wraps a handler and buffers the events, a single digest summarizing them is passed on
+// once per interval: the number of events per kind and event type and the affected objects.
+// The buffered events are sent on Close.
+type Digested struct {
+	Handler Handler
+	// Config holds the digest interval and the cap of the listed objects
+	Config config.Digest
+	// Clock drives the digest interval, defaults to the real clock
+	Clock clock.Clock
+	// Timeout bounds sending a digest, defaults to DefaultTimeout
+	Timeout time.Duration
+
+	mu      sync.Mutex
+	pending *digest
+	// stop ends the ticker flushing the digests, started by the first event
+	stop chan struct{}
+}
+
+// digest holds the events buffered during an interval
+type digest struct {
+	events int
+	// counts of events by kind and event type
+	counts map[string]map[string]int
+	// affected objects, in the order of their first event
+	objects []string
+	seen    map[string]bool
+	status  string
+}
+
+// Init initializes the wrapped handler
+func (d *Digested) Init(conf *config.Config) error {
+	return d.Handler.Init(conf)
+}
+
+// ObjectCreated buffers the created event
+func (d *Digested) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return d.add("created", obj, func() error { return d.Handler.ObjectCreated(ctx, obj) })
+}
+
+// ObjectDeleted buffers the deleted event
+func (d *Digested) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return d.add("deleted", obj, func() error { return d.Handler.ObjectDeleted(ctx, obj) })
+}
+
+// ObjectUpdated buffers the updated event
+func (d *Digested) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return d.add("updated", newObj, func() error { return d.Handler.ObjectUpdated(ctx, oldObj, newObj) })
+}
+
+// TestHandler tests the wrapped handler configuration
+func (d *Digested) TestHandler() {
+	d.Handler.TestHandler()
+}
+
+// Close stops the ticker, sends the buffered events and closes the wrapped handler
+func (d *Digested) Close() error {
+	d.mu.Lock()
+	if d.stop != nil {
+		close(d.stop)
+		d.stop = nil
+	}
+	d.mu.Unlock()
+
+	d.flush()
+	if closer, ok := d.Handler.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// add buffers the event of obj until the next digest, events are sent right away
+// when digests are disabled and for the notifications of kubewatch itself, e.g. ready
+func (d *Digested) add(action string, obj interface{}, send func() error) error {
+	if d.Config.Interval <= 0 {
+		return send()
+	}
+	e := event.New(obj, action)
+	if e.Kind == "kubewatch" {
+		return send()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stop == nil {
+		d.start()
+	}
+	if d.pending == nil {
+		d.pending = &digest{counts: make(map[string]map[string]int), seen: make(map[string]bool)}
+	}
+	d.pending.add(action, e)
+	return nil
+}
+
+// start runs the ticker flushing the digests until Close, d.mu is held
+func (d *Digested) start() {
+	if d.Clock == nil {
+		d.Clock = clock.RealClock{}
+	}
+	ticker := d.Clock.NewTicker(d.Config.Interval)
+	stop := make(chan struct{})
+	d.stop = stop
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C():
+				d.flush()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// flush sends the digest of the buffered events, if any
+func (d *Digested) flush() {
+	d.mu.Lock()
+	pending := d.pending
+	d.pending = nil
+	d.mu.Unlock()
+
+	if pending == nil {
+		return
+	}
+	maxObjects := d.Config.MaxObjects
+	if maxObjects == 0 {
+		maxObjects = defaultDigestObjects
+	}
+
+	ctx, cancel := detached(d.Timeout)
+	defer cancel()
+	if err := d.Handler.ObjectCreated(ctx, pending.event(d.Config.Interval, maxObjects)); err != nil {
+		logrus.Errorf("Error sending events digest: %v", err)
+	}
+}
+
+// add counts the event e of the given event type and records its object
+func (g *digest) add(action string, e event.Event) {
+	g.events++
+	if g.counts[e.Kind] == nil {
+		g.counts[e.Kind] = make(map[string]int)
+	}
+	g.counts[e.Kind][action]++
+
+	name := e.Name
+	if !strings.Contains(name, "/") && e.Namespace != "" {
+		name = e.Namespace + "/" + name
+	}
+	object := fmt.Sprintf("%s %s %s", e.Kind, name, action)
+	if e.Cluster != "" {
+		object = "[" + e.Cluster + "] " + object
+	}
+	if !g.seen[object] {
+		g.seen[object] = true
+		g.objects = append(g.objects, object)
+	}
+
+	if statusRanks[e.Status] > statusRanks[g.status] {
+		g.status = e.Status
+	}
+}
+
+// event returns the digest as an event, listing at most maxObjects objects
+func (g *digest) event(interval time.Duration, maxObjects int) event.Event {
+	lines := []string{fmt.Sprintf("kubewatch digest: %d events in the last %s", g.events, interval)}
+
+	kinds := make([]string, 0, len(g.counts))
+	for kind := range g.counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		var counts []string
+		for _, action := range digestActions {
+			if n := g.counts[kind][action]; n > 0 {
+				counts = append(counts, fmt.Sprintf("%d %s", n, action))
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", kind, strings.Join(counts, ", ")))
+	}
+
+	objects := g.objects
+	if len(objects) > maxObjects {
+		objects = objects[:maxObjects]
+	}
+	for _, object := range objects {
+		lines = append(lines, "- "+object)
+	}
+	if more := len(g.objects) - len(objects); more > 0 {
+		lines = append(lines, fmt.Sprintf("and %d more objects", more))
+	}
+
+	status := g.status
+	if status == "" {
+		status = "Normal"
+	}
+	return event.Event{
+		Kind:   "kubewatch",
+		Reason: "digest",
+		Status: status,
+		Text:   strings.Join(lines, "\n"),
+	}
+}
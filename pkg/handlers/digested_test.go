@@ -0,0 +1,79 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+func TestDigested(t *testing.T) {
+	h := &channelHandler{events: make(chan event.Event, 10)}
+	fakeClock := clock.NewFakeClock(time.Now())
+	d := &Digested{Handler: h, Config: config.Digest{Interval: time.Minute, MaxObjects: 3}, Clock: fakeClock}
+
+	foo := event.Event{Kind: "pod", Name: "default/foo", Namespace: "default", Status: "Normal"}
+	d.ObjectCreated(context.Background(), foo)
+	d.ObjectUpdated(context.Background(), nil, foo)
+	d.ObjectUpdated(context.Background(), nil, foo)
+	d.ObjectDeleted(context.Background(), event.Event{Kind: "pod", Name: "bar", Namespace: "default", Status: "Danger"})
+	d.ObjectUpdated(context.Background(), nil, event.Event{Kind: "deployment", Name: "default/web", Namespace: "default", Status: "Warning"})
+	// the notifications of kubewatch itself aren't delayed
+	d.ObjectCreated(context.Background(), event.Event{Kind: "kubewatch", Reason: "ready", Text: "kubewatch ready"})
+	if ready := h.receive(t, 1)[0]; ready.Text != "kubewatch ready" {
+		t.Fatalf("expected the ready notification, got %v", ready)
+	}
+
+	for !fakeClock.HasWaiters() {
+		time.Sleep(time.Millisecond)
+	}
+	fakeClock.Step(time.Minute)
+	digest := h.receive(t, 1)[0]
+	expected := "kubewatch digest: 5 events in the last 1m0s\n" +
+		"deployment: 1 updated\n" +
+		"pod: 1 created, 2 updated, 1 deleted\n" +
+		"- pod default/foo created\n" +
+		"- pod default/foo updated\n" +
+		"- pod default/bar deleted\n" +
+		"and 1 more objects"
+	if digest.Text != expected || digest.Status != "Danger" {
+		t.Fatalf("expected a Danger digest %q, got %s %q", expected, digest.Status, digest.Text)
+	}
+
+	// intervals without events send no digest, the buffered events are sent on close
+	fakeClock.Step(time.Minute)
+	d.ObjectCreated(context.Background(), foo)
+	if err := d.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+	if digest := h.receive(t, 1)[0]; digest.Text != "kubewatch digest: 1 events in the last 1m0s\npod: 1 created\n- pod default/foo created" {
+		t.Fatalf("expected the buffered event to be sent on close, got %q", digest.Text)
+	}
+}
+
+func TestDigestedDisabled(t *testing.T) {
+	h := &countingHandler{}
+	d := &Digested{Handler: h}
+	if err := d.ObjectCreated(context.Background(), event.Event{Kind: "pod", Name: "foo"}); err != nil || h.created != 1 {
+		t.Fatalf("expected the event to be sent right away, got %d sends: %v", h.created, err)
+	}
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
+)
+
+// maxMessageLength is the limit of Discord messages
+const maxMessageLength = 2000
+
+// maxFieldLength is the limit of Discord embed field values
+const maxFieldLength = 1024
+
+var discordColors = map[string]int{
+	"Normal":  0x2ecc71,
+	"Warning": 0xf1c40f,
+	"Danger":  0xe74c3c,
+}
+
+var discordErrMsg = `
+%s
+
+You need to set the Discord webhook url
+using "--webhookurl/-w" or using environment variables:
+
+export KW_DISCORD_WEBHOOKURL=discord_webhook_url
+
+Command line flags will override environment variables
+
+`
+
+// Discord handler implements handler.Handler interface,
+// Notify event to a Discord channel webhook
+type Discord struct {
+	WebhookURL string
+	Username   string
+	AvatarURL  string
+
+	client *http.Client
+}
+
+// DiscordMessage is the payload of a Discord webhook
+type DiscordMessage struct {
+	Username  string         `json:"username,omitempty"`
+	AvatarURL string         `json:"avatar_url,omitempty"`
+	Embeds    []DiscordEmbed `json:"embeds"`
+}
+
+// DiscordEmbed is a rich message of a Discord webhook
+type DiscordEmbed struct {
+	Title       string              `json:"title"`
+	Description string              `json:"description"`
+	Color       int                 `json:"color"`
+	Fields      []DiscordEmbedField `json:"fields"`
+}
+
+// DiscordEmbedField is a field of a Discord embed
+type DiscordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// Init prepares Discord configuration
+func (d *Discord) Init(c *config.Config) error {
+	webhookURL := c.Handler.Discord.WebhookURL
+	username := c.Handler.Discord.Username
+	avatarURL := c.Handler.Discord.AvatarURL
+
+	if webhookURL == "" {
+		webhookURL = os.Getenv("KW_DISCORD_WEBHOOKURL")
+	}
+
+	if username == "" {
+		username = os.Getenv("KW_DISCORD_USERNAME")
+	}
+
+	if avatarURL == "" {
+		avatarURL = os.Getenv("KW_DISCORD_AVATARURL")
+	}
+
+	d.WebhookURL = webhookURL
+	d.Username = username
+	d.AvatarURL = avatarURL
+
+	client, err := utils.NewHTTPClient(c.Handler.HTTP)
+	if err != nil {
+		return err
+	}
+	d.client = client
+
+	return checkMissingDiscordVars(d)
+}
+
+// ObjectCreated calls notifyDiscord on event creation
+func (d *Discord) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifyDiscord(ctx, d, obj, "created")
+}
+
+// ObjectDeleted calls notifyDiscord on event creation
+func (d *Discord) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifyDiscord(ctx, d, obj, "deleted")
+}
+
+// ObjectUpdated calls notifyDiscord on event creation
+func (d *Discord) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifyDiscord(ctx, d, newObj, "updated")
+}
+
+// TestHandler tests the handler configurarion by sending test messages.
+func (d *Discord) TestHandler() {
+	discordMessage := &DiscordMessage{
+		Username:  d.Username,
+		AvatarURL: d.AvatarURL,
+		Embeds: []DiscordEmbed{
+			{
+				Title:       "kubewatch",
+				Description: "Testing Handler Configuration. This is a Test message.",
+			},
+		},
+	}
+
+	if err := postMessage(context.Background(), d.client, d.WebhookURL, discordMessage); err != nil {
+		log.Printf("%s\n", err)
+		return
+	}
+
+	log.Printf("Message successfully sent to Discord")
+}
+
+func notifyDiscord(ctx context.Context, d *Discord, obj interface{}, action string) error {
+	e := kbEvent.New(obj, action)
+
+	discordMessage := prepareDiscordMessage(e, d)
+	if err := postMessage(ctx, d.client, d.WebhookURL, discordMessage); err != nil {
+		return err
+	}
+
+	log.Printf("Message successfully sent to Discord")
+	return nil
+}
+
+func checkMissingDiscordVars(d *Discord) error {
+	if d.WebhookURL == "" {
+		return fmt.Errorf(discordErrMsg, "Missing Discord webhook url")
+	}
+
+	return nil
+}
+
+func prepareDiscordMessage(e kbEvent.Event, d *Discord) *DiscordMessage {
+	// shorten long names to keep the message within the Discord limit
+	message := e.Message()
+	if excess := len([]rune(message)) - maxMessageLength; excess > 0 {
+		e.Name = truncate(e.Name, len([]rune(e.Name))-excess)
+		message = truncate(e.Message(), maxMessageLength)
+	}
+
+	return &DiscordMessage{
+		Username:  d.Username,
+		AvatarURL: d.AvatarURL,
+		Embeds: []DiscordEmbed{
+			{
+				Title:       "kubewatch",
+				Description: message,
+				Color:       discordColors[e.Status],
+				Fields: []DiscordEmbedField{
+					{Name: "Kind", Value: e.Kind, Inline: true},
+					{Name: "Namespace", Value: e.Namespace, Inline: true},
+					{Name: "Event", Value: e.Reason, Inline: true},
+					{Name: "Name", Value: truncate(e.Name, maxFieldLength)},
+				},
+			},
+		},
+	}
+}
+
+// truncate shortens s to max characters, marking the cut with an ellipsis
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	if max <= 1 {
+		return "…"
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+func postMessage(ctx context.Context, client *http.Client, url string, discordMessage *DiscordMessage) error {
+	message, err := json.Marshal(discordMessage)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("Failed sending to Discord, got %s: %s", resp.Status, body)
+	}
+	return nil
+}
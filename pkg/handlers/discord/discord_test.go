@@ -0,0 +1,136 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+func TestDiscordInit(t *testing.T) {
+	s := &Discord{}
+	expectedError := fmt.Errorf(discordErrMsg, "Missing Discord webhook url")
+
+	var Tests = []struct {
+		discord config.Discord
+		err     error
+	}{
+		{config.Discord{WebhookURL: "foo"}, nil},
+		{config.Discord{WebhookURL: "foo", Username: "kubewatch"}, nil},
+		{config.Discord{Username: "kubewatch"}, expectedError},
+		{config.Discord{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Discord = tt.discord
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestDiscordMessage(t *testing.T) {
+	var messages []DiscordMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var m DiscordMessage
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			t.Errorf("expected a Discord message: %v", err)
+		}
+		messages = append(messages, m)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	d := &Discord{WebhookURL: ts.URL, Username: "kubewatch"}
+	if err := d.ObjectDeleted(context.Background(), kbEvent.Event{Kind: "pod", Name: "default/foo", Namespace: "default"}); err != nil {
+		t.Fatalf("ObjectDeleted(): %v", err)
+	}
+
+	if len(messages) != 1 || len(messages[0].Embeds) != 1 {
+		t.Fatalf("expected a message with an embed, got %v", messages)
+	}
+	embed := messages[0].Embeds[0]
+	if embed.Color != discordColors["Danger"] {
+		t.Fatalf("expected the deleted color, got %x", embed.Color)
+	}
+	expected := []DiscordEmbedField{
+		{Name: "Kind", Value: "pod", Inline: true},
+		{Name: "Namespace", Value: "default", Inline: true},
+		{Name: "Event", Value: "deleted", Inline: true},
+		{Name: "Name", Value: "default/foo"},
+	}
+	if !reflect.DeepEqual(embed.Fields, expected) {
+		t.Fatalf("expected fields %v, got %v", expected, embed.Fields)
+	}
+}
+
+func TestDiscordTruncate(t *testing.T) {
+	e := kbEvent.Event{Kind: "pod", Name: strings.Repeat("a", 3000), Namespace: "default", Reason: "created"}
+	m := prepareDiscordMessage(e, &Discord{})
+
+	description := []rune(m.Embeds[0].Description)
+	if len(description) != maxMessageLength {
+		t.Fatalf("expected a description of %d characters, got %d", maxMessageLength, len(description))
+	}
+	if !strings.HasPrefix(string(description), "A `pod` in namespace `default`") {
+		t.Fatalf("expected the name to be truncated, got %s", string(description[:50]))
+	}
+	if name := []rune(m.Embeds[0].Fields[3].Value); len(name) != maxFieldLength {
+		t.Fatalf("expected a name field of %d characters, got %d", maxFieldLength, len(name))
+	}
+}
+
+func TestDiscordError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad request", http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	d := &Discord{WebhookURL: ts.URL}
+	if err := d.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "foo", Namespace: "default"}); err == nil {
+		t.Fatal("ObjectCreated(): expected an error on a non-2xx response")
+	}
+}
+
+func TestDiscordCancelled(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	d := &Discord{WebhookURL: ts.URL}
+	err := d.ObjectCreated(ctx, kbEvent.Event{Kind: "pod", Name: "foo", Namespace: "default"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ObjectCreated(): expected the hanging request to be cancelled, got %v", err)
+	}
+}
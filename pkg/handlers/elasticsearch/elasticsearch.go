@@ -0,0 +1,261 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
+)
+
+var elasticsearchErrMsg = `
+%s
+
+You need to set the Elasticsearch addresses
+using "--addresses/-a" or using environment variables:
+
+export KW_ELASTICSEARCH_ADDRESSES=https://elasticsearch:9200
+
+Command line flags will override environment variables
+
+`
+
+// Elasticsearch handler implements handler.Handler interface,
+// Index a document per event into a daily index
+type Elasticsearch struct {
+	Addresses []string
+	Index     string
+	Username  string
+	Password  string
+
+	client *http.Client
+	// now returns the time of the indexed events, faked in tests
+	now func() time.Time
+}
+
+// ElasticsearchDocument is the document indexed per event
+type ElasticsearchDocument struct {
+	Timestamp string            `json:"@timestamp"`
+	Kind      string            `json:"kind"`
+	Namespace string            `json:"namespace,omitempty"`
+	Name      string            `json:"name"`
+	EventType string            `json:"eventType"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Cluster   string            `json:"cluster,omitempty"`
+	Message   string            `json:"message"`
+}
+
+// Init prepares Elasticsearch configuration
+func (e *Elasticsearch) Init(c *config.Config) error {
+	addresses := c.Handler.Elasticsearch.Addresses
+	index := c.Handler.Elasticsearch.Index
+	username := c.Handler.Elasticsearch.Username
+	password := c.Handler.Elasticsearch.Password
+
+	if len(addresses) == 0 && os.Getenv("KW_ELASTICSEARCH_ADDRESSES") != "" {
+		addresses = strings.Split(os.Getenv("KW_ELASTICSEARCH_ADDRESSES"), ",")
+	}
+
+	if index == "" {
+		index = "kubewatch"
+	}
+
+	if username == "" {
+		username = os.Getenv("KW_ELASTICSEARCH_USERNAME")
+	}
+
+	if password == "" {
+		password = os.Getenv("KW_ELASTICSEARCH_PASSWORD")
+	}
+
+	if len(addresses) == 0 {
+		return fmt.Errorf(elasticsearchErrMsg, "Missing Elasticsearch addresses")
+	}
+
+	client, err := newClient(c.Handler.HTTP, c.Handler.Elasticsearch.TLS)
+	if err != nil {
+		return err
+	}
+
+	e.Addresses = addresses
+	e.Index = index
+	e.Username = username
+	e.Password = password
+	e.client = client
+	e.now = time.Now
+	return nil
+}
+
+// newClient returns the HTTP client of the handler with the shared proxy settings,
+// and its own TLS settings when set, the shared ones otherwise
+func newClient(h config.HTTP, c config.ElasticsearchTLS) (*http.Client, error) {
+	transport, err := utils.NewHTTPTransport(h)
+	if err != nil {
+		return nil, err
+	}
+	if c == (config.ElasticsearchTLS{}) {
+		return &http.Client{Transport: transport, Timeout: 30 * time.Second}, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify}
+	if c.CAFile != "" {
+		ca, err := ioutil.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed reading Elasticsearch CA file: %v", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("Failed parsing Elasticsearch CA file %s", c.CAFile)
+		}
+	}
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Transport: transport, Timeout: 30 * time.Second}, nil
+}
+
+// ObjectCreated calls notifyElasticsearch on event creation
+func (e *Elasticsearch) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifyElasticsearch(ctx, e, obj, "created")
+}
+
+// ObjectDeleted calls notifyElasticsearch on event creation
+func (e *Elasticsearch) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifyElasticsearch(ctx, e, obj, "deleted")
+}
+
+// ObjectUpdated calls notifyElasticsearch on event creation
+func (e *Elasticsearch) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifyElasticsearch(ctx, e, newObj, "updated")
+}
+
+// TestHandler tests the handler configurarion by sending test messages.
+func (e *Elasticsearch) TestHandler() {
+	now := e.now()
+	err := e.index(context.Background(), now, ElasticsearchDocument{
+		Timestamp: now.UTC().Format(time.RFC3339Nano),
+		Message:   "Testing Handler Configuration. This is a Test message.",
+	})
+	if err != nil {
+		log.Printf("%s\n", err)
+		return
+	}
+
+	log.Printf("Message successfully sent to Elasticsearch")
+}
+
+func notifyElasticsearch(ctx context.Context, e *Elasticsearch, obj interface{}, action string) error {
+	event := kbEvent.New(obj, action)
+	now := e.now()
+	if err := e.index(ctx, now, prepareElasticsearchDocument(event, now)); err != nil {
+		return err
+	}
+
+	log.Printf("Message successfully sent to Elasticsearch")
+	return nil
+}
+
+// indexName returns the daily index of t, e.g. kubewatch-2024.01.02 for ILM to roll over
+func (e *Elasticsearch) indexName(t time.Time) string {
+	return e.Index + "-" + t.UTC().Format("2006.01.02")
+}
+
+// index indexes the document into the index of the day of t, trying the addresses in
+// order until one answers. Failed requests are returned for the controller to retry
+func (e *Elasticsearch) index(ctx context.Context, t time.Time, doc ElasticsearchDocument) error {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("Failed encoding Elasticsearch document: %v", err)
+	}
+
+	var errs []string
+	for _, address := range e.Addresses {
+		url := strings.TrimSuffix(address, "/") + "/" + e.indexName(t) + "/_doc"
+		err := e.post(ctx, url, body)
+		if err == nil {
+			return nil
+		}
+		if _, ok := err.(*ElasticsearchError); ok {
+			// the node answered, the others would reject the document alike
+			return err
+		}
+		errs = append(errs, err.Error())
+	}
+	return fmt.Errorf("Failed sending to Elasticsearch: %s", strings.Join(errs, "; "))
+}
+
+// ElasticsearchError is a document Elasticsearch failed to index
+type ElasticsearchError struct {
+	Status  string
+	Message string
+}
+
+func (e *ElasticsearchError) Error() string {
+	return fmt.Sprintf("Failed indexing to Elasticsearch. Elasticsearch http response: %s, %s", e.Status, e.Message)
+}
+
+func (e *Elasticsearch) post(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.Username != "" {
+		req.SetBasicAuth(e.Username, e.Password)
+	}
+
+	res, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		message, _ := ioutil.ReadAll(res.Body)
+		return &ElasticsearchError{Status: res.Status, Message: string(message)}
+	}
+	return nil
+}
+
+func prepareElasticsearchDocument(e kbEvent.Event, now time.Time) ElasticsearchDocument {
+	name := e.Name
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	return ElasticsearchDocument{
+		Timestamp: now.UTC().Format(time.RFC3339Nano),
+		Kind:      e.Kind,
+		Namespace: e.Namespace,
+		Name:      name,
+		EventType: e.Reason,
+		Labels:    e.Labels,
+		Cluster:   e.Cluster,
+		Message:   e.Message(),
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+func TestElasticsearchInit(t *testing.T) {
+	s := &Elasticsearch{}
+	expectedError := fmt.Errorf(elasticsearchErrMsg, "Missing Elasticsearch addresses")
+
+	var Tests = []struct {
+		elasticsearch config.Elasticsearch
+		err           error
+	}{
+		{config.Elasticsearch{Addresses: []string{"http://localhost:9200"}}, nil},
+		{config.Elasticsearch{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Elasticsearch = tt.elasticsearch
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+	if s.Index != "kubewatch" {
+		t.Errorf("expected the default index, got %q", s.Index)
+	}
+}
+
+func TestElasticsearchIndex(t *testing.T) {
+	var path, username, password string
+	var doc ElasticsearchDocument
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		username, password, _ = r.BasicAuth()
+		if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+			t.Errorf("%v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+
+	c := &config.Config{}
+	// the first address is down, the next one is tried
+	c.Handler.Elasticsearch = config.Elasticsearch{Addresses: []string{"http://127.0.0.1:1", ts.URL}, Username: "foo", Password: "bar"}
+	e := &Elasticsearch{}
+	if err := e.Init(c); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	e.now = func() time.Time { return now }
+
+	event := kbEvent.Event{Kind: "pod", Name: "default/foo", Namespace: "default", Labels: map[string]string{"app": "foo"}}
+	if err := e.ObjectDeleted(context.Background(), event); err != nil {
+		t.Fatalf("ObjectDeleted(): %v", err)
+	}
+
+	if path != "/kubewatch-2024.01.02/_doc" {
+		t.Errorf("expected the daily index, got %s", path)
+	}
+	if username != "foo" || password != "bar" {
+		t.Errorf("expected basic auth, got %s:%s", username, password)
+	}
+	expected := ElasticsearchDocument{
+		Timestamp: "2024-01-02T03:04:05Z",
+		Kind:      "pod",
+		Namespace: "default",
+		Name:      "foo",
+		EventType: "deleted",
+		Labels:    map[string]string{"app": "foo"},
+		Message:   "A `pod` in namespace `default` has been `deleted`:\n`default/foo`",
+	}
+	if !reflect.DeepEqual(doc, expected) {
+		t.Errorf("expected %+v, got %+v", expected, doc)
+	}
+}
+
+func TestElasticsearchIndexError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error": "es_rejected_execution_exception"}`)
+	}))
+	defer ts.Close()
+
+	e := &Elasticsearch{Addresses: []string{ts.URL}, Index: "kubewatch", client: http.DefaultClient, now: time.Now}
+	err := e.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "default/foo", Namespace: "default"})
+	if _, ok := err.(*ElasticsearchError); !ok {
+		t.Fatalf("expected the rejected document to be returned, got %v", err)
+	}
+}
@@ -0,0 +1,311 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"html"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+// DefaultPort is the SMTP submission port, using STARTTLS
+const DefaultPort = 587
+
+// implicitTLSPort is the SMTP port using implicit TLS
+const implicitTLSPort = 465
+
+// DefaultSubject is the subject template of the emails
+const DefaultSubject = "{{if .Cluster}}[{{.Cluster}}] {{end}}kubewatch: {{.Kind}} {{.Name}} {{.Reason}}"
+
+const dialTimeout = 30 * time.Second
+
+var emailErrMsg = `
+%s
+
+You need to set the SMTP host, from and to addresses for email notify,
+using "--host", "--from" and "--to", or using environment variables:
+
+export KW_EMAIL_HOST=smtp_host
+export KW_EMAIL_FROM=from_address
+export KW_EMAIL_TO=to_address,other_to_address
+
+Command line flags will override environment variables
+
+`
+
+// codeSpan matches the backquoted parts of event messages
+var codeSpan = regexp.MustCompile("`([^`]*)`")
+
+// Email handler implements handler.Handler interface,
+// Notify event by email through an SMTP server
+type Email struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+	Subject  *template.Template
+}
+
+// Init prepares Email configuration
+func (m *Email) Init(c *config.Config) error {
+	host := c.Handler.Email.Host
+	port := c.Handler.Email.Port
+	username := c.Handler.Email.Username
+	password := c.Handler.Email.Password
+	from := c.Handler.Email.From
+	to := c.Handler.Email.To
+	subject := c.Handler.Email.Subject
+
+	if host == "" {
+		host = os.Getenv("KW_EMAIL_HOST")
+	}
+
+	if port == 0 {
+		if p, err := strconv.Atoi(os.Getenv("KW_EMAIL_PORT")); err == nil {
+			port = p
+		} else {
+			port = DefaultPort
+		}
+	}
+
+	if username == "" {
+		username = os.Getenv("KW_EMAIL_USERNAME")
+	}
+
+	if password == "" {
+		password = os.Getenv("KW_EMAIL_PASSWORD")
+	}
+
+	if from == "" {
+		from = os.Getenv("KW_EMAIL_FROM")
+	}
+
+	if len(to) == 0 && os.Getenv("KW_EMAIL_TO") != "" {
+		to = strings.Split(os.Getenv("KW_EMAIL_TO"), ",")
+	}
+
+	if subject == "" {
+		subject = DefaultSubject
+	}
+
+	m.Host = host
+	m.Port = port
+	m.Username = username
+	m.Password = password
+	m.From = from
+	m.To = to
+
+	if err := checkMissingEmailVars(m); err != nil {
+		return err
+	}
+
+	t, err := template.New("subject").Parse(subject)
+	if err != nil {
+		return fmt.Errorf("Invalid email subject: %v", err)
+	}
+	m.Subject = t
+	return nil
+}
+
+// ObjectCreated calls notifyEmail on event creation
+func (m *Email) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifyEmail(ctx, m, obj, "created")
+}
+
+// ObjectDeleted calls notifyEmail on event creation
+func (m *Email) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifyEmail(ctx, m, obj, "deleted")
+}
+
+// ObjectUpdated calls notifyEmail on event creation
+func (m *Email) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifyEmail(ctx, m, newObj, "updated")
+}
+
+// TestHandler tests the handler configurarion by sending test messages.
+func (m *Email) TestHandler() {
+	message, err := buildMessage(m, "kubewatch: test", "Testing Handler Configuration. This is a Test message.")
+	if err != nil {
+		log.Printf("%s\n", err)
+		return
+	}
+
+	if err := sendMail(context.Background(), m, message); err != nil {
+		log.Printf("%s\n", err)
+		return
+	}
+
+	log.Printf("Email successfully sent to %s", strings.Join(m.To, ", "))
+}
+
+func notifyEmail(ctx context.Context, m *Email, obj interface{}, action string) error {
+	e := kbEvent.New(obj, action)
+
+	var subject bytes.Buffer
+	if err := m.Subject.Execute(&subject, e); err != nil {
+		return fmt.Errorf("Failed rendering email subject: %v", err)
+	}
+
+	message, err := buildMessage(m, subject.String(), e.Message())
+	if err != nil {
+		return err
+	}
+
+	if err := sendMail(ctx, m, message); err != nil {
+		return err
+	}
+
+	log.Printf("Email successfully sent to %s", strings.Join(m.To, ", "))
+	return nil
+}
+
+func checkMissingEmailVars(m *Email) error {
+	if m.Host == "" || m.From == "" || len(m.To) == 0 {
+		return fmt.Errorf(emailErrMsg, "Missing SMTP host, from or to address")
+	}
+
+	return nil
+}
+
+// buildMessage returns a multipart email with a text and an HTML version of text
+func buildMessage(m *Email, subject, text string) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", toHTML(text)},
+	}
+	for _, part := range parts {
+		w, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"8bit"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", m.From)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(m.To, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&message, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&message, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", writer.Boundary())
+	message.Write(body.Bytes())
+	return message.Bytes(), nil
+}
+
+// toHTML renders the backquoted parts of a message as code
+func toHTML(text string) string {
+	text = codeSpan.ReplaceAllString(html.EscapeString(text), "<code>$1</code>")
+	return "<p>" + strings.Replace(text, "\n", "<br>", -1) + "</p>"
+}
+
+// sendMail sends message to all recipients, using implicit TLS on port 465
+// and STARTTLS on other ports when the server supports it
+func sendMail(ctx context.Context, m *Email, message []byte) error {
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	tlsConfig := &tls.Config{ServerName: m.Host}
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	if m.Port == implicitTLSPort {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed connecting to SMTP server %s: %v", addr, err)
+	}
+	// the SMTP exchange takes no context, it is bounded by the deadline of the connection
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, m.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if m.Port != implicitTLSPort {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		}
+	}
+
+	if m.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.Username, m.Password, m.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(m.From); err != nil {
+		return err
+	}
+	for _, to := range m.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
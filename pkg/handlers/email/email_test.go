@@ -0,0 +1,167 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package email
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+func TestEmailInit(t *testing.T) {
+	s := &Email{}
+	expectedError := fmt.Errorf(emailErrMsg, "Missing SMTP host, from or to address")
+
+	var Tests = []struct {
+		email config.Email
+		err   error
+	}{
+		{config.Email{Host: "smtp", From: "kubewatch@example.com", To: []string{"ops@example.com"}}, nil},
+		{config.Email{Host: "smtp", From: "kubewatch@example.com"}, expectedError},
+		{config.Email{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Email = tt.email
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+// smtpServer accepts a single mail and reports its recipients and data
+func smtpServer(t *testing.T, rcptReply string) (port int, done <-chan []string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := make(chan []string, 1)
+	go func() {
+		defer l.Close()
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var received []string
+		r := bufio.NewReader(conn)
+		fmt.Fprint(conn, "220 localhost\r\n")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				break
+			}
+			switch cmd := strings.ToUpper(strings.Fields(line)[0]); cmd {
+			case "EHLO", "HELO":
+				fmt.Fprint(conn, "250 localhost\r\n")
+			case "RCPT":
+				received = append(received, strings.TrimSpace(line))
+				fmt.Fprint(conn, rcptReply+"\r\n")
+			case "DATA":
+				fmt.Fprint(conn, "354 go ahead\r\n")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil || line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				received = append(received, data.String())
+				fmt.Fprint(conn, "250 ok\r\n")
+			case "QUIT":
+				fmt.Fprint(conn, "221 bye\r\n")
+				result <- received
+				return
+			default:
+				fmt.Fprint(conn, "250 ok\r\n")
+			}
+		}
+		result <- received
+	}()
+	return l.Addr().(*net.TCPAddr).Port, result
+}
+
+func TestEmailSend(t *testing.T) {
+	port, done := smtpServer(t, "250 ok")
+
+	c := &config.Config{}
+	c.Handler.Email = config.Email{
+		Host: "127.0.0.1",
+		Port: port,
+		From: "kubewatch@example.com",
+		To:   []string{"ops@example.com", "dev@example.com"},
+	}
+	m := &Email{}
+	if err := m.Init(c); err != nil {
+		t.Fatalf("Init(): %v", err)
+	}
+
+	e := kbEvent.Event{Kind: "pod", Name: "foo", Namespace: "default", Cluster: "prod"}
+	if err := m.ObjectCreated(context.Background(), e); err != nil {
+		t.Fatalf("ObjectCreated(): %v", err)
+	}
+
+	received := <-done
+	if len(received) != 3 {
+		t.Fatalf("expected 2 recipients and a message, got %v", received)
+	}
+	if received[0] != "RCPT TO:<ops@example.com>" || received[1] != "RCPT TO:<dev@example.com>" {
+		t.Fatalf("unexpected recipients %v", received[:2])
+	}
+	for _, expected := range []string{
+		"To: ops@example.com, dev@example.com\r\n",
+		"Subject: [prod] kubewatch: pod foo created\r\n",
+		"Content-Type: multipart/alternative;",
+		"Content-Type: text/plain; charset=utf-8",
+		"<code>pod</code>",
+	} {
+		if !strings.Contains(received[2], expected) {
+			t.Fatalf("expected the message to contain %q, got %s", expected, received[2])
+		}
+	}
+}
+
+func TestEmailSendError(t *testing.T) {
+	port, _ := smtpServer(t, "550 no such user")
+
+	m := &Email{}
+	c := &config.Config{}
+	c.Handler.Email = config.Email{Host: "127.0.0.1", Port: port, From: "kubewatch@example.com", To: []string{"nobody@example.com"}}
+	if err := m.Init(c); err != nil {
+		t.Fatalf("Init(): %v", err)
+	}
+	if err := m.ObjectDeleted(context.Background(), kbEvent.Event{Kind: "pod", Name: "default/foo", Namespace: "default"}); err == nil {
+		t.Fatal("ObjectDeleted(): expected an error on a rejected recipient")
+	}
+}
+
+func TestToHTML(t *testing.T) {
+	expected := "<p>A <code>pod</code> in <code>&lt;ns&gt;</code><br><code>foo</code></p>"
+	if html := toHTML("A `pod` in `<ns>`\n`foo`"); html != expected {
+		t.Fatalf("toHTML(): expected %q, got %q", expected, html)
+	}
+}
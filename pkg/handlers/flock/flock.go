@@ -17,9 +17,13 @@ limitations under the License.
 package flock
 
 import (
+	"context"
 	"fmt"
+	"html"
+	"io/ioutil"
 	"log"
 	"os"
+	"strings"
 
 	"bytes"
 	"encoding/json"
@@ -28,6 +32,7 @@ import (
 
 	"github.com/mudasirmirza/kubewatch/config"
 	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
 )
 
 var flockColors = map[string]string{
@@ -52,6 +57,8 @@ Command line flags will override environment variables
 // Notify event to Flock channel
 type Flock struct {
 	Url string
+
+	client *http.Client
 }
 
 // FlockMessage struct
@@ -63,14 +70,15 @@ type FlockMessage struct {
 
 // FlockMessageAttachement struct
 type FlockMessageAttachement struct {
-	Title string                       `json:"title"`
-	Color string                       `json:"color"`
-	Views FlockMessageAttachementViews `json:"views"`
+	Title       string                       `json:"title"`
+	Description string                       `json:"description,omitempty"`
+	Color       string                       `json:"color"`
+	Views       FlockMessageAttachementViews `json:"views"`
 }
 
-// FlockMessageAttachementViews struct
+// FlockMessageAttachementViews struct, the flockml view renders the attachment as rich text
 type FlockMessageAttachementViews struct {
-	Flockml string `json:"flockml"`
+	Flockml string `json:"flockml,omitempty"`
 }
 
 // Init prepares Flock configuration
@@ -83,22 +91,28 @@ func (f *Flock) Init(c *config.Config) error {
 
 	f.Url = url
 
+	client, err := utils.NewHTTPClient(c.Handler.HTTP)
+	if err != nil {
+		return err
+	}
+	f.client = client
+
 	return checkMissingFlockVars(f)
 }
 
 // ObjectCreated calls notifyFlock on event creation
-func (f *Flock) ObjectCreated(obj interface{}) {
-	notifyFlock(f, obj, "created")
+func (f *Flock) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifyFlock(ctx, f, obj, "created")
 }
 
 // ObjectDeleted calls notifyFlock on event creation
-func (f *Flock) ObjectDeleted(obj interface{}) {
-	notifyFlock(f, obj, "deleted")
+func (f *Flock) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifyFlock(ctx, f, obj, "deleted")
 }
 
 // ObjectUpdated calls notifyFlock on event creation
-func (f *Flock) ObjectUpdated(oldObj, newObj interface{}) {
-	notifyFlock(f, newObj, "updated")
+func (f *Flock) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifyFlock(ctx, f, newObj, "updated")
 }
 
 // TestHandler tests the handler configurarion by sending test messages.
@@ -114,7 +128,7 @@ func (f *Flock) TestHandler() {
 		},
 	}
 
-	err := postMessage(f.Url, flockMessage)
+	err := postMessage(context.Background(), f.client, f.Url, flockMessage)
 	if err != nil {
 		log.Printf("%s\n", err)
 		return
@@ -123,18 +137,18 @@ func (f *Flock) TestHandler() {
 	log.Printf("Message successfully sent to channel %s at %s", f.Url, time.Now())
 }
 
-func notifyFlock(f *Flock, obj interface{}, action string) {
+func notifyFlock(ctx context.Context, f *Flock, obj interface{}, action string) error {
 	e := kbEvent.New(obj, action)
 
 	flockMessage := prepareFlockMessage(e, f)
 
-	err := postMessage(f.Url, flockMessage)
+	err := postMessage(ctx, f.client, f.Url, flockMessage)
 	if err != nil {
-		log.Printf("%s\n", err)
-		return
+		return err
 	}
 
 	log.Printf("Message successfully sent to channel %s at %s", f.Url, time.Now())
+	return nil
 }
 
 func checkMissingFlockVars(s *Flock) error {
@@ -146,36 +160,74 @@ func checkMissingFlockVars(s *Flock) error {
 }
 
 func prepareFlockMessage(e kbEvent.Event, f *Flock) *FlockMessage {
+	name := e.Name
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	title := fmt.Sprintf("%s %s %s", e.Kind, name, e.Reason)
+	if e.Cluster != "" {
+		title = fmt.Sprintf("[%s] %s", e.Cluster, title)
+	}
 
 	return &FlockMessage{
 		Text:         "Kubewatch Alert",
-		Notification: "Kubewatch Alert",
+		Notification: title,
 		Attachements: []FlockMessageAttachement{
 			{
-				Title: e.Message(),
-				Color: flockColors[e.Status],
+				Title:       title,
+				Description: e.Message(),
+				Color:       flockColors[e.Status],
+				Views:       FlockMessageAttachementViews{Flockml: flockml(e, name)},
 			},
 		},
 	}
 }
 
-func postMessage(url string, flockMessage *FlockMessage) error {
+// flockml renders the fields of the event, followed by its changes or details
+func flockml(e kbEvent.Event, name string) string {
+	fields := [][2]string{{"Kind", e.Kind}}
+	if e.Namespace != "" {
+		fields = append(fields, [2]string{"Namespace", e.Namespace})
+	}
+	fields = append(fields, [2]string{"Name", name}, [2]string{"Action", e.Reason})
+	if e.Cluster != "" {
+		fields = append(fields, [2]string{"Cluster", e.Cluster})
+	}
+
+	var lines []string
+	for _, field := range fields {
+		lines = append(lines, fmt.Sprintf("<b>%s</b>: %s", field[0], html.EscapeString(field[1])))
+	}
+	for _, line := range append(append([]string{}, e.Diff...), e.Access...) {
+		lines = append(lines, "- "+html.EscapeString(line))
+	}
+	return "<flockml>" + strings.Join(lines, "<br/>") + "</flockml>"
+}
+
+func postMessage(ctx context.Context, client *http.Client, url string, flockMessage *FlockMessage) error {
 	message, err := json.Marshal(flockMessage)
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(message))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(message))
 	if err != nil {
 		return err
 	}
 	req.Header.Add("Content-Type", "application/json")
 
-	client := &http.Client{}
-	_, err = client.Do(req)
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("Failed sending to Flock, got %s: %s", resp.Status, body)
+	}
 	return nil
 }
@@ -17,11 +17,16 @@ limitations under the License.
 package flock
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 
 	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
 )
 
 func TestFlockInit(t *testing.T) {
@@ -44,3 +49,47 @@ func TestFlockInit(t *testing.T) {
 		}
 	}
 }
+
+func TestFlockMessage(t *testing.T) {
+	var messages []FlockMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var m FlockMessage
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			t.Errorf("expected a Flock message: %v", err)
+		}
+		messages = append(messages, m)
+	}))
+	defer ts.Close()
+
+	f := &Flock{Url: ts.URL}
+	if err := f.ObjectDeleted(context.Background(), kbEvent.Event{Kind: "pod", Name: "default/<web>", Namespace: "default", Cluster: "prod"}); err != nil {
+		t.Fatalf("ObjectDeleted(): %v", err)
+	}
+
+	if len(messages) != 1 || len(messages[0].Attachements) != 1 {
+		t.Fatalf("expected a message with an attachment, got %v", messages)
+	}
+	if messages[0].Notification != "[prod] pod <web> deleted" {
+		t.Fatalf("unexpected notification %q", messages[0].Notification)
+	}
+	attachment := messages[0].Attachements[0]
+	if attachment.Color != flockColors["Danger"] {
+		t.Fatalf("expected the deleted color, got %s", attachment.Color)
+	}
+	expected := "<flockml><b>Kind</b>: pod<br/><b>Namespace</b>: default<br/><b>Name</b>: &lt;web&gt;<br/><b>Action</b>: deleted<br/><b>Cluster</b>: prod</flockml>"
+	if attachment.Views.Flockml != expected {
+		t.Fatalf("expected flockml %q, got %q", expected, attachment.Views.Flockml)
+	}
+}
+
+func TestFlockError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid token", http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	f := &Flock{Url: ts.URL}
+	if err := f.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "foo", Namespace: "default"}); err == nil {
+		t.Fatal("ObjectCreated(): expected an error on a non-2xx response")
+	}
+}
@@ -0,0 +1,258 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package googlechat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
+)
+
+// maxMessageSize is the limit in bytes of Google Chat messages
+const maxMessageSize = 32000
+
+// maxTextLength is the limit in characters kept for the fallback text and the name widget,
+// leaving room for the card in maxMessageSize
+const maxTextLength = 4000
+
+var googleChatErrMsg = `
+%s
+
+You need to set the Google Chat webhook url
+using "--webhookurl/-w" or using environment variables:
+
+export KW_GOOGLECHAT_WEBHOOKURL=google_chat_webhook_url
+
+Command line flags will override environment variables
+
+`
+
+// GoogleChat handler implements handler.Handler interface,
+// Notify event to a Google Chat space incoming webhook
+type GoogleChat struct {
+	WebhookURL string
+
+	client *http.Client
+}
+
+// GoogleChatMessage is the payload of a Google Chat webhook
+type GoogleChatMessage struct {
+	Text    string           `json:"text"`
+	CardsV2 []GoogleChatCard `json:"cardsV2,omitempty"`
+}
+
+// GoogleChatCard is a card of a Google Chat message
+type GoogleChatCard struct {
+	CardID string `json:"cardId"`
+	Card   struct {
+		Header   GoogleChatHeader    `json:"header"`
+		Sections []GoogleChatSection `json:"sections"`
+	} `json:"card"`
+}
+
+// GoogleChatHeader is the header of a Google Chat card
+type GoogleChatHeader struct {
+	Title    string `json:"title"`
+	Subtitle string `json:"subtitle,omitempty"`
+}
+
+// GoogleChatSection is a section of a Google Chat card
+type GoogleChatSection struct {
+	Widgets []GoogleChatWidget `json:"widgets"`
+}
+
+// GoogleChatWidget is a key/value widget of a Google Chat card section
+type GoogleChatWidget struct {
+	DecoratedText struct {
+		TopLabel string `json:"topLabel"`
+		Text     string `json:"text"`
+	} `json:"decoratedText"`
+}
+
+// GoogleChatError is a failed webhook request, asking to retry after a delay when rate limited
+type GoogleChatError struct {
+	Status string
+	Body   string
+	Retry  time.Duration
+}
+
+func (e *GoogleChatError) Error() string {
+	return fmt.Sprintf("Failed sending to Google Chat, got %s: %s", e.Status, e.Body)
+}
+
+// RetryAfter returns the delay asked for by Google Chat before retrying
+func (e *GoogleChatError) RetryAfter() time.Duration {
+	return e.Retry
+}
+
+// Init prepares Google Chat configuration
+func (g *GoogleChat) Init(c *config.Config) error {
+	webhookURL := c.Handler.GoogleChat.WebhookURL
+
+	if webhookURL == "" {
+		webhookURL = os.Getenv("KW_GOOGLECHAT_WEBHOOKURL")
+	}
+
+	g.WebhookURL = webhookURL
+
+	client, err := utils.NewHTTPClient(c.Handler.HTTP)
+	if err != nil {
+		return err
+	}
+	g.client = client
+
+	return checkMissingGoogleChatVars(g)
+}
+
+// ObjectCreated calls notifyGoogleChat on event creation
+func (g *GoogleChat) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifyGoogleChat(ctx, g, obj, "created")
+}
+
+// ObjectDeleted calls notifyGoogleChat on event creation
+func (g *GoogleChat) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifyGoogleChat(ctx, g, obj, "deleted")
+}
+
+// ObjectUpdated calls notifyGoogleChat on event creation
+func (g *GoogleChat) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifyGoogleChat(ctx, g, newObj, "updated")
+}
+
+// TestHandler tests the handler configurarion by sending test messages.
+func (g *GoogleChat) TestHandler() {
+	googleChatMessage := &GoogleChatMessage{
+		Text: "Testing Handler Configuration. This is a Test message.",
+	}
+
+	if err := postMessage(context.Background(), g.client, g.WebhookURL, googleChatMessage); err != nil {
+		log.Printf("%s\n", err)
+		return
+	}
+
+	log.Printf("Message successfully sent to Google Chat")
+}
+
+func notifyGoogleChat(ctx context.Context, g *GoogleChat, obj interface{}, action string) error {
+	e := kbEvent.New(obj, action)
+
+	googleChatMessage := prepareGoogleChatMessage(e)
+	if err := postMessage(ctx, g.client, g.WebhookURL, googleChatMessage); err != nil {
+		return err
+	}
+
+	log.Printf("Message successfully sent to Google Chat")
+	return nil
+}
+
+func checkMissingGoogleChatVars(g *GoogleChat) error {
+	if g.WebhookURL == "" {
+		return fmt.Errorf(googleChatErrMsg, "Missing Google Chat webhook url")
+	}
+
+	return nil
+}
+
+func prepareGoogleChatMessage(e kbEvent.Event) *GoogleChatMessage {
+	// shorten long names to keep the message within the Google Chat limit
+	message := e.Message()
+	if excess := len([]rune(message)) - maxTextLength; excess > 0 {
+		e.Name = truncate(e.Name, len([]rune(e.Name))-excess)
+		message = truncate(e.Message(), maxTextLength)
+	}
+
+	card := GoogleChatCard{CardID: "kubewatch"}
+	card.Card.Header = GoogleChatHeader{Title: "kubewatch", Subtitle: e.Reason}
+	card.Card.Sections = []GoogleChatSection{{Widgets: []GoogleChatWidget{
+		widget("Kind", e.Kind),
+		widget("Namespace", e.Namespace),
+		widget("Name", e.Name),
+		widget("Event", e.Reason),
+	}}}
+
+	return &GoogleChatMessage{
+		Text:    message,
+		CardsV2: []GoogleChatCard{card},
+	}
+}
+
+func widget(label, text string) GoogleChatWidget {
+	w := GoogleChatWidget{}
+	w.DecoratedText.TopLabel = label
+	w.DecoratedText.Text = text
+	return w
+}
+
+// truncate shortens s to max characters, marking the cut with an ellipsis
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	if max <= 1 {
+		return "…"
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+func postMessage(ctx context.Context, client *http.Client, url string, googleChatMessage *GoogleChatMessage) error {
+	message, err := json.Marshal(googleChatMessage)
+	if err != nil {
+		return err
+	}
+	if len(message) > maxMessageSize {
+		return fmt.Errorf("Google Chat message of %d bytes exceeds the %d bytes limit", len(message), maxMessageSize)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json; charset=UTF-8")
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		chatErr := &GoogleChatError{Status: resp.Status, Body: string(body)}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				chatErr.Retry = time.Duration(seconds) * time.Second
+			}
+		}
+		return chatErr
+	}
+	return nil
+}
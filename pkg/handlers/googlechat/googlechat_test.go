@@ -0,0 +1,119 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package googlechat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+func TestGoogleChatInit(t *testing.T) {
+	s := &GoogleChat{}
+	expectedError := fmt.Errorf(googleChatErrMsg, "Missing Google Chat webhook url")
+
+	var Tests = []struct {
+		googleChat config.GoogleChat
+		err        error
+	}{
+		{config.GoogleChat{WebhookURL: "foo"}, nil},
+		{config.GoogleChat{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.GoogleChat = tt.googleChat
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestGoogleChatMessage(t *testing.T) {
+	var messages []GoogleChatMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var m GoogleChatMessage
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			t.Errorf("expected a Google Chat message: %v", err)
+		}
+		messages = append(messages, m)
+	}))
+	defer ts.Close()
+
+	g := &GoogleChat{WebhookURL: ts.URL}
+	if err := g.ObjectDeleted(context.Background(), kbEvent.Event{Kind: "pod", Name: "default/foo", Namespace: "default"}); err != nil {
+		t.Fatalf("ObjectDeleted(): %v", err)
+	}
+
+	if len(messages) != 1 || len(messages[0].CardsV2) != 1 {
+		t.Fatalf("expected a message with a card, got %v", messages)
+	}
+	card := messages[0].CardsV2[0].Card
+	if card.Header.Title != "kubewatch" || len(card.Sections) != 1 {
+		t.Fatalf("expected a kubewatch card with a section, got %v", card)
+	}
+	expected := []GoogleChatWidget{
+		widget("Kind", "pod"),
+		widget("Namespace", "default"),
+		widget("Name", "default/foo"),
+		widget("Event", "deleted"),
+	}
+	if !reflect.DeepEqual(card.Sections[0].Widgets, expected) {
+		t.Fatalf("expected widgets %v, got %v", expected, card.Sections[0].Widgets)
+	}
+}
+
+func TestGoogleChatTruncate(t *testing.T) {
+	e := kbEvent.Event{Kind: "pod", Name: strings.Repeat("a", 50000), Namespace: "default", Reason: "created"}
+	m := prepareGoogleChatMessage(e)
+
+	if text := []rune(m.Text); len(text) != maxTextLength {
+		t.Fatalf("expected a text of %d characters, got %d", maxTextLength, len(text))
+	}
+	message, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(message) > maxMessageSize {
+		t.Fatalf("expected a message within %d bytes, got %d", maxMessageSize, len(message))
+	}
+}
+
+func TestGoogleChatRateLimited(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	g := &GoogleChat{WebhookURL: ts.URL}
+	err := g.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "foo", Namespace: "default"})
+	var chatErr *GoogleChatError
+	if !errors.As(err, &chatErr) || chatErr.RetryAfter() != 30*time.Second {
+		t.Fatalf("ObjectCreated(): expected a retry after 30s, got %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gotify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
+)
+
+var gotifyErrMsg = `
+%s
+
+You need to set both the Gotify server url and application token,
+using "--url/-u" and "--token/-t", or using environment variables:
+
+export KW_GOTIFY_URL=https://gotify.example.com
+export KW_GOTIFY_TOKEN=gotify_application_token
+
+Command line flags will override environment variables
+
+`
+
+// gotifyPriorities maps the actions of the handler to the priorities of their messages
+var gotifyPriorities = map[string]int{
+	"created": 2,
+	"updated": 5,
+	"deleted": 8,
+}
+
+// Gotify handler implements handler.Handler interface,
+// Notify event to a Gotify server
+type Gotify struct {
+	Url   string
+	Token string
+	// Priority overrides the priorities of the actions when set
+	Priority int
+
+	client *http.Client
+}
+
+// GotifyMessage is the payload of the message endpoint
+type GotifyMessage struct {
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+	Priority int    `json:"priority"`
+}
+
+// Init prepares Gotify configuration
+func (g *Gotify) Init(c *config.Config) error {
+	url := c.Handler.Gotify.Url
+	token := c.Handler.Gotify.Token
+
+	if url == "" {
+		url = os.Getenv("KW_GOTIFY_URL")
+	}
+
+	if token == "" {
+		token = os.Getenv("KW_GOTIFY_TOKEN")
+	}
+
+	g.Url = url
+	g.Token = token
+	g.Priority = c.Handler.Gotify.Priority
+
+	client, err := utils.NewHTTPClient(c.Handler.HTTP)
+	if err != nil {
+		return err
+	}
+	g.client = client
+
+	return checkMissingGotifyVars(g)
+}
+
+// ObjectCreated calls notifyGotify on event creation
+func (g *Gotify) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifyGotify(ctx, g, obj, "created")
+}
+
+// ObjectDeleted calls notifyGotify on event creation
+func (g *Gotify) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifyGotify(ctx, g, obj, "deleted")
+}
+
+// ObjectUpdated calls notifyGotify on event creation
+func (g *Gotify) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifyGotify(ctx, g, newObj, "updated")
+}
+
+// TestHandler tests the handler configurarion by sending test messages.
+func (g *Gotify) TestHandler() {
+	gotifyMessage := &GotifyMessage{
+		Title:    "kubewatch",
+		Message:  "Testing Handler Configuration. This is a Test message.",
+		Priority: g.Priority,
+	}
+
+	if err := postMessage(context.Background(), g, gotifyMessage); err != nil {
+		log.Printf("%s\n", err)
+		return
+	}
+
+	log.Printf("Message successfully sent to %s", g.Url)
+}
+
+func notifyGotify(ctx context.Context, g *Gotify, obj interface{}, action string) error {
+	e := kbEvent.New(obj, action)
+
+	if err := postMessage(ctx, g, prepareGotifyMessage(e, g, action)); err != nil {
+		return err
+	}
+
+	log.Printf("Message successfully sent to %s", g.Url)
+	return nil
+}
+
+func checkMissingGotifyVars(g *Gotify) error {
+	if g.Url == "" || g.Token == "" {
+		return fmt.Errorf(gotifyErrMsg, "Missing Gotify url or token")
+	}
+
+	return nil
+}
+
+func prepareGotifyMessage(e kbEvent.Event, g *Gotify, action string) *GotifyMessage {
+	priority := g.Priority
+	if priority == 0 {
+		priority = gotifyPriorities[action]
+	}
+
+	title := fmt.Sprintf("%s %s", e.Kind, e.Reason)
+	if e.Cluster != "" {
+		title = fmt.Sprintf("[%s] %s", e.Cluster, title)
+	}
+
+	return &GotifyMessage{
+		Title:    title,
+		Message:  e.Message(),
+		Priority: priority,
+	}
+}
+
+func postMessage(ctx context.Context, g *Gotify, gotifyMessage *GotifyMessage) error {
+	message, err := json.Marshal(gotifyMessage)
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/message?token=%s", strings.TrimSuffix(g.Url, "/"), url.QueryEscape(g.Token))
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	client := g.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// the error holds the url, don't leak the token
+		return fmt.Errorf("Failed sending to Gotify: %v", strings.Replace(err.Error(), url.QueryEscape(g.Token), "<token>", -1))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("Failed sending to Gotify, got %s: %s", resp.Status, body)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gotify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+func TestGotifyInit(t *testing.T) {
+	s := &Gotify{}
+	expectedError := fmt.Errorf(gotifyErrMsg, "Missing Gotify url or token")
+
+	var Tests = []struct {
+		gotify config.Gotify
+		err    error
+	}{
+		{config.Gotify{Url: "https://gotify.example.com", Token: "foo"}, nil},
+		{config.Gotify{Url: "https://gotify.example.com"}, expectedError},
+		{config.Gotify{Token: "foo"}, expectedError},
+		{config.Gotify{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Gotify = tt.gotify
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestGotifyMessage(t *testing.T) {
+	var messages []GotifyMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/message" || r.URL.Query().Get("token") != "foo" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		var m GotifyMessage
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			t.Errorf("expected a Gotify message: %v", err)
+		}
+		messages = append(messages, m)
+	}))
+	defer ts.Close()
+
+	g := &Gotify{Url: ts.URL + "/", Token: "foo"}
+	e := kbEvent.Event{Kind: "pod", Name: "web", Namespace: "default", Cluster: "prod"}
+	if err := g.ObjectCreated(context.Background(), e); err != nil {
+		t.Fatalf("ObjectCreated(): %v", err)
+	}
+	if err := g.ObjectDeleted(context.Background(), e); err != nil {
+		t.Fatalf("ObjectDeleted(): %v", err)
+	}
+	g.Priority = 10
+	if err := g.ObjectCreated(context.Background(), e); err != nil {
+		t.Fatalf("ObjectCreated(): %v", err)
+	}
+
+	if len(messages) != 3 {
+		t.Fatalf("expected 3 messages, got %v", messages)
+	}
+	if messages[0].Title != "[prod] pod created" || !strings.Contains(messages[0].Message, "default") {
+		t.Errorf("unexpected message %+v", messages[0])
+	}
+	for i, priority := range []int{2, 8, 10} {
+		if messages[i].Priority != priority {
+			t.Errorf("expected message %d with priority %d, got %d", i, priority, messages[i].Priority)
+		}
+	}
+}
+
+func TestGotifyError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":"Unauthorized","errorCode":401}`)
+	}))
+	defer ts.Close()
+
+	g := &Gotify{Url: ts.URL, Token: "foo"}
+	err := g.ObjectDeleted(context.Background(), kbEvent.Event{Kind: "pod", Name: "web"})
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected the error status to be returned, got %v", err)
+	}
+}
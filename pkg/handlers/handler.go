@@ -17,34 +17,109 @@ limitations under the License.
 package handlers
 
 import (
+	"context"
+	"reflect"
+	"time"
+
 	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers/datadog"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers/discord"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers/elasticsearch"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers/email"
 	"github.com/mudasirmirza/kubewatch/pkg/handlers/flock"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers/googlechat"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers/gotify"
 	"github.com/mudasirmirza/kubewatch/pkg/handlers/hipchat"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers/jira"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers/kafka"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers/matrix"
 	"github.com/mudasirmirza/kubewatch/pkg/handlers/mattermost"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers/mqtt"
 	"github.com/mudasirmirza/kubewatch/pkg/handlers/msteam"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers/nats"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers/opsgenie"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers/pagerduty"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers/pushover"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers/sentry"
 	"github.com/mudasirmirza/kubewatch/pkg/handlers/slack"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers/sns"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers/stdout"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers/syslog"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers/tcp"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers/telegram"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers/victorops"
+	"github.com/mudasirmirza/kubewatch/pkg/handlers/webex"
 	"github.com/mudasirmirza/kubewatch/pkg/handlers/webhook"
 )
 
 // Handler is implemented by any handler.
-// The Handle method is used to process event
+// The Handle method is used to process event, an error
+// returned by the Object methods makes the controller retry the event.
+// The context of the Object methods is cancelled once the event timed out,
+// handlers pass it on to their requests
+// Handlers holding connections or buffered events may implement io.Closer,
+// they are closed once the controllers drained their queues on exit
 type Handler interface {
 	Init(c *config.Config) error
-	ObjectCreated(obj interface{})
-	ObjectDeleted(obj interface{})
-	ObjectUpdated(oldObj, newObj interface{})
+	ObjectCreated(ctx context.Context, obj interface{}) error
+	ObjectDeleted(ctx context.Context, obj interface{}) error
+	ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error
 	TestHandler()
 }
 
+// DefaultTimeout bounds the handling of an event unless configured otherwise
+const DefaultTimeout = 30 * time.Second
+
+// detached returns a context bounded by timeout, DefaultTimeout when 0, for the events
+// sent once the one handled by the controller returned, e.g. the coalesced ones
+func detached(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
 // Map maps each event handler function to a name for easily lookup
 var Map = map[string]interface{}{
-	"default":    &Default{},
-	"slack":      &slack.Slack{},
-	"hipchat":    &hipchat.Hipchat{},
-	"mattermost": &mattermost.Mattermost{},
-	"flock":      &flock.Flock{},
-	"webhook":    &webhook.Webhook{},
-	"ms-teams":   &msteam.MSTeams{},
+	"default":       &Default{},
+	"slack":         &slack.Slack{},
+	"hipchat":       &hipchat.Hipchat{},
+	"mattermost":    &mattermost.Mattermost{},
+	"flock":         &flock.Flock{},
+	"webhook":       &webhook.Webhook{},
+	"ms-teams":      &msteam.MSTeams{},
+	"pagerduty":     &pagerduty.PagerDuty{},
+	"discord":       &discord.Discord{},
+	"telegram":      &telegram.Telegram{},
+	"email":         &email.Email{},
+	"sns":           &sns.SNS{},
+	"opsgenie":      &opsgenie.Opsgenie{},
+	"googlechat":    &googlechat.GoogleChat{},
+	"kafka":         &kafka.Kafka{},
+	"stdout":        &stdout.Stdout{},
+	"syslog":        &syslog.Syslog{},
+	"elasticsearch": &elasticsearch.Elasticsearch{},
+	"nats":          &nats.NATS{},
+	"mqtt":          &mqtt.MQTT{},
+	"sentry":        &sentry.Sentry{},
+	"gotify":        &gotify.Gotify{},
+	"pushover":      &pushover.Pushover{},
+	"webex":         &webex.Webex{},
+	"victorops":     &victorops.VictorOps{},
+	"matrix":        &matrix.Matrix{},
+	"datadog":       &datadog.Datadog{},
+	"tcp":           &tcp.TCP{},
+	"jira":          &jira.Jira{},
+}
+
+// New returns a new instance of the handler of the given name, unlike the shared
+// instances of Map, e.g. for a reloaded config to not touch the running handler
+func New(name string) (Handler, bool) {
+	h, ok := Map[name].(Handler)
+	if !ok {
+		return nil, false
+	}
+	return reflect.New(reflect.TypeOf(h).Elem()).Interface().(Handler), true
 }
 
 // Default handler implements Handler interface,
@@ -59,18 +134,18 @@ func (d *Default) Init(c *config.Config) error {
 }
 
 // ObjectCreated sends events on object creation
-func (d *Default) ObjectCreated(obj interface{}) {
-
+func (d *Default) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return nil
 }
 
 // ObjectDeleted sends events on object deletion
-func (d *Default) ObjectDeleted(obj interface{}) {
-
+func (d *Default) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return nil
 }
 
 // ObjectUpdated sends events on object updation
-func (d *Default) ObjectUpdated(oldObj, newObj interface{}) {
-
+func (d *Default) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return nil
 }
 
 // TestHandler tests the handler configurarion by sending test messages.
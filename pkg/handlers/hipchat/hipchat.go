@@ -17,8 +17,10 @@ limitations under the License.
 package hipchat
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 
 	hipchat "github.com/tbruyelle/hipchat-go/hipchat"
@@ -28,6 +30,7 @@ import (
 	"github.com/mudasirmirza/kubewatch/config"
 	"github.com/mudasirmirza/kubewatch/pkg/event"
 	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
 )
 
 var hipchatColors = map[string]hipchat.Color{
@@ -56,6 +59,8 @@ type Hipchat struct {
 	Token string
 	Room  string
 	Url   string
+
+	client *http.Client
 }
 
 // Init prepares hipchat configuration
@@ -80,28 +85,37 @@ func (s *Hipchat) Init(c *config.Config) error {
 	s.Room = room
 	s.Url = url
 
+	client, err := utils.NewHTTPClient(c.Handler.HTTP)
+	if err != nil {
+		return err
+	}
+	s.client = client
+
 	return checkMissingHipchatVars(s)
 }
 
 // ObjectCreated calls notifyHipchat on event creation
-func (s *Hipchat) ObjectCreated(obj interface{}) {
-	notifyHipchat(s, obj, "created")
+func (s *Hipchat) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifyHipchat(ctx, s, obj, "created")
 }
 
 // ObjectDeleted calls notifyHipchat on event creation
-func (s *Hipchat) ObjectDeleted(obj interface{}) {
-	notifyHipchat(s, obj, "deleted")
+func (s *Hipchat) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifyHipchat(ctx, s, obj, "deleted")
 }
 
 // ObjectUpdated calls notifyHipchat on event creation
-func (s *Hipchat) ObjectUpdated(oldObj, newObj interface{}) {
-	notifyHipchat(s, newObj, "updated")
+func (s *Hipchat) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifyHipchat(ctx, s, newObj, "updated")
 }
 
 // TestHandler tests the handler configurarion by sending test messages.
 func (s *Hipchat) TestHandler() {
 
 	client := hipchat.NewClient(s.Token)
+	if s.client != nil {
+		client.SetHTTPClient(s.client)
+	}
 	if s.Url != "" {
 		baseUrl, err := url.Parse(s.Url)
 		if err != nil {
@@ -125,27 +139,33 @@ func (s *Hipchat) TestHandler() {
 	log.Printf("Message successfully sent to room %s", s.Room)
 }
 
-func notifyHipchat(s *Hipchat, obj interface{}, action string) {
+func notifyHipchat(ctx context.Context, s *Hipchat, obj interface{}, action string) error {
 	e := kbEvent.New(obj, action)
 
 	client := hipchat.NewClient(s.Token)
+	if s.client != nil {
+		client.SetHTTPClient(s.client)
+	}
 	if s.Url != "" {
 		baseUrl, err := url.Parse(s.Url)
 		if err != nil {
-			panic(err)
+			return err
 		}
 		client.BaseURL = baseUrl
 	}
 
+	// the room notification of the hipchat package takes no context, its request is sent the same way
 	notificationRequest := prepareHipchatNotification(e)
-	_, err := client.Room.Notification(s.Room, &notificationRequest)
-
+	req, err := client.NewRequest("POST", fmt.Sprintf("room/%s/notification", s.Room), nil, &notificationRequest)
 	if err != nil {
-		log.Printf("%s\n", err)
-		return
+		return err
+	}
+	if _, err := client.Do(req.WithContext(ctx), nil); err != nil {
+		return err
 	}
 
 	log.Printf("Message successfully sent to room %s", s.Room)
+	return nil
 }
 
 func checkMissingHipchatVars(s *Hipchat) error {
@@ -0,0 +1,74 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"context"
+	"io"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/metrics"
+)
+
+// Instrumented wraps a handler and counts the successes and failures of its sends
+type Instrumented struct {
+	Handler Handler
+	// Name labels the metrics of the handler, e.g. slack
+	Name string
+}
+
+// Init initializes the wrapped handler
+func (i *Instrumented) Init(c *config.Config) error {
+	return i.Handler.Init(c)
+}
+
+// ObjectCreated passes the created event to the wrapped handler and counts the result
+func (i *Instrumented) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return i.count(i.Handler.ObjectCreated(ctx, obj))
+}
+
+// ObjectDeleted passes the deleted event to the wrapped handler and counts the result
+func (i *Instrumented) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return i.count(i.Handler.ObjectDeleted(ctx, obj))
+}
+
+// ObjectUpdated passes the updated event to the wrapped handler and counts the result
+func (i *Instrumented) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return i.count(i.Handler.ObjectUpdated(ctx, oldObj, newObj))
+}
+
+// TestHandler tests the wrapped handler configuration
+func (i *Instrumented) TestHandler() {
+	i.Handler.TestHandler()
+}
+
+// Close closes the wrapped handler when it holds resources
+func (i *Instrumented) Close() error {
+	if closer, ok := i.Handler.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (i *Instrumented) count(err error) error {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	metrics.HandlerSends.WithLabelValues(i.Name, result).Inc()
+	return err
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mudasirmirza/kubewatch/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var errSendFailed = errors.New("send failed")
+
+// failingHandler fails every send
+type failingHandler struct {
+	Default
+}
+
+func (h *failingHandler) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return errSendFailed
+}
+
+func TestInstrumented(t *testing.T) {
+	ok := &Instrumented{Handler: &Default{}, Name: "instrumented-ok"}
+	failing := &Instrumented{Handler: &failingHandler{}, Name: "instrumented-failing"}
+
+	ok.ObjectCreated(context.Background(), nil)
+	ok.ObjectUpdated(context.Background(), nil, nil)
+	if err := failing.ObjectCreated(context.Background(), nil); err == nil {
+		t.Error("expected the error of the wrapped handler")
+	}
+	failing.ObjectDeleted(context.Background(), nil)
+
+	for _, c := range []struct {
+		handler, result string
+		expected        float64
+	}{
+		{"instrumented-ok", "success", 2},
+		{"instrumented-ok", "failure", 0},
+		{"instrumented-failing", "success", 1},
+		{"instrumented-failing", "failure", 1},
+	} {
+		got := testutil.ToFloat64(metrics.HandlerSends.WithLabelValues(c.handler, c.result))
+		if got != c.expected {
+			t.Errorf("%s %s sends: expected %v, got %v", c.handler, c.result, c.expected, got)
+		}
+	}
+}
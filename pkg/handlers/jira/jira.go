@@ -0,0 +1,313 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
+)
+
+// DefaultIssueType is the type of the created issues unless configured otherwise
+const DefaultIssueType = "Task"
+
+// maxSummaryLength is the limit of the summary of Jira issues
+const maxSummaryLength = 255
+
+var jiraErrMsg = `
+%s
+
+You need to set the Jira base url, username, api token and project key,
+using "--url/-u", "--username/-n", "--token/-t" and "--project/-p", or using environment variables:
+
+export KW_JIRA_BASEURL=jira_base_url
+export KW_JIRA_USERNAME=jira_username
+export KW_JIRA_APITOKEN=jira_api_token
+export KW_JIRA_PROJECTKEY=jira_project_key
+
+Command line flags will override environment variables
+
+`
+
+// eventTypes maps the actions of the handler to event types
+var eventTypes = map[string]string{
+	"created": "create",
+	"updated": "update",
+	"deleted": "delete",
+}
+
+// codeSpans matches the markdown code spans of event messages
+var codeSpans = regexp.MustCompile("`([^`]*)`")
+
+// Jira handler implements handler.Handler interface,
+// Create an issue in a Jira project per event
+type Jira struct {
+	BaseURL    string
+	Username   string
+	APIToken   string
+	ProjectKey string
+	IssueType  string
+	// EventTypes creating issues, all of them when empty
+	EventTypes []string
+
+	client *http.Client
+}
+
+// JiraIssue is the payload of the create issue API
+type JiraIssue struct {
+	Fields JiraFields `json:"fields"`
+}
+
+// JiraFields are the fields of a created issue
+type JiraFields struct {
+	Project     JiraProject   `json:"project"`
+	IssueType   JiraIssueType `json:"issuetype"`
+	Summary     string        `json:"summary"`
+	Description string        `json:"description"`
+	Labels      []string      `json:"labels"`
+}
+
+// JiraProject identifies the project of an issue by its key
+type JiraProject struct {
+	Key string `json:"key"`
+}
+
+// JiraIssueType identifies the type of an issue by its name
+type JiraIssueType struct {
+	Name string `json:"name"`
+}
+
+// JiraError is a failed request, asking to retry after a delay when rate limited
+type JiraError struct {
+	Status string
+	Body   string
+	Retry  time.Duration
+}
+
+func (e *JiraError) Error() string {
+	return fmt.Sprintf("Failed sending to Jira, got %s: %s", e.Status, e.Body)
+}
+
+// RetryAfter returns the delay asked for by Jira before retrying
+func (e *JiraError) RetryAfter() time.Duration {
+	return e.Retry
+}
+
+// Init prepares Jira configuration
+func (j *Jira) Init(c *config.Config) error {
+	baseURL := c.Handler.Jira.BaseURL
+	username := c.Handler.Jira.Username
+	apiToken := c.Handler.Jira.APIToken
+	projectKey := c.Handler.Jira.ProjectKey
+	issueType := c.Handler.Jira.IssueType
+
+	if baseURL == "" {
+		baseURL = os.Getenv("KW_JIRA_BASEURL")
+	}
+
+	if username == "" {
+		username = os.Getenv("KW_JIRA_USERNAME")
+	}
+
+	if apiToken == "" {
+		apiToken = os.Getenv("KW_JIRA_APITOKEN")
+	}
+
+	if projectKey == "" {
+		projectKey = os.Getenv("KW_JIRA_PROJECTKEY")
+	}
+
+	if issueType == "" {
+		issueType = DefaultIssueType
+	}
+
+	j.BaseURL = baseURL
+	j.Username = username
+	j.APIToken = apiToken
+	j.ProjectKey = projectKey
+	j.IssueType = issueType
+	j.EventTypes = c.Handler.Jira.EventTypes
+
+	client, err := utils.NewHTTPClient(c.Handler.HTTP)
+	if err != nil {
+		return err
+	}
+	j.client = client
+
+	return checkMissingJiraVars(j)
+}
+
+// ObjectCreated calls notifyJira on event creation
+func (j *Jira) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifyJira(ctx, j, obj, "created")
+}
+
+// ObjectDeleted calls notifyJira on event creation
+func (j *Jira) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifyJira(ctx, j, obj, "deleted")
+}
+
+// ObjectUpdated calls notifyJira on event creation
+func (j *Jira) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifyJira(ctx, j, newObj, "updated")
+}
+
+// TestHandler tests the handler configurarion by checking the access to the project,
+// no test issue is created
+func (j *Jira) TestHandler() {
+	if err := j.Check(context.Background()); err != nil {
+		log.Printf("%s\n", err)
+	}
+}
+
+// Check checks the credentials and the access to the project by getting it
+func (j *Jira) Check(ctx context.Context) error {
+	if err := do(ctx, j, "GET", "/rest/api/2/project/"+url.PathEscape(j.ProjectKey), nil); err != nil {
+		return err
+	}
+
+	log.Printf("Jira user %s has access to project %s", j.Username, j.ProjectKey)
+	return nil
+}
+
+func notifyJira(ctx context.Context, j *Jira, obj interface{}, action string) error {
+	if !j.creates(eventTypes[action]) {
+		return nil
+	}
+
+	e := kbEvent.New(obj, action)
+	if err := do(ctx, j, "POST", "/rest/api/2/issue", prepareJiraIssue(e, j)); err != nil {
+		return err
+	}
+
+	log.Printf("Issue successfully created in project %s", j.ProjectKey)
+	return nil
+}
+
+func checkMissingJiraVars(j *Jira) error {
+	if j.BaseURL == "" || j.Username == "" || j.APIToken == "" || j.ProjectKey == "" {
+		return fmt.Errorf(jiraErrMsg, "Missing Jira base url, username, api token or project key")
+	}
+
+	return nil
+}
+
+// creates reports whether the events of an event type create issues, all of them without event types
+func (j *Jira) creates(eventType string) bool {
+	if len(j.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range j.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// prepareJiraIssue returns the issue of an event, labelled with the resource key of its object
+// so that the issues of an object can be found, e.g. to detect duplicates
+func prepareJiraIssue(e kbEvent.Event, j *Jira) *JiraIssue {
+	summary := fmt.Sprintf("%s %s %s", e.Kind, e.Name, e.Reason)
+	if e.Cluster != "" {
+		summary = fmt.Sprintf("[%s] %s", e.Cluster, summary)
+	}
+
+	return &JiraIssue{
+		Fields: JiraFields{
+			Project:     JiraProject{Key: j.ProjectKey},
+			IssueType:   JiraIssueType{Name: j.IssueType},
+			Summary:     truncate(summary, maxSummaryLength),
+			Description: codeSpans.ReplaceAllString(e.Message(), "{{$1}}"),
+			Labels:      []string{"kubewatch", resourceLabel(e)},
+		},
+	}
+}
+
+// truncate shortens s to max characters, marking the cut with an ellipsis
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+// resourceLabel returns the label of the issues of the object of an event,
+// labels can't contain spaces, e.g. the ones of the kind replica set
+func resourceLabel(e kbEvent.Event) string {
+	return "kubewatch:" + strings.Replace(e.Key(), " ", "-", -1)
+}
+
+// do sends a request to the REST API of Jira with the JSON payload, if any
+func do(ctx context.Context, j *Jira, method, path string, payload interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		message, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewBuffer(message)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(j.BaseURL, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	if payload != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+	req.Header.Add("Accept", "application/json")
+	req.SetBasicAuth(j.Username, j.APIToken)
+
+	client := j.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		jiraErr := &JiraError{Status: resp.Status, Body: string(respBody)}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				jiraErr.Retry = time.Duration(seconds) * time.Second
+			}
+		}
+		return jiraErr
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+func TestJiraInit(t *testing.T) {
+	s := &Jira{}
+	expectedError := fmt.Errorf(jiraErrMsg, "Missing Jira base url, username, api token or project key")
+
+	var Tests = []struct {
+		jira config.Jira
+		err  error
+	}{
+		{config.Jira{BaseURL: "https://example.atlassian.net", Username: "bot@example.com", APIToken: "foo", ProjectKey: "OPS"}, nil},
+		{config.Jira{Username: "bot@example.com", APIToken: "foo", ProjectKey: "OPS"}, expectedError},
+		{config.Jira{BaseURL: "https://example.atlassian.net", Username: "bot@example.com", APIToken: "foo"}, expectedError},
+		{config.Jira{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Jira = tt.jira
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+	if s.IssueType != DefaultIssueType {
+		t.Errorf("expected the default issue type, got %q", s.IssueType)
+	}
+}
+
+func TestJiraIssue(t *testing.T) {
+	var issues []JiraIssue
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/rest/api/2/issue" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if user, token, ok := r.BasicAuth(); !ok || user != "bot@example.com" || token != "foo" {
+			t.Errorf("expected basic auth of bot@example.com, got %q", r.Header.Get("Authorization"))
+		}
+		var issue JiraIssue
+		if err := json.NewDecoder(r.Body).Decode(&issue); err != nil {
+			t.Fatal(err)
+		}
+		issues = append(issues, issue)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": "10000", "key": "OPS-1"}`))
+	}))
+	defer ts.Close()
+
+	j := &Jira{BaseURL: ts.URL, Username: "bot@example.com", APIToken: "foo", ProjectKey: "OPS", IssueType: "Bug", EventTypes: []string{"delete"}}
+	e := kbEvent.Event{Kind: "replica set", Name: "default/web", Namespace: "default", Cluster: "prod"}
+	if err := j.ObjectCreated(context.Background(), e); err != nil {
+		t.Fatalf("ObjectCreated(): %v", err)
+	}
+	if err := j.ObjectDeleted(context.Background(), e); err != nil {
+		t.Fatalf("ObjectDeleted(): %v", err)
+	}
+	if len(issues) != 1 {
+		t.Fatalf("expected an issue for the deletion only, got %v", issues)
+	}
+
+	fields := issues[0].Fields
+	if fields.Project.Key != "OPS" || fields.IssueType.Name != "Bug" || fields.Summary != "[prod] replica set default/web deleted" {
+		t.Errorf("expected a Bug in OPS for the deleted replica set, got %+v", fields)
+	}
+	if !reflect.DeepEqual(fields.Labels, []string{"kubewatch", "kubewatch:prod/replica-set/default/web"}) {
+		t.Errorf("expected the resource key as label, got %v", fields.Labels)
+	}
+	if strings.Contains(fields.Description, "`") || !strings.Contains(fields.Description, "{{default/web}}") {
+		t.Errorf("expected code spans as monospace text, got %q", fields.Description)
+	}
+}
+
+func TestJiraError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"errorMessages": ["Rate limit exceeded"]}`))
+	}))
+	defer ts.Close()
+
+	j := &Jira{BaseURL: ts.URL, Username: "bot@example.com", APIToken: "foo", ProjectKey: "OPS"}
+	err := j.ObjectDeleted(context.Background(), kbEvent.Event{Kind: "pod", Name: "web"})
+	var jiraErr *JiraError
+	if !errors.As(err, &jiraErr) || jiraErr.RetryAfter() != 30*time.Second {
+		t.Fatalf("expected a rate limit error retrying after 30s, got %v", err)
+	}
+}
+
+func TestJiraCheck(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/rest/api/2/project/OPS" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errorMessages": ["No project could be found"]}`))
+			return
+		}
+		w.Write([]byte(`{"key": "OPS"}`))
+	}))
+	defer ts.Close()
+
+	j := &Jira{BaseURL: ts.URL, Username: "bot@example.com", APIToken: "foo", ProjectKey: "OPS"}
+	if err := j.Check(context.Background()); err != nil {
+		t.Fatalf("Check(): %v", err)
+	}
+
+	j.ProjectKey = "OSP"
+	if err := j.Check(context.Background()); err == nil || !strings.Contains(err.Error(), "No project") {
+		t.Errorf("expected the project not to be found, got %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+var kafkaErrMsg = `
+%s
+
+You need to set the Kafka brokers and topic
+using "--brokers/-b", "--topic/-t" or using environment variables:
+
+export KW_KAFKA_BROKERS=kafka_broker,other_kafka_broker
+export KW_KAFKA_TOPIC=kafka_topic
+
+Command line flags will override environment variables
+
+`
+
+// Kafka handler implements handler.Handler interface,
+// Produce event records to a Kafka topic
+type Kafka struct {
+	Brokers []string
+	Topic   string
+
+	writer messageWriter
+}
+
+// messageWriter produces messages, implemented by kafka.Writer
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// KafkaMessage is the JSON record produced per event
+type KafkaMessage struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Reason    string `json:"reason"`
+	Status    string `json:"status"`
+	Cluster   string `json:"cluster,omitempty"`
+	Message   string `json:"message"`
+	Time      string `json:"time"`
+}
+
+// Init prepares Kafka configuration and creates the producer shared by all events
+func (k *Kafka) Init(c *config.Config) error {
+	brokers := c.Handler.Kafka.Brokers
+	topic := c.Handler.Kafka.Topic
+
+	if len(brokers) == 0 && os.Getenv("KW_KAFKA_BROKERS") != "" {
+		brokers = strings.Split(os.Getenv("KW_KAFKA_BROKERS"), ",")
+	}
+
+	if topic == "" {
+		topic = os.Getenv("KW_KAFKA_TOPIC")
+	}
+
+	k.Brokers = brokers
+	k.Topic = topic
+
+	if err := checkMissingKafkaVars(k); err != nil {
+		return err
+	}
+
+	transport, err := newTransport(c.Handler.Kafka)
+	if err != nil {
+		return err
+	}
+	k.writer = &kafka.Writer{
+		Addr:  kafka.TCP(k.Brokers...),
+		Topic: k.Topic,
+		// records of an object share a key, hashing keeps them in one partition and in order
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		// events are written one by one, don't wait for a batch to fill up
+		BatchTimeout: 10 * time.Millisecond,
+		Transport:    transport,
+	}
+	return nil
+}
+
+// ObjectCreated calls notifyKafka on event creation
+func (k *Kafka) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifyKafka(ctx, k, obj, "created")
+}
+
+// ObjectDeleted calls notifyKafka on event creation
+func (k *Kafka) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifyKafka(ctx, k, obj, "deleted")
+}
+
+// ObjectUpdated calls notifyKafka on event creation
+func (k *Kafka) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifyKafka(ctx, k, newObj, "updated")
+}
+
+// TestHandler tests the handler configurarion by sending test messages.
+func (k *Kafka) TestHandler() {
+	err := k.writer.WriteMessages(context.Background(), kafka.Message{
+		Key:   []byte("kubewatch"),
+		Value: []byte("Testing Handler Configuration. This is a Test message."),
+	})
+	if err != nil {
+		log.Printf("%s\n", err)
+		return
+	}
+
+	log.Printf("Message successfully produced to %s", k.Topic)
+}
+
+// Close flushes the pending records and closes the producer
+func (k *Kafka) Close() error {
+	if k.writer == nil {
+		return nil
+	}
+	return k.writer.Close()
+}
+
+func notifyKafka(ctx context.Context, k *Kafka, obj interface{}, action string) error {
+	e := kbEvent.New(obj, action)
+
+	message, err := prepareKafkaMessage(e)
+	if err != nil {
+		return err
+	}
+	if err := k.writer.WriteMessages(ctx, message); err != nil {
+		return fmt.Errorf("Failed producing to Kafka topic %s: %v", k.Topic, err)
+	}
+
+	log.Printf("Message successfully produced to %s", k.Topic)
+	return nil
+}
+
+func checkMissingKafkaVars(k *Kafka) error {
+	if len(k.Brokers) == 0 || k.Topic == "" {
+		return fmt.Errorf(kafkaErrMsg, "Missing Kafka brokers or topic")
+	}
+
+	return nil
+}
+
+// newTransport returns the transport of the producer with the configured SASL and TLS settings
+func newTransport(c config.Kafka) (*kafka.Transport, error) {
+	transport := &kafka.Transport{}
+
+	if c.SASL.Mechanism != "" {
+		var mechanism sasl.Mechanism
+		var err error
+		switch strings.ToLower(c.SASL.Mechanism) {
+		case config.KafkaSASLPlain:
+			mechanism = plain.Mechanism{Username: c.SASL.Username, Password: c.SASL.Password}
+		case config.KafkaSASLScramSHA256:
+			mechanism, err = scram.Mechanism(scram.SHA256, c.SASL.Username, c.SASL.Password)
+		case config.KafkaSASLScramSHA512:
+			mechanism, err = scram.Mechanism(scram.SHA512, c.SASL.Username, c.SASL.Password)
+		default:
+			err = fmt.Errorf("unsupported SASL mechanism %q", c.SASL.Mechanism)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Failed configuring Kafka SASL: %v", err)
+		}
+		transport.SASL = mechanism
+	}
+
+	if c.TLS.Enabled {
+		tlsConfig := &tls.Config{InsecureSkipVerify: c.TLS.InsecureSkipVerify}
+		if c.TLS.CAFile != "" {
+			ca, err := ioutil.ReadFile(c.TLS.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("Failed reading Kafka CA file: %v", err)
+			}
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+				return nil, fmt.Errorf("Failed parsing Kafka CA file %s", c.TLS.CAFile)
+			}
+		}
+		transport.TLS = tlsConfig
+	}
+
+	return transport, nil
+}
+
+// recordKey returns the namespace/name key of the object of an event
+func recordKey(e kbEvent.Event) string {
+	name := e.Name
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if e.Namespace == "" {
+		return name
+	}
+	return e.Namespace + "/" + name
+}
+
+func prepareKafkaMessage(e kbEvent.Event) (kafka.Message, error) {
+	value, err := json.Marshal(KafkaMessage{
+		Kind:      e.Kind,
+		Name:      e.Name,
+		Namespace: e.Namespace,
+		Reason:    e.Reason,
+		Status:    e.Status,
+		Cluster:   e.Cluster,
+		Message:   e.Message(),
+		Time:      time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return kafka.Message{}, err
+	}
+
+	return kafka.Message{
+		Key:   []byte(recordKey(e)),
+		Value: value,
+	}, nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+// fakeWriter records the produced messages
+type fakeWriter struct {
+	messages []kafka.Message
+	err      error
+	closed   bool
+}
+
+func (f *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.messages = append(f.messages, msgs...)
+	return nil
+}
+
+func (f *fakeWriter) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestKafkaInit(t *testing.T) {
+	s := &Kafka{}
+	expectedError := fmt.Errorf(kafkaErrMsg, "Missing Kafka brokers or topic")
+
+	var Tests = []struct {
+		kafka config.Kafka
+		err   error
+	}{
+		{config.Kafka{Brokers: []string{"localhost:9092"}, Topic: "kubewatch"}, nil},
+		{config.Kafka{
+			Brokers: []string{"localhost:9092"},
+			Topic:   "kubewatch",
+			SASL:    config.KafkaSASL{Mechanism: "SCRAM-SHA-512", Username: "foo", Password: "bar"},
+			TLS:     config.KafkaTLS{Enabled: true},
+		}, nil},
+		{config.Kafka{Topic: "kubewatch"}, expectedError},
+		{config.Kafka{Brokers: []string{"localhost:9092"}}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Kafka = tt.kafka
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestKafkaMessage(t *testing.T) {
+	writer := &fakeWriter{}
+	k := &Kafka{Topic: "kubewatch", writer: writer}
+
+	if err := k.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "default/foo", Namespace: "default", Cluster: "prod"}); err != nil {
+		t.Fatalf("ObjectCreated(): %v", err)
+	}
+	if err := k.ObjectDeleted(context.Background(), kbEvent.Event{Kind: "namespace", Name: "foo"}); err != nil {
+		t.Fatalf("ObjectDeleted(): %v", err)
+	}
+
+	if len(writer.messages) != 2 {
+		t.Fatalf("expected 2 messages, got %v", writer.messages)
+	}
+	for i, key := range []string{"default/foo", "foo"} {
+		if string(writer.messages[i].Key) != key {
+			t.Fatalf("expected key %q, got %q", key, writer.messages[i].Key)
+		}
+	}
+
+	var m KafkaMessage
+	if err := json.Unmarshal(writer.messages[0].Value, &m); err != nil {
+		t.Fatalf("expected a JSON record: %v", err)
+	}
+	if m.Kind != "pod" || m.Reason != "created" || m.Cluster != "prod" || m.Time == "" {
+		t.Fatalf("unexpected record %+v", m)
+	}
+
+	if err := k.Close(); err != nil || !writer.closed {
+		t.Fatalf("Close(): expected the producer to be closed, got %v", err)
+	}
+}
+
+func TestKafkaError(t *testing.T) {
+	k := &Kafka{Topic: "kubewatch", writer: &fakeWriter{err: errors.New("leader not available")}}
+	if err := k.ObjectUpdated(context.Background(), nil, kbEvent.Event{Kind: "pod", Name: "default/foo", Namespace: "default"}); err == nil {
+		t.Fatal("ObjectUpdated(): expected the producer error to be returned")
+	}
+}
@@ -0,0 +1,282 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package matrix
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
+)
+
+// matrixColors hints the status of events in the formatted body
+var matrixColors = map[string]string{
+	"Normal":  "#2eb886",
+	"Warning": "#daa038",
+	"Danger":  "#a30200",
+}
+
+var matrixErrMsg = `
+%s
+
+You need to set the Matrix homeserver url, access token and room id,
+using "--homeserver/-s", "--token/-t" and "--roomid/-r", or using environment variables:
+
+export KW_MATRIX_HOMESERVER=matrix_homeserver_url
+export KW_MATRIX_ACCESSTOKEN=matrix_access_token
+export KW_MATRIX_ROOMID=matrix_room_id
+
+Command line flags will override environment variables
+
+`
+
+// codeSpans matches the markdown code spans of event messages
+var codeSpans = regexp.MustCompile("`([^`]*)`")
+
+// Matrix handler implements handler.Handler interface,
+// Notify event to a Matrix room through the client-server API
+type Matrix struct {
+	Homeserver  string
+	AccessToken string
+	RoomID      string
+
+	client *http.Client
+}
+
+// MatrixMessage is the content of a m.room.message event
+type MatrixMessage struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format,omitempty"`
+	FormattedBody string `json:"formatted_body,omitempty"`
+}
+
+// MatrixError is a failed request, asking to retry after a delay when rate limited
+type MatrixError struct {
+	Status string
+	Body   string
+	Retry  time.Duration
+}
+
+func (e *MatrixError) Error() string {
+	return fmt.Sprintf("Failed sending to Matrix, got %s: %s", e.Status, e.Body)
+}
+
+// RetryAfter returns the delay asked for by the homeserver before retrying
+func (e *MatrixError) RetryAfter() time.Duration {
+	return e.Retry
+}
+
+// Init prepares Matrix configuration
+func (m *Matrix) Init(c *config.Config) error {
+	homeserver := c.Handler.Matrix.Homeserver
+	accessToken := c.Handler.Matrix.AccessToken
+	roomID := c.Handler.Matrix.RoomID
+
+	if homeserver == "" {
+		homeserver = os.Getenv("KW_MATRIX_HOMESERVER")
+	}
+
+	if accessToken == "" {
+		accessToken = os.Getenv("KW_MATRIX_ACCESSTOKEN")
+	}
+
+	if roomID == "" {
+		roomID = os.Getenv("KW_MATRIX_ROOMID")
+	}
+
+	m.Homeserver = homeserver
+	m.AccessToken = accessToken
+	m.RoomID = roomID
+
+	client, err := utils.NewHTTPClient(c.Handler.HTTP)
+	if err != nil {
+		return err
+	}
+	m.client = client
+
+	return checkMissingMatrixVars(m)
+}
+
+// ObjectCreated calls notifyMatrix on event creation
+func (m *Matrix) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifyMatrix(ctx, m, obj, "created")
+}
+
+// ObjectDeleted calls notifyMatrix on event deletion
+func (m *Matrix) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifyMatrix(ctx, m, obj, "deleted")
+}
+
+// ObjectUpdated calls notifyMatrix on event update
+func (m *Matrix) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifyMatrix(ctx, m, newObj, "updated")
+}
+
+// TestHandler tests the handler configurarion by sending test messages.
+func (m *Matrix) TestHandler() {
+	matrixMessage := &MatrixMessage{
+		MsgType: "m.notice",
+		Body:    "Testing Handler Configuration. This is a Test message.",
+	}
+
+	txnID := strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := sendMessage(context.Background(), m, txnID, matrixMessage); err != nil {
+		log.Printf("%s\n", err)
+		return
+	}
+
+	log.Printf("Message successfully sent to room %s", m.RoomID)
+}
+
+// Check checks the access token and the membership of the room by listing its joined members
+func (m *Matrix) Check(ctx context.Context) error {
+	endpoint := fmt.Sprintf("%s/_matrix/client/r0/rooms/%s/joined_members",
+		strings.TrimSuffix(m.Homeserver, "/"), url.PathEscape(m.RoomID))
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Authorization", "Bearer "+m.AccessToken)
+
+	client := m.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return &MatrixError{Status: resp.Status, Body: string(body)}
+	}
+
+	log.Printf("Matrix user of the access token has joined room %s", m.RoomID)
+	return nil
+}
+
+func notifyMatrix(ctx context.Context, m *Matrix, obj interface{}, action string) error {
+	e := kbEvent.New(obj, action)
+
+	matrixMessage := prepareMatrixMessage(e)
+	if err := sendMessage(ctx, m, transactionID(e, matrixMessage), matrixMessage); err != nil {
+		return err
+	}
+
+	log.Printf("Message successfully sent to room %s at %s", m.RoomID, time.Now())
+	return nil
+}
+
+func checkMissingMatrixVars(m *Matrix) error {
+	if m.Homeserver == "" || m.AccessToken == "" || m.RoomID == "" {
+		return fmt.Errorf(matrixErrMsg, "Missing Matrix homeserver, access token or room id")
+	}
+
+	return nil
+}
+
+// prepareMatrixMessage renders the event as plain text, and as HTML colored after its status
+func prepareMatrixMessage(e kbEvent.Event) *MatrixMessage {
+	title := fmt.Sprintf("%s %s %s", e.Kind, e.Name, e.Reason)
+	if e.Cluster != "" {
+		title = fmt.Sprintf("[%s] %s", e.Cluster, title)
+	}
+	message := e.Message()
+
+	color, ok := matrixColors[e.Status]
+	if !ok {
+		color = matrixColors["Normal"]
+	}
+	formatted := codeSpans.ReplaceAllString(html.EscapeString(message), "<code>$1</code>")
+	formatted = strings.Replace(formatted, "\n", "<br/>", -1)
+
+	return &MatrixMessage{
+		MsgType: "m.notice",
+		Body:    title + "\n" + message,
+		Format:  "org.matrix.custom.html",
+		FormattedBody: fmt.Sprintf(`<font data-mx-color="%s"><b>%s</b></font><br/>%s`,
+			color, html.EscapeString(title), formatted),
+	}
+}
+
+// transactionID identifies the message of an event by the key and resource version of its object,
+// a retried event reuses it so the homeserver doesn't send it twice. The reason and message tell
+// apart the events of a single version, e.g. the missing backends of an ingress.
+func transactionID(e kbEvent.Event, matrixMessage *MatrixMessage) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{e.Key(), e.ResourceVersion, e.Reason, matrixMessage.Body}, "\x00")))
+	return "kubewatch-" + hex.EncodeToString(sum[:16])
+}
+
+func sendMessage(ctx context.Context, m *Matrix, txnID string, matrixMessage *MatrixMessage) error {
+	message, err := json.Marshal(matrixMessage)
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/_matrix/client/r0/rooms/%s/send/m.room.message/%s",
+		strings.TrimSuffix(m.Homeserver, "/"), url.PathEscape(m.RoomID), url.PathEscape(txnID))
+	req, err := http.NewRequestWithContext(ctx, "PUT", endpoint, bytes.NewBuffer(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+m.AccessToken)
+
+	client := m.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		matrixErr := &MatrixError{Status: resp.Status, Body: string(body)}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			var limited struct {
+				RetryAfterMs int64 `json:"retry_after_ms"`
+			}
+			if json.Unmarshal(body, &limited) == nil {
+				matrixErr.Retry = time.Duration(limited.RetryAfterMs) * time.Millisecond
+			}
+		}
+		return matrixErr
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package matrix
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+func TestMatrixInit(t *testing.T) {
+	s := &Matrix{}
+	expectedError := fmt.Errorf(matrixErrMsg, "Missing Matrix homeserver, access token or room id")
+
+	var Tests = []struct {
+		matrix config.Matrix
+		err    error
+	}{
+		{config.Matrix{Homeserver: "https://matrix.example.com", AccessToken: "foo", RoomID: "!bar:example.com"}, nil},
+		{config.Matrix{AccessToken: "foo", RoomID: "!bar:example.com"}, expectedError},
+		{config.Matrix{Homeserver: "https://matrix.example.com", RoomID: "!bar:example.com"}, expectedError},
+		{config.Matrix{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Matrix = tt.matrix
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestMatrixMessage(t *testing.T) {
+	var paths []string
+	var messages []MatrixMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" || r.Header.Get("Authorization") != "Bearer foo" {
+			t.Errorf("unexpected %s request authorized by %q", r.Method, r.Header.Get("Authorization"))
+		}
+		var m MatrixMessage
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			t.Errorf("expected a Matrix message: %v", err)
+		}
+		paths = append(paths, r.URL.EscapedPath())
+		messages = append(messages, m)
+		w.Write([]byte(`{"event_id": "$baz"}`))
+	}))
+	defer ts.Close()
+
+	m := &Matrix{Homeserver: ts.URL + "/", AccessToken: "foo", RoomID: "!bar:example.com"}
+	e := kbEvent.Event{Kind: "pod", Name: "web", Namespace: "default", Status: "Danger", Cluster: "prod", ResourceVersion: "42"}
+	for i := 0; i < 2; i++ {
+		if err := m.ObjectDeleted(context.Background(), e); err != nil {
+			t.Fatalf("ObjectDeleted(): %v", err)
+		}
+	}
+
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %v", messages)
+	}
+	if !strings.HasPrefix(paths[0], "/_matrix/client/r0/rooms/%21bar:example.com/send/m.room.message/kubewatch-") {
+		t.Errorf("unexpected path %s", paths[0])
+	}
+	// a retried event is sent with the same transaction id
+	if paths[0] != paths[1] {
+		t.Errorf("expected the same transaction id, got %s and %s", paths[0], paths[1])
+	}
+	if expected := kbEvent.New(e, "deleted"); messages[0].Body != "[prod] pod web deleted\n"+expected.Message() {
+		t.Errorf("unexpected body %q", messages[0].Body)
+	}
+	if formatted := messages[0].FormattedBody; !strings.HasPrefix(formatted, `<font data-mx-color="#a30200"><b>[prod] pod web deleted</b></font><br/>`) ||
+		!strings.Contains(formatted, "<code>web</code>") {
+		t.Errorf("unexpected formatted body %q", formatted)
+	}
+}
+
+func TestMatrixTransactionID(t *testing.T) {
+	e := kbEvent.Event{Kind: "pod", Name: "web", Namespace: "default", Reason: "updated", ResourceVersion: "42"}
+	next := e
+	next.ResourceVersion = "43"
+	if transactionID(e, prepareMatrixMessage(e)) == transactionID(next, prepareMatrixMessage(next)) {
+		t.Fatal("expected the versions of an object to be sent with different transaction ids")
+	}
+}
+
+func TestMatrixRateLimit(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"errcode": "M_LIMIT_EXCEEDED", "retry_after_ms": 2000}`))
+	}))
+	defer ts.Close()
+
+	m := &Matrix{Homeserver: ts.URL, AccessToken: "foo", RoomID: "!bar:example.com"}
+	err := m.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "web"})
+	var matrixErr *MatrixError
+	if !errors.As(err, &matrixErr) || matrixErr.RetryAfter() != 2*time.Second {
+		t.Fatalf("expected a rate limit error retrying after 2s, got %v", err)
+	}
+}
+
+func TestMatrixCheck(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.EscapedPath() != "/_matrix/client/r0/rooms/%21bar:example.com/joined_members" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.EscapedPath())
+		}
+		if r.Header.Get("Authorization") != "Bearer foo" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"errcode": "M_UNKNOWN_TOKEN"}`))
+			return
+		}
+		w.Write([]byte(`{"joined": {}}`))
+	}))
+	defer ts.Close()
+
+	m := &Matrix{Homeserver: ts.URL, AccessToken: "foo", RoomID: "!bar:example.com"}
+	if err := m.Check(context.Background()); err != nil {
+		t.Fatalf("Check(): %v", err)
+	}
+
+	m.AccessToken = "typo"
+	var matrixErr *MatrixError
+	if err := m.Check(context.Background()); !errors.As(err, &matrixErr) || !strings.Contains(matrixErr.Body, "M_UNKNOWN_TOKEN") {
+		t.Errorf("expected the token to be unknown, got %v", err)
+	}
+}
@@ -17,17 +17,22 @@ limitations under the License.
 package mattermost
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 
 	"bytes"
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/mudasirmirza/kubewatch/config"
 	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
 )
 
 var mattermostColors = map[string]string{
@@ -36,6 +41,9 @@ var mattermostColors = map[string]string{
 	"Danger":  "#FF0000",
 }
 
+// maxThreads bounds the root posts remembered to thread the notifications of objects
+const maxThreads = 10000
+
 var mattermostErrMsg = `
 %s
 
@@ -50,12 +58,34 @@ Command line flags will override environment variables
 
 `
 
+var mattermostBotErrMsg = `
+%s
+
+You need to set Mattermost url, bot token and channel id for Mattermost bot notify,
+using "--url/-u", "--token/-t" and "--channelid/-i", or using environment variables:
+
+export KW_MATTERMOST_URL=mattermost_url
+export KW_MATTERMOST_TOKEN=mattermost_bot_token
+export KW_MATTERMOST_CHANNELID=mattermost_channel_id
+
+Command line flags will override environment variables
+
+`
+
 // Mattermost handler implements handler.Handler interface,
 // Notify event to Mattermost channel
 type Mattermost struct {
 	Channel  string
 	Url      string
 	Username string
+	// Token and ChannelID post as a bot through the REST API, Url being the server url
+	Token     string
+	ChannelID string
+
+	mu sync.Mutex
+	// threads holds the root post of the objects notified as a bot, by event key
+	threads map[string]string
+	client  *http.Client
 }
 
 // MattermostMessage struct for messages
@@ -73,11 +103,22 @@ type MattermostMessageAttachement struct {
 	Color string `json:"color"`
 }
 
+// MattermostPost is a post created through the REST API, threaded under RootID
+type MattermostPost struct {
+	ID        string                 `json:"id,omitempty"`
+	ChannelID string                 `json:"channel_id"`
+	RootID    string                 `json:"root_id,omitempty"`
+	Message   string                 `json:"message"`
+	Props     map[string]interface{} `json:"props,omitempty"`
+}
+
 // Init prepares Mattermost configuration
 func (m *Mattermost) Init(c *config.Config) error {
 	channel := c.Handler.Mattermost.Channel
 	url := c.Handler.Mattermost.Url
 	username := c.Handler.Mattermost.Username
+	token := c.Handler.Mattermost.Token
+	channelID := c.Handler.Mattermost.ChannelID
 
 	if channel == "" {
 		channel = os.Getenv("KW_MATTERMOST_CHANNEL")
@@ -91,30 +132,59 @@ func (m *Mattermost) Init(c *config.Config) error {
 		username = os.Getenv("KW_MATTERMOST_USERNAME")
 	}
 
+	if token == "" {
+		token = os.Getenv("KW_MATTERMOST_TOKEN")
+	}
+
+	if channelID == "" {
+		channelID = os.Getenv("KW_MATTERMOST_CHANNELID")
+	}
+
 	m.Channel = channel
 	m.Url = url
 	m.Username = username
+	m.Token = token
+	m.ChannelID = channelID
+
+	client, err := utils.NewHTTPClient(c.Handler.HTTP)
+	if err != nil {
+		return err
+	}
+	m.client = client
 
 	return checkMissingMattermostVars(m)
 }
 
 // ObjectCreated calls notifyMattermost on event creation
-func (m *Mattermost) ObjectCreated(obj interface{}) {
-	notifyMattermost(m, obj, "created")
+func (m *Mattermost) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifyMattermost(ctx, m, obj, "created")
 }
 
 // ObjectDeleted calls notifyMattermost on event creation
-func (m *Mattermost) ObjectDeleted(obj interface{}) {
-	notifyMattermost(m, obj, "deleted")
+func (m *Mattermost) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifyMattermost(ctx, m, obj, "deleted")
 }
 
 // ObjectUpdated calls notifyMattermost on event creation
-func (m *Mattermost) ObjectUpdated(oldObj, newObj interface{}) {
-	notifyMattermost(m, newObj, "updated")
+func (m *Mattermost) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifyMattermost(ctx, m, newObj, "updated")
 }
 
 // TestHandler tests the handler configurarion by sending test messages.
 func (m *Mattermost) TestHandler() {
+	if m.bot() {
+		mattermostPost := &MattermostPost{
+			ChannelID: m.ChannelID,
+			Message:   "Testing Handler Configuration. This is a Test message.",
+		}
+		if _, err := createPost(context.Background(), m, mattermostPost); err != nil {
+			log.Printf("%s\n", err)
+			return
+		}
+		log.Printf("Message successfully posted to channel %s at %s", m.ChannelID, time.Now())
+		return
+	}
+
 	mattermostMessage := &MattermostMessage{
 		Channel:  m.Channel,
 		Username: m.Username,
@@ -126,7 +196,7 @@ func (m *Mattermost) TestHandler() {
 		},
 	}
 
-	err := postMessage(m.Url, mattermostMessage)
+	err := postMessage(context.Background(), m.client, m.Url, mattermostMessage)
 	if err != nil {
 		log.Printf("%s\n", err)
 		return
@@ -135,21 +205,78 @@ func (m *Mattermost) TestHandler() {
 	log.Printf("Message successfully sent to channel %s at %s", m.Channel, time.Now())
 }
 
-func notifyMattermost(m *Mattermost, obj interface{}, action string) {
+func notifyMattermost(ctx context.Context, m *Mattermost, obj interface{}, action string) error {
 	e := kbEvent.New(obj, action)
+	if m.bot() {
+		return notifyMattermostBot(ctx, m, e, action)
+	}
 
 	mattermostMessage := prepareMattermostMessage(e, m)
 
-	err := postMessage(m.Url, mattermostMessage)
+	err := postMessage(ctx, m.client, m.Url, mattermostMessage)
 	if err != nil {
-		log.Printf("%s\n", err)
-		return
+		return err
 	}
 
 	log.Printf("Message successfully sent to channel %s at %s", m.Channel, time.Now())
+	return nil
+}
+
+// notifyMattermostBot posts the event as a bot, in reply to the first post of the object
+func notifyMattermostBot(ctx context.Context, m *Mattermost, e kbEvent.Event, action string) error {
+	key := e.Key()
+	mattermostPost := prepareMattermostPost(e, m)
+	mattermostPost.RootID = m.thread(key)
+
+	id, err := createPost(ctx, m, mattermostPost)
+	if err != nil {
+		// the root post may be gone, the retry starts a new thread
+		m.setThread(key, "")
+		return err
+	}
+	switch {
+	case action == "deleted":
+		m.setThread(key, "")
+	case mattermostPost.RootID == "":
+		m.setThread(key, id)
+	}
+
+	log.Printf("Message successfully posted to channel %s at %s", m.ChannelID, time.Now())
+	return nil
+}
+
+// bot tells whether events are posted as a bot rather than through the incoming webhook
+func (m *Mattermost) bot() bool {
+	return m.Token != "" || m.ChannelID != ""
+}
+
+func (m *Mattermost) thread(key string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.threads[key]
+}
+
+// setThread remembers the root post of an object, an empty id forgets it
+func (m *Mattermost) setThread(key, id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if id == "" {
+		delete(m.threads, key)
+		return
+	}
+	if m.threads == nil || len(m.threads) >= maxThreads {
+		m.threads = make(map[string]string)
+	}
+	m.threads[key] = id
 }
 
 func checkMissingMattermostVars(s *Mattermost) error {
+	if s.bot() {
+		if s.Url == "" || s.Token == "" || s.ChannelID == "" {
+			return fmt.Errorf(mattermostBotErrMsg, "Missing Mattermost url, token or channel id")
+		}
+		return nil
+	}
 	if s.Channel == "" || s.Url == "" || s.Username == "" {
 		return fmt.Errorf(mattermostErrMsg, "Missing Mattermost channel, url or username")
 	}
@@ -172,19 +299,71 @@ func prepareMattermostMessage(e kbEvent.Event, m *Mattermost) *MattermostMessage
 	}
 }
 
-func postMessage(url string, mattermostMessage *MattermostMessage) error {
+func prepareMattermostPost(e kbEvent.Event, m *Mattermost) *MattermostPost {
+	return &MattermostPost{
+		ChannelID: m.ChannelID,
+		Props: map[string]interface{}{
+			"attachments": []MattermostMessageAttachement{
+				{
+					Title: e.Message(),
+					Color: mattermostColors[e.Status],
+				},
+			},
+		},
+	}
+}
+
+// createPost creates a post through the REST API and returns its id
+func createPost(ctx context.Context, m *Mattermost, mattermostPost *MattermostPost) (string, error) {
+	message, err := json.Marshal(mattermostPost)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", strings.TrimSuffix(m.Url, "/")+"/api/v4/posts", bytes.NewBuffer(message))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+m.Token)
+
+	client := m.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return "", fmt.Errorf("Failed posting to Mattermost channel %s, got %s: %s", m.ChannelID, resp.Status, body)
+	}
+
+	var created MattermostPost
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return "", fmt.Errorf("Failed reading the Mattermost post: %v", err)
+	}
+	return created.ID, nil
+}
+
+func postMessage(ctx context.Context, client *http.Client, url string, mattermostMessage *MattermostMessage) error {
 	message, err := json.Marshal(mattermostMessage)
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(message))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(message))
 	if err != nil {
 		return err
 	}
 	req.Header.Add("Content-Type", "application/json")
 
-	client := &http.Client{}
+	if client == nil {
+		client = http.DefaultClient
+	}
 	_, err = client.Do(req)
 	if err != nil {
 		return err
@@ -17,11 +17,17 @@ limitations under the License.
 package mattermost
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strconv"
 	"testing"
 
 	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
 )
 
 func TestMattermostInit(t *testing.T) {
@@ -40,6 +46,9 @@ func TestMattermostInit(t *testing.T) {
 		{config.Mattermost{Channel: "bar"}, expectedError},
 		{config.Mattermost{Username: "bar"}, expectedError},
 		{config.Mattermost{}, expectedError},
+		{config.Mattermost{Url: "foo", Token: "bar", ChannelID: "baz"}, nil},
+		{config.Mattermost{Url: "foo", Token: "bar"}, fmt.Errorf(mattermostBotErrMsg, "Missing Mattermost url, token or channel id")},
+		{config.Mattermost{Token: "bar", ChannelID: "baz"}, fmt.Errorf(mattermostBotErrMsg, "Missing Mattermost url, token or channel id")},
 	}
 
 	for _, tt := range Tests {
@@ -50,3 +59,62 @@ func TestMattermostInit(t *testing.T) {
 		}
 	}
 }
+
+func TestMattermostBotThreads(t *testing.T) {
+	var posts []MattermostPost
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v4/posts" || r.Header.Get("Authorization") != "Bearer foo" {
+			t.Errorf("unexpected request %s with authorization %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var p MattermostPost
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			t.Errorf("expected a Mattermost post: %v", err)
+		}
+		posts = append(posts, p)
+		p.ID = "post" + strconv.Itoa(len(posts))
+		json.NewEncoder(w).Encode(p)
+	}))
+	defer ts.Close()
+
+	m := &Mattermost{Url: ts.URL + "/", Token: "foo", ChannelID: "bar"}
+	web := kbEvent.Event{Kind: "pod", Name: "web", Namespace: "default"}
+	db := kbEvent.Event{Kind: "pod", Name: "db", Namespace: "default"}
+	if err := m.ObjectCreated(context.Background(), web); err != nil {
+		t.Fatalf("ObjectCreated(): %v", err)
+	}
+	if err := m.ObjectCreated(context.Background(), db); err != nil {
+		t.Fatalf("ObjectCreated(): %v", err)
+	}
+	if err := m.ObjectUpdated(context.Background(), web, web); err != nil {
+		t.Fatalf("ObjectUpdated(): %v", err)
+	}
+	if err := m.ObjectDeleted(context.Background(), web); err != nil {
+		t.Fatalf("ObjectDeleted(): %v", err)
+	}
+	if err := m.ObjectCreated(context.Background(), web); err != nil {
+		t.Fatalf("ObjectCreated(): %v", err)
+	}
+
+	var roots []string
+	for _, p := range posts {
+		if p.ChannelID != "bar" {
+			t.Errorf("expected a post to channel bar, got %v", p)
+		}
+		roots = append(roots, p.RootID)
+	}
+	if expected := []string{"", "", "post1", "post1", ""}; !reflect.DeepEqual(roots, expected) {
+		t.Fatalf("expected root posts %q, got %q", expected, roots)
+	}
+}
+
+func TestMattermostBotError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	m := &Mattermost{Url: ts.URL, Token: "foo", ChannelID: "bar"}
+	if err := m.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "web"}); err == nil {
+		t.Fatalf("expected an error")
+	}
+}
@@ -0,0 +1,326 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtt
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+var mqttErrMsg = `
+%s
+
+You need to set the MQTT broker
+using "--broker/-b" or using environment variables:
+
+export KW_MQTT_BROKER=tcp://mqtt:1883
+export KW_MQTT_TOPIC=kubewatch/{{.Kind}}/{{.EventType}}
+
+Command line flags will override environment variables
+
+`
+
+// defaultTopic is the topic template used when none is configured
+const defaultTopic = "kubewatch/{{.Kind}}/{{.EventType}}"
+
+// publishTimeout bounds the wait for the broker to accept a message
+const publishTimeout = 30 * time.Second
+
+// errNotConnected is returned while the client reconnects, for the event to be retried
+var errNotConnected = errors.New("not connected to the MQTT broker")
+
+// MQTT handler implements handler.Handler interface,
+// Publish a JSON payload per event to a topic templated from the event
+type MQTT struct {
+	Broker   string
+	QoS      byte
+	Retained bool
+
+	topic  *template.Template
+	client publisher
+}
+
+// publisher publishes messages, implemented by mqtt.Client
+type publisher interface {
+	IsConnectionOpen() bool
+	Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token
+	Disconnect(quiesce uint)
+}
+
+// MQTTMessage is the JSON payload published per event
+type MQTTMessage struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	EventType string `json:"eventType"`
+	Reason    string `json:"reason"`
+	Status    string `json:"status"`
+	Cluster   string `json:"cluster,omitempty"`
+	Message   string `json:"message"`
+	Time      string `json:"time"`
+}
+
+// topicData are the fields of an event available to the topic template
+type topicData struct {
+	Kind      string
+	EventType string
+	Namespace string
+	Name      string
+	Cluster   string
+}
+
+// eventTypes maps the actions of the handler to the event types of the topics
+var eventTypes = map[string]string{
+	"created": "create",
+	"updated": "update",
+	"deleted": "delete",
+}
+
+// Init prepares MQTT configuration and connects the client shared by all events.
+// The client keeps reconnecting in the background when the broker is unreachable.
+func (m *MQTT) Init(c *config.Config) error {
+	broker := c.Handler.MQTT.Broker
+	topic := c.Handler.MQTT.Topic
+	username := c.Handler.MQTT.Username
+	password := c.Handler.MQTT.Password
+
+	if broker == "" {
+		broker = os.Getenv("KW_MQTT_BROKER")
+	}
+
+	if topic == "" {
+		topic = os.Getenv("KW_MQTT_TOPIC")
+	}
+	if topic == "" {
+		topic = defaultTopic
+	}
+
+	if username == "" {
+		username = os.Getenv("KW_MQTT_USERNAME")
+	}
+
+	if password == "" {
+		password = os.Getenv("KW_MQTT_PASSWORD")
+	}
+
+	if broker == "" {
+		return fmt.Errorf(mqttErrMsg, "Missing MQTT broker")
+	}
+
+	tmpl, err := template.New("topic").Parse(topic)
+	if err != nil {
+		return fmt.Errorf("Failed parsing MQTT topic: %v", err)
+	}
+
+	options := mqtt.NewClientOptions().
+		AddBroker(broker).
+		SetClientID(clientID(c.Handler.MQTT.ClientID)).
+		SetUsername(username).
+		SetPassword(password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(5 * time.Second).
+		SetMaxReconnectInterval(time.Minute).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			log.Printf("Lost connection to the MQTT broker: %v", err)
+		}).
+		SetOnConnectHandler(func(mqtt.Client) {
+			log.Printf("Connected to the MQTT broker %s", broker)
+		})
+	if c.Handler.MQTT.TLS.CAFile != "" || c.Handler.MQTT.TLS.InsecureSkipVerify {
+		tlsConfig, err := newTLSConfig(c.Handler.MQTT.TLS)
+		if err != nil {
+			return err
+		}
+		options.SetTLSConfig(tlsConfig)
+	}
+
+	client := mqtt.NewClient(options)
+	// the connection is retried until it succeeds, events fail meanwhile
+	if token := client.Connect(); token.WaitTimeout(10*time.Second) && token.Error() != nil {
+		return fmt.Errorf("Failed connecting to the MQTT broker: %v", token.Error())
+	}
+
+	m.Broker = broker
+	m.QoS = byte(c.Handler.MQTT.QoS)
+	m.Retained = c.Handler.MQTT.Retained
+	m.topic = tmpl
+	m.client = client
+	return nil
+}
+
+// clientID returns the configured client ID, or one per host for kubewatch
+// instances of different clusters not to take over each other's session
+func clientID(id string) string {
+	if id != "" {
+		return id
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "kubewatch"
+	}
+	return "kubewatch-" + hostname
+}
+
+// newTLSConfig returns the TLS config of ssl brokers with the configured settings
+func newTLSConfig(c config.MQTTTLS) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify}
+	if c.CAFile != "" {
+		ca, err := ioutil.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed reading MQTT CA file: %v", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("Failed parsing MQTT CA file %s", c.CAFile)
+		}
+	}
+	return tlsConfig, nil
+}
+
+// ObjectCreated calls notifyMQTT on event creation
+func (m *MQTT) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifyMQTT(ctx, m, obj, "created")
+}
+
+// ObjectDeleted calls notifyMQTT on event creation
+func (m *MQTT) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifyMQTT(ctx, m, obj, "deleted")
+}
+
+// ObjectUpdated calls notifyMQTT on event creation
+func (m *MQTT) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifyMQTT(ctx, m, newObj, "updated")
+}
+
+// TestHandler tests the handler configurarion by sending test messages.
+func (m *MQTT) TestHandler() {
+	topic, err := m.eventTopic(topicData{Kind: "test", EventType: "test"})
+	if err == nil {
+		err = m.publish(context.Background(), topic, []byte("Testing Handler Configuration. This is a Test message."))
+	}
+	if err != nil {
+		log.Printf("%s\n", err)
+		return
+	}
+
+	log.Printf("Message successfully published to %s", topic)
+}
+
+// Close disconnects from the broker, waiting for the messages in flight
+func (m *MQTT) Close() error {
+	if m.client != nil {
+		m.client.Disconnect(1000)
+	}
+	return nil
+}
+
+func notifyMQTT(ctx context.Context, m *MQTT, obj interface{}, action string) error {
+	e := kbEvent.New(obj, action)
+	data := newTopicData(e, eventTypes[action])
+
+	topic, err := m.eventTopic(data)
+	if err != nil {
+		return err
+	}
+	payload, err := prepareMQTTMessage(e, data.EventType)
+	if err != nil {
+		return err
+	}
+	if err := m.publish(ctx, topic, payload); err != nil {
+		return err
+	}
+
+	log.Printf("Message successfully published to %s", topic)
+	return nil
+}
+
+// publish publishes a payload and waits for the broker to accept it for QoS 1 and 2
+func (m *MQTT) publish(ctx context.Context, topic string, payload []byte) error {
+	// messages published while reconnecting would be silently dropped with QoS 0
+	if !m.client.IsConnectionOpen() {
+		return fmt.Errorf("Failed publishing to MQTT topic %s: %v", topic, errNotConnected)
+	}
+	token := m.client.Publish(topic, m.QoS, m.Retained, payload)
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+	select {
+	case <-token.Done():
+	case <-ctx.Done():
+		return fmt.Errorf("Failed publishing to MQTT topic %s: %v", topic, ctx.Err())
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("Failed publishing to MQTT topic %s: %v", topic, err)
+	}
+	return nil
+}
+
+// eventTopic renders the topic of an event
+func (m *MQTT) eventTopic(data topicData) (string, error) {
+	var topic bytes.Buffer
+	if err := m.topic.Execute(&topic, data); err != nil {
+		return "", fmt.Errorf("Failed rendering MQTT topic: %v", err)
+	}
+	return topic.String(), nil
+}
+
+// newTopicData returns the topic fields of an event. Topic level separators and
+// wildcards are dropped from the values, e.g. replica set stays a single level
+func newTopicData(e kbEvent.Event, eventType string) topicData {
+	name := e.Name
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	level := strings.NewReplacer("/", "", "+", "", "#", "")
+	return topicData{
+		Kind:      level.Replace(e.Kind),
+		EventType: eventType,
+		Namespace: level.Replace(e.Namespace),
+		Name:      level.Replace(name),
+		Cluster:   level.Replace(e.Cluster),
+	}
+}
+
+func prepareMQTTMessage(e kbEvent.Event, eventType string) ([]byte, error) {
+	return json.Marshal(MQTTMessage{
+		Kind:      e.Kind,
+		Name:      e.Name,
+		Namespace: e.Namespace,
+		EventType: eventType,
+		Reason:    e.Reason,
+		Status:    e.Status,
+		Cluster:   e.Cluster,
+		Message:   e.Message(),
+		Time:      time.Now().UTC().Format(time.RFC3339),
+	})
+}
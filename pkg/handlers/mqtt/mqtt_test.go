@@ -0,0 +1,141 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"text/template"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+// fakeToken is a completed publish
+type fakeToken struct {
+	err error
+}
+
+func (t *fakeToken) Wait() bool                     { return true }
+func (t *fakeToken) WaitTimeout(time.Duration) bool { return true }
+func (t *fakeToken) Error() error                   { return t.err }
+
+func (t *fakeToken) Done() <-chan struct{} {
+	done := make(chan struct{})
+	close(done)
+	return done
+}
+
+// fakeClient records the published messages
+type fakeClient struct {
+	disconnected bool
+	topics       []string
+	payloads     [][]byte
+	qos          byte
+	retained     bool
+	err          error
+}
+
+func (f *fakeClient) IsConnectionOpen() bool { return !f.disconnected }
+
+func (f *fakeClient) Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+	if f.err != nil {
+		return &fakeToken{err: f.err}
+	}
+	f.topics = append(f.topics, topic)
+	f.payloads = append(f.payloads, payload.([]byte))
+	f.qos, f.retained = qos, retained
+	return &fakeToken{}
+}
+
+func (f *fakeClient) Disconnect(quiesce uint) { f.disconnected = true }
+
+func TestMQTTInit(t *testing.T) {
+	s := &MQTT{}
+	c := &config.Config{}
+	c.Handler.MQTT.Topic = "kubewatch/{{.Kind}}"
+	if err := s.Init(c); !reflect.DeepEqual(err, fmt.Errorf(mqttErrMsg, "Missing MQTT broker")) {
+		t.Fatalf("Init(): %v", err)
+	}
+}
+
+func TestMQTTMessage(t *testing.T) {
+	client := &fakeClient{}
+	m := &MQTT{QoS: 1, Retained: true, topic: template.Must(template.New("topic").Parse(defaultTopic)), client: client}
+
+	if err := m.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "default/foo", Namespace: "default", Cluster: "edge-1"}); err != nil {
+		t.Fatalf("ObjectCreated(): %v", err)
+	}
+	if err := m.ObjectDeleted(context.Background(), kbEvent.Event{Kind: "replica set", Name: "default/foo", Namespace: "default"}); err != nil {
+		t.Fatalf("ObjectDeleted(): %v", err)
+	}
+
+	expected := []string{"kubewatch/pod/create", "kubewatch/replica set/delete"}
+	if !reflect.DeepEqual(client.topics, expected) {
+		t.Fatalf("expected topics %v, got %v", expected, client.topics)
+	}
+	if client.qos != 1 || !client.retained {
+		t.Fatalf("expected retained messages with QoS 1, got QoS %d retained %v", client.qos, client.retained)
+	}
+
+	var msg MQTTMessage
+	if err := json.Unmarshal(client.payloads[0], &msg); err != nil {
+		t.Fatalf("expected a JSON payload: %v", err)
+	}
+	if msg.Kind != "pod" || msg.EventType != "create" || msg.Cluster != "edge-1" || msg.Time == "" {
+		t.Fatalf("unexpected payload %+v", msg)
+	}
+
+	if err := m.Close(); err != nil || !client.disconnected {
+		t.Fatalf("Close(): expected the client to be disconnected, got %v", err)
+	}
+}
+
+func TestMQTTTopicTemplate(t *testing.T) {
+	client := &fakeClient{}
+	m := &MQTT{topic: template.Must(template.New("topic").Parse("clusters/{{.Cluster}}/{{.Namespace}}/{{.Kind}}/{{.Name}}")), client: client}
+
+	if err := m.ObjectUpdated(context.Background(), nil, kbEvent.Event{Kind: "pod", Name: "default/foo", Namespace: "default", Cluster: "edge-1"}); err != nil {
+		t.Fatalf("ObjectUpdated(): %v", err)
+	}
+	if expected := "clusters/edge-1/default/pod/foo"; len(client.topics) != 1 || client.topics[0] != expected {
+		t.Fatalf("expected topic %s, got %v", expected, client.topics)
+	}
+}
+
+func TestMQTTError(t *testing.T) {
+	topic := template.Must(template.New("topic").Parse(defaultTopic))
+	e := kbEvent.Event{Kind: "pod", Name: "default/foo", Namespace: "default"}
+
+	// messages published while reconnecting are retried instead of dropped
+	disconnected := &MQTT{topic: topic, client: &fakeClient{disconnected: true}}
+	if err := disconnected.ObjectCreated(context.Background(), e); err == nil {
+		t.Fatal("ObjectCreated(): expected an error while disconnected")
+	}
+
+	failing := &MQTT{topic: topic, client: &fakeClient{err: errors.New("connection lost before publish completed")}}
+	if err := failing.ObjectCreated(context.Background(), e); err == nil {
+		t.Fatal("ObjectCreated(): expected the publish error to be returned")
+	}
+}
@@ -18,6 +18,7 @@ package msteam
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -27,6 +28,7 @@ import (
 
 	"github.com/mudasirmirza/kubewatch/config"
 	"github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
 )
 
 var msteamsErrMsg = `
@@ -47,10 +49,21 @@ var msTeamsColors = map[string]string{
 	"Danger":  "8C1A1A",
 }
 
+// adaptiveCardColors maps event statuses to Adaptive Card text colors
+var adaptiveCardColors = map[string]string{
+	"Normal":  "Good",
+	"Warning": "Warning",
+	"Danger":  "Attention",
+}
+
 // Constants for Sending a Card
 const (
 	messageType = "MessageCard"
-	context     = "http://schema.org/extensions"
+	cardContext = "http://schema.org/extensions"
+
+	adaptiveCardContentType = "application/vnd.microsoft.card.adaptive"
+	adaptiveCardSchema      = "http://adaptivecards.io/schemas/adaptive-card.json"
+	adaptiveCardVersion     = "1.4"
 )
 
 // TeamsMessageCard is for the Card Fields to send in Teams
@@ -79,25 +92,116 @@ type TeamsMessageCardSectionFacts struct {
 	Value string `json:"value"`
 }
 
+// TeamsAdaptiveCardMessage is the message posted to a Power Automate Workflows webhook
+// The Documentation is in https://learn.microsoft.com/en-us/connectors/teams/#microsoft-teams-webhook
+type TeamsAdaptiveCardMessage struct {
+	Type        string                        `json:"type"`
+	Attachments []TeamsAdaptiveCardAttachment `json:"attachments"`
+}
+
+// TeamsAdaptiveCardAttachment is placed under TeamsAdaptiveCardMessage.Attachments
+type TeamsAdaptiveCardAttachment struct {
+	ContentType string            `json:"contentType"`
+	Content     TeamsAdaptiveCard `json:"content"`
+}
+
+// TeamsAdaptiveCard is the Adaptive Card of a TeamsAdaptiveCardAttachment
+// The Documentation is in https://adaptivecards.io/explorer/AdaptiveCard.html
+type TeamsAdaptiveCard struct {
+	Schema  string                     `json:"$schema"`
+	Type    string                     `json:"type"`
+	Version string                     `json:"version"`
+	Body    []TeamsAdaptiveCardElement `json:"body"`
+}
+
+// TeamsAdaptiveCardElement is a TextBlock or a FactSet of TeamsAdaptiveCard.Body
+type TeamsAdaptiveCardElement struct {
+	Type   string                  `json:"type"`
+	Text   string                  `json:"text,omitempty"`
+	Weight string                  `json:"weight,omitempty"`
+	Size   string                  `json:"size,omitempty"`
+	Color  string                  `json:"color,omitempty"`
+	Wrap   bool                    `json:"wrap,omitempty"`
+	Facts  []TeamsAdaptiveCardFact `json:"facts,omitempty"`
+}
+
+// TeamsAdaptiveCardFact is placed under the facts of a FactSet
+type TeamsAdaptiveCardFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
 // Default handler implements Handler interface,
 // print each event with JSON format
 type MSTeams struct {
-	// TeamsWebhookURL is the webhook url of the Teams connector
+	// TeamsWebhookURL is the webhook url of the Teams connector or workflow
 	TeamsWebhookURL string
+	// AdaptiveCard selects Adaptive Cards over the legacy MessageCards
+	AdaptiveCard bool
+
+	client *http.Client
+}
+
+// newAdaptiveCard returns the message of an Adaptive Card with a colored title and facts
+func newAdaptiveCard(title, color string, facts []TeamsAdaptiveCardFact) *TeamsAdaptiveCardMessage {
+	body := []TeamsAdaptiveCardElement{
+		{Type: "TextBlock", Text: title, Weight: "Bolder", Size: "Medium", Color: color, Wrap: true},
+	}
+	if len(facts) > 0 {
+		body = append(body, TeamsAdaptiveCardElement{Type: "FactSet", Facts: facts})
+	}
+	return &TeamsAdaptiveCardMessage{
+		Type: "message",
+		Attachments: []TeamsAdaptiveCardAttachment{
+			{
+				ContentType: adaptiveCardContentType,
+				Content: TeamsAdaptiveCard{
+					Schema:  adaptiveCardSchema,
+					Type:    "AdaptiveCard",
+					Version: adaptiveCardVersion,
+					Body:    body,
+				},
+			},
+		},
+	}
+}
+
+// eventAdaptiveCard returns the Adaptive Card of an event
+func eventAdaptiveCard(e event.Event) *TeamsAdaptiveCardMessage {
+	facts := []TeamsAdaptiveCardFact{{Title: "Kind", Value: e.Kind}}
+	if e.Namespace != "" {
+		facts = append(facts, TeamsAdaptiveCardFact{Title: "Namespace", Value: e.Namespace})
+	}
+	facts = append(facts,
+		TeamsAdaptiveCardFact{Title: "Name", Value: e.Name},
+		TeamsAdaptiveCardFact{Title: "Event", Value: e.Reason},
+	)
+	return newAdaptiveCard(e.Message(), adaptiveCardColors[e.Status], facts)
 }
 
-// sendCard sends the JSON Encoded TeamsMessageCard to the webhook URL
-func sendCard(ms *MSTeams, card *TeamsMessageCard) (*http.Response, error) {
+// sendCard sends the JSON Encoded card to the webhook URL
+func sendCard(ctx context.Context, ms *MSTeams, card interface{}) (*http.Response, error) {
 	buffer := new(bytes.Buffer)
 	if err := json.NewEncoder(buffer).Encode(card); err != nil {
 		return nil, fmt.Errorf("Failed encoding message card: %v", err)
 	}
-	res, err := http.Post(ms.TeamsWebhookURL, "application/json", buffer)
+	req, err := http.NewRequestWithContext(ctx, "POST", ms.TeamsWebhookURL, buffer)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := ms.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("Failed sending to webhook url %s. Got the error: %v",
 			ms.TeamsWebhookURL, err)
 	}
-	if res.StatusCode != http.StatusOK {
+	// workflows accept messages with 202 Accepted
+	if res.StatusCode < 200 || res.StatusCode > 299 {
 		resMessage, err := ioutil.ReadAll(res.Body)
 		if err != nil {
 			return nil, fmt.Errorf("Failed reading Teams http response: %v", err)
@@ -112,16 +216,23 @@ func sendCard(ms *MSTeams, card *TeamsMessageCard) (*http.Response, error) {
 }
 
 // notifyMSTeams creates the TeamsMessageCard and send to webhook URL
-func notifyMSTeams(ms *MSTeams, obj interface{}, action string) {
+func notifyMSTeams(ctx context.Context, ms *MSTeams, obj interface{}, action string) error {
+	e := event.New(obj, action)
+	if ms.AdaptiveCard {
+		if _, err := sendCard(ctx, ms, eventAdaptiveCard(e)); err != nil {
+			return err
+		}
+		log.Printf("Message successfully sent to MS Teams")
+		return nil
+	}
+
 	card := &TeamsMessageCard{
 		Type:    messageType,
-		Context: context,
+		Context: cardContext,
 		Title:   fmt.Sprintf("kubewatch"),
 		// Set a default Summary, this is required for Microsoft Teams
 		Summary: "kubewatch notification received",
 	}
-
-	e := event.New(obj, action)
 	card.ThemeColor = msTeamsColors[e.Status]
 
 	var s TeamsMessageCardSection
@@ -129,12 +240,12 @@ func notifyMSTeams(ms *MSTeams, obj interface{}, action string) {
 	s.Markdown = true
 	card.Sections = append(card.Sections, s)
 
-	if _, err := sendCard(ms, card); err != nil {
-		log.Printf("%s\n", err)
-		return
+	if _, err := sendCard(ctx, ms, card); err != nil {
+		return err
 	}
 
 	log.Printf("Message successfully sent to MS Teams")
+	return nil
 }
 
 // Init initializes handler configuration
@@ -150,29 +261,46 @@ func (ms *MSTeams) Init(c *config.Config) error {
 	}
 
 	ms.TeamsWebhookURL = webhookURL
+	ms.AdaptiveCard = c.Handler.MSTeams.AdaptiveCard
+
+	client, err := utils.NewHTTPClient(c.Handler.HTTP)
+	if err != nil {
+		return err
+	}
+	ms.client = client
 	return nil
 }
 
 // Notify on object creation
-func (ms *MSTeams) ObjectCreated(obj interface{}) {
-	notifyMSTeams(ms, obj, "created")
+func (ms *MSTeams) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifyMSTeams(ctx, ms, obj, "created")
 }
 
 // Notify on object deletion
-func (ms *MSTeams) ObjectDeleted(obj interface{}) {
-	notifyMSTeams(ms, obj, "deleted")
+func (ms *MSTeams) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifyMSTeams(ctx, ms, obj, "deleted")
 }
 
 // Notify on object update
-func (ms *MSTeams) ObjectUpdated(oldObj, newObj interface{}) {
-	notifyMSTeams(ms, oldObj, "updated")
+func (ms *MSTeams) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifyMSTeams(ctx, ms, oldObj, "updated")
 }
 
 // TestHandler tests the handler configurarion by sending test messages.
 func (ms *MSTeams) TestHandler() {
+	if ms.AdaptiveCard {
+		card := newAdaptiveCard("Testing Handler Configuration. This is a Test message.", adaptiveCardColors["Normal"], nil)
+		if _, err := sendCard(context.Background(), ms, card); err != nil {
+			log.Printf("%s\n", err)
+			return
+		}
+		log.Printf("Message successfully sent to MS Teams")
+		return
+	}
+
 	card := &TeamsMessageCard{
 		Type:    messageType,
-		Context: context,
+		Context: cardContext,
 		Title:   fmt.Sprintf("kubewatch"),
 		// Set a default Summary, this is required for Microsoft Teams
 		Summary: "kubewatch notification received",
@@ -183,7 +311,7 @@ func (ms *MSTeams) TestHandler() {
 	s.Markdown = true
 	card.Sections = append(card.Sections, s)
 
-	if _, err := sendCard(ms, card); err != nil {
+	if _, err := sendCard(context.Background(), ms, card); err != nil {
 		log.Printf("%s\n", err)
 		return
 	}
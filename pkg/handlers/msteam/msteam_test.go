@@ -1,6 +1,7 @@
 package msteam
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -39,14 +40,14 @@ func TestInit(t *testing.T) {
 func TestObjectCreated(t *testing.T) {
 	expectedCard := TeamsMessageCard{
 		Type:       messageType,
-		Context:    context,
+		Context:    cardContext,
 		ThemeColor: msTeamsColors["Normal"],
 		Summary:    "kubewatch notification received",
 		Title:      "kubewatch",
 		Text:       "",
 		Sections: []TeamsMessageCardSection{
 			{
-				ActivityTitle: "A `pod` in namespace `new` has been `created`:\n`foo`",
+				ActivityTitle: "A `Pod` in namespace `new` has been `created`:\n`foo`",
 				Markdown:      true,
 			},
 		},
@@ -79,21 +80,21 @@ func TestObjectCreated(t *testing.T) {
 			Namespace: "new",
 		},
 	}
-	ms.ObjectCreated(p)
+	ms.ObjectCreated(context.Background(), p)
 }
 
 // Tests ObjectDeleted() by passing v1.Pod
 func TestObjectDeleted(t *testing.T) {
 	expectedCard := TeamsMessageCard{
 		Type:       messageType,
-		Context:    context,
+		Context:    cardContext,
 		ThemeColor: msTeamsColors["Danger"],
 		Summary:    "kubewatch notification received",
 		Title:      "kubewatch",
 		Text:       "",
 		Sections: []TeamsMessageCardSection{
 			{
-				ActivityTitle: "A `pod` in namespace `new` has been `deleted`:\n`foo`",
+				ActivityTitle: "A `Pod` in namespace `new` has been `deleted`:\n`foo`",
 				Markdown:      true,
 			},
 		},
@@ -126,21 +127,21 @@ func TestObjectDeleted(t *testing.T) {
 			Namespace: "new",
 		},
 	}
-	ms.ObjectDeleted(p)
+	ms.ObjectDeleted(context.Background(), p)
 }
 
 // Tests ObjectUpdated() by passing v1.Pod
 func TestObjectUpdated(t *testing.T) {
 	expectedCard := TeamsMessageCard{
 		Type:       messageType,
-		Context:    context,
+		Context:    cardContext,
 		ThemeColor: msTeamsColors["Warning"],
 		Summary:    "kubewatch notification received",
 		Title:      "kubewatch",
 		Text:       "",
 		Sections: []TeamsMessageCardSection{
 			{
-				ActivityTitle: "A `pod` in namespace `new` has been `updated`:\n`foo`",
+				ActivityTitle: "A `Pod` in namespace `new` has been `updated`:\n`foo`",
 				Markdown:      true,
 			},
 		},
@@ -187,5 +188,81 @@ func TestObjectUpdated(t *testing.T) {
 		},
 	}
 
-	ms.ObjectUpdated(oldP, newP)
+	ms.ObjectUpdated(context.Background(), oldP, newP)
+}
+
+// Tests ObjectCreated() with Adaptive Cards by passing v1.Pod
+func TestObjectCreatedAdaptiveCard(t *testing.T) {
+	expectedCard := TeamsAdaptiveCardMessage{
+		Type: "message",
+		Attachments: []TeamsAdaptiveCardAttachment{
+			{
+				ContentType: adaptiveCardContentType,
+				Content: TeamsAdaptiveCard{
+					Schema:  adaptiveCardSchema,
+					Type:    "AdaptiveCard",
+					Version: "1.4",
+					Body: []TeamsAdaptiveCardElement{
+						{
+							Type:   "TextBlock",
+							Text:   "A `Pod` in namespace `new` has been `created`:\n`foo`",
+							Weight: "Bolder",
+							Size:   "Medium",
+							Color:  "Good",
+							Wrap:   true,
+						},
+						{
+							Type: "FactSet",
+							Facts: []TeamsAdaptiveCardFact{
+								{Title: "Kind", Value: "Pod"},
+								{Title: "Namespace", Value: "new"},
+								{Title: "Name", Value: "foo"},
+								{Title: "Event", Value: "created"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		var c TeamsAdaptiveCardMessage
+		if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+			t.Errorf("%v", err)
+		}
+		if !reflect.DeepEqual(c, expectedCard) {
+			t.Errorf("expected %v, got %v", expectedCard, c)
+		}
+	}))
+	defer ts.Close()
+
+	ms := &MSTeams{TeamsWebhookURL: ts.URL, AdaptiveCard: true}
+	p := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:       "12345678",
+			Name:      "foo",
+			Namespace: "new",
+		},
+	}
+	if err := ms.ObjectCreated(context.Background(), p); err != nil {
+		t.Fatalf("ObjectCreated(): %v", err)
+	}
+}
+
+// Tests that non-2xx responses are returned as errors
+func TestObjectCreatedError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	for _, adaptiveCard := range []bool{false, true} {
+		ms := &MSTeams{TeamsWebhookURL: ts.URL, AdaptiveCard: adaptiveCard}
+		p := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "new"}}
+		if err := ms.ObjectCreated(context.Background(), p); err == nil {
+			t.Errorf("adaptive card %v: expected an error for a 400 response", adaptiveCard)
+		}
+	}
 }
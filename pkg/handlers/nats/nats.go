@@ -0,0 +1,253 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nats
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+var natsErrMsg = `
+%s
+
+You need to set the NATS url
+using "--url/-u" or using environment variables:
+
+export KW_NATS_URL=nats://nats:4222
+export KW_NATS_SUBJECT=kubewatch
+
+Command line flags will override environment variables
+
+`
+
+// NATS handler implements handler.Handler interface,
+// Publish a JSON message per event to a subject per kind and event type
+type NATS struct {
+	URL     string
+	Subject string
+
+	conn publisher
+}
+
+// publisher publishes messages, implemented by nats.Conn
+type publisher interface {
+	Publish(subject string, data []byte) error
+	Drain() error
+}
+
+// NATSMessage is the JSON message published per event
+type NATSMessage struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	EventType string `json:"eventType"`
+	Reason    string `json:"reason"`
+	Status    string `json:"status"`
+	Cluster   string `json:"cluster,omitempty"`
+	Message   string `json:"message"`
+	Time      string `json:"time"`
+}
+
+// eventTypes maps the actions of the handler to the event types of the subjects
+var eventTypes = map[string]string{
+	"created": "create",
+	"updated": "update",
+	"deleted": "delete",
+}
+
+// Init prepares NATS configuration and opens the connection shared by all events.
+// The connection is retried in the background when the servers are unreachable.
+func (n *NATS) Init(c *config.Config) error {
+	url := c.Handler.NATS.URL
+	subject := c.Handler.NATS.Subject
+
+	if url == "" {
+		url = os.Getenv("KW_NATS_URL")
+	}
+
+	if subject == "" {
+		subject = os.Getenv("KW_NATS_SUBJECT")
+	}
+	if subject == "" {
+		subject = "kubewatch"
+	}
+
+	if url == "" {
+		return fmt.Errorf(natsErrMsg, "Missing NATS url")
+	}
+
+	options, err := connectOptions(c.Handler.NATS)
+	if err != nil {
+		return err
+	}
+	conn, err := nats.Connect(url, options...)
+	if err != nil {
+		return fmt.Errorf("Failed connecting to NATS: %v", err)
+	}
+
+	n.URL = url
+	n.Subject = subject
+	n.conn = conn
+	return nil
+}
+
+// connectOptions returns the options of the connection with the configured auth and TLS settings
+func connectOptions(c config.NATS) ([]nats.Option, error) {
+	options := []nats.Option{
+		nats.Name("kubewatch"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(2 * time.Second),
+		// fail publishes while disconnected instead of buffering them,
+		// the controller retries the events
+		nats.ReconnectBufSize(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				log.Printf("Disconnected from NATS: %v", err)
+			}
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			log.Printf("Reconnected to NATS %s", conn.ConnectedUrl())
+		}),
+	}
+
+	token := c.Token
+	if token == "" {
+		token = os.Getenv("KW_NATS_TOKEN")
+	}
+	if token != "" {
+		options = append(options, nats.Token(token))
+	}
+	if c.Username != "" {
+		options = append(options, nats.UserInfo(c.Username, c.Password))
+	}
+	if c.CredsFile != "" {
+		options = append(options, nats.UserCredentials(c.CredsFile))
+	}
+
+	if c.TLS.CAFile != "" || c.TLS.InsecureSkipVerify {
+		tlsConfig := &tls.Config{InsecureSkipVerify: c.TLS.InsecureSkipVerify}
+		if c.TLS.CAFile != "" {
+			ca, err := ioutil.ReadFile(c.TLS.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("Failed reading NATS CA file: %v", err)
+			}
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+				return nil, fmt.Errorf("Failed parsing NATS CA file %s", c.TLS.CAFile)
+			}
+		}
+		options = append(options, nats.Secure(tlsConfig))
+	}
+
+	return options, nil
+}
+
+// ObjectCreated calls notifyNATS on event creation
+func (n *NATS) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifyNATS(n, obj, "created")
+}
+
+// ObjectDeleted calls notifyNATS on event creation
+func (n *NATS) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifyNATS(n, obj, "deleted")
+}
+
+// ObjectUpdated calls notifyNATS on event creation
+func (n *NATS) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifyNATS(n, newObj, "updated")
+}
+
+// TestHandler tests the handler configurarion by sending test messages.
+func (n *NATS) TestHandler() {
+	subject := n.Subject + ".test"
+	if err := n.conn.Publish(subject, []byte("Testing Handler Configuration. This is a Test message.")); err != nil {
+		log.Printf("%s\n", err)
+		return
+	}
+
+	log.Printf("Message successfully published to %s", subject)
+}
+
+// Close publishes the pending messages and closes the connection
+func (n *NATS) Close() error {
+	if n.conn == nil {
+		return nil
+	}
+	return n.conn.Drain()
+}
+
+func notifyNATS(n *NATS, obj interface{}, action string) error {
+	e := kbEvent.New(obj, action)
+
+	data, err := prepareNATSMessage(e, eventTypes[action])
+	if err != nil {
+		return err
+	}
+	subject := eventSubject(n.Subject, e.Kind, eventTypes[action])
+	if err := n.conn.Publish(subject, data); err != nil {
+		return fmt.Errorf("Failed publishing to NATS subject %s: %v", subject, err)
+	}
+
+	log.Printf("Message successfully published to %s", subject)
+	return nil
+}
+
+// eventSubject returns the subject of the events of a kind and event type,
+// e.g. kubewatch.pod.create, subscribers can use wildcards like kubewatch.*.delete.
+// Characters not allowed in subject tokens are dropped from the kind,
+// e.g. replica set is published to kubewatch.replicaset.create
+func eventSubject(prefix, kind, eventType string) string {
+	token := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '.', '*', '>':
+			return -1
+		}
+		return r
+	}, strings.ToLower(kind))
+	if token == "" {
+		token = "unknown"
+	}
+	return prefix + "." + token + "." + eventType
+}
+
+func prepareNATSMessage(e kbEvent.Event, eventType string) ([]byte, error) {
+	return json.Marshal(NATSMessage{
+		Kind:      e.Kind,
+		Name:      e.Name,
+		Namespace: e.Namespace,
+		EventType: eventType,
+		Reason:    e.Reason,
+		Status:    e.Status,
+		Cluster:   e.Cluster,
+		Message:   e.Message(),
+		Time:      time.Now().UTC().Format(time.RFC3339),
+	})
+}
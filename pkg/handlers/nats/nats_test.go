@@ -0,0 +1,113 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+// fakePublisher records the published messages
+type fakePublisher struct {
+	subjects []string
+	messages [][]byte
+	err      error
+	drained  bool
+}
+
+func (f *fakePublisher) Publish(subject string, data []byte) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.subjects = append(f.subjects, subject)
+	f.messages = append(f.messages, data)
+	return nil
+}
+
+func (f *fakePublisher) Drain() error {
+	f.drained = true
+	return nil
+}
+
+func TestNATSInit(t *testing.T) {
+	s := &NATS{}
+	expectedError := fmt.Errorf(natsErrMsg, "Missing NATS url")
+
+	var Tests = []struct {
+		nats config.NATS
+		err  error
+	}{
+		// unreachable servers are retried in the background
+		{config.NATS{URL: "nats://127.0.0.1:1"}, nil},
+		{config.NATS{Subject: "kubewatch"}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.NATS = tt.nats
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+	if s.Subject != "kubewatch" {
+		t.Fatalf("expected the default subject kubewatch, got %q", s.Subject)
+	}
+	s.Close()
+}
+
+func TestNATSMessage(t *testing.T) {
+	conn := &fakePublisher{}
+	n := &NATS{Subject: "kubewatch", conn: conn}
+
+	if err := n.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "default/foo", Namespace: "default", Cluster: "prod"}); err != nil {
+		t.Fatalf("ObjectCreated(): %v", err)
+	}
+	if err := n.ObjectDeleted(context.Background(), kbEvent.Event{Kind: "replica set", Name: "default/foo", Namespace: "default"}); err != nil {
+		t.Fatalf("ObjectDeleted(): %v", err)
+	}
+
+	expected := []string{"kubewatch.pod.create", "kubewatch.replicaset.delete"}
+	if !reflect.DeepEqual(conn.subjects, expected) {
+		t.Fatalf("expected subjects %v, got %v", expected, conn.subjects)
+	}
+
+	var m NATSMessage
+	if err := json.Unmarshal(conn.messages[0], &m); err != nil {
+		t.Fatalf("expected a JSON message: %v", err)
+	}
+	if m.Kind != "pod" || m.EventType != "create" || m.Cluster != "prod" || m.Time == "" {
+		t.Fatalf("unexpected message %+v", m)
+	}
+
+	if err := n.Close(); err != nil || !conn.drained {
+		t.Fatalf("Close(): expected the connection to be drained, got %v", err)
+	}
+}
+
+func TestNATSError(t *testing.T) {
+	n := &NATS{Subject: "kubewatch", conn: &fakePublisher{err: errors.New("nats: outbound buffer limit exceeded")}}
+	if err := n.ObjectUpdated(context.Background(), nil, kbEvent.Event{Kind: "pod", Name: "default/foo", Namespace: "default"}); err == nil {
+		t.Fatal("ObjectUpdated(): expected the publish error to be returned")
+	}
+}
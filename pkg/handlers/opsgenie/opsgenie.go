@@ -0,0 +1,249 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsgenie
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
+)
+
+// Opsgenie API base urls per region
+const (
+	USUrl = "https://api.opsgenie.com"
+	EUUrl = "https://api.eu.opsgenie.com"
+)
+
+// maxMessageLength is the limit of Opsgenie alert messages
+const maxMessageLength = 130
+
+var opsgenieErrMsg = `
+%s
+
+You need to set the Opsgenie API key
+using "--apikey/-k" or using environment variables:
+
+export KW_OPSGENIE_APIKEY=opsgenie_api_key
+
+Command line flags will override environment variables
+
+`
+
+// Opsgenie handler implements handler.Handler interface,
+// Creates an alert on object creation and update, closed on object deletion
+type Opsgenie struct {
+	APIKey     string
+	Url        string
+	Responders []OpsgenieResponder
+
+	client *http.Client
+}
+
+// OpsgenieResponder is a team, user, escalation or schedule notified of alerts
+type OpsgenieResponder struct {
+	Type     string `json:"type"`
+	Name     string `json:"name,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+// OpsgenieAlert is the payload of the create alert request
+type OpsgenieAlert struct {
+	Message     string              `json:"message"`
+	Alias       string              `json:"alias"`
+	Description string              `json:"description"`
+	Responders  []OpsgenieResponder `json:"responders,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Source      string              `json:"source"`
+}
+
+// OpsgenieClose is the payload of the close alert request
+type OpsgenieClose struct {
+	Source string `json:"source"`
+	Note   string `json:"note"`
+}
+
+// Init prepares Opsgenie configuration
+func (o *Opsgenie) Init(c *config.Config) error {
+	apiKey := c.Handler.Opsgenie.APIKey
+	region := c.Handler.Opsgenie.Region
+
+	if apiKey == "" {
+		apiKey = os.Getenv("KW_OPSGENIE_APIKEY")
+	}
+
+	if region == "" {
+		region = os.Getenv("KW_OPSGENIE_REGION")
+	}
+
+	o.APIKey = apiKey
+	o.Url = USUrl
+	if strings.EqualFold(region, "eu") {
+		o.Url = EUUrl
+	}
+	o.Responders = nil
+	for _, r := range c.Handler.Opsgenie.Responders {
+		responder := OpsgenieResponder{Type: r.Type, Name: r.Name}
+		// users are referred to by username
+		if r.Type == "user" {
+			responder = OpsgenieResponder{Type: r.Type, Username: r.Name}
+		}
+		o.Responders = append(o.Responders, responder)
+	}
+
+	client, err := utils.NewHTTPClient(c.Handler.HTTP)
+	if err != nil {
+		return err
+	}
+	o.client = client
+
+	return checkMissingOpsgenieVars(o)
+}
+
+// ObjectCreated creates an alert on object creation
+func (o *Opsgenie) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifyOpsgenie(ctx, o, obj, "created")
+}
+
+// ObjectDeleted closes the alert of the deleted object
+func (o *Opsgenie) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifyOpsgenie(ctx, o, obj, "deleted")
+}
+
+// ObjectUpdated creates an alert on object update, deduplicated with the open alert of the object
+func (o *Opsgenie) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifyOpsgenie(ctx, o, newObj, "updated")
+}
+
+// TestHandler tests the handler configurarion by creating and closing a test alert.
+func (o *Opsgenie) TestHandler() {
+	alert := &OpsgenieAlert{
+		Message:     "kubewatch test alert",
+		Alias:       "kubewatch/test",
+		Description: "Testing Handler Configuration. This is a Test message.",
+		Source:      "kubewatch",
+	}
+
+	if err := post(context.Background(), o, "/v2/alerts", alert); err != nil {
+		log.Printf("%s\n", err)
+		return
+	}
+	if err := post(context.Background(), o, closePath(alert.Alias), &OpsgenieClose{Source: "kubewatch", Note: "test"}); err != nil {
+		log.Printf("%s\n", err)
+		return
+	}
+
+	log.Printf("Test alert successfully created and closed")
+}
+
+func notifyOpsgenie(ctx context.Context, o *Opsgenie, obj interface{}, action string) error {
+	e := kbEvent.New(obj, action)
+	alias := e.Key()
+
+	var err error
+	if action == "deleted" {
+		err = post(ctx, o, closePath(alias), &OpsgenieClose{Source: source(e), Note: e.Message()})
+	} else {
+		err = post(ctx, o, "/v2/alerts", prepareOpsgenieAlert(e, o))
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Opsgenie alert %s %s", alias, action)
+	return nil
+}
+
+func checkMissingOpsgenieVars(o *Opsgenie) error {
+	if o.APIKey == "" {
+		return fmt.Errorf(opsgenieErrMsg, "Missing Opsgenie API key")
+	}
+
+	return nil
+}
+
+func source(e kbEvent.Event) string {
+	if e.Cluster != "" {
+		return e.Cluster
+	}
+	return "kubewatch"
+}
+
+func closePath(alias string) string {
+	return "/v2/alerts/" + url.PathEscape(alias) + "/close?identifierType=alias"
+}
+
+func prepareOpsgenieAlert(e kbEvent.Event, o *Opsgenie) *OpsgenieAlert {
+	message := e.Message()
+	if runes := []rune(message); len(runes) > maxMessageLength {
+		message = string(runes[:maxMessageLength-1]) + "…"
+	}
+
+	tags := []string{"kubewatch", e.Kind}
+	if e.Namespace != "" {
+		tags = append(tags, e.Namespace)
+	}
+
+	return &OpsgenieAlert{
+		Message:     message,
+		Alias:       e.Key(),
+		Description: e.Message(),
+		Responders:  o.Responders,
+		Tags:        tags,
+		Source:      source(e),
+	}
+}
+
+func post(ctx context.Context, o *Opsgenie, path string, payload interface{}) error {
+	message, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", strings.TrimSuffix(o.Url, "/")+path, bytes.NewBuffer(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "GenieKey "+o.APIKey)
+
+	client := o.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("Opsgenie request %s failed with %s: %s", path, resp.Status, body)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package opsgenie
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+func TestOpsgenieInit(t *testing.T) {
+	s := &Opsgenie{}
+	expectedError := fmt.Errorf(opsgenieErrMsg, "Missing Opsgenie API key")
+
+	var Tests = []struct {
+		opsgenie config.Opsgenie
+		url      string
+		err      error
+	}{
+		{config.Opsgenie{APIKey: "foo"}, USUrl, nil},
+		{config.Opsgenie{APIKey: "foo", Region: "EU"}, EUUrl, nil},
+		{config.Opsgenie{Region: "eu"}, EUUrl, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Opsgenie = tt.opsgenie
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+		if s.Url != tt.url {
+			t.Fatalf("Init(): expected url %s, got %s", tt.url, s.Url)
+		}
+	}
+}
+
+func TestOpsgenieCreateClose(t *testing.T) {
+	type request struct {
+		path string
+		body map[string]interface{}
+	}
+	var requests []request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "GenieKey foo" {
+			t.Errorf("unexpected authorization %q", auth)
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("expected a JSON body: %v", err)
+		}
+		requests = append(requests, request{r.URL.EscapedPath() + "?" + r.URL.RawQuery, body})
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	c := &config.Config{}
+	c.Handler.Opsgenie = config.Opsgenie{
+		APIKey:     "foo",
+		Responders: []config.OpsgenieResponder{{Type: "team", Name: "ops"}, {Type: "user", Name: "jane@example.com"}},
+	}
+	o := &Opsgenie{}
+	if err := o.Init(c); err != nil {
+		t.Fatalf("Init(): %v", err)
+	}
+	o.Url = ts.URL
+
+	if err := o.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "foo", Namespace: "default"}); err != nil {
+		t.Fatalf("ObjectCreated(): %v", err)
+	}
+	if err := o.ObjectDeleted(context.Background(), kbEvent.Event{Kind: "pod", Name: "default/foo", Namespace: "default"}); err != nil {
+		t.Fatalf("ObjectDeleted(): %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got %v", requests)
+	}
+	if requests[0].path != "/v2/alerts?" || requests[0].body["alias"] != "pod/default/foo" {
+		t.Fatalf("expected an alert create, got %v", requests[0])
+	}
+	responders := requests[0].body["responders"].([]interface{})
+	if user := responders[1].(map[string]interface{}); user["username"] != "jane@example.com" {
+		t.Fatalf("expected the user responder by username, got %v", responders)
+	}
+	if requests[1].path != "/v2/alerts/pod%2Fdefault%2Ffoo/close?identifierType=alias" {
+		t.Fatalf("expected the alert to be closed by alias, got %v", requests[1])
+	}
+}
+
+func TestOpsgenieError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Key format is not valid!"}`, http.StatusUnprocessableEntity)
+	}))
+	defer ts.Close()
+
+	o := &Opsgenie{APIKey: "foo", Url: ts.URL}
+	if err := o.ObjectUpdated(context.Background(), nil, kbEvent.Event{Kind: "pod", Name: "default/foo", Namespace: "default"}); err == nil {
+		t.Fatal("ObjectUpdated(): expected an error on a failed request")
+	}
+}
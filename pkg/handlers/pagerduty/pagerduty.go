@@ -0,0 +1,241 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pagerduty
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
+)
+
+// DefaultUrl is the PagerDuty Events API v2 endpoint
+const DefaultUrl = "https://events.pagerduty.com/v2/enqueue"
+
+// DefaultSeverity is the severity of incidents of resource types without one configured
+const DefaultSeverity = "error"
+
+var pagerdutyErrMsg = `
+%s
+
+You need to set the PagerDuty integration key
+using "--integrationkey/-k" or using environment variables:
+
+export KW_PAGERDUTY_INTEGRATIONKEY=pagerduty_integration_key
+
+Command line flags will override environment variables
+
+`
+
+// PagerDuty handler implements handler.Handler interface,
+// Triggers an incident on object creation and update, resolved on object deletion
+type PagerDuty struct {
+	IntegrationKey string
+	Severity       string
+	Severities     map[string]string
+	Url            string
+
+	client *http.Client
+}
+
+// PagerDutyEvent is a PagerDuty Events API v2 event
+type PagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *PagerDutyPayload `json:"payload,omitempty"`
+}
+
+// PagerDutyPayload describes the triggered incident
+type PagerDutyPayload struct {
+	Summary   string `json:"summary"`
+	Source    string `json:"source"`
+	Severity  string `json:"severity"`
+	Component string `json:"component,omitempty"`
+	Group     string `json:"group,omitempty"`
+	Class     string `json:"class,omitempty"`
+}
+
+// Init prepares PagerDuty configuration
+func (p *PagerDuty) Init(c *config.Config) error {
+	integrationKey := c.Handler.PagerDuty.IntegrationKey
+	severity := c.Handler.PagerDuty.Severity
+	url := c.Handler.PagerDuty.Url
+
+	if integrationKey == "" {
+		integrationKey = os.Getenv("KW_PAGERDUTY_INTEGRATIONKEY")
+	}
+
+	if severity == "" {
+		severity = os.Getenv("KW_PAGERDUTY_SEVERITY")
+		if severity == "" {
+			severity = DefaultSeverity
+		}
+	}
+
+	if url == "" {
+		url = DefaultUrl
+	}
+
+	p.IntegrationKey = integrationKey
+	p.Severity = severity
+	p.Severities = c.Handler.PagerDuty.Severities
+	p.Url = url
+
+	client, err := utils.NewHTTPClient(c.Handler.HTTP)
+	if err != nil {
+		return err
+	}
+	p.client = client
+
+	return checkMissingPagerDutyVars(p)
+}
+
+// ObjectCreated triggers an incident on object creation
+func (p *PagerDuty) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifyPagerDuty(ctx, p, obj, "created")
+}
+
+// ObjectDeleted resolves the incident of the deleted object
+func (p *PagerDuty) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifyPagerDuty(ctx, p, obj, "deleted")
+}
+
+// ObjectUpdated triggers an incident on object update
+func (p *PagerDuty) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifyPagerDuty(ctx, p, newObj, "updated")
+}
+
+// TestHandler tests the handler configurarion by triggering and resolving a test incident.
+func (p *PagerDuty) TestHandler() {
+	pagerdutyEvent := &PagerDutyEvent{
+		RoutingKey:  p.IntegrationKey,
+		EventAction: "trigger",
+		DedupKey:    "kubewatch/test",
+		Payload: &PagerDutyPayload{
+			Summary:  "Testing Handler Configuration. This is a Test message.",
+			Source:   "kubewatch",
+			Severity: "info",
+		},
+	}
+
+	if err := postEvent(context.Background(), p.client, p.Url, pagerdutyEvent); err != nil {
+		log.Printf("%s\n", err)
+		return
+	}
+	pagerdutyEvent.EventAction = "resolve"
+	pagerdutyEvent.Payload = nil
+	if err := postEvent(context.Background(), p.client, p.Url, pagerdutyEvent); err != nil {
+		log.Printf("%s\n", err)
+		return
+	}
+
+	log.Printf("Test incident successfully triggered and resolved")
+}
+
+func notifyPagerDuty(ctx context.Context, p *PagerDuty, obj interface{}, action string) error {
+	e := kbEvent.New(obj, action)
+
+	pagerdutyEvent := preparePagerDutyEvent(e, p, action)
+	if err := postEvent(ctx, p.client, p.Url, pagerdutyEvent); err != nil {
+		return err
+	}
+
+	log.Printf("PagerDuty event %s sent for %s", pagerdutyEvent.EventAction, pagerdutyEvent.DedupKey)
+	return nil
+}
+
+func checkMissingPagerDutyVars(p *PagerDuty) error {
+	if p.IntegrationKey == "" {
+		return fmt.Errorf(pagerdutyErrMsg, "Missing PagerDuty integration key")
+	}
+
+	return nil
+}
+
+// severity returns the severity configured for the resource type of the event
+func (p *PagerDuty) severity(e kbEvent.Event) string {
+	for resourceType, severity := range p.Severities {
+		if kbEvent.DisplayName(resourceType) == e.Kind {
+			return severity
+		}
+	}
+	return p.Severity
+}
+
+func preparePagerDutyEvent(e kbEvent.Event, p *PagerDuty, action string) *PagerDutyEvent {
+	pagerdutyEvent := &PagerDutyEvent{
+		RoutingKey:  p.IntegrationKey,
+		EventAction: "trigger",
+		DedupKey:    e.Key(),
+	}
+	if action == "deleted" {
+		pagerdutyEvent.EventAction = "resolve"
+		return pagerdutyEvent
+	}
+
+	source := e.Cluster
+	if source == "" {
+		source = "kubewatch"
+	}
+	pagerdutyEvent.Payload = &PagerDutyPayload{
+		Summary:   e.Message(),
+		Source:    source,
+		Severity:  p.severity(e),
+		Component: e.Kind,
+		Group:     e.Namespace,
+		Class:     e.Reason,
+	}
+	return pagerdutyEvent
+}
+
+func postEvent(ctx context.Context, client *http.Client, url string, pagerdutyEvent *PagerDutyEvent) error {
+	message, err := json.Marshal(pagerdutyEvent)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("PagerDuty event %s for %s failed with %s: %s",
+			pagerdutyEvent.EventAction, pagerdutyEvent.DedupKey, resp.Status, body)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pagerduty
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+func TestPagerDutyInit(t *testing.T) {
+	s := &PagerDuty{}
+	expectedError := fmt.Errorf(pagerdutyErrMsg, "Missing PagerDuty integration key")
+
+	var Tests = []struct {
+		pagerduty config.PagerDuty
+		err       error
+	}{
+		{config.PagerDuty{IntegrationKey: "foo"}, nil},
+		{config.PagerDuty{Severity: "critical"}, expectedError},
+		{config.PagerDuty{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.PagerDuty = tt.pagerduty
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestPagerDutyTriggerResolve(t *testing.T) {
+	var events []PagerDutyEvent
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e PagerDutyEvent
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			t.Errorf("expected a PagerDuty event: %v", err)
+		}
+		events = append(events, e)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	c := &config.Config{}
+	c.Handler.PagerDuty = config.PagerDuty{
+		IntegrationKey: "foo",
+		Severities:     map[string]string{"pod": "critical"},
+		Url:            ts.URL,
+	}
+	p := &PagerDuty{}
+	if err := p.Init(c); err != nil {
+		t.Fatalf("Init(): %v", err)
+	}
+
+	if err := p.ObjectCreated(context.Background(), kbEvent.Event{Kind: "Pod", Name: "bar", Namespace: "default"}); err != nil {
+		t.Fatalf("ObjectCreated(): %v", err)
+	}
+	if err := p.ObjectUpdated(context.Background(), nil, kbEvent.Event{Kind: "Service", Name: "default/bar", Namespace: "default"}); err != nil {
+		t.Fatalf("ObjectUpdated(): %v", err)
+	}
+	if err := p.ObjectDeleted(context.Background(), kbEvent.Event{Kind: "Pod", Name: "default/bar", Namespace: "default"}); err != nil {
+		t.Fatalf("ObjectDeleted(): %v", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %v", events)
+	}
+	var Tests = []struct {
+		action, dedupKey, severity string
+	}{
+		{"trigger", "Pod/default/bar", "critical"},
+		{"trigger", "Service/default/bar", DefaultSeverity},
+		{"resolve", "Pod/default/bar", ""},
+	}
+	for i, tt := range Tests {
+		e := events[i]
+		if e.RoutingKey != "foo" || e.EventAction != tt.action || e.DedupKey != tt.dedupKey {
+			t.Fatalf("%d: unexpected event %+v", i, e)
+		}
+		if tt.severity == "" && e.Payload != nil {
+			t.Fatalf("%d: expected no payload, got %+v", i, e.Payload)
+		}
+		if tt.severity != "" && (e.Payload == nil || e.Payload.Severity != tt.severity) {
+			t.Fatalf("%d: expected severity %s, got %+v", i, tt.severity, e.Payload)
+		}
+	}
+}
+
+func TestPagerDutyError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	p := &PagerDuty{IntegrationKey: "foo", Severity: DefaultSeverity, Url: ts.URL}
+	if err := p.ObjectCreated(context.Background(), kbEvent.Event{Kind: "Pod", Name: "bar", Namespace: "default"}); err == nil {
+		t.Fatal("ObjectCreated(): expected an error on a failed API call")
+	}
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pushover
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
+)
+
+// DefaultUrl is the Pushover messages API url
+const DefaultUrl = "https://api.pushover.net/1/messages.json"
+
+// emergencyPriority asks the user to acknowledge the message, which is repeated until then
+const emergencyPriority = 2
+
+// defaults of the retries of emergency messages
+const (
+	defaultRetry  = time.Minute
+	defaultExpire = time.Hour
+)
+
+// limits of the API, longer titles and messages are truncated
+const (
+	maxTitleLength   = 250
+	maxMessageLength = 1024
+)
+
+var pushoverErrMsg = `
+%s
+
+You need to set both the Pushover application token and user key,
+using "--token/-t" and "--user/-u", or using environment variables:
+
+export KW_PUSHOVER_TOKEN=pushover_application_token
+export KW_PUSHOVER_USER=pushover_user_key
+
+Command line flags will override environment variables
+
+`
+
+// Pushover handler implements handler.Handler interface,
+// Notify event to the devices of a Pushover user
+type Pushover struct {
+	Token    string
+	User     string
+	Device   string
+	Priority int
+	Retry    time.Duration
+	Expire   time.Duration
+	Url      string
+
+	client *http.Client
+}
+
+// PushoverResponse is the response of the messages API
+type PushoverResponse struct {
+	Status int      `json:"status"`
+	Errors []string `json:"errors"`
+}
+
+// Init prepares Pushover configuration
+func (p *Pushover) Init(c *config.Config) error {
+	token := c.Handler.Pushover.Token
+	user := c.Handler.Pushover.User
+	url := c.Handler.Pushover.Url
+
+	if token == "" {
+		token = os.Getenv("KW_PUSHOVER_TOKEN")
+	}
+
+	if user == "" {
+		user = os.Getenv("KW_PUSHOVER_USER")
+	}
+
+	if url == "" {
+		url = DefaultUrl
+	}
+
+	p.Token = token
+	p.User = user
+	p.Device = c.Handler.Pushover.Device
+	p.Priority = c.Handler.Pushover.Priority
+	p.Retry = c.Handler.Pushover.Retry
+	p.Expire = c.Handler.Pushover.Expire
+	p.Url = url
+
+	client, err := utils.NewHTTPClient(c.Handler.HTTP)
+	if err != nil {
+		return err
+	}
+	p.client = client
+
+	return checkMissingPushoverVars(p)
+}
+
+// ObjectCreated calls notifyPushover on event creation
+func (p *Pushover) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifyPushover(ctx, p, obj, "created")
+}
+
+// ObjectDeleted calls notifyPushover on event creation
+func (p *Pushover) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifyPushover(ctx, p, obj, "deleted")
+}
+
+// ObjectUpdated calls notifyPushover on event creation
+func (p *Pushover) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifyPushover(ctx, p, newObj, "updated")
+}
+
+// TestHandler tests the handler configurarion by sending test messages.
+func (p *Pushover) TestHandler() {
+	if err := postMessage(context.Background(), p, "kubewatch", "Testing Handler Configuration. This is a Test message."); err != nil {
+		log.Printf("%s\n", err)
+		return
+	}
+
+	log.Printf("Message successfully sent to Pushover user %s", p.User)
+}
+
+func notifyPushover(ctx context.Context, p *Pushover, obj interface{}, action string) error {
+	e := kbEvent.New(obj, action)
+
+	title := fmt.Sprintf("%s %s %s", e.Kind, e.Name, action)
+	if e.Cluster != "" {
+		title = fmt.Sprintf("[%s] %s", e.Cluster, title)
+	}
+
+	if err := postMessage(ctx, p, title, e.Message()); err != nil {
+		return err
+	}
+
+	log.Printf("Message successfully sent to Pushover user %s", p.User)
+	return nil
+}
+
+func checkMissingPushoverVars(p *Pushover) error {
+	if p.Token == "" || p.User == "" {
+		return fmt.Errorf(pushoverErrMsg, "Missing Pushover token or user")
+	}
+
+	return nil
+}
+
+// pushoverForm returns the form of a message, emergency messages with their retries
+func pushoverForm(p *Pushover, title, message string) url.Values {
+	form := url.Values{
+		"token":   {p.Token},
+		"user":    {p.User},
+		"title":   {truncate(title, maxTitleLength)},
+		"message": {truncate(message, maxMessageLength)},
+	}
+	if p.Device != "" {
+		form.Set("device", p.Device)
+	}
+	if p.Priority != 0 {
+		form.Set("priority", strconv.Itoa(p.Priority))
+	}
+	if p.Priority == emergencyPriority {
+		form.Set("retry", strconv.Itoa(int(durationOrDefault(p.Retry, defaultRetry).Seconds())))
+		form.Set("expire", strconv.Itoa(int(durationOrDefault(p.Expire, defaultExpire).Seconds())))
+	}
+	return form
+}
+
+func durationOrDefault(value, def time.Duration) time.Duration {
+	if value == 0 {
+		return def
+	}
+	return value
+}
+
+// truncate shortens s to max runes, marking the cut with an ellipsis
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+func postMessage(ctx context.Context, p *Pushover, title, message string) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", p.Url, strings.NewReader(pushoverForm(p, title, message).Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := p.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Failed sending to Pushover: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var pushoverResponse PushoverResponse
+	if err := json.NewDecoder(resp.Body).Decode(&pushoverResponse); err != nil {
+		return fmt.Errorf("Failed reading Pushover response, got %s: %v", resp.Status, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 || pushoverResponse.Status != 1 {
+		return fmt.Errorf("Failed sending to Pushover, got %s: %s", resp.Status, strings.Join(pushoverResponse.Errors, ", "))
+	}
+	return nil
+}
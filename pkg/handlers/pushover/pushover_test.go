@@ -0,0 +1,112 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pushover
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+func TestPushoverInit(t *testing.T) {
+	s := &Pushover{}
+	expectedError := fmt.Errorf(pushoverErrMsg, "Missing Pushover token or user")
+
+	var Tests = []struct {
+		pushover config.Pushover
+		err      error
+	}{
+		{config.Pushover{Token: "foo", User: "bar"}, nil},
+		{config.Pushover{Token: "foo"}, expectedError},
+		{config.Pushover{User: "bar"}, expectedError},
+		{config.Pushover{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Pushover = tt.pushover
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+	if s.Url != DefaultUrl {
+		t.Fatalf("expected the default url, got %s", s.Url)
+	}
+}
+
+func TestPushoverMessage(t *testing.T) {
+	var forms []url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("expected a form: %v", err)
+		}
+		forms = append(forms, r.PostForm)
+		fmt.Fprint(w, `{"status":1,"request":"647d2300-702c-4b38-8b2f-d56326ae460b"}`)
+	}))
+	defer ts.Close()
+
+	p := &Pushover{Token: "foo", User: "bar", Device: "phone", Url: ts.URL}
+	e := kbEvent.Event{Kind: "pod", Name: "web", Namespace: "default", Cluster: "prod"}
+	if err := p.ObjectDeleted(context.Background(), e); err != nil {
+		t.Fatalf("ObjectDeleted(): %v", err)
+	}
+	p.Priority, p.Expire = 2, 2*time.Hour
+	if err := p.ObjectDeleted(context.Background(), e); err != nil {
+		t.Fatalf("ObjectDeleted(): %v", err)
+	}
+
+	if len(forms) != 2 {
+		t.Fatalf("expected 2 messages, got %v", forms)
+	}
+	form := forms[0]
+	if form.Get("token") != "foo" || form.Get("user") != "bar" || form.Get("device") != "phone" {
+		t.Errorf("unexpected recipient %v", form)
+	}
+	if form.Get("title") != "[prod] pod web deleted" || !strings.Contains(form.Get("message"), "web") {
+		t.Errorf("unexpected message %v", form)
+	}
+	if form.Get("priority") != "" || form.Get("retry") != "" {
+		t.Errorf("expected the default priority, got %v", form)
+	}
+	emergency := forms[1]
+	if emergency.Get("priority") != "2" || emergency.Get("retry") != "60" || emergency.Get("expire") != "7200" {
+		t.Errorf("expected an emergency message with its retries, got %v", emergency)
+	}
+}
+
+func TestPushoverError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"user":"invalid","errors":["user identifier is invalid"],"status":0}`)
+	}))
+	defer ts.Close()
+
+	p := &Pushover{Token: "foo", User: "bar", Url: ts.URL}
+	err := p.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "web"})
+	if err == nil || !strings.Contains(err.Error(), "user identifier is invalid") {
+		t.Fatalf("expected the API error to be returned, got %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+// Router is implemented by handlers sending the events of each resource type
+// and event type to their own handlers
+type Router interface {
+	Route(resourceType, eventType string) Handler
+}
+
+// Routed sends events to the handlers of the routes matching their resource type
+// and event type, and to all its handlers when no route matches
+type Routed struct {
+	Routes []config.Route
+	// Handlers by name, e.g. slack, initialized by the caller
+	Handlers map[string]Handler
+
+	sent deliveries
+}
+
+// Route returns the handlers of the routes matching the resource type, e.g. pod,
+// and the event type, e.g. create
+func (r *Routed) Route(resourceType, eventType string) Handler {
+	matched := broadcast{sent: &r.sent}
+	for _, route := range r.Routes {
+		if !route.Matches(resourceType, eventType) {
+			continue
+		}
+		for _, name := range route.Handlers {
+			if _, ok := r.Handlers[name]; ok && !matched.has(name) {
+				matched.names = append(matched.names, name)
+				matched.handlers = append(matched.handlers, r.Handlers[name])
+			}
+		}
+	}
+	if len(matched.names) == 0 {
+		return r.all()
+	}
+	return matched
+}
+
+// all returns the handlers sorted by name
+func (r *Routed) all() broadcast {
+	names := make([]string, 0, len(r.Handlers))
+	for name := range r.Handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	all := broadcast{names: names, handlers: make([]Handler, 0, len(names)), sent: &r.sent}
+	for _, name := range names {
+		all.handlers = append(all.handlers, r.Handlers[name])
+	}
+	return all
+}
+
+// Init does nothing, the handlers are initialized by the caller
+func (r *Routed) Init(c *config.Config) error {
+	return nil
+}
+
+// ObjectCreated sends the created event to all handlers
+func (r *Routed) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return r.all().ObjectCreated(ctx, obj)
+}
+
+// ObjectDeleted sends the deleted event to all handlers
+func (r *Routed) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return r.all().ObjectDeleted(ctx, obj)
+}
+
+// ObjectUpdated sends the updated event to all handlers
+func (r *Routed) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return r.all().ObjectUpdated(ctx, oldObj, newObj)
+}
+
+// TestHandler tests the configuration of all handlers
+func (r *Routed) TestHandler() {
+	r.all().TestHandler()
+}
+
+// Close closes the handlers holding resources
+func (r *Routed) Close() error {
+	return r.all().Close()
+}
+
+// deliveries remembers the handlers an event was sent to while others failed,
+// for the retries of the event to go to the failed handlers only
+type deliveries struct {
+	mu sync.Mutex
+	// by object key, an object keeps the delivery of its latest event only
+	sent map[string]*delivery
+}
+
+// delivery holds the names of the handlers an event was sent to
+type delivery struct {
+	// action and resource version of the event
+	id    string
+	names map[string]bool
+}
+
+// deliveryKey returns the object key and the id of the event of obj, the id is
+// empty for events without a resource version which can't be told apart
+func deliveryKey(action string, obj interface{}) (key, id string) {
+	e := event.New(obj, action)
+	if e.ResourceVersion == "" {
+		return "", ""
+	}
+	return e.Key(), action + "/" + e.ResourceVersion
+}
+
+// delivered returns the names of the handlers the event was already sent to
+func (d *deliveries) delivered(key, id string) map[string]bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if sent, ok := d.sent[key]; ok && sent.id == id {
+		return sent.names
+	}
+	return nil
+}
+
+// record remembers the handlers the event was sent to, forgetting them once all were
+func (d *deliveries) record(key, id string, names map[string]bool, done bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if done {
+		delete(d.sent, key)
+		return
+	}
+	if d.sent == nil {
+		d.sent = make(map[string]*delivery)
+	}
+	d.sent[key] = &delivery{id: id, names: names}
+}
+
+// broadcast sends events to each of its handlers. The first error is returned
+// so that the event is retried, the retries skip the handlers it was sent to.
+type broadcast struct {
+	names    []string
+	handlers []Handler
+	sent     *deliveries
+}
+
+func (b broadcast) has(name string) bool {
+	for _, n := range b.names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (b broadcast) Init(c *config.Config) error {
+	return nil
+}
+
+func (b broadcast) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return b.deliver("created", obj, func(h Handler) error { return h.ObjectCreated(ctx, obj) })
+}
+
+func (b broadcast) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return b.deliver("deleted", obj, func(h Handler) error { return h.ObjectDeleted(ctx, obj) })
+}
+
+func (b broadcast) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return b.deliver("updated", newObj, func(h Handler) error { return h.ObjectUpdated(ctx, oldObj, newObj) })
+}
+
+func (b broadcast) TestHandler() {
+	for _, h := range b.handlers {
+		h.TestHandler()
+	}
+}
+
+func (b broadcast) Close() error {
+	return b.each(nil, func(h Handler) error {
+		if closer, ok := h.(io.Closer); ok {
+			return closer.Close()
+		}
+		return nil
+	})
+}
+
+// deliver sends the event of obj to the handlers it wasn't sent to yet
+func (b broadcast) deliver(action string, obj interface{}, send func(h Handler) error) error {
+	key, id := deliveryKey(action, obj)
+	if id == "" || b.sent == nil {
+		return b.each(nil, send)
+	}
+
+	sent := make(map[string]bool)
+	for name := range b.sent.delivered(key, id) {
+		sent[name] = true
+	}
+	err := b.each(sent, send)
+	b.sent.record(key, id, sent, err == nil)
+	return err
+}
+
+// each calls f with every handler not in sent, whatever errors the previous ones
+// returned, and adds the ones succeeding to sent unless it is nil
+func (b broadcast) each(sent map[string]bool, f func(h Handler) error) error {
+	var first error
+	failed := 0
+	for i, h := range b.handlers {
+		if sent[b.names[i]] {
+			continue
+		}
+		if err := f(h); err != nil {
+			if first == nil {
+				first = err
+			}
+			failed++
+			continue
+		}
+		if sent != nil {
+			sent[b.names[i]] = true
+		}
+	}
+	if failed > 1 {
+		return fmt.Errorf("%w (and %d more handler errors)", first, failed-1)
+	}
+	return first
+}
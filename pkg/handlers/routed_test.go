@@ -0,0 +1,139 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+// countingHandler counts the created events it receives
+type countingHandler struct {
+	Default
+	created int
+}
+
+func (h *countingHandler) ObjectCreated(ctx context.Context, obj interface{}) error {
+	h.created++
+	return nil
+}
+
+func TestRoutedRoute(t *testing.T) {
+	slack, opsgenie, webhook := &countingHandler{}, &countingHandler{}, &countingHandler{}
+	r := &Routed{
+		Routes: []config.Route{
+			{Resources: []string{"pod"}, Handlers: []string{"slack"}},
+			{Resources: []string{"secret"}, EventTypes: []string{"delete"}, Handlers: []string{"opsgenie"}},
+			{Resources: []string{"pod", "secret"}, EventTypes: []string{"delete"}, Handlers: []string{"slack", "webhook"}},
+		},
+		Handlers: map[string]Handler{"slack": slack, "opsgenie": opsgenie, "webhook": webhook},
+	}
+
+	var Tests = []struct {
+		resourceType, eventType string
+		expected                []string
+	}{
+		{"pod", "create", []string{"slack"}},
+		{"pod", "delete", []string{"slack", "webhook"}},
+		{"secret", "delete", []string{"opsgenie", "slack", "webhook"}},
+		// events matching no route go to all handlers
+		{"secret", "create", []string{"opsgenie", "slack", "webhook"}},
+		{"service", "delete", []string{"opsgenie", "slack", "webhook"}},
+	}
+
+	for _, tt := range Tests {
+		b := r.Route(tt.resourceType, tt.eventType).(broadcast)
+		if !reflect.DeepEqual(b.names, tt.expected) {
+			t.Errorf("Route(%s, %s): expected %v, got %v", tt.resourceType, tt.eventType, tt.expected, b.names)
+		}
+		for i, name := range b.names {
+			if b.handlers[i] != r.Handlers[name] {
+				t.Errorf("Route(%s, %s): expected the handler of %s", tt.resourceType, tt.eventType, name)
+			}
+		}
+	}
+
+	if err := r.ObjectCreated(context.Background(), nil); err != nil {
+		t.Fatalf("ObjectCreated(): %v", err)
+	}
+	if slack.created != 1 || opsgenie.created != 1 || webhook.created != 1 {
+		t.Errorf("expected events sent through the routed handler to reach all handlers")
+	}
+}
+
+func TestBroadcastErrors(t *testing.T) {
+	ok := &countingHandler{}
+	b := broadcast{names: []string{"first", "ok", "last"}, handlers: []Handler{&failingHandler{}, ok, &failingHandler{}}}
+
+	err := b.ObjectCreated(context.Background(), nil)
+	if err == nil || err.Error() != "send failed (and 1 more handler errors)" {
+		t.Errorf("expected the first error, got %v", err)
+	}
+	if ok.created != 1 {
+		t.Error("expected the handlers after a failing one to receive the event")
+	}
+	if !errors.Is(err, errSendFailed) {
+		t.Error("expected the first error to be wrapped")
+	}
+}
+
+// flakyHandler fails the created events until fail is cleared
+type flakyHandler struct {
+	countingHandler
+	fail bool
+}
+
+func (h *flakyHandler) ObjectCreated(ctx context.Context, obj interface{}) error {
+	h.created++
+	if h.fail {
+		return errSendFailed
+	}
+	return nil
+}
+
+func TestRoutedRetry(t *testing.T) {
+	ok, flaky := &countingHandler{}, &flakyHandler{fail: true}
+	r := &Routed{Handlers: map[string]Handler{"slack": ok, "webhook": flaky}}
+	e := event.Event{Kind: "Pod", Name: "default/foo", Namespace: "default", ResourceVersion: "42"}
+
+	// the retries of an event go to the handlers which failed it only
+	for i := 0; i < 2; i++ {
+		if err := r.ObjectCreated(context.Background(), e); err == nil {
+			t.Fatal("expected the failing handler to fail the event")
+		}
+	}
+	flaky.fail = false
+	if err := r.ObjectCreated(context.Background(), e); err != nil {
+		t.Fatalf("ObjectCreated(): %v", err)
+	}
+	if ok.created != 1 || flaky.created != 3 {
+		t.Fatalf("expected the event to be sent once to the succeeding handler and retried on the failing one, got %d and %d", ok.created, flaky.created)
+	}
+
+	// events once sent to all handlers, or of another version, go to all handlers again
+	r.ObjectCreated(context.Background(), e)
+	e.ResourceVersion = "43"
+	r.ObjectCreated(context.Background(), e)
+	if ok.created != 3 || flaky.created != 5 {
+		t.Fatalf("expected the later events to be sent to all handlers, got %d and %d", ok.created, flaky.created)
+	}
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+// Checker is implemented by handlers able to check their configuration without notifying,
+// e.g. through an authentication endpoint of their API
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// SelfTest checks an initialized handler within timeout, DefaultTimeout when 0.
+// Checkers are checked, the other handlers are sent a test event.
+func SelfTest(h Handler, timeout time.Duration) error {
+	ctx, cancel := detached(timeout)
+	defer cancel()
+
+	if checker, ok := h.(Checker); ok {
+		return checker.Check(ctx)
+	}
+	return h.ObjectCreated(ctx, event.Event{
+		Kind:   "kubewatch",
+		Reason: "self-test",
+		Status: "Normal",
+		Text:   "kubewatch self-test: notifications are delivered through this handler",
+	})
+}
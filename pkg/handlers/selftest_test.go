@@ -0,0 +1,49 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"context"
+	"testing"
+)
+
+// checkingHandler checks its configuration without notifying
+type checkingHandler struct {
+	failingHandler
+	checked bool
+}
+
+func (h *checkingHandler) Check(ctx context.Context) error {
+	h.checked = true
+	return nil
+}
+
+func TestSelfTest(t *testing.T) {
+	checking := &checkingHandler{}
+	if err := SelfTest(checking, 0); err != nil || !checking.checked {
+		t.Errorf("expected checkers to be checked instead of notified, got %v", err)
+	}
+
+	if err := SelfTest(&failingHandler{}, 0); err != errSendFailed {
+		t.Errorf("expected the error of the test event, got %v", err)
+	}
+
+	sent := &countingHandler{}
+	if err := SelfTest(sent, 0); err != nil || sent.created != 1 {
+		t.Errorf("expected a test event to be sent, got %d: %v", sent.created, err)
+	}
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sentry
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
+)
+
+var sentryErrMsg = `
+%s
+
+You need to set the Sentry dsn
+using "--dsn/-d" or using environment variables:
+
+export KW_SENTRY_DSN=https://key@sentry.example.com/1
+export KW_SENTRY_ENVIRONMENT=production
+
+Command line flags will override environment variables
+
+`
+
+// flushTimeout bounds how long Close waits for the buffered events to be sent
+const flushTimeout = 5 * time.Second
+
+// Sentry handler implements handler.Handler interface,
+// Capture events as Sentry messages grouped by object
+type Sentry struct {
+	DSN         string
+	Environment string
+	EventTypes  []string
+
+	client capturer
+}
+
+// capturer captures events, implemented by sentry.Client
+type capturer interface {
+	CaptureEvent(event *sentry.Event, hint *sentry.EventHint, scope sentry.EventModifier) *sentry.EventID
+	Flush(timeout time.Duration) bool
+}
+
+// eventTypes maps the actions of the handler to event types
+var eventTypes = map[string]string{
+	"created": "create",
+	"updated": "update",
+	"deleted": "delete",
+}
+
+// levels maps the statuses of events to Sentry levels
+var levels = map[string]sentry.Level{
+	"Normal":  sentry.LevelInfo,
+	"Warning": sentry.LevelWarning,
+	"Danger":  sentry.LevelError,
+}
+
+// Init prepares Sentry configuration and creates the client shared by all events
+func (s *Sentry) Init(c *config.Config) error {
+	dsn := c.Handler.Sentry.DSN
+	environment := c.Handler.Sentry.Environment
+
+	if dsn == "" {
+		dsn = os.Getenv("KW_SENTRY_DSN")
+	}
+
+	if environment == "" {
+		environment = os.Getenv("KW_SENTRY_ENVIRONMENT")
+	}
+
+	if dsn == "" {
+		return fmt.Errorf(sentryErrMsg, "Missing Sentry dsn")
+	}
+
+	transport, err := utils.NewHTTPTransport(c.Handler.HTTP)
+	if err != nil {
+		return err
+	}
+	client, err := sentry.NewClient(sentry.ClientOptions{Dsn: dsn, Environment: environment, HTTPTransport: transport})
+	if err != nil {
+		return fmt.Errorf("Failed creating Sentry client: %v", err)
+	}
+
+	s.DSN = dsn
+	s.Environment = environment
+	s.EventTypes = c.Handler.Sentry.EventTypes
+	s.client = client
+	return nil
+}
+
+// ObjectCreated calls notifySentry on event creation
+func (s *Sentry) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifySentry(s, obj, "created")
+}
+
+// ObjectDeleted calls notifySentry on event creation
+func (s *Sentry) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifySentry(s, obj, "deleted")
+}
+
+// ObjectUpdated calls notifySentry on event creation
+func (s *Sentry) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifySentry(s, newObj, "updated")
+}
+
+// TestHandler tests the handler configurarion by sending test messages.
+func (s *Sentry) TestHandler() {
+	event := sentry.NewEvent()
+	event.Message = "Testing Handler Configuration. This is a Test message."
+	event.Logger = "kubewatch"
+	if s.client.CaptureEvent(event, nil, nil) == nil || !s.client.Flush(flushTimeout) {
+		log.Printf("Failed sending the test message to Sentry")
+		return
+	}
+
+	log.Printf("Message successfully sent to Sentry")
+}
+
+// Close sends the buffered events
+func (s *Sentry) Close() error {
+	if s.client == nil {
+		return nil
+	}
+	if !s.client.Flush(flushTimeout) {
+		return fmt.Errorf("Timed out sending the buffered Sentry events")
+	}
+	return nil
+}
+
+func notifySentry(s *Sentry, obj interface{}, action string) error {
+	if !s.captures(eventTypes[action]) {
+		return nil
+	}
+
+	e := kbEvent.New(obj, action)
+	// events are sent in the background, only the ones dropped by the client fail
+	if s.client.CaptureEvent(prepareSentryEvent(e, eventTypes[action]), nil, nil) == nil {
+		return fmt.Errorf("Failed capturing Sentry event of %s", e.Key())
+	}
+
+	log.Printf("Event successfully captured by Sentry")
+	return nil
+}
+
+// captures reports whether the events of an event type are captured, all of them without event types
+func (s *Sentry) captures(eventType string) bool {
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// prepareSentryEvent returns the Sentry event of an event, fingerprinted by its
+// object and event type so that the repeated events of an object are grouped
+func prepareSentryEvent(e kbEvent.Event, eventType string) *sentry.Event {
+	event := sentry.NewEvent()
+	event.Message = e.Message()
+	event.Logger = "kubewatch"
+	event.Level = levels[e.Status]
+	event.Fingerprint = []string{"kubewatch", e.Key(), eventType}
+	event.Tags["kind"] = e.Kind
+	event.Tags["event_type"] = eventType
+	event.Tags["reason"] = e.Reason
+	if e.Namespace != "" {
+		event.Tags["namespace"] = e.Namespace
+	}
+	if e.Cluster != "" {
+		event.Tags["cluster"] = e.Cluster
+	}
+	event.Extra["name"] = e.Name
+	return event
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sentry
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	api_v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/mudasirmirza/kubewatch/config"
+)
+
+// fakeCapturer records the captured events
+type fakeCapturer struct {
+	events  []*sentry.Event
+	drop    bool
+	flushed bool
+}
+
+func (f *fakeCapturer) CaptureEvent(event *sentry.Event, hint *sentry.EventHint, scope sentry.EventModifier) *sentry.EventID {
+	if f.drop {
+		return nil
+	}
+	f.events = append(f.events, event)
+	id := sentry.EventID("0")
+	return &id
+}
+
+func (f *fakeCapturer) Flush(timeout time.Duration) bool {
+	f.flushed = true
+	return true
+}
+
+func TestSentryInit(t *testing.T) {
+	s := &Sentry{}
+	expectedError := fmt.Errorf(sentryErrMsg, "Missing Sentry dsn")
+
+	var Tests = []struct {
+		sentry config.Sentry
+		err    error
+	}{
+		{config.Sentry{DSN: "https://key@sentry.example.com/1"}, nil},
+		{config.Sentry{Environment: "production"}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Sentry = tt.sentry
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestSentryEvent(t *testing.T) {
+	client := &fakeCapturer{}
+	s := &Sentry{EventTypes: []string{"delete"}, client: client}
+	pod := &api_v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}}
+
+	if err := s.ObjectCreated(context.Background(), pod); err != nil {
+		t.Fatalf("ObjectCreated(): %v", err)
+	}
+	if len(client.events) != 0 {
+		t.Fatalf("expected create events to be skipped, got %d events", len(client.events))
+	}
+
+	if err := s.ObjectDeleted(context.Background(), pod); err != nil {
+		t.Fatalf("ObjectDeleted(): %v", err)
+	}
+	if len(client.events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(client.events))
+	}
+	event := client.events[0]
+	if event.Level != sentry.LevelError {
+		t.Errorf("expected level error, got %q", event.Level)
+	}
+	expectedTags := map[string]string{"kind": "Pod", "event_type": "delete", "reason": "deleted", "namespace": "default"}
+	if !reflect.DeepEqual(event.Tags, expectedTags) {
+		t.Errorf("expected tags %v, got %v", expectedTags, event.Tags)
+	}
+	if len(event.Fingerprint) != 3 || event.Fingerprint[2] != "delete" {
+		t.Errorf("unexpected fingerprint %v", event.Fingerprint)
+	}
+
+	client.drop = true
+	if err := s.ObjectDeleted(context.Background(), pod); err == nil {
+		t.Fatalf("expected an error when the event is dropped")
+	}
+
+	if err := s.Close(); err != nil || !client.flushed {
+		t.Fatalf("expected Close to flush the events, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"context"
+	"io"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+// Severe wraps a handler and passes on the events at least as severe as MinSeverity,
+// the other ones are dropped
+type Severe struct {
+	Handler     Handler
+	MinSeverity string
+}
+
+// Init initializes the wrapped handler
+func (s *Severe) Init(c *config.Config) error {
+	return s.Handler.Init(c)
+}
+
+// ObjectCreated passes the created event on when it is severe enough
+func (s *Severe) ObjectCreated(ctx context.Context, obj interface{}) error {
+	if !s.severe(obj, "created") {
+		return nil
+	}
+	return s.Handler.ObjectCreated(ctx, obj)
+}
+
+// ObjectDeleted passes the deleted event on when it is severe enough
+func (s *Severe) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	if !s.severe(obj, "deleted") {
+		return nil
+	}
+	return s.Handler.ObjectDeleted(ctx, obj)
+}
+
+// ObjectUpdated passes the updated event on when it is severe enough
+func (s *Severe) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	if !s.severe(newObj, "updated") {
+		return nil
+	}
+	return s.Handler.ObjectUpdated(ctx, oldObj, newObj)
+}
+
+// TestHandler tests the wrapped handler configuration
+func (s *Severe) TestHandler() {
+	s.Handler.TestHandler()
+}
+
+// Close closes the wrapped handler when it holds resources
+func (s *Severe) Close() error {
+	if closer, ok := s.Handler.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (s *Severe) severe(obj interface{}, action string) bool {
+	return event.AtLeast(event.New(obj, action).Severity, s.MinSeverity)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+// severityHandler records the severities of the events it receives
+type severityHandler struct {
+	Default
+	severities []string
+}
+
+func (h *severityHandler) ObjectCreated(ctx context.Context, obj interface{}) error {
+	h.severities = append(h.severities, event.New(obj, "created").Severity)
+	return nil
+}
+
+func (h *severityHandler) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	h.severities = append(h.severities, event.New(obj, "deleted").Severity)
+	return nil
+}
+
+func TestSevere(t *testing.T) {
+	h := &severityHandler{}
+	s := &Severe{Handler: h, MinSeverity: event.SeverityWarning}
+	pod := &api_v1.Pod{ObjectMeta: meta_v1.ObjectMeta{Name: "web", Namespace: "default"}}
+	secret := &api_v1.Secret{ObjectMeta: meta_v1.ObjectMeta{Name: "tls", Namespace: "default"}}
+
+	s.ObjectCreated(context.Background(), pod)
+	s.ObjectDeleted(context.Background(), pod)
+	s.ObjectCreated(context.Background(), secret)
+	s.ObjectDeleted(context.Background(), secret)
+	s.ObjectCreated(context.Background(), event.Event{Kind: "deployment", Reason: "unavailable", Status: "Danger"})
+
+	expected := []string{event.SeverityWarning, event.SeverityCritical, event.SeverityCritical}
+	if len(h.severities) != len(expected) {
+		t.Fatalf("expected %v to be passed on, got %v", expected, h.severities)
+	}
+	for i := range expected {
+		if h.severities[i] != expected[i] {
+			t.Errorf("expected %v to be passed on, got %v", expected, h.severities)
+		}
+	}
+}
@@ -17,17 +17,23 @@ limitations under the License.
 package slack
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/nlopes/slack"
 
 	"github.com/mudasirmirza/kubewatch/config"
 	"github.com/mudasirmirza/kubewatch/pkg/event"
 	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
 )
 
+// defaultTimeout bounds the requests unless the handler timeout is configured
+const defaultTimeout = 30 * time.Second
+
 var slackColors = map[string]string{
 	"Normal":  "good",
 	"Warning": "warning",
@@ -53,6 +59,9 @@ type Slack struct {
 	Token   string
 	Channel string
 	Title   string
+
+	// threads is set when events of an object are threaded
+	threads *threads
 }
 
 // Init prepares slack configuration
@@ -79,23 +88,37 @@ func (s *Slack) Init(c *config.Config) error {
 	s.Token = token
 	s.Channel = channel
 	s.Title = title
+	// the slack package sends all its requests with a single client and takes
+	// no context, the client bounds them by the handler timeout instead
+	client, err := utils.NewHTTPClient(c.Handler.HTTP)
+	if err != nil {
+		return err
+	}
+	client.Timeout = c.HandlerTimeout
+	if client.Timeout == 0 {
+		client.Timeout = defaultTimeout
+	}
+	slack.SetHTTPClient(client)
+	if c.Handler.Slack.Threads {
+		s.threads = newThreads(c.Handler.Slack.ThreadTTL)
+	}
 
 	return checkMissingSlackVars(s)
 }
 
 // ObjectCreated calls notifySlack on event creation
-func (s *Slack) ObjectCreated(obj interface{}) {
-	notifySlack(s, obj, "created")
+func (s *Slack) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifySlack(s, obj, "created")
 }
 
 // ObjectDeleted calls notifySlack on event creation
-func (s *Slack) ObjectDeleted(obj interface{}) {
-	notifySlack(s, obj, "deleted")
+func (s *Slack) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifySlack(s, obj, "deleted")
 }
 
 // ObjectUpdated calls notifySlack on event creation
-func (s *Slack) ObjectUpdated(oldObj, newObj interface{}) {
-	notifySlack(s, newObj, "updated")
+func (s *Slack) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifySlack(s, newObj, "updated")
 }
 
 // TestHandler tests the handler configurarion by sending test messages.
@@ -121,7 +144,19 @@ func (s *Slack) TestHandler() {
 	log.Printf("Message successfully sent to channel %s at %s", channelID, timestamp)
 }
 
-func notifySlack(s *Slack, obj interface{}, action string) {
+// Check checks the token with the auth.test method, bounded by the timeout of the client
+// as the slack package takes no context
+func (s *Slack) Check(ctx context.Context) error {
+	auth, err := slack.New(s.Token).AuthTest()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Slack token is valid for user %s of team %s", auth.User, auth.Team)
+	return nil
+}
+
+func notifySlack(s *Slack, obj interface{}, action string) error {
 	e := kbEvent.New(obj, action)
 	api := slack.New(s.Token)
 	params := slack.PostMessageParameters{}
@@ -129,13 +164,23 @@ func notifySlack(s *Slack, obj interface{}, action string) {
 
 	params.Attachments = []slack.Attachment{attachment}
 	params.AsUser = true
+
+	key := threadKey(e)
+	threaded := false
+	if s.threads != nil {
+		params.ThreadTimestamp, threaded = s.threads.parent(key)
+	}
+
 	channelID, timestamp, err := api.PostMessage(s.Channel, "", params)
 	if err != nil {
-		log.Printf("%s\n", err)
-		return
+		return err
+	}
+	if s.threads != nil && !threaded {
+		s.threads.add(key, timestamp)
 	}
 
 	log.Printf("Message successfully sent to channel %s at %s", channelID, timestamp)
+	return nil
 }
 
 func checkMissingSlackVars(s *Slack) error {
@@ -17,10 +17,17 @@ limitations under the License.
 package slack
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 
+	"github.com/nlopes/slack"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	"github.com/mudasirmirza/kubewatch/config"
 )
 
@@ -46,3 +53,42 @@ func TestSlackInit(t *testing.T) {
 		}
 	}
 }
+
+func TestSlackThreads(t *testing.T) {
+	var threadTimestamps []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		threadTimestamps = append(threadTimestamps, r.Form.Get("thread_ts"))
+		fmt.Fprintf(w, `{"ok": true, "channel": "C1", "ts": "%d.000"}`, len(threadTimestamps))
+	}))
+	defer ts.Close()
+
+	api := slack.SLACK_API
+	slack.SLACK_API = ts.URL + "/"
+	defer func() { slack.SLACK_API = api }()
+
+	c := &config.Config{}
+	c.Handler.Slack = config.Slack{Token: "foo", Channel: "bar", Threads: true}
+	s := &Slack{}
+	if err := s.Init(c); err != nil {
+		t.Fatal(err)
+	}
+
+	foo := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "new"}}
+	bar := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "new"}}
+	for _, err := range []error{
+		s.ObjectCreated(context.Background(), foo),
+		s.ObjectUpdated(context.Background(), foo, foo),
+		s.ObjectCreated(context.Background(), bar),
+		s.ObjectDeleted(context.Background(), foo),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"", "1.000", "", "1.000"}
+	if !reflect.DeepEqual(threadTimestamps, want) {
+		t.Errorf("thread_ts = %q, want %q", threadTimestamps, want)
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package slack
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+// defaultThreadTTL bounds how long events of an object are threaded under its first message
+const defaultThreadTTL = time.Hour
+
+// thread is the parent message of the events of an object
+type thread struct {
+	timestamp string
+	expires   time.Time
+}
+
+// threads maps objects to the timestamps of their parent messages,
+// entries expire after ttl so memory stays bounded
+type threads struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time
+	parents map[string]thread
+}
+
+func newThreads(ttl time.Duration) *threads {
+	if ttl <= 0 {
+		ttl = defaultThreadTTL
+	}
+	return &threads{ttl: ttl, now: time.Now, parents: map[string]thread{}}
+}
+
+// threadKey identifies the object of an event
+func threadKey(e event.Event) string {
+	return e.Cluster + "/" + e.Kind + "/" + e.Namespace + "/" + e.Name
+}
+
+// parent returns the timestamp of the live parent message of key, if any
+func (t *threads) parent(key string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p, ok := t.parents[key]
+	if !ok || !t.now().Before(p.expires) {
+		return "", false
+	}
+	return p.timestamp, true
+}
+
+// add records timestamp as the parent message of key and drops expired entries
+func (t *threads) add(key, timestamp string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	for k, p := range t.parents {
+		if !now.Before(p.expires) {
+			delete(t.parents, k)
+		}
+	}
+	t.parents[key] = thread{timestamp: timestamp, expires: now.Add(t.ttl)}
+}
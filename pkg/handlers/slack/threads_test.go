@@ -0,0 +1,32 @@
+package slack
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThreadsExpire(t *testing.T) {
+	now := time.Unix(0, 0)
+	th := newThreads(time.Minute)
+	th.now = func() time.Time { return now }
+
+	if _, ok := th.parent("pod/new/foo"); ok {
+		t.Fatal("expected no parent before the first message")
+	}
+	th.add("pod/new/foo", "1.000")
+
+	now = now.Add(59 * time.Second)
+	if ts, ok := th.parent("pod/new/foo"); !ok || ts != "1.000" {
+		t.Fatalf("parent() = %q, %v, want 1.000, true", ts, ok)
+	}
+
+	now = now.Add(time.Second)
+	if _, ok := th.parent("pod/new/foo"); ok {
+		t.Fatal("expected the parent to expire after the ttl")
+	}
+
+	th.add("pod/new/bar", "2.000")
+	if len(th.parents) != 1 {
+		t.Errorf("expected expired parents to be dropped, got %v", th.parents)
+	}
+}
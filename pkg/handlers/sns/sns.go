@@ -0,0 +1,194 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+var snsErrMsg = `
+%s
+
+You need to set the SNS topic ARN
+using "--topicarn/-t" or using environment variables:
+
+export KW_SNS_TOPICARN=sns_topic_arn
+
+Command line flags will override environment variables
+
+`
+
+// SNS handler implements handler.Handler interface,
+// Publish event to an AWS SNS topic
+type SNS struct {
+	Region   string
+	TopicARN string
+
+	client snsiface.SNSAPI
+}
+
+// SNSMessage is the JSON message published per event
+type SNSMessage struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Reason    string `json:"reason"`
+	Status    string `json:"status"`
+	Cluster   string `json:"cluster,omitempty"`
+	Message   string `json:"message"`
+}
+
+// Init prepares SNS configuration, credentials default to the
+// AWS credential chain, e.g. the IAM role of the service account
+func (s *SNS) Init(c *config.Config) error {
+	region := c.Handler.SNS.Region
+	topicARN := c.Handler.SNS.TopicARN
+
+	if topicARN == "" {
+		topicARN = os.Getenv("KW_SNS_TOPICARN")
+	}
+
+	if region == "" {
+		region = os.Getenv("KW_SNS_REGION")
+		if region == "" {
+			region = topicRegion(topicARN)
+		}
+	}
+
+	s.Region = region
+	s.TopicARN = topicARN
+
+	if err := checkMissingSNSVars(s); err != nil {
+		return err
+	}
+
+	awsConfig := aws.NewConfig().WithRegion(s.Region)
+	if c.Handler.SNS.AccessKeyID != "" {
+		awsConfig = awsConfig.WithCredentials(credentials.NewStaticCredentials(
+			c.Handler.SNS.AccessKeyID, c.Handler.SNS.SecretAccessKey, ""))
+	}
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return fmt.Errorf("Failed creating AWS session: %v", err)
+	}
+	s.client = sns.New(sess)
+	return nil
+}
+
+// ObjectCreated calls notifySNS on event creation
+func (s *SNS) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifySNS(ctx, s, obj, "created")
+}
+
+// ObjectDeleted calls notifySNS on event creation
+func (s *SNS) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifySNS(ctx, s, obj, "deleted")
+}
+
+// ObjectUpdated calls notifySNS on event creation
+func (s *SNS) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifySNS(ctx, s, newObj, "updated")
+}
+
+// TestHandler tests the handler configurarion by sending test messages.
+func (s *SNS) TestHandler() {
+	_, err := s.client.Publish(&sns.PublishInput{
+		TopicArn: aws.String(s.TopicARN),
+		Message:  aws.String("Testing Handler Configuration. This is a Test message."),
+	})
+	if err != nil {
+		log.Printf("%s\n", err)
+		return
+	}
+
+	log.Printf("Message successfully published to %s", s.TopicARN)
+}
+
+func notifySNS(ctx context.Context, s *SNS, obj interface{}, action string) error {
+	e := kbEvent.New(obj, action)
+
+	input, err := preparePublishInput(e, s)
+	if err != nil {
+		return err
+	}
+	if _, err := s.client.PublishWithContext(ctx, input); err != nil {
+		return err
+	}
+
+	log.Printf("Message successfully published to %s", s.TopicARN)
+	return nil
+}
+
+func checkMissingSNSVars(s *SNS) error {
+	if s.TopicARN == "" || s.Region == "" {
+		return fmt.Errorf(snsErrMsg, "Missing SNS topic ARN or region")
+	}
+
+	return nil
+}
+
+// topicRegion returns the region of a topic ARN, arn:aws:sns:<region>:<account>:<topic>
+func topicRegion(topicARN string) string {
+	parts := strings.Split(topicARN, ":")
+	if len(parts) != 6 {
+		return ""
+	}
+	return parts[3]
+}
+
+func preparePublishInput(e kbEvent.Event, s *SNS) (*sns.PublishInput, error) {
+	message, err := json.Marshal(SNSMessage{
+		Kind:      e.Kind,
+		Name:      e.Name,
+		Namespace: e.Namespace,
+		Reason:    e.Reason,
+		Status:    e.Status,
+		Cluster:   e.Cluster,
+		Message:   e.Message(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// attributes let subscribers filter without parsing the message
+	attributes := map[string]*sns.MessageAttributeValue{
+		"kind": {DataType: aws.String("String"), StringValue: aws.String(e.Kind)},
+	}
+	if e.Reason != "" {
+		attributes["reason"] = &sns.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(e.Reason)}
+	}
+
+	return &sns.PublishInput{
+		TopicArn:          aws.String(s.TopicARN),
+		Message:           aws.String(string(message)),
+		MessageAttributes: attributes,
+	}, nil
+}
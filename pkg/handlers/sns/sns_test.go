@@ -0,0 +1,108 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sns
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+// fakeSNS records the published messages
+type fakeSNS struct {
+	snsiface.SNSAPI
+	published []*sns.PublishInput
+	err       error
+}
+
+func (f *fakeSNS) PublishWithContext(ctx aws.Context, input *sns.PublishInput, opts ...request.Option) (*sns.PublishOutput, error) {
+	f.published = append(f.published, input)
+	return &sns.PublishOutput{}, f.err
+}
+
+func TestSNSInit(t *testing.T) {
+	s := &SNS{}
+	expectedError := fmt.Errorf(snsErrMsg, "Missing SNS topic ARN or region")
+
+	var Tests = []struct {
+		sns    config.SNS
+		region string
+		err    error
+	}{
+		{config.SNS{TopicARN: "arn:aws:sns:eu-west-1:123456789012:kubewatch"}, "eu-west-1", nil},
+		{config.SNS{TopicARN: "arn:aws:sns:eu-west-1:123456789012:kubewatch", Region: "us-east-1"}, "us-east-1", nil},
+		{config.SNS{TopicARN: "kubewatch"}, "", expectedError},
+		{config.SNS{}, "", expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.SNS = tt.sns
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+		if tt.err == nil && s.Region != tt.region {
+			t.Fatalf("Init(): expected region %s, got %s", tt.region, s.Region)
+		}
+	}
+}
+
+func TestSNSPublish(t *testing.T) {
+	client := &fakeSNS{}
+	s := &SNS{TopicARN: "arn:aws:sns:eu-west-1:123456789012:kubewatch", client: client}
+
+	if err := s.ObjectDeleted(context.Background(), kbEvent.Event{Kind: "pod", Name: "default/foo", Namespace: "default"}); err != nil {
+		t.Fatalf("ObjectDeleted(): %v", err)
+	}
+	if len(client.published) != 1 {
+		t.Fatalf("expected a published message, got %v", client.published)
+	}
+
+	input := client.published[0]
+	if *input.TopicArn != s.TopicARN {
+		t.Fatalf("expected topic %s, got %s", s.TopicARN, *input.TopicArn)
+	}
+	if kind := input.MessageAttributes["kind"]; kind == nil || *kind.StringValue != "pod" {
+		t.Fatalf("expected a kind attribute, got %v", input.MessageAttributes)
+	}
+
+	var message SNSMessage
+	if err := json.Unmarshal([]byte(*input.Message), &message); err != nil {
+		t.Fatalf("expected a JSON message: %v", err)
+	}
+	if message.Kind != "pod" || message.Name != "default/foo" || message.Reason != "deleted" {
+		t.Fatalf("unexpected message %+v", message)
+	}
+}
+
+func TestSNSPublishError(t *testing.T) {
+	s := &SNS{TopicARN: "arn:aws:sns:eu-west-1:123456789012:kubewatch", client: &fakeSNS{err: errors.New("throttled")}}
+	if err := s.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "foo", Namespace: "default"}); err == nil {
+		t.Fatal("ObjectCreated(): expected the publish error")
+	}
+}
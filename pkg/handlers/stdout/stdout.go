@@ -0,0 +1,122 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stdout
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+// Stdout handler implements handler.Handler interface,
+// Print each event as a line of JSON to standard out
+type Stdout struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// StdoutMessage is the JSON line printed per event
+type StdoutMessage struct {
+	Timestamp string `json:"timestamp"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	EventType string `json:"eventType"`
+	Status    string `json:"status,omitempty"`
+	Cluster   string `json:"cluster,omitempty"`
+	Message   string `json:"message"`
+}
+
+// Init prepares Stdout configuration
+func (s *Stdout) Init(c *config.Config) error {
+	s.out = os.Stdout
+	return nil
+}
+
+// ObjectCreated calls notifyStdout on event creation
+func (s *Stdout) ObjectCreated(ctx context.Context, obj interface{}) error {
+	notifyStdout(s, obj, "created")
+	return nil
+}
+
+// ObjectDeleted calls notifyStdout on event creation
+func (s *Stdout) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	notifyStdout(s, obj, "deleted")
+	return nil
+}
+
+// ObjectUpdated calls notifyStdout on event creation
+func (s *Stdout) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	notifyStdout(s, newObj, "updated")
+	return nil
+}
+
+// TestHandler tests the handler configurarion by sending test messages.
+func (s *Stdout) TestHandler() {
+	s.print(StdoutMessage{
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Message:   "Testing Handler Configuration. This is a Test message.",
+	})
+}
+
+// notifyStdout prints the event, stdout is local so failures are only logged
+// and never make the controller retry
+func notifyStdout(s *Stdout, obj interface{}, action string) {
+	e := kbEvent.New(obj, action)
+	s.print(prepareStdoutMessage(e))
+}
+
+func (s *Stdout) print(m StdoutMessage) {
+	line, err := json.Marshal(m)
+	if err != nil {
+		log.Printf("Failed encoding event: %v", err)
+		return
+	}
+
+	// a single write per line keeps concurrent events from interleaving
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.out.Write(append(line, '\n')); err != nil {
+		log.Printf("Failed writing event to stdout: %v", err)
+	}
+}
+
+func prepareStdoutMessage(e kbEvent.Event) StdoutMessage {
+	name := e.Name
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	return StdoutMessage{
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Kind:      e.Kind,
+		Namespace: e.Namespace,
+		Name:      name,
+		EventType: e.Reason,
+		Status:    e.Status,
+		Cluster:   e.Cluster,
+		Message:   e.Message(),
+	}
+}
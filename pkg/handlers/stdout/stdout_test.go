@@ -0,0 +1,77 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stdout
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+func TestStdoutMessage(t *testing.T) {
+	var out bytes.Buffer
+	s := &Stdout{out: &out}
+
+	if err := s.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "default/foo", Namespace: "default"}); err != nil {
+		t.Fatalf("ObjectCreated(): %v", err)
+	}
+	if err := s.ObjectDeleted(context.Background(), kbEvent.Event{Kind: "namespace", Name: "bar"}); err != nil {
+		t.Fatalf("ObjectDeleted(): %v", err)
+	}
+
+	var messages []StdoutMessage
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var m StdoutMessage
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			t.Fatalf("expected a JSON line, got %q: %v", scanner.Text(), err)
+		}
+		messages = append(messages, m)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 lines, got %v", messages)
+	}
+
+	m := messages[0]
+	if m.Kind != "pod" || m.Namespace != "default" || m.Name != "foo" || m.EventType != "created" {
+		t.Fatalf("unexpected line %+v", m)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, m.Timestamp); err != nil {
+		t.Fatalf("expected an RFC 3339 timestamp: %v", err)
+	}
+	if m := messages[1]; m.Name != "bar" || m.EventType != "deleted" {
+		t.Fatalf("unexpected line %+v", m)
+	}
+}
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) { return 0, errors.New("broken pipe") }
+
+func TestStdoutNeverErrors(t *testing.T) {
+	s := &Stdout{out: failingWriter{}}
+	if err := s.ObjectUpdated(context.Background(), nil, kbEvent.Event{Kind: "pod", Name: "default/foo", Namespace: "default"}); err != nil {
+		t.Fatalf("ObjectUpdated(): expected write failures to be ignored, got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syslog
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+var syslogErrMsg = `
+%s
+
+You need to set both the syslog network and address for remote syslog,
+or enable the local syslog, using environment variables:
+
+export KW_SYSLOG_NETWORK=udp
+export KW_SYSLOG_ADDRESS=syslog:514
+
+Command line flags will override environment variables
+
+`
+
+// options returns the syslog config completed with the environment and defaults
+func options(c *config.Config) (config.Syslog, error) {
+	s := c.Handler.Syslog
+	if s.Network == "" && s.Address == "" {
+		s.Network = os.Getenv("KW_SYSLOG_NETWORK")
+		s.Address = os.Getenv("KW_SYSLOG_ADDRESS")
+	}
+	if s.Tag == "" {
+		s.Tag = "kubewatch"
+	}
+	if s.Severity == "" {
+		s.Severity = "info"
+	}
+	if s.Facility == "" {
+		s.Facility = "daemon"
+	}
+	if err := s.Validate(); err != nil {
+		return s, fmt.Errorf(syslogErrMsg, err)
+	}
+	return s, nil
+}
+
+// formatLine returns the line of an event: its kind, namespace/name and event type
+func formatLine(e kbEvent.Event) string {
+	name := e.Name
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if e.Namespace != "" {
+		name = e.Namespace + "/" + name
+	}
+	line := fmt.Sprintf("kind=%s name=%s event=%s", e.Kind, name, e.Reason)
+	if e.Cluster != "" {
+		line = fmt.Sprintf("cluster=%s %s", e.Cluster, line)
+	}
+	return line
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syslog
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+func TestFormatLine(t *testing.T) {
+	var Tests = []struct {
+		event kbEvent.Event
+		line  string
+	}{
+		{kbEvent.Event{Kind: "pod", Name: "default/foo", Namespace: "default", Reason: "created"}, "kind=pod name=default/foo event=created"},
+		{kbEvent.Event{Kind: "node", Name: "bar", Reason: "deleted"}, "kind=node name=bar event=deleted"},
+		{kbEvent.Event{Kind: "node", Name: "bar", Reason: "updated", Cluster: "prod"}, "cluster=prod kind=node name=bar event=updated"},
+	}
+
+	for _, tt := range Tests {
+		if line := formatLine(tt.event); line != tt.line {
+			t.Errorf("formatLine(%+v) = %q, want %q", tt.event, line, tt.line)
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	c := &config.Config{}
+	c.Handler.Syslog = config.Syslog{Enabled: true}
+	opts, err := options(c)
+	if err != nil {
+		t.Fatalf("options(): %v", err)
+	}
+	if opts.Tag != "kubewatch" || opts.Severity != "info" || opts.Facility != "daemon" {
+		t.Errorf("expected the defaults, got %+v", opts)
+	}
+
+	c.Handler.Syslog = config.Syslog{Network: "tcp"}
+	expectedError := fmt.Errorf(syslogErrMsg, "syslog: network and address must be set together")
+	if _, err := options(c); err == nil || err.Error() != expectedError.Error() {
+		t.Errorf("options(): expected %v, got %v", expectedError, err)
+	}
+}
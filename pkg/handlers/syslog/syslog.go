@@ -0,0 +1,124 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syslog
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/syslog"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+var severities = map[string]syslog.Priority{
+	"emerg":   syslog.LOG_EMERG,
+	"alert":   syslog.LOG_ALERT,
+	"crit":    syslog.LOG_CRIT,
+	"err":     syslog.LOG_ERR,
+	"warning": syslog.LOG_WARNING,
+	"notice":  syslog.LOG_NOTICE,
+	"info":    syslog.LOG_INFO,
+	"debug":   syslog.LOG_DEBUG,
+}
+
+var facilities = map[string]syslog.Priority{
+	"kern":     syslog.LOG_KERN,
+	"user":     syslog.LOG_USER,
+	"mail":     syslog.LOG_MAIL,
+	"daemon":   syslog.LOG_DAEMON,
+	"auth":     syslog.LOG_AUTH,
+	"syslog":   syslog.LOG_SYSLOG,
+	"lpr":      syslog.LOG_LPR,
+	"news":     syslog.LOG_NEWS,
+	"uucp":     syslog.LOG_UUCP,
+	"cron":     syslog.LOG_CRON,
+	"authpriv": syslog.LOG_AUTHPRIV,
+	"ftp":      syslog.LOG_FTP,
+	"local0":   syslog.LOG_LOCAL0,
+	"local1":   syslog.LOG_LOCAL1,
+	"local2":   syslog.LOG_LOCAL2,
+	"local3":   syslog.LOG_LOCAL3,
+	"local4":   syslog.LOG_LOCAL4,
+	"local5":   syslog.LOG_LOCAL5,
+	"local6":   syslog.LOG_LOCAL6,
+	"local7":   syslog.LOG_LOCAL7,
+}
+
+// Syslog handler implements handler.Handler interface,
+// Write each event as a line to the local or a remote syslog
+type Syslog struct {
+	writer *syslog.Writer
+}
+
+// Init dials the syslog, so that an unreachable one fails at startup
+func (s *Syslog) Init(c *config.Config) error {
+	opts, err := options(c)
+	if err != nil {
+		return err
+	}
+
+	writer, err := syslog.Dial(opts.Network, opts.Address, severities[opts.Severity]|facilities[opts.Facility], opts.Tag)
+	if err != nil {
+		return fmt.Errorf("Failed connecting to syslog %s: %v", opts.Address, err)
+	}
+	s.writer = writer
+	return nil
+}
+
+// ObjectCreated calls notifySyslog on event creation
+func (s *Syslog) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifySyslog(s, obj, "created")
+}
+
+// ObjectDeleted calls notifySyslog on event creation
+func (s *Syslog) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifySyslog(s, obj, "deleted")
+}
+
+// ObjectUpdated calls notifySyslog on event creation
+func (s *Syslog) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifySyslog(s, newObj, "updated")
+}
+
+// TestHandler tests the handler configurarion by sending test messages.
+func (s *Syslog) TestHandler() {
+	if _, err := s.writer.Write([]byte("Testing Handler Configuration. This is a Test message.")); err != nil {
+		log.Printf("%s\n", err)
+		return
+	}
+	log.Printf("Message successfully sent to syslog")
+}
+
+// Close closes the connection to the syslog
+func (s *Syslog) Close() error {
+	return s.writer.Close()
+}
+
+// notifySyslog writes the line of the event, log/syslog reconnects and
+// writes again once when a write fails, e.g. after the remote restarted
+func notifySyslog(s *Syslog, obj interface{}, action string) error {
+	e := kbEvent.New(obj, action)
+	if _, err := s.writer.Write([]byte(formatLine(e))); err != nil {
+		return fmt.Errorf("Failed writing to syslog: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+//go:build windows || plan9
+// +build windows plan9
+
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syslog
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+
+	"github.com/mudasirmirza/kubewatch/config"
+)
+
+// Syslog handler is unavailable where log/syslog isn't, it fails at startup
+type Syslog struct{}
+
+// Init reports that syslog is unsupported on this platform
+func (s *Syslog) Init(c *config.Config) error {
+	if _, err := options(c); err != nil {
+		return err
+	}
+	return fmt.Errorf("syslog handler is not supported on %s", runtime.GOOS)
+}
+
+// ObjectCreated is never called as Init fails
+func (s *Syslog) ObjectCreated(ctx context.Context, obj interface{}) error { return nil }
+
+// ObjectDeleted is never called as Init fails
+func (s *Syslog) ObjectDeleted(ctx context.Context, obj interface{}) error { return nil }
+
+// ObjectUpdated is never called as Init fails
+func (s *Syslog) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error { return nil }
+
+// TestHandler is never called as Init fails
+func (s *Syslog) TestHandler() {}
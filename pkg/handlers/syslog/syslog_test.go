@@ -0,0 +1,66 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syslog
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/mudasirmirza/kubewatch/config"
+)
+
+func TestSyslogRemote(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	c := &config.Config{}
+	c.Handler.Syslog = config.Syslog{Network: "udp", Address: conn.LocalAddr().String(), Tag: "kw", Severity: "warning", Facility: "local0"}
+	s := &Syslog{}
+	if err := s.Init(c); err != nil {
+		t.Fatalf("Init(): %v", err)
+	}
+	defer s.Close()
+
+	p := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "new"}}
+	if err := s.ObjectCreated(context.Background(), p); err != nil {
+		t.Fatalf("ObjectCreated(): %v", err)
+	}
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// local0.warning is priority 16*8+4
+	msg := string(buf[:n])
+	if !strings.HasPrefix(msg, "<132>") || !strings.Contains(msg, " kw[") || !strings.HasSuffix(strings.TrimSpace(msg), "kind=Pod name=new/foo event=created") {
+		t.Errorf("unexpected syslog message %q", msg)
+	}
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tcp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+// dialTimeout bounds connecting to the collector
+const dialTimeout = 30 * time.Second
+
+var tcpErrMsg = `
+%s
+
+You need to set the host:port of the collector,
+using "--address/-a", or using environment variables:
+
+export KW_TCP_ADDRESS=collector:9000
+
+Command line flags will override environment variables
+
+`
+
+// TCP handler implements handler.Handler interface,
+// Write each event as a line of JSON to a TCP socket
+type TCP struct {
+	Address string
+
+	tlsConfig *tls.Config
+	// mu guards the connection, which is opened on the first event
+	// and opened again once a write failed
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// TCPMessage is the JSON line written per event
+type TCPMessage struct {
+	Timestamp string `json:"timestamp"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	EventType string `json:"eventType"`
+	Status    string `json:"status,omitempty"`
+	Cluster   string `json:"cluster,omitempty"`
+	Message   string `json:"message"`
+}
+
+// Init prepares TCP configuration, the collector is connected to on the first event
+func (t *TCP) Init(c *config.Config) error {
+	address := c.Handler.TCP.Address
+
+	if address == "" {
+		address = os.Getenv("KW_TCP_ADDRESS")
+	}
+
+	t.Address = address
+	t.tlsConfig = nil
+	if c.Handler.TCP.TLS.Enabled {
+		tlsConfig, err := newTLSConfig(c.Handler.TCP.TLS)
+		if err != nil {
+			return err
+		}
+		t.tlsConfig = tlsConfig
+	}
+
+	return checkMissingTCPVars(t)
+}
+
+// ObjectCreated calls notifyTCP on event creation
+func (t *TCP) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifyTCP(ctx, t, obj, "created")
+}
+
+// ObjectDeleted calls notifyTCP on event deletion
+func (t *TCP) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifyTCP(ctx, t, obj, "deleted")
+}
+
+// ObjectUpdated calls notifyTCP on event update
+func (t *TCP) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifyTCP(ctx, t, newObj, "updated")
+}
+
+// TestHandler tests the handler configurarion by sending test messages.
+func (t *TCP) TestHandler() {
+	tcpMessage := TCPMessage{
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Message:   "Testing Handler Configuration. This is a Test message.",
+	}
+
+	if err := t.send(context.Background(), tcpMessage); err != nil {
+		log.Printf("%s\n", err)
+		return
+	}
+
+	log.Printf("Message successfully sent to %s", t.Address)
+}
+
+// Close closes the connection to the collector, if any
+func (t *TCP) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conn == nil {
+		return nil
+	}
+	err := t.conn.Close()
+	t.conn = nil
+	return err
+}
+
+func notifyTCP(ctx context.Context, t *TCP, obj interface{}, action string) error {
+	e := kbEvent.New(obj, action)
+	return t.send(ctx, prepareTCPMessage(e))
+}
+
+func checkMissingTCPVars(t *TCP) error {
+	if t.Address == "" {
+		return fmt.Errorf(tcpErrMsg, "Missing collector address")
+	}
+
+	return nil
+}
+
+func newTLSConfig(c config.TCPTLS) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify}
+	if c.CAFile != "" {
+		ca, err := ioutil.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed reading TCP CA file: %v", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("Failed parsing TCP CA file %s", c.CAFile)
+		}
+	}
+	return tlsConfig, nil
+}
+
+func prepareTCPMessage(e kbEvent.Event) TCPMessage {
+	name := e.Name
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	return TCPMessage{
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Kind:      e.Kind,
+		Namespace: e.Namespace,
+		Name:      name,
+		EventType: e.Reason,
+		Status:    e.Status,
+		Cluster:   e.Cluster,
+		Message:   e.Message(),
+	}
+}
+
+// send writes the line of m. A write failing, e.g. once the collector restarted, closes
+// the connection and is tried once more on a new one, the error of the second write is returned
+func (t *TCP) send(ctx context.Context, m TCPMessage) error {
+	line, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	// a single write per line keeps concurrent events from interleaving
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for attempt := 1; ; attempt++ {
+		if t.conn == nil {
+			conn, err := t.dial(ctx)
+			if err != nil {
+				return fmt.Errorf("Failed connecting to %s: %v", t.Address, err)
+			}
+			t.conn = conn
+		}
+
+		deadline, _ := ctx.Deadline()
+		t.conn.SetWriteDeadline(deadline)
+		_, err := t.conn.Write(line)
+		if err == nil {
+			return nil
+		}
+		t.conn.Close()
+		t.conn = nil
+		if attempt == 2 || ctx.Err() != nil {
+			return fmt.Errorf("Failed writing to %s: %v", t.Address, err)
+		}
+	}
+}
+
+func (t *TCP) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	if t.tlsConfig != nil {
+		return (&tls.Dialer{NetDialer: dialer, Config: t.tlsConfig}).DialContext(ctx, "tcp", t.Address)
+	}
+	return dialer.DialContext(ctx, "tcp", t.Address)
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+// listen accepts connections and sends each line read from them on the returned channel
+func listen(t *testing.T) (net.Listener, <-chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen(): %v", err)
+	}
+	lines := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					lines <- scanner.Text()
+				}
+			}()
+		}
+	}()
+	return l, lines
+}
+
+func TestTCPInit(t *testing.T) {
+	s := &TCP{}
+	expectedError := fmt.Errorf(tcpErrMsg, "Missing collector address")
+
+	var Tests = []struct {
+		tcp config.TCP
+		err error
+	}{
+		{config.TCP{Address: "collector:9000"}, nil},
+		{config.TCP{Address: "collector:9000", TLS: config.TCPTLS{Enabled: true}}, nil},
+		{config.TCP{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.TCP = tt.tcp
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestTCPMessage(t *testing.T) {
+	l, lines := listen(t)
+	defer l.Close()
+
+	s := &TCP{Address: l.Addr().String()}
+	defer s.Close()
+	if err := s.ObjectDeleted(context.Background(), kbEvent.Event{Kind: "pod", Name: "default/web", Namespace: "default", Cluster: "prod"}); err != nil {
+		t.Fatalf("ObjectDeleted(): %v", err)
+	}
+
+	var m TCPMessage
+	if err := json.Unmarshal([]byte(<-lines), &m); err != nil {
+		t.Fatalf("expected a line of JSON: %v", err)
+	}
+	if m.Kind != "pod" || m.Namespace != "default" || m.Name != "web" || m.EventType != "deleted" || m.Cluster != "prod" {
+		t.Fatalf("unexpected message %+v", m)
+	}
+}
+
+func TestTCPReconnect(t *testing.T) {
+	l, lines := listen(t)
+	defer l.Close()
+
+	s := &TCP{Address: l.Addr().String()}
+	defer s.Close()
+	if err := s.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "web"}); err != nil {
+		t.Fatalf("ObjectCreated(): %v", err)
+	}
+	<-lines
+
+	// the write on the broken connection fails and is tried again on a new one
+	s.conn.Close()
+	if err := s.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "api"}); err != nil {
+		t.Fatalf("ObjectCreated(): expected the event to be sent on a new connection: %v", err)
+	}
+	var m TCPMessage
+	if err := json.Unmarshal([]byte(<-lines), &m); err != nil || m.Name != "api" {
+		t.Fatalf("expected the event after reconnecting, got %+v: %v", m, err)
+	}
+}
+
+func TestTCPError(t *testing.T) {
+	l, _ := listen(t)
+	address := l.Addr().String()
+	l.Close()
+
+	s := &TCP{Address: address}
+	if err := s.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "web"}); err == nil {
+		t.Fatal("ObjectCreated(): expected the unreachable collector to be returned for retry")
+	}
+}
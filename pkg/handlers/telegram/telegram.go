@@ -0,0 +1,293 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
+)
+
+// DefaultUrl is the Telegram Bot API url
+const DefaultUrl = "https://api.telegram.org"
+
+var telegramEmojis = map[string]string{
+	"created": "🆕",
+	"updated": "🔄",
+	"deleted": "🗑",
+}
+
+var telegramStatusEmojis = map[string]string{
+	"Normal":  "✅",
+	"Warning": "⚠️",
+	"Danger":  "🚨",
+}
+
+var telegramErrMsg = `
+%s
+
+You need to set both the Telegram bot token and chat id,
+using "--token/-t" and "--chatid/-c", or using environment variables:
+
+export KW_TELEGRAM_BOTTOKEN=telegram_bot_token
+export KW_TELEGRAM_CHATID=telegram_chat_id
+
+Command line flags will override environment variables
+
+`
+
+// markdownEscaper escapes the entities of the Telegram Markdown parse mode
+var markdownEscaper = strings.NewReplacer("_", "\\_", "*", "\\*", "`", "\\`", "[", "\\[")
+
+// Telegram handler implements handler.Handler interface,
+// Notify event to a Telegram chat through a bot
+type Telegram struct {
+	BotToken string
+	// ChatID is either a numeric chat id or the @username of a channel
+	ChatID string
+	Url    string
+
+	client *http.Client
+}
+
+// TelegramMessage is the payload of the sendMessage method
+type TelegramMessage struct {
+	ChatID    interface{} `json:"chat_id"`
+	Text      string      `json:"text"`
+	ParseMode string      `json:"parse_mode,omitempty"`
+}
+
+// TelegramResponse is the response of the Bot API
+type TelegramResponse struct {
+	Ok          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+	Parameters  struct {
+		RetryAfter int `json:"retry_after"`
+	} `json:"parameters"`
+}
+
+// TelegramError is a Bot API error, asking to retry after a delay when rate limited
+type TelegramError struct {
+	Code        int
+	Description string
+	Retry       time.Duration
+}
+
+func (e *TelegramError) Error() string {
+	if e.Retry > 0 {
+		return fmt.Sprintf("Telegram API error %d: %s (retry after %s)", e.Code, e.Description, e.Retry)
+	}
+	return fmt.Sprintf("Telegram API error %d: %s", e.Code, e.Description)
+}
+
+// RetryAfter returns the delay asked for by the API before retrying
+func (e *TelegramError) RetryAfter() time.Duration {
+	return e.Retry
+}
+
+// Init prepares Telegram configuration
+func (t *Telegram) Init(c *config.Config) error {
+	botToken := c.Handler.Telegram.BotToken
+	chatID := c.Handler.Telegram.ChatID
+	url := c.Handler.Telegram.Url
+
+	if botToken == "" {
+		botToken = os.Getenv("KW_TELEGRAM_BOTTOKEN")
+	}
+
+	if chatID == "" {
+		chatID = os.Getenv("KW_TELEGRAM_CHATID")
+	}
+
+	if url == "" {
+		url = DefaultUrl
+	}
+
+	t.BotToken = botToken
+	t.ChatID = chatID
+	t.Url = url
+
+	client, err := utils.NewHTTPClient(c.Handler.HTTP)
+	if err != nil {
+		return err
+	}
+	t.client = client
+
+	return checkMissingTelegramVars(t)
+}
+
+// ObjectCreated calls notifyTelegram on event creation
+func (t *Telegram) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifyTelegram(ctx, t, obj, "created")
+}
+
+// ObjectDeleted calls notifyTelegram on event creation
+func (t *Telegram) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifyTelegram(ctx, t, obj, "deleted")
+}
+
+// ObjectUpdated calls notifyTelegram on event creation
+func (t *Telegram) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifyTelegram(ctx, t, newObj, "updated")
+}
+
+// TestHandler tests the handler configurarion by sending test messages.
+func (t *Telegram) TestHandler() {
+	telegramMessage := &TelegramMessage{
+		ChatID: chatID(t.ChatID),
+		Text:   "Testing Handler Configuration. This is a Test message.",
+	}
+
+	if err := sendMessage(context.Background(), t, telegramMessage); err != nil {
+		log.Printf("%s\n", err)
+		return
+	}
+
+	log.Printf("Message successfully sent to chat %s", t.ChatID)
+}
+
+// Check checks the bot token and its access to the chat with the getChat method
+func (t *Telegram) Check(ctx context.Context) error {
+	if err := callMethod(ctx, t, "getChat", map[string]interface{}{"chat_id": chatID(t.ChatID)}); err != nil {
+		return err
+	}
+
+	log.Printf("Telegram bot has access to chat %s", t.ChatID)
+	return nil
+}
+
+func notifyTelegram(ctx context.Context, t *Telegram, obj interface{}, action string) error {
+	e := kbEvent.New(obj, action)
+
+	if err := sendMessage(ctx, t, prepareTelegramMessage(e, t)); err != nil {
+		return err
+	}
+
+	log.Printf("Message successfully sent to chat %s", t.ChatID)
+	return nil
+}
+
+func checkMissingTelegramVars(t *Telegram) error {
+	if t.BotToken == "" || t.ChatID == "" {
+		return fmt.Errorf(telegramErrMsg, "Missing Telegram bot token or chat id")
+	}
+
+	return nil
+}
+
+// chatID sends numeric chat ids as numbers, channel usernames as strings
+func chatID(id string) interface{} {
+	if n, err := strconv.ParseInt(id, 10, 64); err == nil {
+		return n
+	}
+	return id
+}
+
+func prepareTelegramMessage(e kbEvent.Event, t *Telegram) *TelegramMessage {
+	emoji, ok := telegramEmojis[e.Reason]
+	if !ok {
+		emoji = telegramStatusEmojis[e.Status]
+	}
+
+	// templated messages are sent as is, their content can't be escaped
+	if e.Text != "" {
+		return &TelegramMessage{
+			ChatID: chatID(t.ChatID),
+			Text:   emoji + " " + e.Text,
+		}
+	}
+
+	verb := "has been"
+	if !ok {
+		verb = "reports"
+	}
+	text := fmt.Sprintf("%s *%s* `%s` in namespace `%s` %s *%s*",
+		emoji,
+		markdownEscaper.Replace(e.Kind),
+		e.Name,
+		e.Namespace,
+		verb,
+		markdownEscaper.Replace(e.Reason),
+	)
+	if e.Kind == kbEvent.DisplayName("namespace") {
+		text = fmt.Sprintf("%s *namespace* `%s` %s *%s*", emoji, e.Name, verb, markdownEscaper.Replace(e.Reason))
+	}
+	if e.Cluster != "" {
+		text = fmt.Sprintf("\\[%s] %s", markdownEscaper.Replace(e.Cluster), text)
+	}
+
+	return &TelegramMessage{
+		ChatID:    chatID(t.ChatID),
+		Text:      text,
+		ParseMode: "Markdown",
+	}
+}
+
+func sendMessage(ctx context.Context, t *Telegram, telegramMessage *TelegramMessage) error {
+	return callMethod(ctx, t, "sendMessage", telegramMessage)
+}
+
+// callMethod calls a method of the Bot API with its JSON parameters
+func callMethod(ctx context.Context, t *Telegram, method string, params interface{}) error {
+	message, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/bot%s/%s", strings.TrimSuffix(t.Url, "/"), t.BotToken, method)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	client := t.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// the error holds the url, don't leak the bot token
+		return fmt.Errorf("Failed sending to Telegram: %v", strings.Replace(err.Error(), t.BotToken, "<token>", -1))
+	}
+	defer resp.Body.Close()
+
+	var telegramResponse TelegramResponse
+	if err := json.NewDecoder(resp.Body).Decode(&telegramResponse); err != nil {
+		return fmt.Errorf("Failed reading Telegram response, got %s: %v", resp.Status, err)
+	}
+	if !telegramResponse.Ok {
+		return &TelegramError{
+			Code:        telegramResponse.ErrorCode,
+			Description: telegramResponse.Description,
+			Retry:       time.Duration(telegramResponse.Parameters.RetryAfter) * time.Second,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+func TestTelegramInit(t *testing.T) {
+	s := &Telegram{}
+	expectedError := fmt.Errorf(telegramErrMsg, "Missing Telegram bot token or chat id")
+
+	var Tests = []struct {
+		telegram config.Telegram
+		err      error
+	}{
+		{config.Telegram{BotToken: "foo", ChatID: "-100123"}, nil},
+		{config.Telegram{BotToken: "foo", ChatID: "@kubewatch"}, nil},
+		{config.Telegram{BotToken: "foo"}, expectedError},
+		{config.Telegram{ChatID: "@kubewatch"}, expectedError},
+		{config.Telegram{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Telegram = tt.telegram
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestTelegramMessage(t *testing.T) {
+	var messages []map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/botfoo/sendMessage" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var m map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			t.Errorf("expected a Telegram message: %v", err)
+		}
+		messages = append(messages, m)
+		fmt.Fprint(w, `{"ok":true,"result":{}}`)
+	}))
+	defer ts.Close()
+
+	for _, id := range []string{"-100123", "@kubewatch"} {
+		tg := &Telegram{BotToken: "foo", ChatID: id, Url: ts.URL}
+		if err := tg.ObjectCreated(context.Background(), kbEvent.Event{Kind: "replica set", Name: "foo_bar", Namespace: "default"}); err != nil {
+			t.Fatalf("ObjectCreated(): %v", err)
+		}
+	}
+
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %v", messages)
+	}
+	if id, ok := messages[0]["chat_id"].(float64); !ok || id != -100123 {
+		t.Fatalf("expected a numeric chat id, got %v", messages[0]["chat_id"])
+	}
+	if id, ok := messages[1]["chat_id"].(string); !ok || id != "@kubewatch" {
+		t.Fatalf("expected a channel username chat id, got %v", messages[1]["chat_id"])
+	}
+	expected := "🆕 *replica set* `foo_bar` in namespace `default` has been *created*"
+	if messages[0]["text"] != expected || messages[0]["parse_mode"] != "Markdown" {
+		t.Fatalf("expected markdown text %q, got %v", expected, messages[0])
+	}
+}
+
+func TestTelegramRetryAfter(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 7","parameters":{"retry_after":7}}`)
+	}))
+	defer ts.Close()
+
+	tg := &Telegram{BotToken: "foo", ChatID: "@kubewatch", Url: ts.URL}
+	err := tg.ObjectDeleted(context.Background(), kbEvent.Event{Kind: "pod", Name: "default/foo", Namespace: "default"})
+	telegramErr, ok := err.(*TelegramError)
+	if !ok {
+		t.Fatalf("ObjectDeleted(): expected a TelegramError, got %v", err)
+	}
+	if telegramErr.Code != 429 || telegramErr.RetryAfter() != 7*time.Second {
+		t.Fatalf("expected to retry after 7s, got %v", telegramErr)
+	}
+}
+
+func TestTelegramCheck(t *testing.T) {
+	var path, body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		path, body = r.URL.Path, string(b)
+		if strings.Contains(body, "@unknown") {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`)
+			return
+		}
+		fmt.Fprint(w, `{"ok":true,"result":{"id":-100,"type":"channel"}}`)
+	}))
+	defer ts.Close()
+
+	tg := &Telegram{BotToken: "foo", ChatID: "@kubewatch", Url: ts.URL}
+	if err := tg.Check(context.Background()); err != nil {
+		t.Fatalf("Check(): %v", err)
+	}
+	if path != "/botfoo/getChat" || body != `{"chat_id":"@kubewatch"}` {
+		t.Errorf("expected getChat of @kubewatch, got %s %s", path, body)
+	}
+
+	tg.ChatID = "@unknown"
+	if err := tg.Check(context.Background()); err == nil || !strings.Contains(err.Error(), "chat not found") {
+		t.Errorf("expected the chat not to be found, got %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"context"
+	"io"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+// Templated wraps a handler and renders the message of each event
+// with the user supplied templates before passing it on
+type Templated struct {
+	Handler   Handler
+	Templates *event.Templates
+}
+
+// Init initializes the wrapped handler
+func (t *Templated) Init(c *config.Config) error {
+	return t.Handler.Init(c)
+}
+
+// ObjectCreated renders the created event and passes it to the wrapped handler
+func (t *Templated) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return t.Handler.ObjectCreated(ctx, t.render(obj, "created"))
+}
+
+// ObjectDeleted renders the deleted event and passes it to the wrapped handler
+func (t *Templated) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return t.Handler.ObjectDeleted(ctx, t.render(obj, "deleted"))
+}
+
+// ObjectUpdated renders the updated event and passes it to the wrapped handler
+func (t *Templated) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	e := t.render(newObj, "updated")
+	return t.Handler.ObjectUpdated(ctx, e, e)
+}
+
+// TestHandler tests the wrapped handler configuration
+func (t *Templated) TestHandler() {
+	t.Handler.TestHandler()
+}
+
+// Close closes the wrapped handler when it holds resources
+func (t *Templated) Close() error {
+	if closer, ok := t.Handler.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// render builds the event of obj with the message rendered from the action template.
+// Rendering errors are logged and the standard message is kept.
+func (t *Templated) render(obj interface{}, action string) event.Event {
+	e := event.New(obj, action)
+	text, err := t.Templates.Render(action, e)
+	if err != nil {
+		logrus.Errorf("Error rendering %s template: %v", action, err)
+		return e
+	}
+	e.Text = text
+	return e
+}
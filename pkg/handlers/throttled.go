@@ -0,0 +1,193 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+// Throttled wraps a handler and passes on the first event of an object and event type,
+// the repeated ones are suppressed until the window elapses. With Summary the number
+// of suppressed events is sent once the window elapses.
+type Throttled struct {
+	Handler Handler
+	// Config holds the throttling window and whether suppressed events are summarized
+	Config config.Throttle
+	// Clock drives the throttling windows, defaults to the real clock
+	Clock clock.Clock
+	// Timeout bounds sending a summary, defaults to DefaultTimeout
+	Timeout time.Duration
+
+	mu      sync.Mutex
+	windows map[string]*throttleWindow
+}
+
+// throttleWindow is the window opened by the first event of a key
+type throttleWindow struct {
+	end        time.Time
+	action     string
+	suppressed int
+	// latest suppressed event, summarized once the window elapses
+	last event.Event
+}
+
+// Init initializes the wrapped handler
+func (t *Throttled) Init(conf *config.Config) error {
+	return t.Handler.Init(conf)
+}
+
+// ObjectCreated throttles the created event
+func (t *Throttled) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return t.add("created", obj, func() error { return t.Handler.ObjectCreated(ctx, obj) })
+}
+
+// ObjectDeleted throttles the deleted event
+func (t *Throttled) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return t.add("deleted", obj, func() error { return t.Handler.ObjectDeleted(ctx, obj) })
+}
+
+// ObjectUpdated throttles the updated event
+func (t *Throttled) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return t.add("updated", newObj, func() error { return t.Handler.ObjectUpdated(ctx, oldObj, newObj) })
+}
+
+// TestHandler tests the wrapped handler configuration
+func (t *Throttled) TestHandler() {
+	t.Handler.TestHandler()
+}
+
+// Close sends the pending summaries and closes the wrapped handler
+func (t *Throttled) Close() error {
+	t.mu.Lock()
+	windows := t.windows
+	t.windows = nil
+	t.mu.Unlock()
+
+	if t.Config.Summary {
+		for _, w := range windows {
+			t.summarize(w)
+		}
+	}
+	if closer, ok := t.Handler.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// add sends the event of obj when it opens a window of its object and action,
+// and suppresses it when one is open. Events failing to send close their window
+// for the controller retries not to be suppressed.
+func (t *Throttled) add(action string, obj interface{}, send func() error) error {
+	if t.Config.Window <= 0 {
+		return send()
+	}
+	e := event.New(obj, action)
+	key := e.Key() + "/" + action
+
+	t.mu.Lock()
+	if t.Clock == nil {
+		t.Clock = clock.RealClock{}
+	}
+	now := t.Clock.Now()
+	if t.windows == nil {
+		t.windows = make(map[string]*throttleWindow)
+	}
+	t.prune(now)
+	if w, ok := t.windows[key]; ok && now.Before(w.end) {
+		w.suppressed++
+		w.last = e
+		t.mu.Unlock()
+		return nil
+	}
+	w := &throttleWindow{end: now.Add(t.Config.Window), action: action}
+	t.windows[key] = w
+	if t.Config.Summary {
+		timer := t.Clock.NewTimer(t.Config.Window)
+		go func() {
+			<-timer.C()
+			t.flush(key, w)
+		}()
+	}
+	t.mu.Unlock()
+
+	err := send()
+	if err != nil {
+		t.mu.Lock()
+		if t.windows[key] == w {
+			delete(t.windows, key)
+		}
+		t.mu.Unlock()
+	}
+	return err
+}
+
+// prune drops the elapsed windows without suppressed events, the ones
+// with suppressed events are dropped by flush once summarized
+func (t *Throttled) prune(now time.Time) {
+	for key, w := range t.windows {
+		if !now.Before(w.end) && (w.suppressed == 0 || !t.Config.Summary) {
+			delete(t.windows, key)
+		}
+	}
+}
+
+// flush closes the window of key and sends its summary
+func (t *Throttled) flush(key string, w *throttleWindow) {
+	t.mu.Lock()
+	if t.windows[key] != w {
+		t.mu.Unlock()
+		return
+	}
+	delete(t.windows, key)
+	t.mu.Unlock()
+
+	t.summarize(w)
+}
+
+// summarize sends the number of events suppressed in a window, if any, like its latest one
+func (t *Throttled) summarize(w *throttleWindow) {
+	if w.suppressed == 0 {
+		return
+	}
+	summary := w.last
+	summary.Text = fmt.Sprintf("%s\n%d more times in the last %s", summary.Message(), w.suppressed, t.Config.Window)
+
+	ctx, cancel := detached(t.Timeout)
+	defer cancel()
+
+	var err error
+	switch w.action {
+	case "created":
+		err = t.Handler.ObjectCreated(ctx, summary)
+	case "deleted":
+		err = t.Handler.ObjectDeleted(ctx, summary)
+	default:
+		err = t.Handler.ObjectUpdated(ctx, nil, summary)
+	}
+	if err != nil {
+		logrus.Errorf("Error sending throttled events summary: %v", err)
+	}
+}
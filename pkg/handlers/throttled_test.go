@@ -0,0 +1,106 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/event"
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+func newThrottled(summary bool) (*Throttled, *channelHandler, *clock.FakeClock) {
+	h := &channelHandler{events: make(chan event.Event, 10)}
+	fakeClock := clock.NewFakeClock(time.Now())
+	t := &Throttled{
+		Handler: h,
+		Config:  config.Throttle{Window: time.Minute, Summary: summary},
+		Clock:   fakeClock,
+	}
+	return t, h, fakeClock
+}
+
+func TestThrottled(t *testing.T) {
+	th, h, fakeClock := newThrottled(false)
+	update := event.Event{Kind: "pod", Name: "default/foo", Namespace: "default"}
+
+	for i := 0; i < 3; i++ {
+		th.ObjectUpdated(context.Background(), nil, update)
+	}
+	// other objects and event types have their own windows
+	th.ObjectUpdated(context.Background(), nil, event.Event{Kind: "pod", Name: "default/bar", Namespace: "default"})
+	th.ObjectDeleted(context.Background(), update)
+	if events := h.receive(t, 3); events[0].Name != "default/foo" || events[2].Reason != "deleted" {
+		t.Fatalf("expected the first event per object and event type, got %v", events)
+	}
+
+	fakeClock.Step(time.Minute)
+	th.ObjectUpdated(context.Background(), nil, update)
+	h.receive(t, 1)
+	if len(th.windows) != 1 {
+		t.Fatalf("expected the elapsed windows to be pruned, got %d windows", len(th.windows))
+	}
+}
+
+func TestThrottledSummary(t *testing.T) {
+	th, h, fakeClock := newThrottled(true)
+	update := event.Event{Kind: "pod", Name: "default/foo", Namespace: "default", Cluster: "prod"}
+
+	for i := 0; i < 4; i++ {
+		th.ObjectUpdated(context.Background(), nil, update)
+	}
+	h.receive(t, 1)
+
+	for !fakeClock.HasWaiters() {
+		time.Sleep(time.Millisecond)
+	}
+	fakeClock.Step(time.Minute)
+	summary := h.receive(t, 1)[0]
+	expected := "[prod] A `pod` in namespace `default` has been `updated`:\n`default/foo`\n3 more times in the last 1m0s"
+	if msg := summary.Message(); msg != expected {
+		t.Fatalf("expected summary %q, got %q", expected, msg)
+	}
+}
+
+func TestThrottledRetry(t *testing.T) {
+	th := &Throttled{Handler: &failingHandler{}, Config: config.Throttle{Window: time.Minute}, Clock: clock.NewFakeClock(time.Now())}
+	created := event.Event{Kind: "pod", Name: "foo", Namespace: "default"}
+	if err := th.ObjectCreated(context.Background(), created); err == nil {
+		t.Fatal("ObjectCreated(): expected the error of the handler")
+	}
+
+	// the retry of the failed event isn't suppressed
+	h := &countingHandler{}
+	th.Handler = h
+	if err := th.ObjectCreated(context.Background(), created); err != nil || h.created != 1 {
+		t.Fatalf("expected the retry to be sent, got %d sends: %v", h.created, err)
+	}
+}
+
+func TestThrottledConcurrent(t *testing.T) {
+	h := &channelHandler{events: make(chan event.Event, 10)}
+	th := &Throttled{Handler: h, Config: config.Throttle{Window: time.Minute}}
+
+	// workers of several controllers share the handler, its clock defaults once
+	for _, name := range []string{"default/foo", "default/bar"} {
+		go th.ObjectUpdated(context.Background(), nil, event.Event{Kind: "Pod", Name: name, Namespace: "default"})
+	}
+	h.receive(t, 2)
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package victorops
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
+)
+
+// DefaultUrl is the VictorOps REST integration endpoint, the api and routing keys are appended to it
+const DefaultUrl = "https://alert.victorops.com/integrations/generic/20131114/alert"
+
+var victoropsErrMsg = `
+%s
+
+You need to set both the VictorOps REST integration api key and routing key,
+using "--apikey/-k" and "--routingkey/-r", or using environment variables:
+
+export KW_VICTOROPS_APIKEY=victorops_api_key
+export KW_VICTOROPS_ROUTINGKEY=victorops_routing_key
+
+Command line flags will override environment variables
+
+`
+
+// VictorOps handler implements handler.Handler interface,
+// Raises a critical incident on object deletion, informational alerts otherwise
+type VictorOps struct {
+	APIKey          string
+	RoutingKey      string
+	ResolveOnDelete bool
+	Url             string
+
+	client *http.Client
+}
+
+// VictorOpsAlert is a VictorOps REST integration alert
+type VictorOpsAlert struct {
+	MessageType       string `json:"message_type"`
+	EntityID          string `json:"entity_id"`
+	EntityDisplayName string `json:"entity_display_name,omitempty"`
+	StateMessage      string `json:"state_message,omitempty"`
+	MonitoringTool    string `json:"monitoring_tool"`
+}
+
+// Init prepares VictorOps configuration
+func (v *VictorOps) Init(c *config.Config) error {
+	apiKey := c.Handler.VictorOps.APIKey
+	routingKey := c.Handler.VictorOps.RoutingKey
+	url := c.Handler.VictorOps.Url
+
+	if apiKey == "" {
+		apiKey = os.Getenv("KW_VICTOROPS_APIKEY")
+	}
+
+	if routingKey == "" {
+		routingKey = os.Getenv("KW_VICTOROPS_ROUTINGKEY")
+	}
+
+	if url == "" {
+		url = DefaultUrl
+	}
+
+	v.APIKey = apiKey
+	v.RoutingKey = routingKey
+	v.ResolveOnDelete = c.Handler.VictorOps.ResolveOnDelete
+	v.Url = url
+
+	client, err := utils.NewHTTPClient(c.Handler.HTTP)
+	if err != nil {
+		return err
+	}
+	v.client = client
+
+	return checkMissingVictorOpsVars(v)
+}
+
+// ObjectCreated sends an informational alert on object creation
+func (v *VictorOps) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifyVictorOps(ctx, v, obj, "created")
+}
+
+// ObjectDeleted raises a critical incident on object deletion, or recovers it with ResolveOnDelete
+func (v *VictorOps) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifyVictorOps(ctx, v, obj, "deleted")
+}
+
+// ObjectUpdated sends an informational alert on object update
+func (v *VictorOps) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifyVictorOps(ctx, v, newObj, "updated")
+}
+
+// TestHandler tests the handler configurarion by sending an informational alert.
+func (v *VictorOps) TestHandler() {
+	victoropsAlert := &VictorOpsAlert{
+		MessageType:       "INFO",
+		EntityID:          "kubewatch/test",
+		EntityDisplayName: "kubewatch test",
+		StateMessage:      "Testing Handler Configuration. This is a Test message.",
+		MonitoringTool:    "kubewatch",
+	}
+
+	if err := postAlert(context.Background(), v, victoropsAlert); err != nil {
+		log.Printf("%s\n", err)
+		return
+	}
+
+	log.Printf("Test alert successfully sent to routing key %s", v.RoutingKey)
+}
+
+func notifyVictorOps(ctx context.Context, v *VictorOps, obj interface{}, action string) error {
+	e := kbEvent.New(obj, action)
+
+	victoropsAlert := prepareVictorOpsAlert(e, v, action)
+	if err := postAlert(ctx, v, victoropsAlert); err != nil {
+		return err
+	}
+
+	log.Printf("VictorOps alert %s sent for %s", victoropsAlert.MessageType, victoropsAlert.EntityID)
+	return nil
+}
+
+func checkMissingVictorOpsVars(v *VictorOps) error {
+	if v.APIKey == "" || v.RoutingKey == "" {
+		return fmt.Errorf(victoropsErrMsg, "Missing VictorOps api key or routing key")
+	}
+
+	return nil
+}
+
+// messageType maps the event action to the VictorOps message type
+func (v *VictorOps) messageType(action string) string {
+	if action != "deleted" {
+		return "INFO"
+	}
+	if v.ResolveOnDelete {
+		return "RECOVERY"
+	}
+	return "CRITICAL"
+}
+
+func prepareVictorOpsAlert(e kbEvent.Event, v *VictorOps, action string) *VictorOpsAlert {
+	displayName := e.Kind + " " + e.Name
+	if e.Namespace != "" {
+		displayName = e.Kind + " " + e.Namespace + "/" + e.Name
+	}
+	if e.Cluster != "" {
+		displayName = fmt.Sprintf("[%s] %s", e.Cluster, displayName)
+	}
+
+	return &VictorOpsAlert{
+		MessageType:       v.messageType(action),
+		EntityID:          e.Key(),
+		EntityDisplayName: displayName,
+		StateMessage:      e.Message(),
+		MonitoringTool:    "kubewatch",
+	}
+}
+
+func postAlert(ctx context.Context, v *VictorOps, victoropsAlert *VictorOpsAlert) error {
+	message, err := json.Marshal(victoropsAlert)
+	if err != nil {
+		return err
+	}
+
+	endpoint := strings.TrimSuffix(v.Url, "/") + "/" + url.PathEscape(v.APIKey) + "/" + url.PathEscape(v.RoutingKey)
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(message))
+	if err != nil {
+		return fmt.Errorf("Failed sending to VictorOps: %v", scrubAPIKey(v, err))
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	client := v.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Failed sending to VictorOps: %v", scrubAPIKey(v, err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("VictorOps alert %s for %s failed with %s: %s",
+			victoropsAlert.MessageType, victoropsAlert.EntityID, resp.Status, body)
+	}
+	return nil
+}
+
+// scrubAPIKey keeps the api key, part of the url, out of logged errors
+func scrubAPIKey(v *VictorOps, err error) string {
+	return strings.Replace(err.Error(), url.PathEscape(v.APIKey), "<apikey>", -1)
+}
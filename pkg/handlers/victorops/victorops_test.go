@@ -0,0 +1,119 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package victorops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+func TestVictorOpsInit(t *testing.T) {
+	s := &VictorOps{}
+	expectedError := fmt.Errorf(victoropsErrMsg, "Missing VictorOps api key or routing key")
+
+	var Tests = []struct {
+		victorops config.VictorOps
+		err       error
+	}{
+		{config.VictorOps{APIKey: "foo", RoutingKey: "bar"}, nil},
+		{config.VictorOps{APIKey: "foo"}, expectedError},
+		{config.VictorOps{RoutingKey: "bar"}, expectedError},
+		{config.VictorOps{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.VictorOps = tt.victorops
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestVictorOpsAlert(t *testing.T) {
+	var paths []string
+	var alerts []VictorOpsAlert
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		var a VictorOpsAlert
+		if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+			t.Errorf("expected a VictorOps alert: %v", err)
+		}
+		alerts = append(alerts, a)
+	}))
+	defer ts.Close()
+
+	v := &VictorOps{APIKey: "foo", RoutingKey: "bar", Url: ts.URL + "/alert"}
+	e := kbEvent.Event{Kind: "pod", Name: "web", Namespace: "default", Cluster: "prod"}
+	if err := v.ObjectCreated(context.Background(), e); err != nil {
+		t.Fatalf("ObjectCreated(): %v", err)
+	}
+	if err := v.ObjectDeleted(context.Background(), e); err != nil {
+		t.Fatalf("ObjectDeleted(): %v", err)
+	}
+	v.ResolveOnDelete = true
+	if err := v.ObjectDeleted(context.Background(), e); err != nil {
+		t.Fatalf("ObjectDeleted(): %v", err)
+	}
+
+	if len(alerts) != 3 {
+		t.Fatalf("expected 3 alerts, got %v", alerts)
+	}
+	if paths[0] != "/alert/foo/bar" {
+		t.Errorf("unexpected path %q", paths[0])
+	}
+	for i, messageType := range []string{"INFO", "CRITICAL", "RECOVERY"} {
+		if alerts[i].MessageType != messageType {
+			t.Errorf("alert %d: expected message type %s, got %s", i, messageType, alerts[i].MessageType)
+		}
+		if alerts[i].EntityID != alerts[0].EntityID {
+			t.Errorf("alert %d: expected entity id %s, got %s", i, alerts[0].EntityID, alerts[i].EntityID)
+		}
+	}
+	if alerts[0].EntityDisplayName != "[prod] pod default/web" {
+		t.Errorf("unexpected entity display name %q", alerts[0].EntityDisplayName)
+	}
+	if !strings.Contains(alerts[0].StateMessage, "web") {
+		t.Errorf("unexpected state message %q", alerts[0].StateMessage)
+	}
+}
+
+func TestVictorOpsError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad api key", http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	v := &VictorOps{APIKey: "foo", RoutingKey: "bar", Url: ts.URL}
+	if err := v.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "web"}); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected an error with the status, got %v", err)
+	}
+
+	v.Url = "http://127.0.0.1:0"
+	if err := v.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "web"}); err == nil || strings.Contains(err.Error(), "foo") {
+		t.Fatalf("expected an error without the api key, got %v", err)
+	}
+}
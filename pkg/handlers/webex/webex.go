@@ -0,0 +1,219 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
+)
+
+// DefaultUrl is the Webex messages API url
+const DefaultUrl = "https://webexapis.com/v1/messages"
+
+// webexColors hints the status of events, Webex markdown has no colors
+var webexColors = map[string]string{
+	"Normal":  "🟢",
+	"Warning": "🟡",
+	"Danger":  "🔴",
+}
+
+var webexErrMsg = `
+%s
+
+You need to set both the Webex bot token and room id,
+using "--token/-t" and "--roomid/-r", or using environment variables:
+
+export KW_WEBEX_BOTTOKEN=webex_bot_token
+export KW_WEBEX_ROOMID=webex_room_id
+
+Command line flags will override environment variables
+
+`
+
+// Webex handler implements handler.Handler interface,
+// Notify event to a Webex space through a bot
+type Webex struct {
+	BotToken string
+	RoomID   string
+	Url      string
+
+	client *http.Client
+}
+
+// WebexMessage is the payload of the messages API
+type WebexMessage struct {
+	RoomID   string `json:"roomId"`
+	Markdown string `json:"markdown"`
+}
+
+// WebexError is a failed request, asking to retry after a delay when rate limited
+type WebexError struct {
+	Status string
+	Body   string
+	Retry  time.Duration
+}
+
+func (e *WebexError) Error() string {
+	return fmt.Sprintf("Failed sending to Webex, got %s: %s", e.Status, e.Body)
+}
+
+// RetryAfter returns the delay asked for by Webex before retrying
+func (e *WebexError) RetryAfter() time.Duration {
+	return e.Retry
+}
+
+// Init prepares Webex configuration
+func (w *Webex) Init(c *config.Config) error {
+	botToken := c.Handler.Webex.BotToken
+	roomID := c.Handler.Webex.RoomID
+	url := c.Handler.Webex.Url
+
+	if botToken == "" {
+		botToken = os.Getenv("KW_WEBEX_BOTTOKEN")
+	}
+
+	if roomID == "" {
+		roomID = os.Getenv("KW_WEBEX_ROOMID")
+	}
+
+	if url == "" {
+		url = DefaultUrl
+	}
+
+	w.BotToken = botToken
+	w.RoomID = roomID
+	w.Url = url
+
+	client, err := utils.NewHTTPClient(c.Handler.HTTP)
+	if err != nil {
+		return err
+	}
+	w.client = client
+
+	return checkMissingWebexVars(w)
+}
+
+// ObjectCreated calls notifyWebex on event creation
+func (w *Webex) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifyWebex(ctx, w, obj, "created")
+}
+
+// ObjectDeleted calls notifyWebex on event creation
+func (w *Webex) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifyWebex(ctx, w, obj, "deleted")
+}
+
+// ObjectUpdated calls notifyWebex on event creation
+func (w *Webex) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifyWebex(ctx, w, newObj, "updated")
+}
+
+// TestHandler tests the handler configurarion by sending test messages.
+func (w *Webex) TestHandler() {
+	webexMessage := &WebexMessage{
+		RoomID:   w.RoomID,
+		Markdown: "Testing Handler Configuration. This is a Test message.",
+	}
+
+	if err := postMessage(context.Background(), w, webexMessage); err != nil {
+		log.Printf("%s\n", err)
+		return
+	}
+
+	log.Printf("Message successfully sent to room %s", w.RoomID)
+}
+
+func notifyWebex(ctx context.Context, w *Webex, obj interface{}, action string) error {
+	e := kbEvent.New(obj, action)
+
+	if err := postMessage(ctx, w, prepareWebexMessage(e, w)); err != nil {
+		return err
+	}
+
+	log.Printf("Message successfully sent to room %s", w.RoomID)
+	return nil
+}
+
+func checkMissingWebexVars(w *Webex) error {
+	if w.BotToken == "" || w.RoomID == "" {
+		return fmt.Errorf(webexErrMsg, "Missing Webex bot token or room id")
+	}
+
+	return nil
+}
+
+func prepareWebexMessage(e kbEvent.Event, w *Webex) *WebexMessage {
+	color, ok := webexColors[e.Status]
+	if !ok {
+		color = webexColors["Normal"]
+	}
+
+	markdown := color + " " + e.Message()
+	if e.Cluster != "" {
+		markdown = fmt.Sprintf("%s **[%s]** %s", color, e.Cluster, e.Message())
+	}
+
+	return &WebexMessage{RoomID: w.RoomID, Markdown: markdown}
+}
+
+func postMessage(ctx context.Context, w *Webex, webexMessage *WebexMessage) error {
+	message, err := json.Marshal(webexMessage)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", w.Url, bytes.NewBuffer(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+w.BotToken)
+
+	client := w.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		webexErr := &WebexError{Status: resp.Status, Body: string(body)}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				webexErr.Retry = time.Duration(seconds) * time.Second
+			}
+		}
+		return webexErr
+	}
+	return nil
+}
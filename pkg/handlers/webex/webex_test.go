@@ -0,0 +1,98 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+)
+
+func TestWebexInit(t *testing.T) {
+	s := &Webex{}
+	expectedError := fmt.Errorf(webexErrMsg, "Missing Webex bot token or room id")
+
+	var Tests = []struct {
+		webex config.Webex
+		err   error
+	}{
+		{config.Webex{BotToken: "foo", RoomID: "bar"}, nil},
+		{config.Webex{BotToken: "foo"}, expectedError},
+		{config.Webex{RoomID: "bar"}, expectedError},
+		{config.Webex{}, expectedError},
+	}
+
+	for _, tt := range Tests {
+		c := &config.Config{}
+		c.Handler.Webex = tt.webex
+		if err := s.Init(c); !reflect.DeepEqual(err, tt.err) {
+			t.Fatalf("Init(): %v", err)
+		}
+	}
+}
+
+func TestWebexMessage(t *testing.T) {
+	var messages []WebexMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer foo" {
+			t.Errorf("unexpected authorization %q", r.Header.Get("Authorization"))
+		}
+		var m WebexMessage
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			t.Errorf("expected a Webex message: %v", err)
+		}
+		messages = append(messages, m)
+	}))
+	defer ts.Close()
+
+	w := &Webex{BotToken: "foo", RoomID: "bar", Url: ts.URL}
+	if err := w.ObjectDeleted(context.Background(), kbEvent.Event{Kind: "pod", Name: "web", Namespace: "default", Status: "Danger", Cluster: "prod"}); err != nil {
+		t.Fatalf("ObjectDeleted(): %v", err)
+	}
+
+	if len(messages) != 1 || messages[0].RoomID != "bar" {
+		t.Fatalf("expected a message to room bar, got %v", messages)
+	}
+	if markdown := messages[0].Markdown; !strings.HasPrefix(markdown, "🔴 **[prod]** ") || !strings.Contains(markdown, "`web`") {
+		t.Errorf("unexpected markdown %q", markdown)
+	}
+}
+
+func TestWebexRateLimit(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	w := &Webex{BotToken: "foo", RoomID: "bar", Url: ts.URL}
+	err := w.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "web"})
+	var webexErr *WebexError
+	if !errors.As(err, &webexErr) || webexErr.RetryAfter() != 30*time.Second {
+		t.Fatalf("expected a rate limit error retrying after 30s, got %v", err)
+	}
+}
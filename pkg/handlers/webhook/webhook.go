@@ -17,17 +17,26 @@ limitations under the License.
 package webhook
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"sync"
 
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"time"
 
 	"github.com/mudasirmirza/kubewatch/config"
 	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+	"github.com/mudasirmirza/kubewatch/pkg/utils"
+	"k8s.io/apimachinery/pkg/util/clock"
 )
 
 var webhookErrMsg = `
@@ -46,8 +55,38 @@ Command line flags will override environment variables
 // Notify event to Webhook channel
 type Webhook struct {
 	Url string
+	// batching sends buffered messages as a JSON array once BatchSize
+	// messages are buffered, every FlushInterval and on Close
+	BatchSize     int
+	FlushInterval time.Duration
+	// Headers and either BearerToken or basic auth are set on every request
+	Headers     map[string]string
+	BearerToken string
+	Username    string
+	Password    string
+	// Secret signs the requests, the signature is set in SignatureHeader
+	// and covers the timestamp header too when SignTimestamp is set
+	Secret          string
+	SignatureHeader string
+	SignTimestamp   bool
+
+	client *http.Client
+	mu     sync.Mutex
+	batch  []*WebhookMessage
+	stop   chan struct{}
+	// clock drives the periodic flush, defaults to the real clock
+	clock clock.Clock
 }
 
+// defaultTimeout bounds the requests when no timeout is configured
+const defaultTimeout = 30 * time.Second
+
+// Headers of signed requests
+const (
+	DefaultSignatureHeader = "X-Kubewatch-Signature"
+	TimestampHeader        = "X-Kubewatch-Timestamp"
+)
+
 // WebhookMessage for messages
 type WebhookMessage struct {
 	Text string `json:"text"`
@@ -61,24 +100,79 @@ func (m *Webhook) Init(c *config.Config) error {
 		url = os.Getenv("KW_WEBHOOK_URL")
 	}
 
+	bearerToken := c.Handler.Webhook.BearerToken
+	if bearerToken == "" {
+		bearerToken = os.Getenv("KW_WEBHOOK_BEARERTOKEN")
+	}
+
+	username := c.Handler.Webhook.Username
+	if username == "" {
+		username = os.Getenv("KW_WEBHOOK_USERNAME")
+	}
+
+	password := c.Handler.Webhook.Password
+	if password == "" {
+		password = os.Getenv("KW_WEBHOOK_PASSWORD")
+	}
+
+	secret := c.Handler.Webhook.Secret
+	if secret == "" {
+		secret = os.Getenv("KW_WEBHOOK_SECRET")
+	}
+
+	signatureHeader := c.Handler.Webhook.SignatureHeader
+	if signatureHeader == "" {
+		signatureHeader = DefaultSignatureHeader
+	}
+
+	timeout := c.Handler.Webhook.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
 	m.Url = url
+	m.BatchSize = c.Handler.Webhook.BatchSize
+	m.FlushInterval = c.Handler.Webhook.FlushInterval
+	m.Headers = c.Handler.Webhook.Headers
+	m.BearerToken = bearerToken
+	m.Username = username
+	m.Password = password
+	m.Secret = secret
+	m.SignatureHeader = signatureHeader
+	m.SignTimestamp = c.Handler.Webhook.SignTimestamp
+	transport, err := utils.NewHTTPTransport(c.Handler.HTTP)
+	if err != nil {
+		return err
+	}
+	m.client = &http.Client{Transport: transport, Timeout: timeout}
+
+	if err := checkMissingWebhookVars(m); err != nil {
+		return err
+	}
 
-	return checkMissingWebhookVars(m)
+	if m.clock == nil {
+		m.clock = clock.RealClock{}
+	}
+	if m.FlushInterval > 0 {
+		m.stop = make(chan struct{})
+		go m.flushEvery(m.FlushInterval, m.stop)
+	}
+	return nil
 }
 
 // ObjectCreated calls notifyWebhook on event creation
-func (m *Webhook) ObjectCreated(obj interface{}) {
-	notifyWebhook(m, obj, "created")
+func (m *Webhook) ObjectCreated(ctx context.Context, obj interface{}) error {
+	return notifyWebhook(ctx, m, obj, "created")
 }
 
 // ObjectDeleted calls notifyWebhook on event creation
-func (m *Webhook) ObjectDeleted(obj interface{}) {
-	notifyWebhook(m, obj, "deleted")
+func (m *Webhook) ObjectDeleted(ctx context.Context, obj interface{}) error {
+	return notifyWebhook(ctx, m, obj, "deleted")
 }
 
 // ObjectUpdated calls notifyWebhook on event creation
-func (m *Webhook) ObjectUpdated(oldObj, newObj interface{}) {
-	notifyWebhook(m, newObj, "updated")
+func (m *Webhook) ObjectUpdated(ctx context.Context, oldObj, newObj interface{}) error {
+	return notifyWebhook(ctx, m, newObj, "updated")
 }
 
 // TestHandler tests the handler configurarion by sending test messages.
@@ -88,7 +182,7 @@ func (m *Webhook) TestHandler() {
 		"Testing Handler Configuration. This is a Test message.",
 	}
 
-	err := postMessage(m.Url, webhookMessage)
+	err := m.postMessage(context.Background(), webhookMessage)
 	if err != nil {
 		log.Printf("%s\n", err)
 		return
@@ -97,18 +191,84 @@ func (m *Webhook) TestHandler() {
 	log.Printf("Message successfully sent to %s at %s ", m.Url, time.Now())
 }
 
-func notifyWebhook(m *Webhook, obj interface{}, action string) {
+// Close stops the periodic flush and sends the buffered messages
+func (m *Webhook) Close() error {
+	m.mu.Lock()
+	if m.stop != nil {
+		close(m.stop)
+		m.stop = nil
+	}
+	m.mu.Unlock()
+
+	return m.flush()
+}
+
+func (m *Webhook) batching() bool {
+	return m.BatchSize > 0 || m.FlushInterval > 0
+}
+
+// enqueue buffers a message, flushing the batch once it is full
+func (m *Webhook) enqueue(webhookMessage *WebhookMessage) error {
+	m.mu.Lock()
+	m.batch = append(m.batch, webhookMessage)
+	full := m.BatchSize > 0 && len(m.batch) >= m.BatchSize
+	m.mu.Unlock()
+
+	if full {
+		return m.flush()
+	}
+	return nil
+}
+
+// flush sends the buffered messages as a single JSON array
+func (m *Webhook) flush() error {
+	m.mu.Lock()
+	batch := m.batch
+	m.batch = nil
+	m.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+	return m.postMessage(context.Background(), batch)
+}
+
+func (m *Webhook) flushEvery(interval time.Duration, stop <-chan struct{}) {
+	ticker := m.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+			if err := m.flush(); err != nil {
+				log.Printf("%s\n", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func notifyWebhook(ctx context.Context, m *Webhook, obj interface{}, action string) error {
 	e := kbEvent.New(obj, action)
 
 	webhookMessage := prepareWebhookMessage(e, m)
 
-	err := postMessage(m.Url, webhookMessage)
+	if m.batching() {
+		// a failed batch holds other events too, retrying this one wouldn't resend it
+		if err := m.enqueue(webhookMessage); err != nil {
+			log.Printf("%s\n", err)
+		}
+		return nil
+	}
+
+	err := m.postMessage(ctx, webhookMessage)
 	if err != nil {
-		log.Printf("%s\n", err)
-		return
+		return err
 	}
 
 	log.Printf("Message successfully sent to %s at %s ", m.Url, time.Now())
+	return nil
 }
 
 func checkMissingWebhookVars(s *Webhook) error {
@@ -126,23 +286,65 @@ func prepareWebhookMessage(e kbEvent.Event, m *Webhook) *WebhookMessage {
 
 }
 
-func postMessage(url string, webhookMessage *WebhookMessage) error {
+// sign returns the signature of a request: sha256= and the hex encoded HMAC-SHA256
+// of the body with the secret, or of the timestamp, a dot and the body with SignTimestamp
+func (m *Webhook) sign(timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(m.Secret))
+	if m.SignTimestamp {
+		mac.Write([]byte(timestamp + "."))
+	}
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// now returns the time of the timestamp header
+func (m *Webhook) now() time.Time {
+	if m.clock == nil {
+		return time.Now()
+	}
+	return m.clock.Now()
+}
+
+// postMessage posts a message with the configured headers and auth,
+// responses other than 2xx are returned as errors, e.g. for rejected credentials
+func (m *Webhook) postMessage(ctx context.Context, webhookMessage interface{}) error {
 	message, err := json.Marshal(webhookMessage)
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(message))
+	req, err := http.NewRequestWithContext(ctx, "POST", m.Url, bytes.NewBuffer(message))
 	if err != nil {
 		return err
 	}
 	req.Header.Add("Content-Type", "application/json")
+	for name, value := range m.Headers {
+		req.Header.Set(name, value)
+	}
+	if m.Secret != "" {
+		timestamp := strconv.FormatInt(m.now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(m.SignatureHeader, m.sign(timestamp, message))
+	}
+	if m.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+m.BearerToken)
+	} else if m.Username != "" {
+		req.SetBasicAuth(m.Username, m.Password)
+	}
 
-	client := &http.Client{}
-	_, err = client.Do(req)
+	client := m.client
+	if client == nil {
+		client = &http.Client{Timeout: defaultTimeout}
+	}
+	res, err := client.Do(req)
 	if err != nil {
 		return err
 	}
+	defer res.Body.Close()
 
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("Failed sending to webhook %s. Webhook http response: %s, %s", m.Url, res.Status, string(body))
+	}
 	return nil
 }
@@ -17,11 +17,22 @@ limitations under the License.
 package webhook
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/mudasirmirza/kubewatch/config"
+	kbEvent "github.com/mudasirmirza/kubewatch/pkg/event"
+	"k8s.io/apimachinery/pkg/util/clock"
 )
 
 func TestWebhookInit(t *testing.T) {
@@ -44,3 +55,178 @@ func TestWebhookInit(t *testing.T) {
 		}
 	}
 }
+
+func TestWebhookBatch(t *testing.T) {
+	var batches [][]WebhookMessage
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("expected application/json content type, got %s", ct)
+		}
+		var batch []WebhookMessage
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Errorf("expected a JSON array: %v", err)
+		}
+		batches = append(batches, batch)
+	}))
+	defer ts.Close()
+
+	c := &config.Config{}
+	c.Handler.Webhook = config.Webhook{Url: ts.URL, BatchSize: 2}
+	m := &Webhook{}
+	if err := m.Init(c); err != nil {
+		t.Fatalf("Init(): %v", err)
+	}
+
+	for _, name := range []string{"foo", "bar", "baz"} {
+		m.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: name, Namespace: "new"})
+	}
+	if len(batches) != 1 || len(batches[0]) != 2 {
+		t.Fatalf("expected a single batch of 2 messages before Close(), got %v", batches)
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+	if len(batches) != 2 || len(batches[1]) != 1 {
+		t.Fatalf("expected the remaining message to be flushed on Close(), got %v", batches)
+	}
+	expected := "A `pod` in namespace `new` has been `created`:\n`baz`"
+	if batches[1][0].Text != expected {
+		t.Fatalf("expected %q, got %q", expected, batches[1][0].Text)
+	}
+
+	if err := m.Close(); err != nil || len(batches) != 2 {
+		t.Fatalf("expected nothing to flush on second Close(), got %v", batches)
+	}
+}
+
+func TestWebhookFlushInterval(t *testing.T) {
+	batches := make(chan []WebhookMessage, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch []WebhookMessage
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Errorf("expected a JSON array: %v", err)
+		}
+		batches <- batch
+	}))
+	defer ts.Close()
+
+	fakeClock := clock.NewFakeClock(time.Now())
+	c := &config.Config{}
+	c.Handler.Webhook = config.Webhook{Url: ts.URL, FlushInterval: time.Minute}
+	m := &Webhook{clock: fakeClock}
+	if err := m.Init(c); err != nil {
+		t.Fatalf("Init(): %v", err)
+	}
+	defer m.Close()
+
+	m.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "foo", Namespace: "new"})
+	for !fakeClock.HasWaiters() {
+		time.Sleep(time.Millisecond)
+	}
+
+	fakeClock.Step(59 * time.Second)
+	select {
+	case batch := <-batches:
+		t.Fatalf("expected no flush before the interval, got %v", batch)
+	default:
+	}
+
+	fakeClock.Step(time.Second)
+	select {
+	case batch := <-batches:
+		if len(batch) != 1 {
+			t.Fatalf("expected a batch of 1 message, got %v", batch)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the batch to be flushed after the interval")
+	}
+}
+
+func TestWebhookAuth(t *testing.T) {
+	var Tests = []struct {
+		webhook       config.Webhook
+		authorization string
+	}{
+		{config.Webhook{BearerToken: "secret"}, "Bearer secret"},
+		{config.Webhook{Username: "kubewatch", Password: "secret"}, "Basic a3ViZXdhdGNoOnNlY3JldA=="},
+		{config.Webhook{}, ""},
+	}
+
+	for _, tt := range Tests {
+		var authorization, apiKey string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization, apiKey = r.Header.Get("Authorization"), r.Header.Get("X-Api-Key")
+		}))
+
+		c := &config.Config{}
+		c.Handler.Webhook = tt.webhook
+		c.Handler.Webhook.Url = ts.URL
+		c.Handler.Webhook.Headers = map[string]string{"X-Api-Key": "foo"}
+		m := &Webhook{}
+		if err := m.Init(c); err != nil {
+			t.Fatalf("Init(): %v", err)
+		}
+		if err := m.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "foo", Namespace: "default"}); err != nil {
+			t.Fatalf("ObjectCreated(): %v", err)
+		}
+		ts.Close()
+
+		if authorization != tt.authorization || apiKey != "foo" {
+			t.Fatalf("expected Authorization %q and X-Api-Key foo, got %q and %q", tt.authorization, authorization, apiKey)
+		}
+	}
+}
+
+func TestWebhookError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	c := &config.Config{}
+	c.Handler.Webhook = config.Webhook{Url: ts.URL, BearerToken: "expired", Timeout: time.Second}
+	m := &Webhook{}
+	if err := m.Init(c); err != nil {
+		t.Fatalf("Init(): %v", err)
+	}
+	if err := m.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "foo", Namespace: "default"}); err == nil {
+		t.Fatal("ObjectCreated(): expected the rejected request to be returned for retry")
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	for _, signTimestamp := range []bool{false, true} {
+		var timestamp, signature string
+		var body []byte
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timestamp, signature = r.Header.Get("X-Kubewatch-Timestamp"), r.Header.Get("X-Signature")
+			body, _ = ioutil.ReadAll(r.Body)
+		}))
+
+		c := &config.Config{}
+		c.Handler.Webhook = config.Webhook{Url: ts.URL, Secret: "secret", SignatureHeader: "X-Signature", SignTimestamp: signTimestamp}
+		m := &Webhook{clock: clock.NewFakeClock(now)}
+		if err := m.Init(c); err != nil {
+			t.Fatalf("Init(): %v", err)
+		}
+		if err := m.ObjectCreated(context.Background(), kbEvent.Event{Kind: "pod", Name: "foo", Namespace: "default"}); err != nil {
+			t.Fatalf("ObjectCreated(): %v", err)
+		}
+		ts.Close()
+
+		// verify the request the way receivers do
+		signed := body
+		if signTimestamp {
+			signed = append([]byte(timestamp+"."), body...)
+		}
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(signed)
+		expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		if timestamp != "1700000000" || signature != expected {
+			t.Fatalf("signtimestamp %v: expected timestamp 1700000000 and signature %s, got %s and %s", signTimestamp, expected, timestamp, signature)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	// EventsProcessed counts the events taken from the queues, by resource type and event type
+	EventsProcessed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kubewatch_events_processed_total",
+		Help: "Events processed by resource type and event type.",
+	}, []string{"resource", "event_type"})
+
+	// HandlerSends counts the events sent by the handlers, by handler name and result
+	HandlerSends = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kubewatch_handler_sends_total",
+		Help: "Events sent by handler and result, success or failure.",
+	}, []string{"handler", "result"})
+
+	// QueueRetries counts the events requeued after an error, by resource type
+	QueueRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kubewatch_workqueue_retries_total",
+		Help: "Events requeued after a processing error by resource type.",
+	}, []string{"resource"})
+
+	// QueueDrops counts the events given up after too many retries, by resource type
+	QueueDrops = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kubewatch_workqueue_drops_total",
+		Help: "Events dropped after too many retries by resource type.",
+	}, []string{"resource"})
+
+	queueDepth = &queueDepthCollector{
+		desc: prometheus.NewDesc(
+			"kubewatch_workqueue_depth",
+			"Events waiting in the work queues by resource type.",
+			[]string{"resource"}, nil,
+		),
+	}
+
+	// Registry holds the kubewatch collectors along with the process and Go runtime ones
+	Registry = prometheus.NewRegistry()
+)
+
+func init() {
+	Registry.MustRegister(
+		EventsProcessed,
+		HandlerSends,
+		QueueRetries,
+		QueueDrops,
+		queueDepth,
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves the metrics of the registry
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// RegisterQueue adds the length of a work queue to the depth of its resource type,
+// until the returned function is called.
+// Resource types watched in several namespaces or clusters have a queue each.
+func RegisterQueue(resource string, length func() int) (unregister func()) {
+	queueDepth.mu.Lock()
+	defer queueDepth.mu.Unlock()
+	if queueDepth.queues == nil {
+		queueDepth.queues = make(map[*queue]bool)
+	}
+	q := &queue{resource, length}
+	queueDepth.queues[q] = true
+
+	return func() {
+		queueDepth.mu.Lock()
+		defer queueDepth.mu.Unlock()
+		delete(queueDepth.queues, q)
+	}
+}
+
+type queue struct {
+	resource string
+	length   func() int
+}
+
+// queueDepthCollector sums the lengths of the queues of each resource type when scraped
+type queueDepthCollector struct {
+	desc   *prometheus.Desc
+	mu     sync.Mutex
+	queues map[*queue]bool
+}
+
+func (q *queueDepthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- q.desc
+}
+
+func (q *queueDepthCollector) Collect(ch chan<- prometheus.Metric) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	depths := make(map[string]int)
+	for queue := range q.queues {
+		depths[queue.resource] += queue.length()
+	}
+	for resource, depth := range depths {
+		ch <- prometheus.MustNewConstMetric(q.desc, prometheus.GaugeValue, float64(depth), resource)
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestQueueDepth(t *testing.T) {
+	RegisterQueue("pod", func() int { return 2 })
+	RegisterQueue("pod", func() int { return 3 })
+	RegisterQueue("service", func() int { return 0 })
+	unregister := RegisterQueue("service", func() int { return 4 })
+	unregister()
+
+	expected := `
+# HELP kubewatch_workqueue_depth Events waiting in the work queues by resource type.
+# TYPE kubewatch_workqueue_depth gauge
+kubewatch_workqueue_depth{resource="pod"} 5
+kubewatch_workqueue_depth{resource="service"} 0
+`
+	if err := testutil.CollectAndCompare(queueDepth, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	EventsProcessed.WithLabelValues("pod", "create").Inc()
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := rec.Body.String()
+	for _, metric := range []string{
+		`kubewatch_events_processed_total{event_type="create",resource="pod"} 1`,
+		"go_goroutines",
+		"process_start_time_seconds",
+	} {
+		if !strings.Contains(body, metric) {
+			t.Errorf("expected %q in the metrics, got:\n%s", metric, body)
+		}
+	}
+}
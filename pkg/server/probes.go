@@ -0,0 +1,83 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Check reports why a probe fails, nil when it passes
+type Check func() error
+
+var probes struct {
+	mu      sync.Mutex
+	ready   []Check
+	healthy []Check
+}
+
+// AddReadyCheck adds a check to /readyz.
+// The probe fails until at least one ready check is added.
+func AddReadyCheck(check Check) {
+	probes.mu.Lock()
+	defer probes.mu.Unlock()
+	probes.ready = append(probes.ready, check)
+}
+
+// AddHealthCheck adds a check to /healthz
+func AddHealthCheck(check Check) {
+	probes.mu.Lock()
+	defer probes.mu.Unlock()
+	probes.healthy = append(probes.healthy, check)
+}
+
+func readyz(w http.ResponseWriter, r *http.Request) {
+	probes.mu.Lock()
+	checks := probes.ready
+	probes.mu.Unlock()
+
+	if len(checks) == 0 {
+		http.Error(w, "controllers not started", http.StatusServiceUnavailable)
+		return
+	}
+	serveChecks(w, checks)
+}
+
+func healthz(w http.ResponseWriter, r *http.Request) {
+	probes.mu.Lock()
+	checks := probes.healthy
+	probes.mu.Unlock()
+
+	serveChecks(w, checks)
+}
+
+// serveChecks answers 200 when all checks pass, 503 with the failures otherwise
+func serveChecks(w http.ResponseWriter, checks []Check) {
+	var failures []string
+	for _, check := range checks {
+		if err := check(); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		http.Error(w, strings.Join(failures, "\n"), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mudasirmirza/kubewatch/config"
+)
+
+func TestProbes(t *testing.T) {
+	defer func() {
+		probes.ready, probes.healthy = nil, nil
+	}()
+	mux := newMux(&config.Config{})
+
+	probe := func(path string) (int, string) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec.Code, rec.Body.String()
+	}
+
+	if code, _ := probe("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("expected /readyz to fail before controllers start, got %d", code)
+	}
+	if code, _ := probe("/healthz"); code != http.StatusOK {
+		t.Errorf("expected /healthz to pass without checks, got %d", code)
+	}
+
+	var notSynced error = errors.New("pod: cache not synced")
+	AddReadyCheck(func() error { return notSynced })
+	AddHealthCheck(func() error { return nil })
+	AddHealthCheck(func() error { return errors.New("service: resource version unchanged") })
+
+	if code, body := probe("/readyz"); code != http.StatusServiceUnavailable || body != "pod: cache not synced\n" {
+		t.Errorf("expected /readyz to report the unsynced cache, got %d %q", code, body)
+	}
+	notSynced = nil
+	if code, body := probe("/readyz"); code != http.StatusOK || body != "ok\n" {
+		t.Errorf("expected /readyz to pass, got %d %q", code, body)
+	}
+	if code, body := probe("/healthz"); code != http.StatusServiceUnavailable || body != "service: resource version unchanged\n" {
+		t.Errorf("expected /healthz to report the failing check, got %d %q", code, body)
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/mudasirmirza/kubewatch/config"
+	"github.com/mudasirmirza/kubewatch/pkg/metrics"
+)
+
+const defaultPprofPath = "/debug/pprof/"
+
+const defaultMetricsPath = "/metrics"
+
+// profiles served by pprof.Handler under the pprof path
+var profiles = []string{
+	"allocs",
+	"block",
+	"goroutine",
+	"heap",
+	"mutex",
+	"threadcreate",
+}
+
+// Start runs the optional HTTP server, metrics and pprof endpoints in the background.
+// Nothing is started when no port is configured.
+func Start(conf *config.Config) {
+	if conf.Server.Port != 0 {
+		listen("HTTP server", conf.Server.Port, newMux(conf))
+	}
+
+	if conf.Metrics.Port != 0 && conf.Metrics.Port != conf.Server.Port {
+		mux := http.NewServeMux()
+		mux.Handle(metricsPath(conf.Metrics.Path), metrics.Handler())
+		listen("metrics endpoint", conf.Metrics.Port, mux)
+	}
+
+	if conf.Server.PprofPort != 0 && conf.Server.PprofPort != conf.Server.Port {
+		mux := http.NewServeMux()
+		registerPprof(mux, pprofPath(conf.Server.PprofPath))
+		listen("pprof endpoint", conf.Server.PprofPort, mux)
+	}
+}
+
+func listen(name string, port int, mux *http.ServeMux) {
+	addr := fmt.Sprintf(":%d", port)
+	go func() {
+		logrus.Infof("Starting kubewatch %s on %s", name, addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			logrus.Errorf("kubewatch %s stopped: %v", name, err)
+		}
+	}()
+}
+
+// newMux builds the request multiplexer of the HTTP server
+func newMux(conf *config.Config) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", healthz)
+	mux.HandleFunc("/readyz", readyz)
+	if conf.Server.EnablePprof || (conf.Server.PprofPort != 0 && conf.Server.PprofPort == conf.Server.Port) {
+		registerPprof(mux, pprofPath(conf.Server.PprofPath))
+	}
+	if conf.Metrics.Port != 0 && conf.Metrics.Port == conf.Server.Port {
+		mux.Handle(metricsPath(conf.Metrics.Path), metrics.Handler())
+	}
+	return mux
+}
+
+func metricsPath(path string) string {
+	if path == "" {
+		return defaultMetricsPath
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path
+}
+
+func pprofPath(path string) string {
+	if path == "" {
+		return defaultPprofPath
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if !strings.HasSuffix(path, "/") {
+		path = path + "/"
+	}
+	return path
+}
+
+// registerPprof mounts the pprof handlers under path.
+// pprof.Index resolves profiles relative to /debug/pprof/, so named profiles
+// are registered explicitly to keep custom paths working.
+func registerPprof(mux *http.ServeMux, path string) {
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		r.URL.Path = defaultPprofPath
+		pprof.Index(w, r)
+	})
+	mux.HandleFunc(path+"cmdline", pprof.Cmdline)
+	mux.HandleFunc(path+"profile", pprof.Profile)
+	mux.HandleFunc(path+"symbol", pprof.Symbol)
+	mux.HandleFunc(path+"trace", pprof.Trace)
+	for _, name := range profiles {
+		mux.Handle(path+name, pprof.Handler(name))
+	}
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2016 Skippbox, Ltd.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mudasirmirza/kubewatch/config"
+)
+
+func TestNewMuxPprof(t *testing.T) {
+	var Tests = []struct {
+		server config.Server
+		code   int
+	}{
+		{config.Server{Port: 8080}, http.StatusNotFound},
+		{config.Server{Port: 8080, EnablePprof: true}, http.StatusOK},
+		{config.Server{Port: 8080, PprofPort: 8080}, http.StatusOK},
+		// served by its own listener
+		{config.Server{Port: 8080, PprofPort: 6060}, http.StatusNotFound},
+	}
+
+	for _, tt := range Tests {
+		mux := newMux(&config.Config{Server: tt.server})
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/pprof/cmdline", nil))
+		if rec.Code != tt.code {
+			t.Errorf("newMux(%+v): expected %d for the pprof handlers, got %d", tt.server, tt.code, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/mudasirmirza/kubewatch/config"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// NewHTTPClient returns the client of the HTTP based handlers with the configured proxies and TLS
+// settings. The proxies default to the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+func NewHTTPClient(c config.HTTP) (*http.Client, error) {
+	transport, err := NewHTTPTransport(c)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// NewHTTPTransport returns the transport of NewHTTPClient, e.g. for handlers adding TLS settings of their own
+func NewHTTPTransport(c config.HTTP) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if c.HTTPProxy != "" || c.HTTPSProxy != "" || c.NoProxy != "" {
+		proxyConfig := httpproxy.FromEnvironment()
+		if c.HTTPProxy != "" {
+			proxyConfig.HTTPProxy = c.HTTPProxy
+		}
+		if c.HTTPSProxy != "" {
+			proxyConfig.HTTPSProxy = c.HTTPSProxy
+		}
+		if c.NoProxy != "" {
+			proxyConfig.NoProxy = c.NoProxy
+		}
+		proxy := proxyConfig.ProxyFunc()
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxy(req.URL)
+		}
+	}
+
+	if c.CACertFile != "" || c.InsecureSkipVerify {
+		tlsConfig := &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify}
+		if c.CACertFile != "" {
+			ca, err := ioutil.ReadFile(c.CACertFile)
+			if err != nil {
+				return nil, fmt.Errorf("Failed reading CA cert file: %v", err)
+			}
+			// the private CA is trusted in addition to the system ones
+			rootCAs, err := x509.SystemCertPool()
+			if err != nil {
+				rootCAs = x509.NewCertPool()
+			}
+			if !rootCAs.AppendCertsFromPEM(ca) {
+				return nil, fmt.Errorf("Failed parsing CA cert file %s", c.CACertFile)
+			}
+			tlsConfig.RootCAs = rootCAs
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	return transport, nil
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/mudasirmirza/kubewatch/config"
+)
+
+func TestNewHTTPClientProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.Host)
+	}))
+	defer proxy.Close()
+
+	client, err := NewHTTPClient(config.HTTP{HTTPProxy: proxy.URL, NoProxy: "direct.example.com"})
+	if err != nil {
+		t.Fatalf("NewHTTPClient(): %v", err)
+	}
+	resp, err := client.Get("http://hooks.example.com/kubewatch")
+	if err != nil {
+		t.Fatalf("Get(): %v", err)
+	}
+	resp.Body.Close()
+	if len(proxied) != 1 || proxied[0] != "hooks.example.com" {
+		t.Fatalf("expected the request through the proxy, got %v", proxied)
+	}
+
+	transport := client.Transport.(*http.Transport)
+	req, _ := http.NewRequest("GET", "http://direct.example.com/", nil)
+	if u, err := transport.Proxy(req); err != nil || u != nil {
+		t.Fatalf("expected no proxy for direct.example.com, got %v, %v", u, err)
+	}
+}
+
+func TestNewHTTPClientCACert(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	if err := ioutil.WriteFile(caFile, ca, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewHTTPClient(config.HTTP{CACertFile: caFile})
+	if err != nil {
+		t.Fatalf("NewHTTPClient(): %v", err)
+	}
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("expected the private CA to be trusted: %v", err)
+	}
+	resp.Body.Close()
+
+	if _, err := NewHTTPClient(config.HTTP{CACertFile: filepath.Join(dir, "missing.pem")}); err == nil {
+		t.Fatalf("expected an error for a missing CA cert file")
+	}
+	if err := ioutil.WriteFile(caFile, []byte("not a cert"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewHTTPClient(config.HTTP{CACertFile: caFile}); err == nil {
+		t.Fatalf("expected an error for an invalid CA cert file")
+	}
+}
@@ -1,14 +1,27 @@
 package utils
 
 import (
+	"fmt"
 	"os"
+	"sort"
+	"strings"
 
 	"github.com/Sirupsen/logrus"
 	apps_v1 "k8s.io/api/apps/v1"
+	apps_v1beta1 "k8s.io/api/apps/v1beta1"
+	autoscaling_v1 "k8s.io/api/autoscaling/v1"
 	batch_v1 "k8s.io/api/batch/v1"
+	batch_v1beta1 "k8s.io/api/batch/v1beta1"
 	api_v1 "k8s.io/api/core/v1"
 	ext_v1beta1 "k8s.io/api/extensions/v1beta1"
+	networking_v1 "k8s.io/api/networking/v1"
+	policy_v1 "k8s.io/api/policy/v1"
+	policy_v1beta1 "k8s.io/api/policy/v1beta1"
+	rbac_v1 "k8s.io/api/rbac/v1"
+	storage_v1 "k8s.io/api/storage/v1"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -16,10 +29,7 @@ import (
 
 // GetClient returns a k8s clientset to the request from inside of cluster
 func GetClient() kubernetes.Interface {
-	config, err := rest.InClusterConfig()
-	if err != nil {
-		logrus.Fatalf("Can not get kubernetes config: %v", err)
-	}
+	config := GetConfig()
 
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
@@ -39,19 +49,116 @@ func buildOutOfClusterConfig() (*rest.Config, error) {
 
 // GetClientOutOfCluster returns a k8s clientset to the request from outside of cluster
 func GetClientOutOfCluster() kubernetes.Interface {
-	config, err := buildOutOfClusterConfig()
+	config := GetConfigOutOfCluster()
+
+	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		logrus.Fatalf("Can not get kubernetes config: %v", err)
 	}
 
+	return clientset
+}
+
+// GetClientForContext returns a k8s clientset to the cluster of a kubeconfig context
+func GetClientForContext(context string) kubernetes.Interface {
+	return GetClientForKubeconfig("", context)
+}
+
+// GetClientForKubeconfig returns a k8s clientset to the cluster of a context of a kubeconfig file.
+// $KUBECONFIG, defaulting to $HOME/.kube/config, is loaded when kubeconfig is empty,
+// and its current context is used when context is empty.
+func GetClientForKubeconfig(kubeconfig, context string) kubernetes.Interface {
+	config := GetConfigForKubeconfig(kubeconfig, context)
+
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		logrus.Fatalf("Can not get kubernetes config: %v", err)
+		logrus.Fatalf("Can not create kubernetes client for context %s: %v", context, err)
 	}
 
 	return clientset
 }
 
+// GetConfig returns the client config to the request from inside of cluster
+func GetConfig() *rest.Config {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		logrus.Fatalf("Can not get kubernetes config: %v", err)
+	}
+	return config
+}
+
+// GetConfigOutOfCluster returns the client config to the request from outside of cluster
+func GetConfigOutOfCluster() *rest.Config {
+	config, err := buildOutOfClusterConfig()
+	if err != nil {
+		logrus.Fatalf("Can not get kubernetes config: %v", err)
+	}
+	return config
+}
+
+// GetConfigForKubeconfig returns the client config of a context of a kubeconfig file,
+// see GetClientForKubeconfig
+func GetConfigForKubeconfig(kubeconfig, context string) *rest.Config {
+	config, err := BuildKubeconfig(kubeconfig, context)
+	if err != nil {
+		logrus.Fatalf("Can not get kubernetes config: %v", err)
+	}
+	return config
+}
+
+// SetRateLimits sets the client side rate limits of a client config,
+// client-go's defaults are kept when qps or burst is 0
+func SetRateLimits(config *rest.Config, qps float32, burst int) {
+	if qps > 0 {
+		config.QPS = qps
+	}
+	if burst > 0 {
+		config.Burst = burst
+	}
+}
+
+// GetDynamicClient returns a dynamic client for a client config,
+// used to watch resources without typed clients such as custom resources
+func GetDynamicClient(config *rest.Config) dynamic.Interface {
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		logrus.Fatalf("Can not create kubernetes dynamic client: %v", err)
+	}
+	return client
+}
+
+// BuildKubeconfig returns the client config of a context of a kubeconfig file,
+// see GetClientForKubeconfig. Unknown contexts are reported with the known ones.
+func BuildKubeconfig(kubeconfig, context string) (*rest.Config, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		loadingRules.ExplicitPath = kubeconfig
+	}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: context}
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+
+	if context != "" {
+		raw, err := clientConfig.RawConfig()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := raw.Contexts[context]; !ok {
+			var known []string
+			for name := range raw.Contexts {
+				known = append(known, name)
+			}
+			sort.Strings(known)
+			return nil, fmt.Errorf("context %q not found in kubeconfig, known contexts: %s", context, strings.Join(known, ", "))
+		}
+	}
+
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("context %q: %v", context, err)
+	}
+	return config, nil
+}
+
 // GetObjectMetaData returns metadata of a given k8s object
 func GetObjectMetaData(obj interface{}) meta_v1.ObjectMeta {
 
@@ -60,26 +167,74 @@ func GetObjectMetaData(obj interface{}) meta_v1.ObjectMeta {
 	switch object := obj.(type) {
 	case *apps_v1.Deployment:
 		objectMeta = object.ObjectMeta
+	case *apps_v1beta1.Deployment:
+		objectMeta = object.ObjectMeta
 	case *api_v1.ReplicationController:
 		objectMeta = object.ObjectMeta
 	case *apps_v1.ReplicaSet:
 		objectMeta = object.ObjectMeta
+	case *ext_v1beta1.ReplicaSet:
+		objectMeta = object.ObjectMeta
 	case *apps_v1.DaemonSet:
 		objectMeta = object.ObjectMeta
+	case *ext_v1beta1.DaemonSet:
+		objectMeta = object.ObjectMeta
 	case *api_v1.Service:
 		objectMeta = object.ObjectMeta
 	case *api_v1.Pod:
 		objectMeta = object.ObjectMeta
+	case *apps_v1beta1.StatefulSet:
+		objectMeta = object.ObjectMeta
 	case *batch_v1.Job:
 		objectMeta = object.ObjectMeta
+	case *batch_v1beta1.CronJob:
+		objectMeta = object.ObjectMeta
+	case *api_v1.Node:
+		objectMeta = object.ObjectMeta
 	case *api_v1.PersistentVolume:
 		objectMeta = object.ObjectMeta
 	case *api_v1.Namespace:
 		objectMeta = object.ObjectMeta
 	case *api_v1.Secret:
 		objectMeta = object.ObjectMeta
+	case *api_v1.ConfigMap:
+		objectMeta = object.ObjectMeta
+	case *api_v1.Event:
+		objectMeta = object.ObjectMeta
+	case *autoscaling_v1.HorizontalPodAutoscaler:
+		objectMeta = object.ObjectMeta
 	case *ext_v1beta1.Ingress:
 		objectMeta = object.ObjectMeta
+	case *networking_v1.Ingress:
+		objectMeta = object.ObjectMeta
+	case *api_v1.ServiceAccount:
+		objectMeta = object.ObjectMeta
+	case *rbac_v1.Role:
+		objectMeta = object.ObjectMeta
+	case *rbac_v1.RoleBinding:
+		objectMeta = object.ObjectMeta
+	case *storage_v1.StorageClass:
+		objectMeta = object.ObjectMeta
+	case *networking_v1.NetworkPolicy:
+		objectMeta = object.ObjectMeta
+	case *api_v1.ResourceQuota:
+		objectMeta = object.ObjectMeta
+	case *api_v1.LimitRange:
+		objectMeta = object.ObjectMeta
+	case *policy_v1.PodDisruptionBudget:
+		objectMeta = object.ObjectMeta
+	case *policy_v1beta1.PodDisruptionBudget:
+		objectMeta = object.ObjectMeta
+	case *unstructured.Unstructured:
+		objectMeta = meta_v1.ObjectMeta{
+			Name:              object.GetName(),
+			Namespace:         object.GetNamespace(),
+			UID:               object.GetUID(),
+			ResourceVersion:   object.GetResourceVersion(),
+			CreationTimestamp: object.GetCreationTimestamp(),
+			Labels:            object.GetLabels(),
+			Annotations:       object.GetAnnotations(),
+		}
 	}
 	return objectMeta
 }
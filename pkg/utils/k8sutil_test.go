@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/rest"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: prod
+  cluster:
+    server: https://prod.example.com
+- name: staging
+  cluster:
+    server: https://staging.example.com
+contexts:
+- name: prod
+  context:
+    cluster: prod
+- name: staging
+  context:
+    cluster: staging
+current-context: prod
+`
+
+func writeKubeconfig(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	if err := ioutil.WriteFile(path, []byte(testKubeconfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBuildKubeconfig(t *testing.T) {
+	path := writeKubeconfig(t)
+
+	tests := []struct {
+		context string
+		host    string
+	}{
+		{"", "https://prod.example.com"},
+		{"staging", "https://staging.example.com"},
+	}
+	for _, tt := range tests {
+		config, err := BuildKubeconfig(path, tt.context)
+		if err != nil {
+			t.Fatalf("context %q: %v", tt.context, err)
+		}
+		if config.Host != tt.host {
+			t.Errorf("context %q: host = %s, want %s", tt.context, config.Host, tt.host)
+		}
+	}
+}
+
+func TestBuildKubeconfigUnknownContext(t *testing.T) {
+	_, err := BuildKubeconfig(writeKubeconfig(t), "dev")
+	if err == nil {
+		t.Fatal("expected an error for an unknown context")
+	}
+	if !strings.Contains(err.Error(), `"dev"`) || !strings.Contains(err.Error(), "prod, staging") {
+		t.Errorf("error %q does not name the context and the known ones", err)
+	}
+}
+
+func TestSetRateLimits(t *testing.T) {
+	config := &rest.Config{}
+	SetRateLimits(config, 0, 0)
+	if config.QPS != 0 || config.Burst != 0 {
+		t.Errorf("expected client-go's defaults to be kept, got %v qps and %d burst", config.QPS, config.Burst)
+	}
+
+	SetRateLimits(config, 50, 100)
+	if config.QPS != 50 || config.Burst != 100 {
+		t.Errorf("expected 50 qps and 100 burst, got %v qps and %d burst", config.QPS, config.Burst)
+	}
+}